@@ -2,6 +2,11 @@
 package config
 
 import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/alexsasharegan/dotenv"
 	"github.com/dustin/go-humanize"
 	"github.com/kelseyhightower/envconfig"
@@ -18,14 +23,60 @@ type Config struct {
 	MaxUploadSize      string `default:"5 GB" split_words:"true"`
 	MaxUploadSizeBytes int64  `ignored:"true"` // This is set by the parser to the byte value of MaxUploadSize
 	AllowRegistration  bool   `default:"true" split_words:"true"`
+	DefaultQuota       string `default:"0" split_words:"true"` // How much storage users without a quota of their own get, 0 means unlimited
+	DefaultQuotaBytes  int64  `ignored:"true"`                 // This is set by the parser to the byte value of DefaultQuota
+
+	ShutdownGracePeriod time.Duration `default:"5m" split_words:"true"` // How long running transcodes get to finish after a SIGTERM before they're killed and resumed on the next start
 
 	FFmpeg struct {
-		Concurrency    int      `default:"1"`
-		Threads        int      `default:"0"`
-		Preset         string   `default:"medium"` // https://trac.ffmpeg.org/wiki/Encode/H.264#:~:text=preset%20and%20tune-,Preset,-A%20preset%20is
-		Tune           string   `default:"film"`   // https://trac.ffmpeg.org/wiki/Encode/H.264#:~:text=x264%20%2D%2Dfullhelp.-,Tune,-You%20can%20optionally
-		Codec          string   `default:"libx264"`
-		QualityPresets []string `split_words:"true" default:"640x360-30@1,854x480-30@2.5,1280x720-30@5,1920x1080-30@8,1920x1080-60@12,2560x1440-30@16,2560x1440-60@24,3840x2160-30@45,3840x2160-60@68,7680x4320-30@160,7680x4320-60@240"`
+		Concurrency    int           `default:"1"`
+		Threads        int           `default:"0"`
+		Preset         string        `default:"medium"` // https://trac.ffmpeg.org/wiki/Encode/H.264#:~:text=preset%20and%20tune-,Preset,-A%20preset%20is
+		Tune           string        `default:"film"`   // https://trac.ffmpeg.org/wiki/Encode/H.264#:~:text=x264%20%2D%2Dfullhelp.-,Tune,-You%20can%20optionally
+		Codec          string        `default:"libx264"`
+		ChunkDuration  time.Duration `default:"0" split_words:"true"` // Opt-in, videos are encoded in chunks this long so they can be resumed and encoded in parallel. 0 encodes them in one go
+		QualityPresets []string      `split_words:"true" default:"640x360-30@1,854x480-30@2.5,1280x720-30@5,1920x1080-30@8,1920x1080-60@12,2560x1440-30@16,2560x1440-60@24,3840x2160-30@45,3840x2160-60@68,7680x4320-30@160,7680x4320-60@240"`
+	}
+
+	// Limits uploads are checked against before they're accepted, 0 means unlimited
+	Media struct {
+		MaxDuration time.Duration `default:"0" split_words:"true"`
+		MaxWidth    int           `default:"0" split_words:"true"` // Measured along the long side, so portrait videos get the same limits as landscape ones
+		MaxHeight   int           `default:"0" split_words:"true"` // Measured along the short side
+		MaxStreams  int           `default:"0" split_words:"true"` // Streams of any kind, including subtitles and attachments
+	}
+
+	Downloads struct {
+		Enabled      bool  `default:"false"`                    // Encode progressive MP4s of every clip next to its DASH renditions so they can be downloaded
+		Qualities    []int `default:"720"`                      // Heights of the MP4s, each needs a quality preset of the same height. Sources are never upscaled
+		KeepOriginal bool  `default:"false" split_words:"true"` // Keep the uploaded file after transcoding so it can be downloaded as is
+	}
+
+	Imports struct {
+		Timeout      time.Duration `default:"1h"`                       // How long fetching the file of a clip imported from a URL may take
+		MaxRedirects int           `default:"5" split_words:"true"`     // How many redirects are followed while fetching it
+		AllowPrivate bool          `default:"false" split_words:"true"` // Allow imports from loopback, private and link-local addresses, so the server can't be used to reach internal services otherwise
+	}
+
+	Uploads struct {
+		Expiry time.Duration `default:"24h"` // How long an unfinished resumable upload is kept after its last chunk
+	}
+
+	GC struct {
+		Interval    time.Duration `default:"6h"`                       // How often storage is checked for objects no clip or upload refers to, 0 disables it
+		GracePeriod time.Duration `default:"24h" split_words:"true"`   // How old unreferenced objects have to be before they're deleted
+		DryRun      bool          `default:"false" split_words:"true"` // Only log what would be deleted
+	}
+
+	Verify struct {
+		Interval time.Duration `default:"0"` // How often every stored object is read back and checked against its checksum, 0 disables it. Reading everything costs as much as serving it once
+	}
+
+	Dedupe struct {
+		Enabled             bool `default:"true"`
+		Conflict            bool `default:"false"`                 // Respond with 409 instead of the existing clip when a user uploads a duplicate of their own clip
+		Perceptual          bool `default:"false"`                 // Hash a frame of every video to find near-duplicates
+		PerceptualThreshold int  `default:"10" split_words:"true"` // Max differing bits between two perceptual hashes to be considered near-duplicates
 	}
 
 	CORS struct {
@@ -33,17 +84,72 @@ type Config struct {
 		Enabled bool
 	}
 
+	OIDC struct {
+		Providers   []string                 // Names of the OpenID Connect providers users can log in with, each is configured with OIDC_<NAME>_* variables like OIDC_<NAME>_ISSUER
+		RedirectURL string                   `split_words:"true"` // Public URL of the API the providers send users back to, e.g. https://clips.example.com/api
+		Provider    map[string]*OIDCProvider `ignored:"true"`     // This is set by the parser from the variables of every provider in Providers
+	}
+
+	// An account that's made an admin on every start, so there's always someone to manage the others
+	Admin struct {
+		Username string // Empty disables it
+		Password string // Only used to create the account if there's no user with the name yet, existing accounts keep their password
+	}
+
+	TOTP struct {
+		Required bool          `default:"false"`    // Users have to set up two-factor authentication before they can do anything but manage their account
+		Issuer   string        `default:"Clipable"` // Name authenticator apps show next to the username
+		Lockout  time.Duration `default:"15m"`      // How long a user can't log in after too many wrong codes in a row
+	}
+
 	Cookie struct {
 		Key    string
 		Domain string
 	}
 
+	Storage struct {
+		Backend string `default:"s3"`   // Where objects are kept, either s3 or filesystem
+		Path    string `default:"data"` // Directory the filesystem backend stores objects in
+	}
+
+	Tiering struct {
+		Enabled          bool          `default:"false"`
+		Backend          string        `default:"s3"` // Where the cold tier is kept, either s3 or filesystem. It's encrypted the same way as the hot one
+		Bucket           string        // Bucket of the cold tier with the s3 backend, on the same server as S3.Bucket
+		Path             string        `default:"data-cold"`                // Directory of the cold tier with the filesystem backend
+		After            time.Duration `default:"2160h"`                    // How long a clip has to go without views before its high renditions are moved to the cold tier
+		MinHeight        int           `default:"1440" split_words:"true"`  // Renditions and downloads at least this tall are moved, lower ones stay in the hot tier
+		Interval         time.Duration `default:"24h"`                      // How often clips are checked, 0 disables the mover
+		DropFromManifest bool          `default:"false" split_words:"true"` // Leave renditions in the cold tier out of the manifest, so players never request them
+	}
+
+	Encryption struct {
+		Mode     string `default:"none"` // How objects are encrypted at rest: none, sse-s3 or sse-c with the s3 backend, envelope with the filesystem backend
+		Key      string // Base64 encoded 32 byte key the sse-c and envelope keys are derived from, changing it makes existing objects unreadable
+		KeyBytes []byte `ignored:"true"`                     // This is set by the parser to the decoded Key
+		PerClip  bool   `default:"false" split_words:"true"` // Derive a separate key for every clip instead of one for all of them
+	}
+
+	Delivery struct {
+		Mode          string        `default:"proxy"`                                 // How segments and thumbnails are served: proxy streams them through the backend, presign redirects to S3, accel hands them to nginx with X-Accel-Redirect
+		PresignExpiry time.Duration `default:"5m" split_words:"true"`                 // How long presigned URLs stay valid
+		AccelPrefix   string        `default:"/internal/objects/" split_words:"true"` // Internal nginx location X-Accel-Redirect points at
+	}
+
 	S3 struct {
 		Address string
 		Secure  bool `default:"false"`
 		Access  string
 		Secret  string
 		Bucket  string
+		Region  string `default:"us-east-1"`
+
+		PublicAddress string `split_words:"true"` // Address presigned URLs are made for when clients can't reach Address, defaults to Address
+		PublicSecure  bool   `default:"false" split_words:"true"`
+
+		PartSize          string `default:"16 MB" split_words:"true"` // Size of the parts uploads are split into, S3 doesn't accept parts smaller than 5 MiB
+		PartSizeBytes     int64  `ignored:"true"`                     // This is set by the parser to the byte value of PartSize
+		UploadConcurrency int    `default:"4" split_words:"true"`     // How many parts of a single upload are sent at once
 	}
 
 	DB struct {
@@ -57,6 +163,19 @@ type Config struct {
 	}
 }
 
+// OIDCProvider holds the settings of an OpenID Connect provider
+type OIDCProvider struct {
+	Issuer        string   `required:"true"` // Where the provider's discovery document is, without /.well-known/openid-configuration
+	ClientID      string   `required:"true" split_words:"true"`
+	ClientSecret  string   `split_words:"true"`
+	Scopes        []string `default:"openid,profile,email"`
+	UsernameClaim string   `default:"preferred_username" split_words:"true"` // Claim of the ID token new accounts are named after
+	AutoProvision bool     `default:"false" split_words:"true"`              // Create an account for identities that aren't linked to one yet, otherwise users have to link them while logged in
+}
+
+// oidcProviderName limits provider names to what fits in a URL path and an environment variable
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9]+$`)
+
 // New uses a file path stored in the CONFIG environment variable to populate the Config struct
 func New() (*Config, error) {
 	cfg := &Config{}
@@ -77,5 +196,65 @@ func New() (*Config, error) {
 
 	cfg.MaxUploadSizeBytes = int64(maxUploadSizeBytes)
 
+	// Parse the human readable default quota into bytes
+	defaultQuotaBytes, err := humanize.ParseBytes(cfg.DefaultQuota)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse default quota")
+	}
+
+	cfg.DefaultQuotaBytes = int64(defaultQuotaBytes)
+
+	// Parse the human readable s3 part size into bytes
+	partSizeBytes, err := humanize.ParseBytes(cfg.S3.PartSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse s3 part size")
+	}
+
+	if partSizeBytes < 5*humanize.MiByte {
+		return nil, errors.New("s3 part size must be at least 5 MiB")
+	}
+
+	cfg.S3.PartSizeBytes = int64(partSizeBytes)
+
+	// S3 doesn't take more than 10000 parts, a larger upload would only fail once all of those were sent
+	if cfg.MaxUploadSizeBytes > cfg.S3.PartSizeBytes*10000 {
+		return nil, errors.New("s3 part size must be at least a 10000th of the max upload size")
+	}
+
+	if cfg.Encryption.Key != "" {
+		cfg.Encryption.KeyBytes, err = base64.StdEncoding.DecodeString(cfg.Encryption.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode encryption key")
+		}
+
+		if len(cfg.Encryption.KeyBytes) != 32 {
+			return nil, errors.New("encryption key must be 32 bytes")
+		}
+	}
+
+	cfg.OIDC.Provider = make(map[string]*OIDCProvider, len(cfg.OIDC.Providers))
+
+	for _, name := range cfg.OIDC.Providers {
+		if !oidcProviderName.MatchString(name) {
+			return nil, errors.Errorf("oidc provider name %q must only contain lowercase letters and digits", name)
+		}
+
+		provider := &OIDCProvider{}
+
+		if err := envconfig.Process("OIDC_"+strings.ToUpper(name), provider); err != nil {
+			return nil, errors.Wrapf(err, "failed to process config of oidc provider %s", name)
+		}
+
+		cfg.OIDC.Provider[name] = provider
+	}
+
+	if len(cfg.OIDC.Providers) > 0 && cfg.OIDC.RedirectURL == "" {
+		return nil, errors.New("oidc providers need a redirect url")
+	}
+
+	if cfg.S3.UploadConcurrency < 1 {
+		cfg.S3.UploadConcurrency = 1
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,299 @@
+// Package e2e drives the API over HTTP from upload to playback, against a throwaway Postgres schema,
+// the in-memory ObjectStore and the fake transcoder. It's skipped unless E2E_DATABASE_URL points at a Postgres database,
+// e.g. postgres://postgres@localhost:5432/postgres?sslmode=disable for the container the Makefile starts
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/modelsx"
+	"webserver/routes"
+	"webserver/services"
+	"webserver/services/db"
+	"webserver/services/object"
+	"webserver/services/transcoder"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/gorilla/sessions"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	// Migrate Postgres driver import
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	// Migrate file driver import
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	// Postgres driver import
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+// mp4Header is the start of an MP4, enough for uploads to be sniffed as one
+const mp4Header = "\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"
+
+// harness is a running API with a client that keeps the session cookie of whoever it last registered
+type harness struct {
+	t      *testing.T
+	server *httptest.Server
+	client *http.Client
+	store  services.ObjectStore
+}
+
+func newHarness(t *testing.T) *harness {
+	dsn := os.Getenv("E2E_DATABASE_URL")
+
+	if dsn == "" {
+		t.Skip("E2E_DATABASE_URL isn't set")
+	}
+
+	// Every test gets a schema of its own, dropped along with everything in it once the test is done
+	schema := fmt.Sprintf("e2e_%d", time.Now().UnixNano())
+
+	admin, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Logf("Failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+
+	// Extensions like pg_trgm may already be installed in public, their operators have to stay visible
+	query := u.Query()
+	query.Set("search_path", schema+",public")
+	u.RawQuery = query.Encode()
+
+	m, err := migrate.New("file://../migrations", u.String())
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+
+	sdb, err := sql.Open("pgx", u.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { sdb.Close() })
+
+	cfg, err := config.New()
+	require.NoError(t, err)
+
+	cfg.Delivery.Mode = routes.DeliveryProxy
+	cfg.Encryption.Mode = object.EncryptionNone
+	cfg.Tiering.Enabled = false
+	cfg.CORS.Enabled = false
+
+	modelsx.SetHashEncoder("e2e")
+
+	store := object.NewMemoryStore()
+
+	group := &services.Group{
+		ObjectStore: store,
+		Users:       db.NewUsers(sdb),
+		Clips:       db.NewClips(sdb, store),
+		Chapters:    db.NewChapters(sdb),
+		Chunks:      db.NewChunks(sdb),
+		Uploads:     db.NewUploads(sdb),
+		Tokens:      db.NewTokens(sdb),
+		Identities:  db.NewIdentities(sdb),
+		Sessions:    db.NewSessions(sdb),
+	}
+
+	group.Transcoder = transcoder.NewFake(cfg, group)
+
+	key := bytes.Repeat([]byte{1}, 32)
+
+	r, err := routes.New(cfg, group, sessions.NewCookieStore(key, key))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(r.Router)
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	return &harness{t, server, &http.Client{Jar: jar}, store}
+}
+
+// do sends a request to the API, failing the test if it can't be sent. The response body is read and closed
+func (h *harness) do(method, path string, body io.Reader, headers map[string]string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, h.server.URL+path, body)
+	require.NoError(h.t, err)
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	require.NoError(h.t, err)
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(h.t, err)
+
+	return resp, data
+}
+
+func (h *harness) register(username string) {
+	resp, body := h.do(http.MethodPost, "/api/auth/register", strings.NewReader(fmt.Sprintf(`{"username":%q,"password":"password"}`, username)), nil)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode, string(body))
+}
+
+// clip is what the tests look at in the clips the API returns
+type clip struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Processing bool   `json:"processing"`
+	Views      int64  `json:"views"`
+	MediaType  string `json:"media_type"`
+}
+
+func (h *harness) getClip(id string) *clip {
+	resp, body := h.do(http.MethodGet, "/api/clips/"+id, nil, nil)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode, string(body))
+
+	c := &clip{}
+	require.NoError(h.t, jsoniter.Unmarshal(body, c))
+
+	return c
+}
+
+// assertPlayable fetches a clip's manifest and the first bytes of every rendition it lists, like a player would
+func (h *harness) assertPlayable(id string) {
+	resp, manifest := h.do(http.MethodGet, "/api/clips/"+id+"/dash.mpd", nil, nil)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode, string(manifest))
+
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("dash-stream%d.m4s", i)
+		assert.Contains(h.t, string(manifest), "<BaseURL>"+name+"</BaseURL>")
+
+		resp, segment := h.do(http.MethodGet, "/api/clips/"+id+"/"+name, nil, map[string]string{"Range": "bytes=0-4"})
+		assert.Equal(h.t, http.StatusPartialContent, resp.StatusCode, name)
+		assert.Len(h.t, segment, 5, name)
+	}
+}
+
+func TestUploadTranscodeStream(t *testing.T) {
+	h := newHarness(t)
+	h.register("e2e-uploader")
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	part, err := mw.CreateFormField("json")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(`{"title":"End to end"}`))
+	require.NoError(t, err)
+
+	part, err = mw.CreateFormFile("video", "video.mp4")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(mp4Header + "uploaded in one request"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	resp, data := h.do(http.MethodPost, "/api/clips", &body, map[string]string{"Content-Type": mw.FormDataContentType()})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(data))
+
+	created := &clip{}
+	require.NoError(t, jsoniter.Unmarshal(data, created))
+
+	// The fake transcoder is done by the time the upload is
+	c := h.getClip(created.ID)
+	assert.Equal(t, "End to end", c.Title)
+	assert.False(t, c.Processing)
+	assert.Equal(t, modelsx.MediaTypeVideo, c.MediaType)
+
+	h.assertPlayable(created.ID)
+
+	// Fetching the manifest is what counts as a view
+	assert.Equal(t, int64(1), h.getClip(created.ID).Views)
+
+	cid, err := modelsx.HashDecodeSingle(created.ID)
+	require.NoError(t, err)
+	assert.False(t, h.store.HasObject(context.Background(), cid, "raw"))
+
+	// Other users can't delete it, the uploader can
+	h.register("e2e-someone-else")
+
+	resp, _ = h.do(http.MethodDelete, "/api/clips/"+created.ID, nil, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = h.do(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"e2e-uploader","password":"password"}`), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, data = h.do(http.MethodDelete, "/api/clips/"+created.ID, nil, nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode, string(data))
+
+	resp, _ = h.do(http.MethodGet, "/api/clips/"+created.ID, nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestResumableUploadTranscodeStream(t *testing.T) {
+	h := newHarness(t)
+	h.register("e2e-resumer")
+
+	video := []byte(mp4Header + strings.Repeat("resumed in chunks ", 64))
+	tus := map[string]string{"Tus-Resumable": "1.0.0"}
+
+	create := map[string]string{
+		"Tus-Resumable":   "1.0.0",
+		"Upload-Length":   strconv.Itoa(len(video)),
+		"Upload-Metadata": "title " + base64.StdEncoding.EncodeToString([]byte("Resumed")),
+	}
+
+	resp, data := h.do(http.MethodPost, "/api/uploads", nil, create)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(data))
+
+	location := resp.Header.Get("Location")
+	require.NotEmpty(t, location)
+
+	// Sent in two chunks, with the offset checked in between like a client resuming after a dropped connection would
+	half := len(video) / 2
+
+	patch := func(offset int, chunk []byte) *http.Response {
+		resp, data := h.do(http.MethodPatch, location, bytes.NewReader(chunk), map[string]string{
+			"Tus-Resumable": "1.0.0",
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": strconv.Itoa(offset),
+		})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode, string(data))
+
+		return resp
+	}
+
+	patch(0, video[:half])
+
+	resp, _ = h.do(http.MethodHead, location, nil, tus)
+	require.Equal(t, strconv.Itoa(half), resp.Header.Get("Upload-Offset"))
+
+	resp = patch(half, video[half:])
+
+	id := resp.Header.Get("Clip-ID")
+	require.NotEmpty(t, id)
+
+	c := h.getClip(id)
+	assert.Equal(t, "Resumed", c.Title)
+	assert.False(t, c.Processing)
+
+	h.assertPlayable(id)
+}
@@ -0,0 +1 @@
+ALTER TABLE "clips" DROP COLUMN "media_type";
\ No newline at end of file
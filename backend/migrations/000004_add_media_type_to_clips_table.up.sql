@@ -0,0 +1 @@
+ALTER TABLE "clips" ADD "media_type" varchar NOT NULL DEFAULT 'video';
\ No newline at end of file
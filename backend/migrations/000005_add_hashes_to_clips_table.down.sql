@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_clip_content_hash;
+ALTER TABLE "clips" DROP COLUMN "perceptual_hash";
+ALTER TABLE "clips" DROP COLUMN "content_hash";
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE "clips" ADD "content_hash" varchar;
+ALTER TABLE "clips" ADD "perceptual_hash" bigint;
+
+CREATE INDEX IF NOT EXISTS idx_clip_content_hash ON "clips" (content_hash);
\ No newline at end of file
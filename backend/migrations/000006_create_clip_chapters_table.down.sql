@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "clip_chapters";
\ No newline at end of file
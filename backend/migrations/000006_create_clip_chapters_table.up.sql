@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "clip_chapters" (
+  id            bigserial   PRIMARY KEY,
+  clip_id       bigint      REFERENCES "clips" (id) ON DELETE CASCADE NOT NULL,
+  title         varchar     NOT NULL,
+  start_ms      bigint      NOT NULL,
+  end_ms        bigint      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_clip_chapters_clip_id ON "clip_chapters" (clip_id);
\ No newline at end of file
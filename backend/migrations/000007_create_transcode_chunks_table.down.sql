@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "transcode_chunks";
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "transcode_chunks" (
+  clip_id       bigint      REFERENCES "clips" (id) ON DELETE CASCADE NOT NULL,
+  chunk_index   int         NOT NULL,
+  start_ms      bigint      NOT NULL,
+  duration_ms   bigint      NOT NULL,
+  PRIMARY KEY (clip_id, chunk_index)
+);
\ No newline at end of file
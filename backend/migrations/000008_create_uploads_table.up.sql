@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "uploads" (
+  id              bigserial                 PRIMARY KEY,
+  user_id         bigint                    REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+  clip_id         bigint                    NOT NULL,
+  title           varchar                   NOT NULL,
+  "description"   varchar,
+  unlisted        boolean                   NOT NULL DEFAULT false,
+  upload_length   bigint                    NOT NULL,
+  upload_offset   bigint                    NOT NULL DEFAULT 0,
+  parts           int                       NOT NULL DEFAULT 0,
+  completed       boolean                   NOT NULL DEFAULT false,
+  created_at      timestamp with time zone  NOT NULL DEFAULT now(),
+  expires_at      timestamp with time zone  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_expires_at ON "uploads" (expires_at);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_clip_creator_id;
+ALTER TABLE "user" DROP COLUMN "quota_bytes";
+ALTER TABLE "clips" DROP COLUMN "size_bytes";
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE "clips" ADD "size_bytes" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "user" ADD "quota_bytes" bigint;
+
+CREATE INDEX IF NOT EXISTS idx_clip_creator_id ON "clips" (creator_id);
\ No newline at end of file
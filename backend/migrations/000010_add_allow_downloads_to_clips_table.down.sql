@@ -0,0 +1 @@
+ALTER TABLE "clips" DROP COLUMN "allow_downloads";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "clips" ADD "allow_downloads" boolean NOT NULL DEFAULT true;
\ No newline at end of file
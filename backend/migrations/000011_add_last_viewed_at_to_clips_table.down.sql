@@ -0,0 +1 @@
+ALTER TABLE "clips" DROP COLUMN "last_viewed_at";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "clips" ADD "last_viewed_at" timestamptz;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "tokens" (
+  id              bigserial                 PRIMARY KEY,
+  user_id         bigint                    REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+  "name"          varchar                   NOT NULL,
+  hash            varchar                   NOT NULL UNIQUE,
+  prefix          varchar                   NOT NULL,
+  scopes          varchar                   NOT NULL,
+  created_at      timestamp with time zone  NOT NULL DEFAULT now(),
+  last_used_at    timestamp with time zone,
+  expires_at      timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_tokens_user_id ON "tokens" (user_id);
\ No newline at end of file
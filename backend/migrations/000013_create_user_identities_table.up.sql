@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "user_identities" (
+  id              bigserial                 PRIMARY KEY,
+  user_id         bigint                    REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+  provider        varchar                   NOT NULL,
+  subject         varchar                   NOT NULL,
+  created_at      timestamp with time zone  NOT NULL DEFAULT now(),
+  UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON "user_identities" (user_id);
\ No newline at end of file
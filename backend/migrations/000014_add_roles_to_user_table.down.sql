@@ -0,0 +1,2 @@
+ALTER TABLE "user" DROP COLUMN "disabled";
+ALTER TABLE "user" DROP COLUMN "role";
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE "user" ADD "role" varchar NOT NULL DEFAULT 'user';
+ALTER TABLE "user" ADD "disabled" boolean NOT NULL DEFAULT false;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "sessions" (
+  id              bigserial                 PRIMARY KEY,
+  user_id         bigint                    REFERENCES "user" (id) ON DELETE CASCADE NOT NULL,
+  hash            varchar                   NOT NULL UNIQUE,
+  "data"          bytea                     NOT NULL,
+  ip              varchar                   NOT NULL DEFAULT '',
+  user_agent      varchar                   NOT NULL DEFAULT '',
+  created_at      timestamp with time zone  NOT NULL DEFAULT now(),
+  last_seen_at    timestamp with time zone  NOT NULL DEFAULT now(),
+  expires_at      timestamp with time zone  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON "sessions" (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON "sessions" (expires_at);
\ No newline at end of file
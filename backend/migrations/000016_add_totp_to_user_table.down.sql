@@ -0,0 +1,4 @@
+ALTER TABLE "user" DROP COLUMN "totp_last_step";
+ALTER TABLE "user" DROP COLUMN "totp_recovery_codes";
+ALTER TABLE "user" DROP COLUMN "totp_enabled";
+ALTER TABLE "user" DROP COLUMN "totp_secret";
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE "user" ADD "totp_secret" varchar;
+ALTER TABLE "user" ADD "totp_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "user" ADD "totp_recovery_codes" varchar NOT NULL DEFAULT '';
+ALTER TABLE "user" ADD "totp_last_step" bigint NOT NULL DEFAULT 0;
\ No newline at end of file
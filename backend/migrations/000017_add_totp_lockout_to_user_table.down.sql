@@ -0,0 +1,2 @@
+ALTER TABLE "user" DROP COLUMN "totp_locked_until";
+ALTER TABLE "user" DROP COLUMN "totp_failed_attempts";
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE "user" ADD "totp_failed_attempts" int NOT NULL DEFAULT 0;
+ALTER TABLE "user" ADD "totp_locked_until" timestamptz;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "user" DROP COLUMN "password_unset";
\ No newline at end of file
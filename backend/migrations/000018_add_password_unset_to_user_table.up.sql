@@ -0,0 +1 @@
+ALTER TABLE "user" ADD "password_unset" boolean NOT NULL DEFAULT false;
\ No newline at end of file
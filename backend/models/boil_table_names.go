@@ -4,11 +4,23 @@
 package models
 
 var TableNames = struct {
+	ClipChapters     string
 	Clips            string
 	SchemaMigrations string
+	Sessions         string
+	Tokens           string
+	TranscodeChunks  string
+	Uploads          string
 	User             string
+	UserIdentities   string
 }{
+	ClipChapters:     "clip_chapters",
 	Clips:            "clips",
 	SchemaMigrations: "schema_migrations",
+	Sessions:         "sessions",
+	Tokens:           "tokens",
+	TranscodeChunks:  "transcode_chunks",
+	Uploads:          "uploads",
 	User:             "user",
+	UserIdentities:   "user_identities",
 }
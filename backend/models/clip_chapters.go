@@ -0,0 +1,1249 @@
+// Code generated by SQLBoiler 4.14.1 (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"github.com/volatiletech/sqlboiler/v4/queries/qmhelper"
+	"github.com/volatiletech/strmangle"
+)
+
+// ClipChapter is an object representing the database table.
+type ClipChapter struct {
+	ID      int64  `boil:"id" json:"id" toml:"id" yaml:"id"`
+	ClipID  int64  `boil:"clip_id" json:"clip_id" toml:"clip_id" yaml:"clip_id"`
+	Title   string `boil:"title" json:"title" toml:"title" yaml:"title"`
+	StartMS int64  `boil:"start_ms" json:"start_ms" toml:"start_ms" yaml:"start_ms"`
+	EndMS   int64  `boil:"end_ms" json:"end_ms" toml:"end_ms" yaml:"end_ms"`
+
+	R *clipChapterR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L clipChapterL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var ClipChapterColumns = struct {
+	ID      string
+	ClipID  string
+	Title   string
+	StartMS string
+	EndMS   string
+}{
+	ID:      "id",
+	ClipID:  "clip_id",
+	Title:   "title",
+	StartMS: "start_ms",
+	EndMS:   "end_ms",
+}
+
+var ClipChapterTableColumns = struct {
+	ID      string
+	ClipID  string
+	Title   string
+	StartMS string
+	EndMS   string
+}{
+	ID:      "clip_chapters.id",
+	ClipID:  "clip_chapters.clip_id",
+	Title:   "clip_chapters.title",
+	StartMS: "clip_chapters.start_ms",
+	EndMS:   "clip_chapters.end_ms",
+}
+
+// Generated where
+
+type whereHelperint64 struct{ field string }
+
+func (w whereHelperint64) EQ(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperint64) NEQ(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperint64) LT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperint64) LTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperint64) GT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperint64) GTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+func (w whereHelperint64) IN(slice []int64) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
+}
+func (w whereHelperint64) NIN(slice []int64) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
+}
+
+type whereHelperstring struct{ field string }
+
+func (w whereHelperstring) EQ(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperstring) NEQ(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperstring) LT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperstring) LTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperstring) GT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperstring) GTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+func (w whereHelperstring) IN(slice []string) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
+}
+func (w whereHelperstring) NIN(slice []string) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
+}
+
+var ClipChapterWhere = struct {
+	ID      whereHelperint64
+	ClipID  whereHelperint64
+	Title   whereHelperstring
+	StartMS whereHelperint64
+	EndMS   whereHelperint64
+}{
+	ID:      whereHelperint64{field: "\"clip_chapters\".\"id\""},
+	ClipID:  whereHelperint64{field: "\"clip_chapters\".\"clip_id\""},
+	Title:   whereHelperstring{field: "\"clip_chapters\".\"title\""},
+	StartMS: whereHelperint64{field: "\"clip_chapters\".\"start_ms\""},
+	EndMS:   whereHelperint64{field: "\"clip_chapters\".\"end_ms\""},
+}
+
+// ClipChapterRels is where relationship names are stored.
+var ClipChapterRels = struct {
+	Clip string
+}{
+	Clip: "Clip",
+}
+
+// clipChapterR is where relationships are stored.
+type clipChapterR struct {
+	Clip *Clip `boil:"Clip" json:"Clip" toml:"Clip" yaml:"Clip"`
+}
+
+// NewStruct creates a new relationship struct
+func (*clipChapterR) NewStruct() *clipChapterR {
+	return &clipChapterR{}
+}
+
+func (r *clipChapterR) GetClip() *Clip {
+	if r == nil {
+		return nil
+	}
+	return r.Clip
+}
+
+// clipChapterL is where Load methods for each relationship are stored.
+type clipChapterL struct{}
+
+var (
+	clipChapterAllColumns            = []string{"id", "clip_id", "title", "start_ms", "end_ms"}
+	clipChapterColumnsWithoutDefault = []string{"clip_id", "title", "start_ms", "end_ms"}
+	clipChapterColumnsWithDefault    = []string{"id"}
+	clipChapterPrimaryKeyColumns     = []string{"id"}
+	clipChapterGeneratedColumns      = []string{}
+)
+
+type (
+	// ClipChapterSlice is an alias for a slice of pointers to ClipChapter.
+	// This should almost always be used instead of []ClipChapter.
+	ClipChapterSlice []*ClipChapter
+	// ClipChapterHook is the signature for custom ClipChapter hook methods
+	ClipChapterHook func(context.Context, boil.ContextExecutor, *ClipChapter) error
+
+	clipChapterQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	clipChapterType                 = reflect.TypeOf(&ClipChapter{})
+	clipChapterMapping              = queries.MakeStructMapping(clipChapterType)
+	clipChapterPrimaryKeyMapping, _ = queries.BindMapping(clipChapterType, clipChapterMapping, clipChapterPrimaryKeyColumns)
+	clipChapterInsertCacheMut       sync.RWMutex
+	clipChapterInsertCache          = make(map[string]insertCache)
+	clipChapterUpdateCacheMut       sync.RWMutex
+	clipChapterUpdateCache          = make(map[string]updateCache)
+	clipChapterUpsertCacheMut       sync.RWMutex
+	clipChapterUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var clipChapterAfterSelectHooks []ClipChapterHook
+
+var clipChapterBeforeInsertHooks []ClipChapterHook
+var clipChapterAfterInsertHooks []ClipChapterHook
+
+var clipChapterBeforeUpdateHooks []ClipChapterHook
+var clipChapterAfterUpdateHooks []ClipChapterHook
+
+var clipChapterBeforeDeleteHooks []ClipChapterHook
+var clipChapterAfterDeleteHooks []ClipChapterHook
+
+var clipChapterBeforeUpsertHooks []ClipChapterHook
+var clipChapterAfterUpsertHooks []ClipChapterHook
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *ClipChapter) doAfterSelectHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterAfterSelectHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *ClipChapter) doBeforeInsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterBeforeInsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *ClipChapter) doAfterInsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterAfterInsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *ClipChapter) doBeforeUpdateHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterBeforeUpdateHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *ClipChapter) doAfterUpdateHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterAfterUpdateHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *ClipChapter) doBeforeDeleteHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterBeforeDeleteHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *ClipChapter) doAfterDeleteHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterAfterDeleteHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *ClipChapter) doBeforeUpsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterBeforeUpsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *ClipChapter) doAfterUpsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range clipChapterAfterUpsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddClipChapterHook registers your hook function for all future operations.
+func AddClipChapterHook(hookPoint boil.HookPoint, clipChapterHook ClipChapterHook) {
+	switch hookPoint {
+	case boil.AfterSelectHook:
+		clipChapterAfterSelectHooks = append(clipChapterAfterSelectHooks, clipChapterHook)
+	case boil.BeforeInsertHook:
+		clipChapterBeforeInsertHooks = append(clipChapterBeforeInsertHooks, clipChapterHook)
+	case boil.AfterInsertHook:
+		clipChapterAfterInsertHooks = append(clipChapterAfterInsertHooks, clipChapterHook)
+	case boil.BeforeUpdateHook:
+		clipChapterBeforeUpdateHooks = append(clipChapterBeforeUpdateHooks, clipChapterHook)
+	case boil.AfterUpdateHook:
+		clipChapterAfterUpdateHooks = append(clipChapterAfterUpdateHooks, clipChapterHook)
+	case boil.BeforeDeleteHook:
+		clipChapterBeforeDeleteHooks = append(clipChapterBeforeDeleteHooks, clipChapterHook)
+	case boil.AfterDeleteHook:
+		clipChapterAfterDeleteHooks = append(clipChapterAfterDeleteHooks, clipChapterHook)
+	case boil.BeforeUpsertHook:
+		clipChapterBeforeUpsertHooks = append(clipChapterBeforeUpsertHooks, clipChapterHook)
+	case boil.AfterUpsertHook:
+		clipChapterAfterUpsertHooks = append(clipChapterAfterUpsertHooks, clipChapterHook)
+	}
+}
+
+// OneG returns a single clipChapter record from the query using the global executor.
+func (q clipChapterQuery) OneG(ctx context.Context) (*ClipChapter, error) {
+	return q.One(ctx, boil.GetContextDB())
+}
+
+// One returns a single clipChapter record from the query.
+func (q clipChapterQuery) One(ctx context.Context, exec boil.ContextExecutor) (*ClipChapter, error) {
+	o := &ClipChapter{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(ctx, exec, o)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for clip_chapters")
+	}
+
+	if err := o.doAfterSelectHooks(ctx, exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all ClipChapter records from the query using the global executor.
+func (q clipChapterQuery) AllG(ctx context.Context) (ClipChapterSlice, error) {
+	return q.All(ctx, boil.GetContextDB())
+}
+
+// All returns all ClipChapter records from the query.
+func (q clipChapterQuery) All(ctx context.Context, exec boil.ContextExecutor) (ClipChapterSlice, error) {
+	var o []*ClipChapter
+
+	err := q.Bind(ctx, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to ClipChapter slice")
+	}
+
+	if len(clipChapterAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(ctx, exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all ClipChapter records in the query using the global executor
+func (q clipChapterQuery) CountG(ctx context.Context) (int64, error) {
+	return q.Count(ctx, boil.GetContextDB())
+}
+
+// Count returns the count of all ClipChapter records in the query.
+func (q clipChapterQuery) Count(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRowContext(ctx, exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count clip_chapters rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table using the global executor.
+func (q clipChapterQuery) ExistsG(ctx context.Context) (bool, error) {
+	return q.Exists(ctx, boil.GetContextDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q clipChapterQuery) Exists(ctx context.Context, exec boil.ContextExecutor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRowContext(ctx, exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if clip_chapters exists")
+	}
+
+	return count > 0, nil
+}
+
+// Clip pointed to by the foreign key.
+func (o *ClipChapter) Clip(mods ...qm.QueryMod) clipQuery {
+	queryMods := []qm.QueryMod{
+		qm.Where("\"id\" = ?", o.ClipID),
+	}
+
+	queryMods = append(queryMods, mods...)
+
+	return Clips(queryMods...)
+}
+
+// LoadClip allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for an N-1 relationship.
+func (clipChapterL) LoadClip(ctx context.Context, e boil.ContextExecutor, singular bool, maybeClipChapter interface{}, mods queries.Applicator) error {
+	var slice []*ClipChapter
+	var object *ClipChapter
+
+	if singular {
+		var ok bool
+		object, ok = maybeClipChapter.(*ClipChapter)
+		if !ok {
+			object = new(ClipChapter)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeClipChapter)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeClipChapter))
+			}
+		}
+	} else {
+		s, ok := maybeClipChapter.(*[]*ClipChapter)
+		if ok {
+			slice = *s
+		} else {
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeClipChapter)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeClipChapter))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &clipChapterR{}
+		}
+		args = append(args, object.ClipID)
+
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &clipChapterR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ClipID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ClipID)
+
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`clips`),
+		qm.WhereIn(`clips.id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load Clip")
+	}
+
+	var resultSlice []*Clip
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice Clip")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results of eager load for clips")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for clips")
+	}
+
+	if len(clipAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(resultSlice) == 0 {
+		return nil
+	}
+
+	if singular {
+		foreign := resultSlice[0]
+		object.R.Clip = foreign
+		if foreign.R == nil {
+			foreign.R = &clipR{}
+		}
+		foreign.R.ClipChapters = append(foreign.R.ClipChapters, object)
+		return nil
+	}
+
+	for _, local := range slice {
+		for _, foreign := range resultSlice {
+			if local.ClipID == foreign.ID {
+				local.R.Clip = foreign
+				if foreign.R == nil {
+					foreign.R = &clipR{}
+				}
+				foreign.R.ClipChapters = append(foreign.R.ClipChapters, local)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetClipG of the clipChapter to the related item.
+// Sets o.R.Clip to related.
+// Adds o to related.R.ClipChapters.
+// Uses the global database handle.
+func (o *ClipChapter) SetClipG(ctx context.Context, insert bool, related *Clip) error {
+	return o.SetClip(ctx, boil.GetContextDB(), insert, related)
+}
+
+// SetClip of the clipChapter to the related item.
+// Sets o.R.Clip to related.
+// Adds o to related.R.ClipChapters.
+func (o *ClipChapter) SetClip(ctx context.Context, exec boil.ContextExecutor, insert bool, related *Clip) error {
+	var err error
+	if insert {
+		if err = related.Insert(ctx, exec, boil.Infer()); err != nil {
+			return errors.Wrap(err, "failed to insert into foreign table")
+		}
+	}
+
+	updateQuery := fmt.Sprintf(
+		"UPDATE \"clip_chapters\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, []string{"clip_id"}),
+		strmangle.WhereClause("\"", "\"", 2, clipChapterPrimaryKeyColumns),
+	)
+	values := []interface{}{related.ID, o.ID}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, updateQuery)
+		fmt.Fprintln(writer, values)
+	}
+	if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+		return errors.Wrap(err, "failed to update local table")
+	}
+
+	o.ClipID = related.ID
+	if o.R == nil {
+		o.R = &clipChapterR{
+			Clip: related,
+		}
+	} else {
+		o.R.Clip = related
+	}
+
+	if related.R == nil {
+		related.R = &clipR{
+			ClipChapters: ClipChapterSlice{o},
+		}
+	} else {
+		related.R.ClipChapters = append(related.R.ClipChapters, o)
+	}
+
+	return nil
+}
+
+// ClipChapters retrieves all the records using an executor.
+func ClipChapters(mods ...qm.QueryMod) clipChapterQuery {
+	mods = append(mods, qm.From("\"clip_chapters\""))
+	q := NewQuery(mods...)
+	if len(queries.GetSelect(q)) == 0 {
+		queries.SetSelect(q, []string{"\"clip_chapters\".*"})
+	}
+
+	return clipChapterQuery{q}
+}
+
+// FindClipChapterG retrieves a single record by ID.
+func FindClipChapterG(ctx context.Context, iD int64, selectCols ...string) (*ClipChapter, error) {
+	return FindClipChapter(ctx, boil.GetContextDB(), iD, selectCols...)
+}
+
+// FindClipChapter retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindClipChapter(ctx context.Context, exec boil.ContextExecutor, iD int64, selectCols ...string) (*ClipChapter, error) {
+	clipChapterObj := &ClipChapter{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"clip_chapters\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(ctx, exec, clipChapterObj)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from clip_chapters")
+	}
+
+	if err = clipChapterObj.doAfterSelectHooks(ctx, exec); err != nil {
+		return clipChapterObj, err
+	}
+
+	return clipChapterObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *ClipChapter) InsertG(ctx context.Context, columns boil.Columns) error {
+	return o.Insert(ctx, boil.GetContextDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *ClipChapter) Insert(ctx context.Context, exec boil.ContextExecutor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no clip_chapters provided for insertion")
+	}
+
+	var err error
+
+	if err := o.doBeforeInsertHooks(ctx, exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(clipChapterColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	clipChapterInsertCacheMut.RLock()
+	cache, cached := clipChapterInsertCache[key]
+	clipChapterInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			clipChapterAllColumns,
+			clipChapterColumnsWithDefault,
+			clipChapterColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(clipChapterType, clipChapterMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(clipChapterType, clipChapterMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"clip_chapters\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"clip_chapters\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRowContext(ctx, cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.ExecContext(ctx, cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into clip_chapters")
+	}
+
+	if !cached {
+		clipChapterInsertCacheMut.Lock()
+		clipChapterInsertCache[key] = cache
+		clipChapterInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(ctx, exec)
+}
+
+// UpdateG a single ClipChapter record using the global executor.
+// See Update for more documentation.
+func (o *ClipChapter) UpdateG(ctx context.Context, columns boil.Columns) (int64, error) {
+	return o.Update(ctx, boil.GetContextDB(), columns)
+}
+
+// Update uses an executor to update the ClipChapter.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *ClipChapter) Update(ctx context.Context, exec boil.ContextExecutor, columns boil.Columns) (int64, error) {
+	var err error
+	if err = o.doBeforeUpdateHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	clipChapterUpdateCacheMut.RLock()
+	cache, cached := clipChapterUpdateCache[key]
+	clipChapterUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			clipChapterAllColumns,
+			clipChapterPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update clip_chapters, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"clip_chapters\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, clipChapterPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(clipChapterType, clipChapterMapping, append(wl, clipChapterPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, values)
+	}
+	var result sql.Result
+	result, err = exec.ExecContext(ctx, cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update clip_chapters row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for clip_chapters")
+	}
+
+	if !cached {
+		clipChapterUpdateCacheMut.Lock()
+		clipChapterUpdateCache[key] = cache
+		clipChapterUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(ctx, exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q clipChapterQuery) UpdateAllG(ctx context.Context, cols M) (int64, error) {
+	return q.UpdateAll(ctx, boil.GetContextDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q clipChapterQuery) UpdateAll(ctx context.Context, exec boil.ContextExecutor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for clip_chapters")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for clip_chapters")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o ClipChapterSlice) UpdateAllG(ctx context.Context, cols M) (int64, error) {
+	return o.UpdateAll(ctx, boil.GetContextDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o ClipChapterSlice) UpdateAll(ctx context.Context, exec boil.ContextExecutor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), clipChapterPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"clip_chapters\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, clipChapterPrimaryKeyColumns, len(o)))
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args...)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in clipChapter slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all clipChapter")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *ClipChapter) UpsertG(ctx context.Context, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(ctx, boil.GetContextDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *ClipChapter) Upsert(ctx context.Context, exec boil.ContextExecutor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no clip_chapters provided for upsert")
+	}
+
+	if err := o.doBeforeUpsertHooks(ctx, exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(clipChapterColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	clipChapterUpsertCacheMut.RLock()
+	cache, cached := clipChapterUpsertCache[key]
+	clipChapterUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			clipChapterAllColumns,
+			clipChapterColumnsWithDefault,
+			clipChapterColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		update := updateColumns.UpdateColumnSet(
+			clipChapterAllColumns,
+			clipChapterPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert clip_chapters, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(clipChapterPrimaryKeyColumns))
+			copy(conflict, clipChapterPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"clip_chapters\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(clipChapterType, clipChapterMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(clipChapterType, clipChapterMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, vals)
+	}
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRowContext(ctx, cache.query, vals...).Scan(returns...)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.ExecContext(ctx, cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert clip_chapters")
+	}
+
+	if !cached {
+		clipChapterUpsertCacheMut.Lock()
+		clipChapterUpsertCache[key] = cache
+		clipChapterUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(ctx, exec)
+}
+
+// DeleteG deletes a single ClipChapter record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *ClipChapter) DeleteG(ctx context.Context) (int64, error) {
+	return o.Delete(ctx, boil.GetContextDB())
+}
+
+// Delete deletes a single ClipChapter record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *ClipChapter) Delete(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no ClipChapter provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), clipChapterPrimaryKeyMapping)
+	sql := "DELETE FROM \"clip_chapters\" WHERE \"id\"=$1"
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args...)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from clip_chapters")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for clip_chapters")
+	}
+
+	if err := o.doAfterDeleteHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+func (q clipChapterQuery) DeleteAllG(ctx context.Context) (int64, error) {
+	return q.DeleteAll(ctx, boil.GetContextDB())
+}
+
+// DeleteAll deletes all matching rows.
+func (q clipChapterQuery) DeleteAll(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no clipChapterQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from clip_chapters")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for clip_chapters")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o ClipChapterSlice) DeleteAllG(ctx context.Context) (int64, error) {
+	return o.DeleteAll(ctx, boil.GetContextDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o ClipChapterSlice) DeleteAll(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(clipChapterBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(ctx, exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), clipChapterPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"clip_chapters\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, clipChapterPrimaryKeyColumns, len(o))
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from clipChapter slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for clip_chapters")
+	}
+
+	if len(clipChapterAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(ctx, exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *ClipChapter) ReloadG(ctx context.Context) error {
+	if o == nil {
+		return errors.New("models: no ClipChapter provided for reload")
+	}
+
+	return o.Reload(ctx, boil.GetContextDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *ClipChapter) Reload(ctx context.Context, exec boil.ContextExecutor) error {
+	ret, err := FindClipChapter(ctx, exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *ClipChapterSlice) ReloadAllG(ctx context.Context) error {
+	if o == nil {
+		return errors.New("models: empty ClipChapterSlice provided for reload all")
+	}
+
+	return o.ReloadAll(ctx, boil.GetContextDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *ClipChapterSlice) ReloadAll(ctx context.Context, exec boil.ContextExecutor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := ClipChapterSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), clipChapterPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"clip_chapters\".* FROM \"clip_chapters\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, clipChapterPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(ctx, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in ClipChapterSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// ClipChapterExistsG checks if the ClipChapter row exists.
+func ClipChapterExistsG(ctx context.Context, iD int64) (bool, error) {
+	return ClipChapterExists(ctx, boil.GetContextDB(), iD)
+}
+
+// ClipChapterExists checks if the ClipChapter row exists.
+func ClipChapterExists(ctx context.Context, exec boil.ContextExecutor, iD int64) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"clip_chapters\" where \"id\"=$1 limit 1)"
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, iD)
+	}
+	row := exec.QueryRowContext(ctx, sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if clip_chapters exists")
+	}
+
+	return exists, nil
+}
+
+// Exists checks if the ClipChapter row exists.
+func (o *ClipChapter) Exists(ctx context.Context, exec boil.ContextExecutor) (bool, error) {
+	return ClipChapterExists(ctx, exec, o.ID)
+}
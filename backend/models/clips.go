@@ -24,107 +24,91 @@ import (
 
 // Clip is an object representing the database table.
 type Clip struct {
-	ID          int64       `boil:"id" json:"id" toml:"id" yaml:"id"`
-	Title       string      `boil:"title" json:"title" toml:"title" yaml:"title"`
-	Description null.String `boil:"description" json:"description,omitempty" toml:"description" yaml:"description,omitempty"`
-	CreatorID   int64       `boil:"creator_id" json:"creator_id" toml:"creator_id" yaml:"creator_id"`
-	Processing  bool        `boil:"processing" json:"processing" toml:"processing" yaml:"processing"`
-	CreatedAt   time.Time   `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
-	Views       int64       `boil:"views" json:"views" toml:"views" yaml:"views"`
-	Unlisted    bool        `boil:"unlisted" json:"unlisted" toml:"unlisted" yaml:"unlisted"`
+	ID             int64       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Title          string      `boil:"title" json:"title" toml:"title" yaml:"title"`
+	Description    null.String `boil:"description" json:"description,omitempty" toml:"description" yaml:"description,omitempty"`
+	CreatorID      int64       `boil:"creator_id" json:"creator_id" toml:"creator_id" yaml:"creator_id"`
+	Processing     bool        `boil:"processing" json:"processing" toml:"processing" yaml:"processing"`
+	CreatedAt      time.Time   `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	Views          int64       `boil:"views" json:"views" toml:"views" yaml:"views"`
+	Unlisted       bool        `boil:"unlisted" json:"unlisted" toml:"unlisted" yaml:"unlisted"`
+	MediaType      string      `boil:"media_type" json:"media_type" toml:"media_type" yaml:"media_type"`
+	ContentHash    null.String `boil:"content_hash" json:"content_hash,omitempty" toml:"content_hash" yaml:"content_hash,omitempty"`
+	PerceptualHash null.Int64  `boil:"perceptual_hash" json:"perceptual_hash,omitempty" toml:"perceptual_hash" yaml:"perceptual_hash,omitempty"`
+	SizeBytes      int64       `boil:"size_bytes" json:"size_bytes" toml:"size_bytes" yaml:"size_bytes"`
+	AllowDownloads bool        `boil:"allow_downloads" json:"allow_downloads" toml:"allow_downloads" yaml:"allow_downloads"`
+	LastViewedAt   null.Time   `boil:"last_viewed_at" json:"last_viewed_at,omitempty" toml:"last_viewed_at" yaml:"last_viewed_at,omitempty"`
 
 	R *clipR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L clipL  `boil:"-" json:"-" toml:"-" yaml:"-"`
 }
 
 var ClipColumns = struct {
-	ID          string
-	Title       string
-	Description string
-	CreatorID   string
-	Processing  string
-	CreatedAt   string
-	Views       string
-	Unlisted    string
+	ID             string
+	Title          string
+	Description    string
+	CreatorID      string
+	Processing     string
+	CreatedAt      string
+	Views          string
+	Unlisted       string
+	MediaType      string
+	ContentHash    string
+	PerceptualHash string
+	SizeBytes      string
+	AllowDownloads string
+	LastViewedAt   string
 }{
-	ID:          "id",
-	Title:       "title",
-	Description: "description",
-	CreatorID:   "creator_id",
-	Processing:  "processing",
-	CreatedAt:   "created_at",
-	Views:       "views",
-	Unlisted:    "unlisted",
+	ID:             "id",
+	Title:          "title",
+	Description:    "description",
+	CreatorID:      "creator_id",
+	Processing:     "processing",
+	CreatedAt:      "created_at",
+	Views:          "views",
+	Unlisted:       "unlisted",
+	MediaType:      "media_type",
+	ContentHash:    "content_hash",
+	PerceptualHash: "perceptual_hash",
+	SizeBytes:      "size_bytes",
+	AllowDownloads: "allow_downloads",
+	LastViewedAt:   "last_viewed_at",
 }
 
 var ClipTableColumns = struct {
-	ID          string
-	Title       string
-	Description string
-	CreatorID   string
-	Processing  string
-	CreatedAt   string
-	Views       string
-	Unlisted    string
+	ID             string
+	Title          string
+	Description    string
+	CreatorID      string
+	Processing     string
+	CreatedAt      string
+	Views          string
+	Unlisted       string
+	MediaType      string
+	ContentHash    string
+	PerceptualHash string
+	SizeBytes      string
+	AllowDownloads string
+	LastViewedAt   string
 }{
-	ID:          "clips.id",
-	Title:       "clips.title",
-	Description: "clips.description",
-	CreatorID:   "clips.creator_id",
-	Processing:  "clips.processing",
-	CreatedAt:   "clips.created_at",
-	Views:       "clips.views",
-	Unlisted:    "clips.unlisted",
+	ID:             "clips.id",
+	Title:          "clips.title",
+	Description:    "clips.description",
+	CreatorID:      "clips.creator_id",
+	Processing:     "clips.processing",
+	CreatedAt:      "clips.created_at",
+	Views:          "clips.views",
+	Unlisted:       "clips.unlisted",
+	MediaType:      "clips.media_type",
+	ContentHash:    "clips.content_hash",
+	PerceptualHash: "clips.perceptual_hash",
+	SizeBytes:      "clips.size_bytes",
+	AllowDownloads: "clips.allow_downloads",
+	LastViewedAt:   "clips.last_viewed_at",
 }
 
 // Generated where
 
-type whereHelperint64 struct{ field string }
-
-func (w whereHelperint64) EQ(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
-func (w whereHelperint64) NEQ(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
-func (w whereHelperint64) LT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
-func (w whereHelperint64) LTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
-func (w whereHelperint64) GT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
-func (w whereHelperint64) GTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
-func (w whereHelperint64) IN(slice []int64) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
-}
-func (w whereHelperint64) NIN(slice []int64) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
-}
-
-type whereHelperstring struct{ field string }
-
-func (w whereHelperstring) EQ(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
-func (w whereHelperstring) NEQ(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
-func (w whereHelperstring) LT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
-func (w whereHelperstring) LTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
-func (w whereHelperstring) GT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
-func (w whereHelperstring) GTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
-func (w whereHelperstring) IN(slice []string) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
-}
-func (w whereHelperstring) NIN(slice []string) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
-}
-
 type whereHelpernull_String struct{ field string }
 
 func (w whereHelpernull_String) EQ(x null.String) qm.QueryMod {
@@ -193,36 +177,116 @@ func (w whereHelpertime_Time) GTE(x time.Time) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GTE, x)
 }
 
+type whereHelpernull_Int64 struct{ field string }
+
+func (w whereHelpernull_Int64) EQ(x null.Int64) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_Int64) NEQ(x null.Int64) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_Int64) LT(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_Int64) LTE(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_Int64) GT(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_Int64) GTE(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+func (w whereHelpernull_Int64) IN(slice []int64) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
+}
+func (w whereHelpernull_Int64) NIN(slice []int64) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
+}
+
+func (w whereHelpernull_Int64) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_Int64) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
+type whereHelpernull_Time struct{ field string }
+
+func (w whereHelpernull_Time) EQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_Time) NEQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_Time) LT(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_Time) LTE(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_Time) GT(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_Time) GTE(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+func (w whereHelpernull_Time) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_Time) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
 var ClipWhere = struct {
-	ID          whereHelperint64
-	Title       whereHelperstring
-	Description whereHelpernull_String
-	CreatorID   whereHelperint64
-	Processing  whereHelperbool
-	CreatedAt   whereHelpertime_Time
-	Views       whereHelperint64
-	Unlisted    whereHelperbool
+	ID             whereHelperint64
+	Title          whereHelperstring
+	Description    whereHelpernull_String
+	CreatorID      whereHelperint64
+	Processing     whereHelperbool
+	CreatedAt      whereHelpertime_Time
+	Views          whereHelperint64
+	Unlisted       whereHelperbool
+	MediaType      whereHelperstring
+	ContentHash    whereHelpernull_String
+	PerceptualHash whereHelpernull_Int64
+	SizeBytes      whereHelperint64
+	AllowDownloads whereHelperbool
+	LastViewedAt   whereHelpernull_Time
 }{
-	ID:          whereHelperint64{field: "\"clips\".\"id\""},
-	Title:       whereHelperstring{field: "\"clips\".\"title\""},
-	Description: whereHelpernull_String{field: "\"clips\".\"description\""},
-	CreatorID:   whereHelperint64{field: "\"clips\".\"creator_id\""},
-	Processing:  whereHelperbool{field: "\"clips\".\"processing\""},
-	CreatedAt:   whereHelpertime_Time{field: "\"clips\".\"created_at\""},
-	Views:       whereHelperint64{field: "\"clips\".\"views\""},
-	Unlisted:    whereHelperbool{field: "\"clips\".\"unlisted\""},
+	ID:             whereHelperint64{field: "\"clips\".\"id\""},
+	Title:          whereHelperstring{field: "\"clips\".\"title\""},
+	Description:    whereHelpernull_String{field: "\"clips\".\"description\""},
+	CreatorID:      whereHelperint64{field: "\"clips\".\"creator_id\""},
+	Processing:     whereHelperbool{field: "\"clips\".\"processing\""},
+	CreatedAt:      whereHelpertime_Time{field: "\"clips\".\"created_at\""},
+	Views:          whereHelperint64{field: "\"clips\".\"views\""},
+	Unlisted:       whereHelperbool{field: "\"clips\".\"unlisted\""},
+	MediaType:      whereHelperstring{field: "\"clips\".\"media_type\""},
+	ContentHash:    whereHelpernull_String{field: "\"clips\".\"content_hash\""},
+	PerceptualHash: whereHelpernull_Int64{field: "\"clips\".\"perceptual_hash\""},
+	SizeBytes:      whereHelperint64{field: "\"clips\".\"size_bytes\""},
+	AllowDownloads: whereHelperbool{field: "\"clips\".\"allow_downloads\""},
+	LastViewedAt:   whereHelpernull_Time{field: "\"clips\".\"last_viewed_at\""},
 }
 
 // ClipRels is where relationship names are stored.
 var ClipRels = struct {
-	Creator string
+	Creator         string
+	ClipChapters    string
+	TranscodeChunks string
 }{
-	Creator: "Creator",
+	Creator:         "Creator",
+	ClipChapters:    "ClipChapters",
+	TranscodeChunks: "TranscodeChunks",
 }
 
 // clipR is where relationships are stored.
 type clipR struct {
-	Creator *User `boil:"Creator" json:"Creator" toml:"Creator" yaml:"Creator"`
+	Creator         *User               `boil:"Creator" json:"Creator" toml:"Creator" yaml:"Creator"`
+	ClipChapters    ClipChapterSlice    `boil:"ClipChapters" json:"ClipChapters" toml:"ClipChapters" yaml:"ClipChapters"`
+	TranscodeChunks TranscodeChunkSlice `boil:"TranscodeChunks" json:"TranscodeChunks" toml:"TranscodeChunks" yaml:"TranscodeChunks"`
 }
 
 // NewStruct creates a new relationship struct
@@ -237,13 +301,27 @@ func (r *clipR) GetCreator() *User {
 	return r.Creator
 }
 
+func (r *clipR) GetClipChapters() ClipChapterSlice {
+	if r == nil {
+		return nil
+	}
+	return r.ClipChapters
+}
+
+func (r *clipR) GetTranscodeChunks() TranscodeChunkSlice {
+	if r == nil {
+		return nil
+	}
+	return r.TranscodeChunks
+}
+
 // clipL is where Load methods for each relationship are stored.
 type clipL struct{}
 
 var (
-	clipAllColumns            = []string{"id", "title", "description", "creator_id", "processing", "created_at", "views", "unlisted"}
+	clipAllColumns            = []string{"id", "title", "description", "creator_id", "processing", "created_at", "views", "unlisted", "media_type", "content_hash", "perceptual_hash", "size_bytes", "allow_downloads", "last_viewed_at"}
 	clipColumnsWithoutDefault = []string{"title", "creator_id"}
-	clipColumnsWithDefault    = []string{"id", "description", "processing", "created_at", "views", "unlisted"}
+	clipColumnsWithDefault    = []string{"id", "description", "processing", "created_at", "views", "unlisted", "media_type", "content_hash", "perceptual_hash", "size_bytes", "allow_downloads", "last_viewed_at"}
 	clipPrimaryKeyColumns     = []string{"id"}
 	clipGeneratedColumns      = []string{}
 )
@@ -557,6 +635,34 @@ func (o *Clip) Creator(mods ...qm.QueryMod) userQuery {
 	return Users(queryMods...)
 }
 
+// ClipChapters retrieves all the clip_chapter's ClipChapters with an executor.
+func (o *Clip) ClipChapters(mods ...qm.QueryMod) clipChapterQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"clip_chapters\".\"clip_id\"=?", o.ID),
+	)
+
+	return ClipChapters(queryMods...)
+}
+
+// TranscodeChunks retrieves all the transcode_chunk's TranscodeChunks with an executor.
+func (o *Clip) TranscodeChunks(mods ...qm.QueryMod) transcodeChunkQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"transcode_chunks\".\"clip_id\"=?", o.ID),
+	)
+
+	return TranscodeChunks(queryMods...)
+}
+
 // LoadCreator allows an eager lookup of values, cached into the
 // loaded structs of the objects. This is for an N-1 relationship.
 func (clipL) LoadCreator(ctx context.Context, e boil.ContextExecutor, singular bool, maybeClip interface{}, mods queries.Applicator) error {
@@ -677,6 +783,234 @@ func (clipL) LoadCreator(ctx context.Context, e boil.ContextExecutor, singular b
 	return nil
 }
 
+// LoadClipChapters allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (clipL) LoadClipChapters(ctx context.Context, e boil.ContextExecutor, singular bool, maybeClip interface{}, mods queries.Applicator) error {
+	var slice []*Clip
+	var object *Clip
+
+	if singular {
+		var ok bool
+		object, ok = maybeClip.(*Clip)
+		if !ok {
+			object = new(Clip)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeClip)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeClip))
+			}
+		}
+	} else {
+		s, ok := maybeClip.(*[]*Clip)
+		if ok {
+			slice = *s
+		} else {
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeClip)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeClip))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &clipR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &clipR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`clip_chapters`),
+		qm.WhereIn(`clip_chapters.clip_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load clip_chapters")
+	}
+
+	var resultSlice []*ClipChapter
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice clip_chapters")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on clip_chapters")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for clip_chapters")
+	}
+
+	if len(clipChapterAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.ClipChapters = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &clipChapterR{}
+			}
+			foreign.R.Clip = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.ClipID {
+				local.R.ClipChapters = append(local.R.ClipChapters, foreign)
+				if foreign.R == nil {
+					foreign.R = &clipChapterR{}
+				}
+				foreign.R.Clip = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadTranscodeChunks allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (clipL) LoadTranscodeChunks(ctx context.Context, e boil.ContextExecutor, singular bool, maybeClip interface{}, mods queries.Applicator) error {
+	var slice []*Clip
+	var object *Clip
+
+	if singular {
+		var ok bool
+		object, ok = maybeClip.(*Clip)
+		if !ok {
+			object = new(Clip)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeClip)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeClip))
+			}
+		}
+	} else {
+		s, ok := maybeClip.(*[]*Clip)
+		if ok {
+			slice = *s
+		} else {
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeClip)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeClip))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &clipR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &clipR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`transcode_chunks`),
+		qm.WhereIn(`transcode_chunks.clip_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load transcode_chunks")
+	}
+
+	var resultSlice []*TranscodeChunk
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice transcode_chunks")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on transcode_chunks")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for transcode_chunks")
+	}
+
+	if len(transcodeChunkAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.TranscodeChunks = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &transcodeChunkR{}
+			}
+			foreign.R.Clip = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.ClipID {
+				local.R.TranscodeChunks = append(local.R.TranscodeChunks, foreign)
+				if foreign.R == nil {
+					foreign.R = &transcodeChunkR{}
+				}
+				foreign.R.Clip = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
 // SetCreatorG of the clip to the related item.
 // Sets o.R.Creator to related.
 // Adds o to related.R.CreatorClips.
@@ -732,6 +1066,130 @@ func (o *Clip) SetCreator(ctx context.Context, exec boil.ContextExecutor, insert
 	return nil
 }
 
+// AddClipChaptersG adds the given related objects to the existing relationships
+// of the clip, optionally inserting them as new records.
+// Appends related to o.R.ClipChapters.
+// Sets related.R.Clip appropriately.
+// Uses the global database handle.
+func (o *Clip) AddClipChaptersG(ctx context.Context, insert bool, related ...*ClipChapter) error {
+	return o.AddClipChapters(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddClipChapters adds the given related objects to the existing relationships
+// of the clip, optionally inserting them as new records.
+// Appends related to o.R.ClipChapters.
+// Sets related.R.Clip appropriately.
+func (o *Clip) AddClipChapters(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*ClipChapter) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.ClipID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"clip_chapters\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"clip_id"}),
+				strmangle.WhereClause("\"", "\"", 2, clipChapterPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.ClipID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &clipR{
+			ClipChapters: related,
+		}
+	} else {
+		o.R.ClipChapters = append(o.R.ClipChapters, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &clipChapterR{
+				Clip: o,
+			}
+		} else {
+			rel.R.Clip = o
+		}
+	}
+	return nil
+}
+
+// AddTranscodeChunksG adds the given related objects to the existing relationships
+// of the clip, optionally inserting them as new records.
+// Appends related to o.R.TranscodeChunks.
+// Sets related.R.Clip appropriately.
+// Uses the global database handle.
+func (o *Clip) AddTranscodeChunksG(ctx context.Context, insert bool, related ...*TranscodeChunk) error {
+	return o.AddTranscodeChunks(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddTranscodeChunks adds the given related objects to the existing relationships
+// of the clip, optionally inserting them as new records.
+// Appends related to o.R.TranscodeChunks.
+// Sets related.R.Clip appropriately.
+func (o *Clip) AddTranscodeChunks(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*TranscodeChunk) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.ClipID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"transcode_chunks\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"clip_id"}),
+				strmangle.WhereClause("\"", "\"", 2, transcodeChunkPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ClipID, rel.ChunkIndex}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.ClipID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &clipR{
+			TranscodeChunks: related,
+		}
+	} else {
+		o.R.TranscodeChunks = append(o.R.TranscodeChunks, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &transcodeChunkR{
+				Clip: o,
+			}
+		} else {
+			rel.R.Clip = o
+		}
+	}
+	return nil
+}
+
 // Clips retrieves all the records using an executor.
 func Clips(mods ...qm.QueryMod) clipQuery {
 	mods = append(mods, qm.From("\"clips\""))
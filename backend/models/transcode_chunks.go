@@ -0,0 +1,1219 @@
+// Code generated by SQLBoiler 4.14.1 (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"github.com/volatiletech/sqlboiler/v4/queries/qmhelper"
+	"github.com/volatiletech/strmangle"
+)
+
+// TranscodeChunk is an object representing the database table.
+type TranscodeChunk struct {
+	ClipID     int64 `boil:"clip_id" json:"clip_id" toml:"clip_id" yaml:"clip_id"`
+	ChunkIndex int   `boil:"chunk_index" json:"chunk_index" toml:"chunk_index" yaml:"chunk_index"`
+	StartMS    int64 `boil:"start_ms" json:"start_ms" toml:"start_ms" yaml:"start_ms"`
+	DurationMS int64 `boil:"duration_ms" json:"duration_ms" toml:"duration_ms" yaml:"duration_ms"`
+
+	R *transcodeChunkR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L transcodeChunkL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var TranscodeChunkColumns = struct {
+	ClipID     string
+	ChunkIndex string
+	StartMS    string
+	DurationMS string
+}{
+	ClipID:     "clip_id",
+	ChunkIndex: "chunk_index",
+	StartMS:    "start_ms",
+	DurationMS: "duration_ms",
+}
+
+var TranscodeChunkTableColumns = struct {
+	ClipID     string
+	ChunkIndex string
+	StartMS    string
+	DurationMS string
+}{
+	ClipID:     "transcode_chunks.clip_id",
+	ChunkIndex: "transcode_chunks.chunk_index",
+	StartMS:    "transcode_chunks.start_ms",
+	DurationMS: "transcode_chunks.duration_ms",
+}
+
+// Generated where
+
+type whereHelperint struct{ field string }
+
+func (w whereHelperint) EQ(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperint) NEQ(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperint) LT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperint) LTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperint) GT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperint) GTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+func (w whereHelperint) IN(slice []int) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
+}
+func (w whereHelperint) NIN(slice []int) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
+}
+
+var TranscodeChunkWhere = struct {
+	ClipID     whereHelperint64
+	ChunkIndex whereHelperint
+	StartMS    whereHelperint64
+	DurationMS whereHelperint64
+}{
+	ClipID:     whereHelperint64{field: "\"transcode_chunks\".\"clip_id\""},
+	ChunkIndex: whereHelperint{field: "\"transcode_chunks\".\"chunk_index\""},
+	StartMS:    whereHelperint64{field: "\"transcode_chunks\".\"start_ms\""},
+	DurationMS: whereHelperint64{field: "\"transcode_chunks\".\"duration_ms\""},
+}
+
+// TranscodeChunkRels is where relationship names are stored.
+var TranscodeChunkRels = struct {
+	Clip string
+}{
+	Clip: "Clip",
+}
+
+// transcodeChunkR is where relationships are stored.
+type transcodeChunkR struct {
+	Clip *Clip `boil:"Clip" json:"Clip" toml:"Clip" yaml:"Clip"`
+}
+
+// NewStruct creates a new relationship struct
+func (*transcodeChunkR) NewStruct() *transcodeChunkR {
+	return &transcodeChunkR{}
+}
+
+func (r *transcodeChunkR) GetClip() *Clip {
+	if r == nil {
+		return nil
+	}
+	return r.Clip
+}
+
+// transcodeChunkL is where Load methods for each relationship are stored.
+type transcodeChunkL struct{}
+
+var (
+	transcodeChunkAllColumns            = []string{"clip_id", "chunk_index", "start_ms", "duration_ms"}
+	transcodeChunkColumnsWithoutDefault = []string{"clip_id", "chunk_index", "start_ms", "duration_ms"}
+	transcodeChunkColumnsWithDefault    = []string{}
+	transcodeChunkPrimaryKeyColumns     = []string{"clip_id", "chunk_index"}
+	transcodeChunkGeneratedColumns      = []string{}
+)
+
+type (
+	// TranscodeChunkSlice is an alias for a slice of pointers to TranscodeChunk.
+	// This should almost always be used instead of []TranscodeChunk.
+	TranscodeChunkSlice []*TranscodeChunk
+	// TranscodeChunkHook is the signature for custom TranscodeChunk hook methods
+	TranscodeChunkHook func(context.Context, boil.ContextExecutor, *TranscodeChunk) error
+
+	transcodeChunkQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	transcodeChunkType                 = reflect.TypeOf(&TranscodeChunk{})
+	transcodeChunkMapping              = queries.MakeStructMapping(transcodeChunkType)
+	transcodeChunkPrimaryKeyMapping, _ = queries.BindMapping(transcodeChunkType, transcodeChunkMapping, transcodeChunkPrimaryKeyColumns)
+	transcodeChunkInsertCacheMut       sync.RWMutex
+	transcodeChunkInsertCache          = make(map[string]insertCache)
+	transcodeChunkUpdateCacheMut       sync.RWMutex
+	transcodeChunkUpdateCache          = make(map[string]updateCache)
+	transcodeChunkUpsertCacheMut       sync.RWMutex
+	transcodeChunkUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var transcodeChunkAfterSelectHooks []TranscodeChunkHook
+
+var transcodeChunkBeforeInsertHooks []TranscodeChunkHook
+var transcodeChunkAfterInsertHooks []TranscodeChunkHook
+
+var transcodeChunkBeforeUpdateHooks []TranscodeChunkHook
+var transcodeChunkAfterUpdateHooks []TranscodeChunkHook
+
+var transcodeChunkBeforeDeleteHooks []TranscodeChunkHook
+var transcodeChunkAfterDeleteHooks []TranscodeChunkHook
+
+var transcodeChunkBeforeUpsertHooks []TranscodeChunkHook
+var transcodeChunkAfterUpsertHooks []TranscodeChunkHook
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *TranscodeChunk) doAfterSelectHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkAfterSelectHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *TranscodeChunk) doBeforeInsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkBeforeInsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *TranscodeChunk) doAfterInsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkAfterInsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *TranscodeChunk) doBeforeUpdateHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkBeforeUpdateHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *TranscodeChunk) doAfterUpdateHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkAfterUpdateHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *TranscodeChunk) doBeforeDeleteHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkBeforeDeleteHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *TranscodeChunk) doAfterDeleteHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkAfterDeleteHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *TranscodeChunk) doBeforeUpsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkBeforeUpsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *TranscodeChunk) doAfterUpsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range transcodeChunkAfterUpsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddTranscodeChunkHook registers your hook function for all future operations.
+func AddTranscodeChunkHook(hookPoint boil.HookPoint, transcodeChunkHook TranscodeChunkHook) {
+	switch hookPoint {
+	case boil.AfterSelectHook:
+		transcodeChunkAfterSelectHooks = append(transcodeChunkAfterSelectHooks, transcodeChunkHook)
+	case boil.BeforeInsertHook:
+		transcodeChunkBeforeInsertHooks = append(transcodeChunkBeforeInsertHooks, transcodeChunkHook)
+	case boil.AfterInsertHook:
+		transcodeChunkAfterInsertHooks = append(transcodeChunkAfterInsertHooks, transcodeChunkHook)
+	case boil.BeforeUpdateHook:
+		transcodeChunkBeforeUpdateHooks = append(transcodeChunkBeforeUpdateHooks, transcodeChunkHook)
+	case boil.AfterUpdateHook:
+		transcodeChunkAfterUpdateHooks = append(transcodeChunkAfterUpdateHooks, transcodeChunkHook)
+	case boil.BeforeDeleteHook:
+		transcodeChunkBeforeDeleteHooks = append(transcodeChunkBeforeDeleteHooks, transcodeChunkHook)
+	case boil.AfterDeleteHook:
+		transcodeChunkAfterDeleteHooks = append(transcodeChunkAfterDeleteHooks, transcodeChunkHook)
+	case boil.BeforeUpsertHook:
+		transcodeChunkBeforeUpsertHooks = append(transcodeChunkBeforeUpsertHooks, transcodeChunkHook)
+	case boil.AfterUpsertHook:
+		transcodeChunkAfterUpsertHooks = append(transcodeChunkAfterUpsertHooks, transcodeChunkHook)
+	}
+}
+
+// OneG returns a single transcodeChunk record from the query using the global executor.
+func (q transcodeChunkQuery) OneG(ctx context.Context) (*TranscodeChunk, error) {
+	return q.One(ctx, boil.GetContextDB())
+}
+
+// One returns a single transcodeChunk record from the query.
+func (q transcodeChunkQuery) One(ctx context.Context, exec boil.ContextExecutor) (*TranscodeChunk, error) {
+	o := &TranscodeChunk{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(ctx, exec, o)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for transcode_chunks")
+	}
+
+	if err := o.doAfterSelectHooks(ctx, exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all TranscodeChunk records from the query using the global executor.
+func (q transcodeChunkQuery) AllG(ctx context.Context) (TranscodeChunkSlice, error) {
+	return q.All(ctx, boil.GetContextDB())
+}
+
+// All returns all TranscodeChunk records from the query.
+func (q transcodeChunkQuery) All(ctx context.Context, exec boil.ContextExecutor) (TranscodeChunkSlice, error) {
+	var o []*TranscodeChunk
+
+	err := q.Bind(ctx, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to TranscodeChunk slice")
+	}
+
+	if len(transcodeChunkAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(ctx, exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all TranscodeChunk records in the query using the global executor
+func (q transcodeChunkQuery) CountG(ctx context.Context) (int64, error) {
+	return q.Count(ctx, boil.GetContextDB())
+}
+
+// Count returns the count of all TranscodeChunk records in the query.
+func (q transcodeChunkQuery) Count(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRowContext(ctx, exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count transcode_chunks rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table using the global executor.
+func (q transcodeChunkQuery) ExistsG(ctx context.Context) (bool, error) {
+	return q.Exists(ctx, boil.GetContextDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q transcodeChunkQuery) Exists(ctx context.Context, exec boil.ContextExecutor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRowContext(ctx, exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if transcode_chunks exists")
+	}
+
+	return count > 0, nil
+}
+
+// Clip pointed to by the foreign key.
+func (o *TranscodeChunk) Clip(mods ...qm.QueryMod) clipQuery {
+	queryMods := []qm.QueryMod{
+		qm.Where("\"id\" = ?", o.ClipID),
+	}
+
+	queryMods = append(queryMods, mods...)
+
+	return Clips(queryMods...)
+}
+
+// LoadClip allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for an N-1 relationship.
+func (transcodeChunkL) LoadClip(ctx context.Context, e boil.ContextExecutor, singular bool, maybeTranscodeChunk interface{}, mods queries.Applicator) error {
+	var slice []*TranscodeChunk
+	var object *TranscodeChunk
+
+	if singular {
+		var ok bool
+		object, ok = maybeTranscodeChunk.(*TranscodeChunk)
+		if !ok {
+			object = new(TranscodeChunk)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeTranscodeChunk)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeTranscodeChunk))
+			}
+		}
+	} else {
+		s, ok := maybeTranscodeChunk.(*[]*TranscodeChunk)
+		if ok {
+			slice = *s
+		} else {
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeTranscodeChunk)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeTranscodeChunk))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &transcodeChunkR{}
+		}
+		args = append(args, object.ClipID)
+
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &transcodeChunkR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ClipID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ClipID)
+
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`clips`),
+		qm.WhereIn(`clips.id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load Clip")
+	}
+
+	var resultSlice []*Clip
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice Clip")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results of eager load for clips")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for clips")
+	}
+
+	if len(clipAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(resultSlice) == 0 {
+		return nil
+	}
+
+	if singular {
+		foreign := resultSlice[0]
+		object.R.Clip = foreign
+		if foreign.R == nil {
+			foreign.R = &clipR{}
+		}
+		foreign.R.TranscodeChunks = append(foreign.R.TranscodeChunks, object)
+		return nil
+	}
+
+	for _, local := range slice {
+		for _, foreign := range resultSlice {
+			if local.ClipID == foreign.ID {
+				local.R.Clip = foreign
+				if foreign.R == nil {
+					foreign.R = &clipR{}
+				}
+				foreign.R.TranscodeChunks = append(foreign.R.TranscodeChunks, local)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetClipG of the transcodeChunk to the related item.
+// Sets o.R.Clip to related.
+// Adds o to related.R.TranscodeChunks.
+// Uses the global database handle.
+func (o *TranscodeChunk) SetClipG(ctx context.Context, insert bool, related *Clip) error {
+	return o.SetClip(ctx, boil.GetContextDB(), insert, related)
+}
+
+// SetClip of the transcodeChunk to the related item.
+// Sets o.R.Clip to related.
+// Adds o to related.R.TranscodeChunks.
+func (o *TranscodeChunk) SetClip(ctx context.Context, exec boil.ContextExecutor, insert bool, related *Clip) error {
+	var err error
+	if insert {
+		if err = related.Insert(ctx, exec, boil.Infer()); err != nil {
+			return errors.Wrap(err, "failed to insert into foreign table")
+		}
+	}
+
+	updateQuery := fmt.Sprintf(
+		"UPDATE \"transcode_chunks\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, []string{"clip_id"}),
+		strmangle.WhereClause("\"", "\"", 2, transcodeChunkPrimaryKeyColumns),
+	)
+	values := []interface{}{related.ID, o.ClipID, o.ChunkIndex}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, updateQuery)
+		fmt.Fprintln(writer, values)
+	}
+	if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+		return errors.Wrap(err, "failed to update local table")
+	}
+
+	o.ClipID = related.ID
+	if o.R == nil {
+		o.R = &transcodeChunkR{
+			Clip: related,
+		}
+	} else {
+		o.R.Clip = related
+	}
+
+	if related.R == nil {
+		related.R = &clipR{
+			TranscodeChunks: TranscodeChunkSlice{o},
+		}
+	} else {
+		related.R.TranscodeChunks = append(related.R.TranscodeChunks, o)
+	}
+
+	return nil
+}
+
+// TranscodeChunks retrieves all the records using an executor.
+func TranscodeChunks(mods ...qm.QueryMod) transcodeChunkQuery {
+	mods = append(mods, qm.From("\"transcode_chunks\""))
+	q := NewQuery(mods...)
+	if len(queries.GetSelect(q)) == 0 {
+		queries.SetSelect(q, []string{"\"transcode_chunks\".*"})
+	}
+
+	return transcodeChunkQuery{q}
+}
+
+// FindTranscodeChunkG retrieves a single record by ID.
+func FindTranscodeChunkG(ctx context.Context, clipID int64, chunkIndex int, selectCols ...string) (*TranscodeChunk, error) {
+	return FindTranscodeChunk(ctx, boil.GetContextDB(), clipID, chunkIndex, selectCols...)
+}
+
+// FindTranscodeChunk retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindTranscodeChunk(ctx context.Context, exec boil.ContextExecutor, clipID int64, chunkIndex int, selectCols ...string) (*TranscodeChunk, error) {
+	transcodeChunkObj := &TranscodeChunk{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"transcode_chunks\" where \"clip_id\"=$1 AND \"chunk_index\"=$2", sel,
+	)
+
+	q := queries.Raw(query, clipID, chunkIndex)
+
+	err := q.Bind(ctx, exec, transcodeChunkObj)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from transcode_chunks")
+	}
+
+	if err = transcodeChunkObj.doAfterSelectHooks(ctx, exec); err != nil {
+		return transcodeChunkObj, err
+	}
+
+	return transcodeChunkObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *TranscodeChunk) InsertG(ctx context.Context, columns boil.Columns) error {
+	return o.Insert(ctx, boil.GetContextDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *TranscodeChunk) Insert(ctx context.Context, exec boil.ContextExecutor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no transcode_chunks provided for insertion")
+	}
+
+	var err error
+
+	if err := o.doBeforeInsertHooks(ctx, exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(transcodeChunkColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	transcodeChunkInsertCacheMut.RLock()
+	cache, cached := transcodeChunkInsertCache[key]
+	transcodeChunkInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			transcodeChunkAllColumns,
+			transcodeChunkColumnsWithDefault,
+			transcodeChunkColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(transcodeChunkType, transcodeChunkMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(transcodeChunkType, transcodeChunkMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"transcode_chunks\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"transcode_chunks\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRowContext(ctx, cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.ExecContext(ctx, cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into transcode_chunks")
+	}
+
+	if !cached {
+		transcodeChunkInsertCacheMut.Lock()
+		transcodeChunkInsertCache[key] = cache
+		transcodeChunkInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(ctx, exec)
+}
+
+// UpdateG a single TranscodeChunk record using the global executor.
+// See Update for more documentation.
+func (o *TranscodeChunk) UpdateG(ctx context.Context, columns boil.Columns) (int64, error) {
+	return o.Update(ctx, boil.GetContextDB(), columns)
+}
+
+// Update uses an executor to update the TranscodeChunk.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *TranscodeChunk) Update(ctx context.Context, exec boil.ContextExecutor, columns boil.Columns) (int64, error) {
+	var err error
+	if err = o.doBeforeUpdateHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	transcodeChunkUpdateCacheMut.RLock()
+	cache, cached := transcodeChunkUpdateCache[key]
+	transcodeChunkUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			transcodeChunkAllColumns,
+			transcodeChunkPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update transcode_chunks, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"transcode_chunks\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, transcodeChunkPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(transcodeChunkType, transcodeChunkMapping, append(wl, transcodeChunkPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, values)
+	}
+	var result sql.Result
+	result, err = exec.ExecContext(ctx, cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update transcode_chunks row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for transcode_chunks")
+	}
+
+	if !cached {
+		transcodeChunkUpdateCacheMut.Lock()
+		transcodeChunkUpdateCache[key] = cache
+		transcodeChunkUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(ctx, exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q transcodeChunkQuery) UpdateAllG(ctx context.Context, cols M) (int64, error) {
+	return q.UpdateAll(ctx, boil.GetContextDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q transcodeChunkQuery) UpdateAll(ctx context.Context, exec boil.ContextExecutor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for transcode_chunks")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for transcode_chunks")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o TranscodeChunkSlice) UpdateAllG(ctx context.Context, cols M) (int64, error) {
+	return o.UpdateAll(ctx, boil.GetContextDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o TranscodeChunkSlice) UpdateAll(ctx context.Context, exec boil.ContextExecutor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), transcodeChunkPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"transcode_chunks\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, transcodeChunkPrimaryKeyColumns, len(o)))
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args...)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in transcodeChunk slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all transcodeChunk")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *TranscodeChunk) UpsertG(ctx context.Context, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(ctx, boil.GetContextDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *TranscodeChunk) Upsert(ctx context.Context, exec boil.ContextExecutor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no transcode_chunks provided for upsert")
+	}
+
+	if err := o.doBeforeUpsertHooks(ctx, exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(transcodeChunkColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	transcodeChunkUpsertCacheMut.RLock()
+	cache, cached := transcodeChunkUpsertCache[key]
+	transcodeChunkUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			transcodeChunkAllColumns,
+			transcodeChunkColumnsWithDefault,
+			transcodeChunkColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		update := updateColumns.UpdateColumnSet(
+			transcodeChunkAllColumns,
+			transcodeChunkPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert transcode_chunks, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(transcodeChunkPrimaryKeyColumns))
+			copy(conflict, transcodeChunkPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"transcode_chunks\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(transcodeChunkType, transcodeChunkMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(transcodeChunkType, transcodeChunkMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, vals)
+	}
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRowContext(ctx, cache.query, vals...).Scan(returns...)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.ExecContext(ctx, cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert transcode_chunks")
+	}
+
+	if !cached {
+		transcodeChunkUpsertCacheMut.Lock()
+		transcodeChunkUpsertCache[key] = cache
+		transcodeChunkUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(ctx, exec)
+}
+
+// DeleteG deletes a single TranscodeChunk record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *TranscodeChunk) DeleteG(ctx context.Context) (int64, error) {
+	return o.Delete(ctx, boil.GetContextDB())
+}
+
+// Delete deletes a single TranscodeChunk record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *TranscodeChunk) Delete(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no TranscodeChunk provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), transcodeChunkPrimaryKeyMapping)
+	sql := "DELETE FROM \"transcode_chunks\" WHERE \"clip_id\"=$1 AND \"chunk_index\"=$2"
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args...)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from transcode_chunks")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for transcode_chunks")
+	}
+
+	if err := o.doAfterDeleteHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+func (q transcodeChunkQuery) DeleteAllG(ctx context.Context) (int64, error) {
+	return q.DeleteAll(ctx, boil.GetContextDB())
+}
+
+// DeleteAll deletes all matching rows.
+func (q transcodeChunkQuery) DeleteAll(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no transcodeChunkQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from transcode_chunks")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for transcode_chunks")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o TranscodeChunkSlice) DeleteAllG(ctx context.Context) (int64, error) {
+	return o.DeleteAll(ctx, boil.GetContextDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o TranscodeChunkSlice) DeleteAll(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(transcodeChunkBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(ctx, exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), transcodeChunkPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"transcode_chunks\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, transcodeChunkPrimaryKeyColumns, len(o))
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from transcodeChunk slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for transcode_chunks")
+	}
+
+	if len(transcodeChunkAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(ctx, exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *TranscodeChunk) ReloadG(ctx context.Context) error {
+	if o == nil {
+		return errors.New("models: no TranscodeChunk provided for reload")
+	}
+
+	return o.Reload(ctx, boil.GetContextDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *TranscodeChunk) Reload(ctx context.Context, exec boil.ContextExecutor) error {
+	ret, err := FindTranscodeChunk(ctx, exec, o.ClipID, o.ChunkIndex)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *TranscodeChunkSlice) ReloadAllG(ctx context.Context) error {
+	if o == nil {
+		return errors.New("models: empty TranscodeChunkSlice provided for reload all")
+	}
+
+	return o.ReloadAll(ctx, boil.GetContextDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *TranscodeChunkSlice) ReloadAll(ctx context.Context, exec boil.ContextExecutor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := TranscodeChunkSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), transcodeChunkPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"transcode_chunks\".* FROM \"transcode_chunks\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, transcodeChunkPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(ctx, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in TranscodeChunkSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// TranscodeChunkExistsG checks if the TranscodeChunk row exists.
+func TranscodeChunkExistsG(ctx context.Context, clipID int64, chunkIndex int) (bool, error) {
+	return TranscodeChunkExists(ctx, boil.GetContextDB(), clipID, chunkIndex)
+}
+
+// TranscodeChunkExists checks if the TranscodeChunk row exists.
+func TranscodeChunkExists(ctx context.Context, exec boil.ContextExecutor, clipID int64, chunkIndex int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"transcode_chunks\" where \"clip_id\"=$1 AND \"chunk_index\"=$2 limit 1)"
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, clipID, chunkIndex)
+	}
+	row := exec.QueryRowContext(ctx, sql, clipID, chunkIndex)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if transcode_chunks exists")
+	}
+
+	return exists, nil
+}
+
+// Exists checks if the TranscodeChunk row exists.
+func (o *TranscodeChunk) Exists(ctx context.Context, exec boil.ContextExecutor) (bool, error) {
+	return TranscodeChunkExists(ctx, exec, o.ClipID, o.ChunkIndex)
+}
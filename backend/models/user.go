@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -23,63 +24,145 @@ import (
 
 // User is an object representing the database table.
 type User struct {
-	ID       int64     `boil:"id" json:"id" toml:"id" yaml:"id"`
-	Username string    `boil:"username" json:"username" toml:"username" yaml:"username"`
-	Password string    `boil:"password" json:"password" toml:"password" yaml:"password"`
-	JoinedAt time.Time `boil:"joined_at" json:"joined_at" toml:"joined_at" yaml:"joined_at"`
+	ID                 int64       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Username           string      `boil:"username" json:"username" toml:"username" yaml:"username"`
+	Password           string      `boil:"password" json:"password" toml:"password" yaml:"password"`
+	JoinedAt           time.Time   `boil:"joined_at" json:"joined_at" toml:"joined_at" yaml:"joined_at"`
+	QuotaBytes         null.Int64  `boil:"quota_bytes" json:"quota_bytes,omitempty" toml:"quota_bytes" yaml:"quota_bytes,omitempty"`
+	Role               string      `boil:"role" json:"role" toml:"role" yaml:"role"`
+	Disabled           bool        `boil:"disabled" json:"disabled" toml:"disabled" yaml:"disabled"`
+	TotpSecret         null.String `boil:"totp_secret" json:"totp_secret,omitempty" toml:"totp_secret" yaml:"totp_secret,omitempty"`
+	TotpEnabled        bool        `boil:"totp_enabled" json:"totp_enabled" toml:"totp_enabled" yaml:"totp_enabled"`
+	TotpRecoveryCodes  string      `boil:"totp_recovery_codes" json:"totp_recovery_codes" toml:"totp_recovery_codes" yaml:"totp_recovery_codes"`
+	TotpLastStep       int64       `boil:"totp_last_step" json:"totp_last_step" toml:"totp_last_step" yaml:"totp_last_step"`
+	TotpFailedAttempts int         `boil:"totp_failed_attempts" json:"totp_failed_attempts" toml:"totp_failed_attempts" yaml:"totp_failed_attempts"`
+	TotpLockedUntil    null.Time   `boil:"totp_locked_until" json:"totp_locked_until,omitempty" toml:"totp_locked_until" yaml:"totp_locked_until,omitempty"`
+	PasswordUnset      bool        `boil:"password_unset" json:"password_unset" toml:"password_unset" yaml:"password_unset"`
 
 	R *userR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L userL  `boil:"-" json:"-" toml:"-" yaml:"-"`
 }
 
 var UserColumns = struct {
-	ID       string
-	Username string
-	Password string
-	JoinedAt string
+	ID                 string
+	Username           string
+	Password           string
+	JoinedAt           string
+	QuotaBytes         string
+	Role               string
+	Disabled           string
+	TotpSecret         string
+	TotpEnabled        string
+	TotpRecoveryCodes  string
+	TotpLastStep       string
+	TotpFailedAttempts string
+	TotpLockedUntil    string
+	PasswordUnset      string
 }{
-	ID:       "id",
-	Username: "username",
-	Password: "password",
-	JoinedAt: "joined_at",
+	ID:                 "id",
+	Username:           "username",
+	Password:           "password",
+	JoinedAt:           "joined_at",
+	QuotaBytes:         "quota_bytes",
+	Role:               "role",
+	Disabled:           "disabled",
+	TotpSecret:         "totp_secret",
+	TotpEnabled:        "totp_enabled",
+	TotpRecoveryCodes:  "totp_recovery_codes",
+	TotpLastStep:       "totp_last_step",
+	TotpFailedAttempts: "totp_failed_attempts",
+	TotpLockedUntil:    "totp_locked_until",
+	PasswordUnset:      "password_unset",
 }
 
 var UserTableColumns = struct {
-	ID       string
-	Username string
-	Password string
-	JoinedAt string
+	ID                 string
+	Username           string
+	Password           string
+	JoinedAt           string
+	QuotaBytes         string
+	Role               string
+	Disabled           string
+	TotpSecret         string
+	TotpEnabled        string
+	TotpRecoveryCodes  string
+	TotpLastStep       string
+	TotpFailedAttempts string
+	TotpLockedUntil    string
+	PasswordUnset      string
 }{
-	ID:       "user.id",
-	Username: "user.username",
-	Password: "user.password",
-	JoinedAt: "user.joined_at",
+	ID:                 "user.id",
+	Username:           "user.username",
+	Password:           "user.password",
+	JoinedAt:           "user.joined_at",
+	QuotaBytes:         "user.quota_bytes",
+	Role:               "user.role",
+	Disabled:           "user.disabled",
+	TotpSecret:         "user.totp_secret",
+	TotpEnabled:        "user.totp_enabled",
+	TotpRecoveryCodes:  "user.totp_recovery_codes",
+	TotpLastStep:       "user.totp_last_step",
+	TotpFailedAttempts: "user.totp_failed_attempts",
+	TotpLockedUntil:    "user.totp_locked_until",
+	PasswordUnset:      "user.password_unset",
 }
 
 // Generated where
 
 var UserWhere = struct {
-	ID       whereHelperint64
-	Username whereHelperstring
-	Password whereHelperstring
-	JoinedAt whereHelpertime_Time
+	ID                 whereHelperint64
+	Username           whereHelperstring
+	Password           whereHelperstring
+	JoinedAt           whereHelpertime_Time
+	QuotaBytes         whereHelpernull_Int64
+	Role               whereHelperstring
+	Disabled           whereHelperbool
+	TotpSecret         whereHelpernull_String
+	TotpEnabled        whereHelperbool
+	TotpRecoveryCodes  whereHelperstring
+	TotpLastStep       whereHelperint64
+	TotpFailedAttempts whereHelperint
+	TotpLockedUntil    whereHelpernull_Time
+	PasswordUnset      whereHelperbool
 }{
-	ID:       whereHelperint64{field: "\"user\".\"id\""},
-	Username: whereHelperstring{field: "\"user\".\"username\""},
-	Password: whereHelperstring{field: "\"user\".\"password\""},
-	JoinedAt: whereHelpertime_Time{field: "\"user\".\"joined_at\""},
+	ID:                 whereHelperint64{field: "\"user\".\"id\""},
+	Username:           whereHelperstring{field: "\"user\".\"username\""},
+	Password:           whereHelperstring{field: "\"user\".\"password\""},
+	JoinedAt:           whereHelpertime_Time{field: "\"user\".\"joined_at\""},
+	QuotaBytes:         whereHelpernull_Int64{field: "\"user\".\"quota_bytes\""},
+	Role:               whereHelperstring{field: "\"user\".\"role\""},
+	Disabled:           whereHelperbool{field: "\"user\".\"disabled\""},
+	TotpSecret:         whereHelpernull_String{field: "\"user\".\"totp_secret\""},
+	TotpEnabled:        whereHelperbool{field: "\"user\".\"totp_enabled\""},
+	TotpRecoveryCodes:  whereHelperstring{field: "\"user\".\"totp_recovery_codes\""},
+	TotpLastStep:       whereHelperint64{field: "\"user\".\"totp_last_step\""},
+	TotpFailedAttempts: whereHelperint{field: "\"user\".\"totp_failed_attempts\""},
+	TotpLockedUntil:    whereHelpernull_Time{field: "\"user\".\"totp_locked_until\""},
+	PasswordUnset:      whereHelperbool{field: "\"user\".\"password_unset\""},
 }
 
 // UserRels is where relationship names are stored.
 var UserRels = struct {
-	CreatorClips string
+	CreatorClips   string
+	Sessions       string
+	Tokens         string
+	Uploads        string
+	UserIdentities string
 }{
-	CreatorClips: "CreatorClips",
+	CreatorClips:   "CreatorClips",
+	Sessions:       "Sessions",
+	Tokens:         "Tokens",
+	Uploads:        "Uploads",
+	UserIdentities: "UserIdentities",
 }
 
 // userR is where relationships are stored.
 type userR struct {
-	CreatorClips ClipSlice `boil:"CreatorClips" json:"CreatorClips" toml:"CreatorClips" yaml:"CreatorClips"`
+	CreatorClips   ClipSlice         `boil:"CreatorClips" json:"CreatorClips" toml:"CreatorClips" yaml:"CreatorClips"`
+	Sessions       SessionSlice      `boil:"Sessions" json:"Sessions" toml:"Sessions" yaml:"Sessions"`
+	Tokens         TokenSlice        `boil:"Tokens" json:"Tokens" toml:"Tokens" yaml:"Tokens"`
+	Uploads        UploadSlice       `boil:"Uploads" json:"Uploads" toml:"Uploads" yaml:"Uploads"`
+	UserIdentities UserIdentitySlice `boil:"UserIdentities" json:"UserIdentities" toml:"UserIdentities" yaml:"UserIdentities"`
 }
 
 // NewStruct creates a new relationship struct
@@ -94,13 +177,41 @@ func (r *userR) GetCreatorClips() ClipSlice {
 	return r.CreatorClips
 }
 
+func (r *userR) GetSessions() SessionSlice {
+	if r == nil {
+		return nil
+	}
+	return r.Sessions
+}
+
+func (r *userR) GetTokens() TokenSlice {
+	if r == nil {
+		return nil
+	}
+	return r.Tokens
+}
+
+func (r *userR) GetUploads() UploadSlice {
+	if r == nil {
+		return nil
+	}
+	return r.Uploads
+}
+
+func (r *userR) GetUserIdentities() UserIdentitySlice {
+	if r == nil {
+		return nil
+	}
+	return r.UserIdentities
+}
+
 // userL is where Load methods for each relationship are stored.
 type userL struct{}
 
 var (
-	userAllColumns            = []string{"id", "username", "password", "joined_at"}
+	userAllColumns            = []string{"id", "username", "password", "joined_at", "quota_bytes", "role", "disabled", "totp_secret", "totp_enabled", "totp_recovery_codes", "totp_last_step", "totp_failed_attempts", "totp_locked_until", "password_unset"}
 	userColumnsWithoutDefault = []string{"username", "password"}
-	userColumnsWithDefault    = []string{"id", "joined_at"}
+	userColumnsWithDefault    = []string{"id", "joined_at", "quota_bytes", "role", "disabled", "totp_secret", "totp_enabled", "totp_recovery_codes", "totp_last_step", "totp_failed_attempts", "totp_locked_until", "password_unset"}
 	userPrimaryKeyColumns     = []string{"id"}
 	userGeneratedColumns      = []string{}
 )
@@ -417,6 +528,62 @@ func (o *User) CreatorClips(mods ...qm.QueryMod) clipQuery {
 	return Clips(queryMods...)
 }
 
+// Sessions retrieves all the session's Sessions with an executor.
+func (o *User) Sessions(mods ...qm.QueryMod) sessionQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"sessions\".\"user_id\"=?", o.ID),
+	)
+
+	return Sessions(queryMods...)
+}
+
+// Tokens retrieves all the token's Tokens with an executor.
+func (o *User) Tokens(mods ...qm.QueryMod) tokenQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"tokens\".\"user_id\"=?", o.ID),
+	)
+
+	return Tokens(queryMods...)
+}
+
+// Uploads retrieves all the upload's Uploads with an executor.
+func (o *User) Uploads(mods ...qm.QueryMod) uploadQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"uploads\".\"user_id\"=?", o.ID),
+	)
+
+	return Uploads(queryMods...)
+}
+
+// UserIdentities retrieves all the user_identity's UserIdentities with an executor.
+func (o *User) UserIdentities(mods ...qm.QueryMod) userIdentityQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"user_identities\".\"user_id\"=?", o.ID),
+	)
+
+	return UserIdentities(queryMods...)
+}
+
 // LoadCreatorClips allows an eager lookup of values, cached into the
 // loaded structs of the objects. This is for a 1-M or N-M relationship.
 func (userL) LoadCreatorClips(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
@@ -531,63 +698,767 @@ func (userL) LoadCreatorClips(ctx context.Context, e boil.ContextExecutor, singu
 	return nil
 }
 
-// AddCreatorClipsG adds the given related objects to the existing relationships
-// of the user, optionally inserting them as new records.
-// Appends related to o.R.CreatorClips.
-// Sets related.R.Creator appropriately.
-// Uses the global database handle.
-func (o *User) AddCreatorClipsG(ctx context.Context, insert bool, related ...*Clip) error {
-	return o.AddCreatorClips(ctx, boil.GetContextDB(), insert, related...)
-}
+// LoadSessions allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (userL) LoadSessions(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
+	var slice []*User
+	var object *User
 
-// AddCreatorClips adds the given related objects to the existing relationships
-// of the user, optionally inserting them as new records.
-// Appends related to o.R.CreatorClips.
-// Sets related.R.Creator appropriately.
-func (o *User) AddCreatorClips(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*Clip) error {
-	var err error
-	for _, rel := range related {
-		if insert {
-			rel.CreatorID = o.ID
-			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
-				return errors.Wrap(err, "failed to insert into foreign table")
+	if singular {
+		var ok bool
+		object, ok = maybeUser.(*User)
+		if !ok {
+			object = new(User)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeUser))
 			}
+		}
+	} else {
+		s, ok := maybeUser.(*[]*User)
+		if ok {
+			slice = *s
 		} else {
-			updateQuery := fmt.Sprintf(
-				"UPDATE \"clips\" SET %s WHERE %s",
-				strmangle.SetParamNames("\"", "\"", 1, []string{"creator_id"}),
-				strmangle.WhereClause("\"", "\"", 2, clipPrimaryKeyColumns),
-			)
-			values := []interface{}{o.ID, rel.ID}
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeUser))
+			}
+		}
+	}
 
-			if boil.IsDebug(ctx) {
-				writer := boil.DebugWriterFrom(ctx)
-				fmt.Fprintln(writer, updateQuery)
-				fmt.Fprintln(writer, values)
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &userR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &userR{}
 			}
-			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
-				return errors.Wrap(err, "failed to update foreign table")
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
 			}
 
-			rel.CreatorID = o.ID
+			args = append(args, obj.ID)
 		}
 	}
 
-	if o.R == nil {
-		o.R = &userR{
-			CreatorClips: related,
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`sessions`),
+		qm.WhereIn(`sessions.user_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load sessions")
+	}
+
+	var resultSlice []*Session
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice sessions")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on sessions")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for sessions")
+	}
+
+	if len(sessionAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
 		}
-	} else {
-		o.R.CreatorClips = append(o.R.CreatorClips, related...)
+	}
+	if singular {
+		object.R.Sessions = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &sessionR{}
+			}
+			foreign.R.User = object
+		}
+		return nil
 	}
 
-	for _, rel := range related {
-		if rel.R == nil {
-			rel.R = &clipR{
-				Creator: o,
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.UserID {
+				local.R.Sessions = append(local.R.Sessions, foreign)
+				if foreign.R == nil {
+					foreign.R = &sessionR{}
+				}
+				foreign.R.User = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadTokens allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (userL) LoadTokens(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
+	var slice []*User
+	var object *User
+
+	if singular {
+		var ok bool
+		object, ok = maybeUser.(*User)
+		if !ok {
+			object = new(User)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeUser))
 			}
+		}
+	} else {
+		s, ok := maybeUser.(*[]*User)
+		if ok {
+			slice = *s
 		} else {
-			rel.R.Creator = o
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeUser))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &userR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &userR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`tokens`),
+		qm.WhereIn(`tokens.user_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load tokens")
+	}
+
+	var resultSlice []*Token
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice tokens")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on tokens")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for tokens")
+	}
+
+	if len(tokenAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.Tokens = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &tokenR{}
+			}
+			foreign.R.User = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.UserID {
+				local.R.Tokens = append(local.R.Tokens, foreign)
+				if foreign.R == nil {
+					foreign.R = &tokenR{}
+				}
+				foreign.R.User = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadUploads allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (userL) LoadUploads(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
+	var slice []*User
+	var object *User
+
+	if singular {
+		var ok bool
+		object, ok = maybeUser.(*User)
+		if !ok {
+			object = new(User)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeUser))
+			}
+		}
+	} else {
+		s, ok := maybeUser.(*[]*User)
+		if ok {
+			slice = *s
+		} else {
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeUser))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &userR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &userR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`uploads`),
+		qm.WhereIn(`uploads.user_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load uploads")
+	}
+
+	var resultSlice []*Upload
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice uploads")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on uploads")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for uploads")
+	}
+
+	if len(uploadAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.Uploads = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &uploadR{}
+			}
+			foreign.R.User = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.UserID {
+				local.R.Uploads = append(local.R.Uploads, foreign)
+				if foreign.R == nil {
+					foreign.R = &uploadR{}
+				}
+				foreign.R.User = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadUserIdentities allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (userL) LoadUserIdentities(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
+	var slice []*User
+	var object *User
+
+	if singular {
+		var ok bool
+		object, ok = maybeUser.(*User)
+		if !ok {
+			object = new(User)
+			ok = queries.SetFromEmbeddedStruct(&object, &maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", object, maybeUser))
+			}
+		}
+	} else {
+		s, ok := maybeUser.(*[]*User)
+		if ok {
+			slice = *s
+		} else {
+			ok = queries.SetFromEmbeddedStruct(&slice, maybeUser)
+			if !ok {
+				return errors.New(fmt.Sprintf("failed to set %T from embedded struct %T", slice, maybeUser))
+			}
+		}
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &userR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &userR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`user_identities`),
+		qm.WhereIn(`user_identities.user_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load user_identities")
+	}
+
+	var resultSlice []*UserIdentity
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice user_identities")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on user_identities")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for user_identities")
+	}
+
+	if len(userIdentityAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.UserIdentities = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &userIdentityR{}
+			}
+			foreign.R.User = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.UserID {
+				local.R.UserIdentities = append(local.R.UserIdentities, foreign)
+				if foreign.R == nil {
+					foreign.R = &userIdentityR{}
+				}
+				foreign.R.User = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddCreatorClipsG adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.CreatorClips.
+// Sets related.R.Creator appropriately.
+// Uses the global database handle.
+func (o *User) AddCreatorClipsG(ctx context.Context, insert bool, related ...*Clip) error {
+	return o.AddCreatorClips(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddCreatorClips adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.CreatorClips.
+// Sets related.R.Creator appropriately.
+func (o *User) AddCreatorClips(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*Clip) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.CreatorID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"clips\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"creator_id"}),
+				strmangle.WhereClause("\"", "\"", 2, clipPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.CreatorID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			CreatorClips: related,
+		}
+	} else {
+		o.R.CreatorClips = append(o.R.CreatorClips, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &clipR{
+				Creator: o,
+			}
+		} else {
+			rel.R.Creator = o
+		}
+	}
+	return nil
+}
+
+// AddSessionsG adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.Sessions.
+// Sets related.R.User appropriately.
+// Uses the global database handle.
+func (o *User) AddSessionsG(ctx context.Context, insert bool, related ...*Session) error {
+	return o.AddSessions(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddSessions adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.Sessions.
+// Sets related.R.User appropriately.
+func (o *User) AddSessions(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*Session) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.UserID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"sessions\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"user_id"}),
+				strmangle.WhereClause("\"", "\"", 2, sessionPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.UserID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			Sessions: related,
+		}
+	} else {
+		o.R.Sessions = append(o.R.Sessions, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &sessionR{
+				User: o,
+			}
+		} else {
+			rel.R.User = o
+		}
+	}
+	return nil
+}
+
+// AddTokensG adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.Tokens.
+// Sets related.R.User appropriately.
+// Uses the global database handle.
+func (o *User) AddTokensG(ctx context.Context, insert bool, related ...*Token) error {
+	return o.AddTokens(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddTokens adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.Tokens.
+// Sets related.R.User appropriately.
+func (o *User) AddTokens(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*Token) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.UserID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"tokens\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"user_id"}),
+				strmangle.WhereClause("\"", "\"", 2, tokenPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.UserID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			Tokens: related,
+		}
+	} else {
+		o.R.Tokens = append(o.R.Tokens, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &tokenR{
+				User: o,
+			}
+		} else {
+			rel.R.User = o
+		}
+	}
+	return nil
+}
+
+// AddUploadsG adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.Uploads.
+// Sets related.R.User appropriately.
+// Uses the global database handle.
+func (o *User) AddUploadsG(ctx context.Context, insert bool, related ...*Upload) error {
+	return o.AddUploads(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddUploads adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.Uploads.
+// Sets related.R.User appropriately.
+func (o *User) AddUploads(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*Upload) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.UserID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"uploads\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"user_id"}),
+				strmangle.WhereClause("\"", "\"", 2, uploadPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.UserID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			Uploads: related,
+		}
+	} else {
+		o.R.Uploads = append(o.R.Uploads, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &uploadR{
+				User: o,
+			}
+		} else {
+			rel.R.User = o
+		}
+	}
+	return nil
+}
+
+// AddUserIdentitiesG adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.UserIdentities.
+// Sets related.R.User appropriately.
+// Uses the global database handle.
+func (o *User) AddUserIdentitiesG(ctx context.Context, insert bool, related ...*UserIdentity) error {
+	return o.AddUserIdentities(ctx, boil.GetContextDB(), insert, related...)
+}
+
+// AddUserIdentities adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.UserIdentities.
+// Sets related.R.User appropriately.
+func (o *User) AddUserIdentities(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*UserIdentity) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.UserID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"user_identities\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"user_id"}),
+				strmangle.WhereClause("\"", "\"", 2, userIdentityPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.UserID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			UserIdentities: related,
+		}
+	} else {
+		o.R.UserIdentities = append(o.R.UserIdentities, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &userIdentityR{
+				User: o,
+			}
+		} else {
+			rel.R.User = o
 		}
 	}
 	return nil
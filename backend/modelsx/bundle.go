@@ -0,0 +1,145 @@
+package modelsx
+
+import (
+	"io"
+	"strings"
+
+	"webserver/models"
+
+	. "github.com/docker/go-units"
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// ClipBundleCodec de/serializes the metadata of clip bundles, IDs are left out as they mean nothing on another instance
+var ClipBundleCodec = MakeCodec("bundle")
+
+// A clip bundle is a tar with the metadata first, followed by every object of the clip under ClipBundleObjects
+const (
+	ClipBundleVersion  = 1
+	ClipBundleMetadata = "clip.json"
+	ClipBundleObjects  = "objects/"
+)
+
+// ClipBundle is the metadata of an exported clip, enough to recreate it without transcoding it again
+type ClipBundle struct {
+	Version        int          `bundle:"version"`
+	Clip           *Clip        `bundle:"clip"`
+	ContentHash    null.String  `bundle:"content_hash,omitempty"`
+	PerceptualHash null.Int64   `bundle:"perceptual_hash,omitempty"`
+	Chapters       ChapterArray `bundle:"chapters"`
+	Objects        []string     `bundle:"objects"`
+}
+
+// NewClipBundle describes a clip and the objects that are exported with it
+func NewClipBundle(clip *models.Clip, chapters models.ClipChapterSlice, objects []string) *ClipBundle {
+	return &ClipBundle{
+		Version:        ClipBundleVersion,
+		Clip:           ClipFromModel(clip),
+		ContentHash:    clip.ContentHash,
+		PerceptualHash: clip.PerceptualHash,
+		Chapters:       ChapterFromModelBatch(chapters...),
+		Objects:        objects,
+	}
+}
+
+func (b *ClipBundle) Marshal() ([]byte, error) {
+	return ClipBundleCodec.Marshal(b)
+}
+
+// ToModel converts the bundle into a clip that's done processing, along with the columns to insert
+// Whether the clip was processing and its views are the source instance's business, they're never taken from the bundle
+func (b *ClipBundle) ToModel() (*models.Clip, boil.Columns) {
+	clip := b.Clip.ToModel()
+	clip.ContentHash = b.ContentHash
+	clip.PerceptualHash = b.PerceptualHash
+	clip.Processing = false
+	clip.Views = 0
+
+	columns := boil.Whitelist(
+		models.ClipColumns.Title,
+		models.ClipColumns.Description,
+		models.ClipColumns.CreatedAt,
+		models.ClipColumns.Processing,
+		models.ClipColumns.Unlisted,
+		models.ClipColumns.Views,
+		models.ClipColumns.MediaType,
+		models.ClipColumns.ContentHash,
+		models.ClipColumns.PerceptualHash,
+	)
+
+	// Bundles from before downloads could be disallowed leave it to the default
+	if b.Clip.AllowDownloads.Valid {
+		columns.Cols = append(columns.Cols, models.ClipColumns.AllowDownloads)
+	}
+
+	return clip, columns
+}
+
+// ChaptersToModel converts the chapters of the bundle for a clip with the ID cid
+func (b *ClipBundle) ChaptersToModel(cid int64) models.ClipChapterSlice {
+	chapters := make(models.ClipChapterSlice, 0, len(b.Chapters))
+
+	for _, c := range b.Chapters {
+		chapter := c.ToModel()
+		chapter.ClipID = cid
+		chapters = append(chapters, chapter)
+	}
+
+	return chapters
+}
+
+// ParseClipBundle parses and validates the metadata of a clip bundle
+func ParseClipBundle(r io.Reader) (*ClipBundle, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MB))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bundle metadata")
+	}
+
+	b := &ClipBundle{}
+
+	if err := ClipBundleCodec.Unmarshal(data, b); err != nil {
+		return nil, errors.Wrap(err, "failed to parse bundle metadata")
+	}
+
+	if b.Version != ClipBundleVersion {
+		return nil, errors.Errorf("unsupported bundle version %d", b.Version)
+	}
+
+	if b.Clip == nil {
+		return nil, errors.New("bundle has no clip")
+	}
+
+	if err := ClipValidate.Struct(b.Clip); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	if b.Clip.MediaType != MediaTypeVideo && b.Clip.MediaType != MediaTypeAudio {
+		return nil, errors.Errorf("unknown media type %q", b.Clip.MediaType)
+	}
+
+	for _, c := range b.Chapters {
+		if err := ChapterValidate.Struct(c); err != nil {
+			return nil, handleValidationError(err)
+		}
+
+		if !c.Title.Valid || !c.StartMS.Valid || !c.EndMS.Valid || c.EndMS.Int64 <= c.StartMS.Int64 {
+			return nil, errors.New("invalid chapter")
+		}
+	}
+
+	seen := make(map[string]bool, len(b.Objects))
+
+	for _, name := range b.Objects {
+		// Objects are stored under the clip's prefix, a name must not be able to leave it
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`+"\x00") || seen[name] {
+			return nil, errors.Errorf("invalid object name %q", name)
+		}
+
+		seen[name] = true
+	}
+
+	return b, nil
+}
@@ -0,0 +1,164 @@
+package modelsx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"webserver/models"
+
+	. "github.com/docker/go-units"
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
+)
+
+// De/Serializer cases
+var (
+	ChapterSerialize = MakeCodec("out")
+
+	ChapterDeserialize = MakeCodec("in")
+
+	ChapterValidate = makeValidator("validate")
+)
+
+// Chapter objects represent a named section of a clip
+type Chapter struct {
+	ID      HashID      `validate:"-"                       in:"-"        out:"id"       bundle:"-"       `
+	ClipID  HashID      `validate:"-"                       in:"-"        out:"-"        bundle:"-"       `
+	Title   null.String `validate:"omitempty,min=1,max=128" in:"title"    out:"title"    bundle:"title"   `
+	StartMS null.Int64  `validate:"omitempty,min=0"         in:"start_ms" out:"start_ms" bundle:"start_ms"`
+	EndMS   null.Int64  `validate:"omitempty,min=1"         in:"end_ms"   out:"end_ms"   bundle:"end_ms"  `
+}
+
+// ToModel converts a modelsx.Chapter object to a model.ClipChapter object
+func (c *Chapter) ToModel() *models.ClipChapter {
+	return &models.ClipChapter{
+		ID:      int64(c.ID),
+		ClipID:  int64(c.ClipID),
+		Title:   c.Title.String,
+		StartMS: c.StartMS.Int64,
+		EndMS:   c.EndMS.Int64,
+	}
+}
+
+// Send marshals a modelsx.Chapter object into a sendable json byte array
+func (c *Chapter) Marshal() (int, []byte, error) {
+	data, err := ChapterSerialize.Marshal(c)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
+
+// GetUpdateWhitelist returns a list of fields from a Chapter object that are valid
+func (c *Chapter) GetUpdateWhitelist() []string {
+	nonNullFields := make([]string, 0)
+
+	if c.Title.Valid {
+		nonNullFields = append(nonNullFields, models.ClipChapterColumns.Title)
+	}
+
+	if c.StartMS.Valid {
+		nonNullFields = append(nonNullFields, models.ClipChapterColumns.StartMS)
+	}
+
+	if c.EndMS.Valid {
+		nonNullFields = append(nonNullFields, models.ClipChapterColumns.EndMS)
+	}
+
+	return nonNullFields
+}
+
+// ChapterFromModel converts a models.ClipChapter object into a modelsx.Chapter object
+func ChapterFromModel(c *models.ClipChapter) *Chapter {
+	return &Chapter{
+		ID:      HashID(c.ID),
+		ClipID:  HashID(c.ClipID),
+		Title:   null.StringFrom(c.Title),
+		StartMS: null.Int64From(c.StartMS),
+		EndMS:   null.Int64From(c.EndMS),
+	}
+}
+
+// ParseChapter parses a Chapter object out of a client request
+func ParseChapter(req io.Reader) (*Chapter, error) {
+	data, err := io.ReadAll(io.LimitReader(req, 2*KB))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+
+	c := &Chapter{}
+
+	if err := ChapterDeserialize.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+
+	if err := ChapterValidate.Struct(c); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	return c, nil
+}
+
+// ChapterArray is a helper type representing an array of Chapter objects
+type ChapterArray []*Chapter
+
+// Send converts a ChapterArray into a sendable json byte array
+func (ca ChapterArray) Marshal() (int, []byte, error) {
+	data, err := ChapterSerialize.Marshal(ca)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
+
+// WebVTT renders the chapters as a WebVTT chapters track
+func (ca ChapterArray) WebVTT() []byte {
+	sb := &strings.Builder{}
+
+	sb.WriteString("WEBVTT\n")
+
+	for i, c := range ca {
+		fmt.Fprintf(sb, "\n%d\n%s --> %s\n%s\n",
+			i+1,
+			vttTimestamp(c.StartMS.Int64),
+			vttTimestamp(c.EndMS.Int64),
+			// A blank line or an arrow would break the cue, so titles have to stay on one line without them
+			strings.ReplaceAll(strings.Join(strings.Fields(c.Title.String), " "), "-->", "->"),
+		)
+	}
+
+	return []byte(sb.String())
+}
+
+// vttTimestamp formats milliseconds as a WebVTT hh:mm:ss.ttt timestamp
+func vttTimestamp(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		int64(d/time.Hour),
+		int64(d%time.Hour/time.Minute),
+		int64(d%time.Minute/time.Second),
+		int64(d%time.Second/time.Millisecond),
+	)
+}
+
+// ChapterFromModelBatch converts multiple models.ClipChapter into a modelsx.ChapterArray
+func ChapterFromModelBatch(model ...*models.ClipChapter) ChapterArray {
+	var chapters ChapterArray
+
+	for _, m := range model {
+		chapters = append(chapters, ChapterFromModel(m))
+	}
+
+	return chapters
+}
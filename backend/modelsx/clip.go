@@ -1,8 +1,12 @@
 package modelsx
 
 import (
+	"encoding/base64"
 	"io"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"webserver/models"
@@ -21,31 +25,41 @@ var (
 	ClipValidate = makeValidator("validate")
 )
 
+// Media types a clip can be transcoded as
+const (
+	MediaTypeVideo = "video"
+	MediaTypeAudio = "audio"
+)
+
 // Clip objects represent Clip accounts
 type Clip struct {
-	ID          HashID      `validate:"-"                  in:"-"           out:"id"                   `
-	Title       string      `validate:"min=2,max=64"       in:"title"       out:"title"                `
-	Description null.String `validate:"omitempty,max=1024" in:"description" out:"description,omitempty"`
-	CreatedAt   time.Time   `validate:"-"                  in:"-"           out:"created_at"           `
-	CreatorID   HashID      `validate:"-"                  in:"-"           out:"-"                    `
-	Processing  bool        `validate:"-"                  in:"-"           out:"processing"           `
-	Unlisted    null.Bool   `validate:"-"                  in:"unlisted"    out:"unlisted"             `
-	Views       int64       `validate:"-"                  in:"-"           out:"views"                `
-
-	Creator *User `validate:"-" in:"-" out:"creator"`
+	ID             HashID      `validate:"-"                  in:"-"               out:"id"                    bundle:"-"                    `
+	Title          string      `validate:"min=2,max=64"       in:"title"           out:"title"                 bundle:"title"                `
+	Description    null.String `validate:"omitempty,max=1024" in:"description"     out:"description,omitempty" bundle:"description,omitempty"`
+	CreatedAt      time.Time   `validate:"-"                  in:"-"               out:"created_at"            bundle:"created_at"           `
+	CreatorID      HashID      `validate:"-"                  in:"-"               out:"-"                     bundle:"-"                    `
+	Processing     bool        `validate:"-"                  in:"-"               out:"processing"            bundle:"-"                    `
+	Unlisted       null.Bool   `validate:"-"                  in:"unlisted"        out:"unlisted"              bundle:"unlisted"             `
+	Views          int64       `validate:"-"                  in:"-"               out:"views"                 bundle:"views"                `
+	MediaType      string      `validate:"-"                  in:"-"               out:"media_type"            bundle:"media_type"           `
+	AllowDownloads null.Bool   `validate:"-"                  in:"allow_downloads" out:"allow_downloads"       bundle:"allow_downloads"      `
+
+	Creator *User `validate:"-" in:"-" out:"creator" bundle:"-"`
 }
 
 // ToModel converts a modelsx.Clip object to a model.Clip object
 func (u *Clip) ToModel() *models.Clip {
 	return &models.Clip{
-		ID:          int64(u.ID),
-		Title:       u.Title,
-		Description: u.Description,
-		CreatedAt:   u.CreatedAt,
-		CreatorID:   int64(u.CreatorID),
-		Processing:  u.Processing,
-		Unlisted:    u.Unlisted.Bool,
-		Views:       u.Views,
+		ID:             int64(u.ID),
+		Title:          u.Title,
+		Description:    u.Description,
+		CreatedAt:      u.CreatedAt,
+		CreatorID:      int64(u.CreatorID),
+		Processing:     u.Processing,
+		Unlisted:       u.Unlisted.Bool,
+		Views:          u.Views,
+		MediaType:      u.MediaType,
+		AllowDownloads: u.AllowDownloads.Bool,
 	}
 }
 
@@ -81,20 +95,26 @@ func (u *Clip) GetUpdateWhitelist() []string {
 		nonNullFields = append(nonNullFields, models.ClipColumns.Unlisted)
 	}
 
+	if u.AllowDownloads.Valid {
+		nonNullFields = append(nonNullFields, models.ClipColumns.AllowDownloads)
+	}
+
 	return nonNullFields
 }
 
 // ClipFromModel converts a models.Clip object into a modelsx.Clip object
 func ClipFromModel(u *models.Clip) *Clip {
 	Clip := &Clip{
-		ID:          HashID(u.ID),
-		Title:       u.Title,
-		Description: u.Description,
-		CreatedAt:   u.CreatedAt,
-		CreatorID:   HashID(u.CreatorID),
-		Processing:  u.Processing,
-		Unlisted:    null.BoolFrom(u.Unlisted),
-		Views:       u.Views,
+		ID:             HashID(u.ID),
+		Title:          u.Title,
+		Description:    u.Description,
+		CreatedAt:      u.CreatedAt,
+		CreatorID:      HashID(u.CreatorID),
+		Processing:     u.Processing,
+		Unlisted:       null.BoolFrom(u.Unlisted),
+		Views:          u.Views,
+		MediaType:      u.MediaType,
+		AllowDownloads: null.BoolFrom(u.AllowDownloads),
 	}
 
 	if u.R != nil {
@@ -125,6 +145,54 @@ func ParseClip(req io.Reader) (*Clip, error) {
 	return a, nil
 }
 
+// ParseClipMetadata parses a Clip object out of the Upload-Metadata header of a tus upload
+// Most tus clients only send the file's name, so the title falls back to it
+func ParseClipMetadata(header string) (*Clip, error) {
+	metadata := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+
+		if key == "" {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(value)
+
+		if err != nil {
+			return nil, errors.Errorf("invalid metadata value for %s", key)
+		}
+
+		metadata[key] = string(decoded)
+	}
+
+	a := &Clip{Title: metadata["title"]}
+
+	if a.Title == "" {
+		a.Title = strings.TrimSuffix(metadata["filename"], filepath.Ext(metadata["filename"]))
+	}
+
+	if description, ok := metadata["description"]; ok {
+		a.Description = null.StringFrom(description)
+	}
+
+	if unlisted, ok := metadata["unlisted"]; ok {
+		b, err := strconv.ParseBool(unlisted)
+
+		if err != nil {
+			return nil, errors.New("invalid metadata value for unlisted")
+		}
+
+		a.Unlisted = null.BoolFrom(b)
+	}
+
+	if err := ClipValidate.Struct(a); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	return a, nil
+}
+
 // ClipArray is a helper type representing an array of Clip objects
 type ClipArray []*Clip
 
@@ -0,0 +1,38 @@
+package modelsx
+
+import (
+	"net/http"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Reasons an object is considered garbage
+const (
+	GarbageOrphaned       = "orphaned"
+	GarbageUploadPart     = "upload_part"
+	GarbageTranscodeChunk = "transcode_chunk"
+	GarbageRawVideo       = "raw_video"
+	GarbageComposePart    = "compose_part"
+	GarbageChecksums      = "checksums"
+)
+
+// Garbage lists the objects a garbage collection deleted, or would delete during a dry run
+type Garbage struct {
+	DryRun  bool             `json:"dry_run"`
+	Objects []*GarbageObject `json:"objects"`
+	Bytes   int64            `json:"bytes"`
+}
+
+type GarbageObject struct {
+	ClipID       int64     `json:"clip_id"`
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	Reason       string    `json:"reason"`
+}
+
+func (g *Garbage) Marshal() (int, []byte, error) {
+	data, err := jsoniter.Marshal(g)
+	return http.StatusOK, data, err
+}
@@ -0,0 +1,60 @@
+package modelsx
+
+import (
+	"io"
+
+	. "github.com/docker/go-units"
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
+)
+
+// ClipImport is a request to create a clip from a file on another server, instead of uploading it
+// URL is either a media file, or a clip on another instance
+type ClipImport struct {
+	URL            string      `validate:"required,url,max=2048"  in:"url"`
+	Title          string      `validate:"omitempty,min=2,max=64" in:"title"`
+	Description    null.String `validate:"omitempty,max=1024"     in:"description"`
+	Unlisted       null.Bool   `validate:"-"                      in:"unlisted"`
+	AllowDownloads null.Bool   `validate:"-"                      in:"allow_downloads"`
+}
+
+// ToClip is the clip the import creates, its title falls back to title when none was given
+func (c *ClipImport) ToClip(title string) (*Clip, error) {
+	clip := &Clip{
+		Title:          c.Title,
+		Description:    c.Description,
+		Unlisted:       c.Unlisted,
+		AllowDownloads: c.AllowDownloads,
+	}
+
+	if clip.Title == "" {
+		clip.Title = title
+	}
+
+	if err := ClipValidate.Struct(clip); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	return clip, nil
+}
+
+// ParseClipImport parses a ClipImport object out of a client request
+func ParseClipImport(req io.Reader) (*ClipImport, error) {
+	data, err := io.ReadAll(io.LimitReader(req, 4*KB))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+
+	c := &ClipImport{}
+
+	if err := ClipDeserialize.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+
+	if err := ClipValidate.Struct(c); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,34 @@
+package modelsx
+
+import (
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Reasons an uploaded file is refused
+const (
+	MediaUnsupportedFormat = "unsupported_format"
+	MediaUnreadable        = "unreadable"
+	MediaNoStreams         = "no_streams"
+	MediaTooLong           = "too_long"
+	MediaResolutionTooHigh = "resolution_too_high"
+	MediaTooManyStreams    = "too_many_streams"
+)
+
+// MediaError explains why an uploaded file was refused, Code is one of the reasons above so clients don't have to parse Message
+// Limit and Actual are set when a configured limit was exceeded
+type MediaError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   string `json:"limit,omitempty"`
+	Actual  string `json:"actual,omitempty"`
+}
+
+func (e *MediaError) Error() string {
+	return e.Message
+}
+
+func (e *MediaError) Marshal() (int, []byte, error) {
+	data, err := jsoniter.Marshal(e)
+	return e.Status, data, err
+}
@@ -14,3 +14,20 @@ func (p *Progress) Marshal() (int, []byte, error) {
 	data, err := jsoniter.Marshal(p)
 	return http.StatusOK, data, err
 }
+
+// Queue is every clip that's being transcoded, along with how far along the ones that started are
+type Queue struct {
+	Clips    ClipArray      `out:"clips"`
+	Progress map[HashID]int `out:"progress"`
+}
+
+func (q *Queue) Marshal() (int, []byte, error) {
+	data, err := ClipSerialize.Marshal(q)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
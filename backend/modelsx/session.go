@@ -0,0 +1,52 @@
+package modelsx
+
+import (
+	"net/http"
+	"time"
+
+	"webserver/models"
+)
+
+// De/Serializer cases
+var (
+	SessionSerialize = MakeCodec("out")
+)
+
+// Session objects describe where a user is logged in, without the secret their cookie holds
+type Session struct {
+	ID         HashID    `out:"id"`
+	IP         string    `out:"ip"`
+	UserAgent  string    `out:"user_agent"`
+	CreatedAt  time.Time `out:"created_at"`
+	LastSeenAt time.Time `out:"last_seen_at"`
+	ExpiresAt  time.Time `out:"expires_at"`
+	// Current is set on the session the request listing the sessions was made with
+	Current bool `out:"current"`
+}
+
+// SessionFromModel converts a models.Session object into a modelsx.Session object
+func SessionFromModel(s *models.Session) *Session {
+	return &Session{
+		ID:         HashID(s.ID),
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
+
+// SessionArray is a helper type representing an array of Session objects
+type SessionArray []*Session
+
+// Marshal converts a SessionArray into a sendable json byte array
+func (sa SessionArray) Marshal() (int, []byte, error) {
+	data, err := SessionSerialize.Marshal(sa)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
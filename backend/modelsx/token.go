@@ -0,0 +1,149 @@
+package modelsx
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"webserver/models"
+
+	. "github.com/docker/go-units"
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
+)
+
+// Scopes a personal access token can be given, a token is only let through to the endpoints its scopes cover
+const (
+	// ScopeUpload covers uploading clips, in one request, from a URL or resumably
+	ScopeUpload = "upload"
+	// ScopeRead covers every GET, HEAD and OPTIONS request that isn't part of an upload
+	ScopeRead = "read"
+	// ScopeManage covers every other change, like editing or deleting clips
+	ScopeManage = "manage"
+)
+
+// De/Serializer cases
+var (
+	TokenSerialize = MakeCodec("out")
+
+	TokenDeserialize = MakeCodec("in")
+
+	TokenValidate = makeValidator("validate")
+)
+
+// Token objects represent the personal access tokens scripts authenticate with instead of a session
+type Token struct {
+	ID         HashID      `validate:"-"                                            in:"-"          out:"id"`
+	Name       null.String `validate:"required,min=1,max=64"                        in:"name"       out:"name"`
+	Scopes     []string    `validate:"required,min=1,dive,oneof=upload read manage" in:"scopes"     out:"scopes"`
+	Prefix     string      `validate:"-"                                            in:"-"          out:"prefix"`
+	CreatedAt  time.Time   `validate:"-"                                            in:"-"          out:"created_at"`
+	LastUsedAt null.Time   `validate:"-"                                            in:"-"          out:"last_used_at"`
+	ExpiresAt  null.Time   `validate:"-"                                            in:"expires_at" out:"expires_at"`
+
+	// The secret itself is only ever sent once, in the response to creating the token
+	Secret string `validate:"-" in:"-" out:"token,omitempty"`
+}
+
+// ToModel converts a modelsx.Token object to a models.Token object, the hash and prefix are left for the caller to fill in
+func (t *Token) ToModel() *models.Token {
+	return &models.Token{
+		ID:        int64(t.ID),
+		Name:      t.Name.String,
+		Scopes:    strings.Join(t.Scopes, " "),
+		ExpiresAt: t.ExpiresAt,
+	}
+}
+
+// Marshal marshals a modelsx.Token object into a sendable json byte array
+func (t *Token) Marshal() (int, []byte, error) {
+	data, err := TokenSerialize.Marshal(t)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
+
+// TokenFromModel converts a models.Token object into a modelsx.Token object
+func TokenFromModel(t *models.Token) *Token {
+	return &Token{
+		ID:         HashID(t.ID),
+		Name:       null.StringFrom(t.Name),
+		Scopes:     TokenScopes(t),
+		Prefix:     t.Prefix,
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+		ExpiresAt:  t.ExpiresAt,
+	}
+}
+
+// TokenScopes splits the scopes stored with a token
+func TokenScopes(t *models.Token) []string {
+	return strings.Fields(t.Scopes)
+}
+
+// TokenHasScope reports whether a token was given a scope
+func TokenHasScope(t *models.Token, scope string) bool {
+	for _, s := range TokenScopes(t) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseToken parses a Token object out of a client request
+func ParseToken(req io.Reader) (*Token, error) {
+	data, err := io.ReadAll(io.LimitReader(req, 2*KB))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+
+	t := &Token{}
+
+	if err := TokenDeserialize.Unmarshal(data, t); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+
+	if err := TokenValidate.Struct(t); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	if t.ExpiresAt.Valid && !t.ExpiresAt.Time.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	return t, nil
+}
+
+// TokenArray is a helper type representing an array of Token objects
+type TokenArray []*Token
+
+// Marshal converts a TokenArray into a sendable json byte array
+func (ta TokenArray) Marshal() (int, []byte, error) {
+	data, err := TokenSerialize.Marshal(ta)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
+
+// TokenFromModelBatch converts multiple models.Token into a modelsx.TokenArray
+func TokenFromModelBatch(model ...*models.Token) TokenArray {
+	tokens := TokenArray{}
+
+	for _, m := range model {
+		tokens = append(tokens, TokenFromModel(m))
+	}
+
+	return tokens
+}
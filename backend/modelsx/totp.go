@@ -0,0 +1,82 @@
+package modelsx
+
+import (
+	"io"
+	"net/http"
+
+	. "github.com/docker/go-units"
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
+)
+
+// De/Serializer cases
+var (
+	TOTPSerialize = MakeCodec("out")
+
+	TOTPDeserialize = MakeCodec("in")
+
+	TOTPValidate = makeValidator("validate")
+)
+
+// TOTPKey is the secret a user sets up their authenticator app with, it's only sent while enrolling
+type TOTPKey struct {
+	Secret string `out:"secret"`
+	URI    string `out:"uri"` // otpauth:// URI, usually shown as a QR code
+}
+
+// Marshal marshals a TOTPKey object into a sendable json byte array
+func (k *TOTPKey) Marshal() (int, []byte, error) {
+	return marshalTOTP(k)
+}
+
+// TOTPRecoveryCodes are sent once, when two-factor authentication is enabled, only their hashes are kept
+type TOTPRecoveryCodes struct {
+	Codes []string `out:"recovery_codes"`
+}
+
+// Marshal marshals a TOTPRecoveryCodes object into a sendable json byte array
+func (c *TOTPRecoveryCodes) Marshal() (int, []byte, error) {
+	return marshalTOTP(c)
+}
+
+// TOTPCode is a code of a user's authenticator app, or one of their recovery codes
+type TOTPCode struct {
+	Code         null.String `validate:"omitempty,len=6,numeric" in:"code"`
+	RecoveryCode null.String `validate:"omitempty,max=32"        in:"recovery_code"`
+}
+
+// ParseTOTPCode parses a TOTPCode object out of a client request
+func ParseTOTPCode(req io.Reader) (*TOTPCode, error) {
+	data, err := io.ReadAll(io.LimitReader(req, 1*KB))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+
+	c := &TOTPCode{}
+
+	if err := TOTPDeserialize.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+
+	if err := TOTPValidate.Struct(c); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	if !c.Code.Valid && !c.RecoveryCode.Valid {
+		return nil, errors.New("code or recovery_code is required")
+	}
+
+	return c, nil
+}
+
+func marshalTOTP(v interface{}) (int, []byte, error) {
+	data, err := TOTPSerialize.Marshal(v)
+	code := http.StatusOK
+
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	return code, data, err
+}
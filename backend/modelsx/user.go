@@ -29,6 +29,9 @@ var (
 
 	UserValidateEdit     = &UserValidator{makeValidator("validateedit")}
 	UserValidateRegister = &UserValidator{makeValidator("validateregister")}
+
+	UserDeserializeAdmin UserDeserialize = MakeCodec("admin-in")
+	UserValidateAdmin                    = &UserValidator{makeValidator("validateadmin")}
 )
 
 // User objects represent user accounts
@@ -37,6 +40,17 @@ type User struct {
 	Username null.String `validateregister:"min=2,max=64"  validateedit:"omitempty,min=2,max=64"  self-in:"username" out:"username"`
 	Password null.String `validateregister:"min=2,max=256" validateedit:"omitempty,min=2,max=256" self-in:"password" out:"-"`
 	JoinedAt time.Time   `validateregister:"-"             validateedit:"-"                       self-in:"-"        out:"joined_at"`
+	Role     string      `validateregister:"-"             validateedit:"-"                       self-in:"-"        out:"role"`
+	Disabled bool        `validateregister:"-"             validateedit:"-"                       self-in:"-"        out:"disabled,omitempty"`
+
+	// Only filled in for the user themselves
+	Usage null.Int64 `validateregister:"-" validateedit:"-" self-in:"-" out:"usage,omitempty"`
+	Quota null.Int64 `validateregister:"-" validateedit:"-" self-in:"-" out:"quota,omitempty"`
+	// Whether they log in with a code of an authenticator app as well as their password
+	TwoFactor bool `validateregister:"-" validateedit:"-" self-in:"-" out:"two_factor,omitempty"`
+
+	// The password of whoever changes it, so a stolen session can't lock the owner out
+	CurrentPassword null.String `validateregister:"-" validateedit:"omitempty,max=256" self-in:"current_password" out:"-"`
 }
 
 // ToModel converts a modelsx.User object to a model.User object
@@ -66,7 +80,7 @@ func (u *User) GetUpdateWhitelist() []string {
 	nonNullFields := make([]string, 0)
 
 	if u.Password.Valid {
-		nonNullFields = append(nonNullFields, models.UserColumns.Password)
+		nonNullFields = append(nonNullFields, models.UserColumns.Password, models.UserColumns.PasswordUnset)
 	}
 
 	if u.Username.Valid {
@@ -83,6 +97,8 @@ func UserFromModel(u *models.User) *User {
 		Password: null.NewString(u.Password, u.Password != ""),
 		Username: null.NewString(u.Username, u.Username != ""),
 		JoinedAt: u.JoinedAt,
+		Role:     u.Role,
+		Disabled: u.Disabled,
 	}
 
 	return user
@@ -110,6 +126,65 @@ func ParseUser(req *http.Request, v *UserValidator) (*User, error) {
 	return a, nil
 }
 
+// UserAdminEdit is what admins can change about other users
+type UserAdminEdit struct {
+	Role     null.String `validateadmin:"omitempty,oneof=user moderator admin" admin-in:"role"`
+	Disabled null.Bool   `validateadmin:"-"                                    admin-in:"disabled"`
+	// Two-factor authentication can only be turned off, for users who lost both their authenticator and recovery codes
+	TwoFactor null.Bool `validateadmin:"omitempty,eq=false" admin-in:"two_factor"`
+}
+
+// Apply sets the fields that were given on user and returns the columns that changed
+func (e *UserAdminEdit) Apply(user *models.User) []string {
+	columns := make([]string, 0)
+
+	if e.Role.Valid {
+		user.Role = e.Role.String
+		columns = append(columns, models.UserColumns.Role)
+	}
+
+	if e.Disabled.Valid {
+		user.Disabled = e.Disabled.Bool
+		columns = append(columns, models.UserColumns.Disabled)
+	}
+
+	if e.TwoFactor.Valid && !e.TwoFactor.Bool {
+		user.TotpSecret = null.String{}
+		user.TotpEnabled = false
+		user.TotpRecoveryCodes = ""
+		user.TotpLastStep = 0
+		columns = append(columns,
+			models.UserColumns.TotpSecret,
+			models.UserColumns.TotpEnabled,
+			models.UserColumns.TotpRecoveryCodes,
+			models.UserColumns.TotpLastStep,
+		)
+	}
+
+	return columns
+}
+
+// ParseUserAdminEdit parses the changes an admin makes to a user out of a client request
+func ParseUserAdminEdit(req *http.Request) (*UserAdminEdit, error) {
+	data, err := ioutil.ReadAll(req.Body)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+
+	e := &UserAdminEdit{}
+
+	if err := UserDeserializeAdmin.Unmarshal(data, e); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal request body")
+	}
+
+	if err := UserValidateAdmin.Struct(e); err != nil {
+		return nil, handleValidationError(err)
+	}
+
+	return e, nil
+}
+
 // UserArray is a helper type representing an array of User objects
 type UserArray []*User
 
@@ -0,0 +1,29 @@
+package modelsx
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Verification lists the objects that no longer match the checksums they were stored with
+type Verification struct {
+	Objects    int              `json:"objects"` // How many objects were read back and checked
+	Bytes      int64            `json:"bytes"`
+	Unverified int              `json:"unverified"` // Objects without a checksum to check them against
+	Corrupt    []*CorruptObject `json:"corrupt"`
+}
+
+type CorruptObject struct {
+	ClipID   int64  `json:"clip_id"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"` // Why the object couldn't be read at all
+}
+
+func (v *Verification) Marshal() (int, []byte, error) {
+	data, err := jsoniter.Marshal(v)
+	return http.StatusOK, data, err
+}
@@ -0,0 +1,212 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services/policy"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/friendsofgo/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// adminOnly checks the requesting user is an admin, returning the status code to respond with if they aren't
+func adminOnly(user *models.User) (int, bool) {
+	if user == nil {
+		return http.StatusUnauthorized, false
+	}
+
+	if !policy.IsAdmin(user) {
+		return http.StatusForbidden, false
+	}
+
+	return 0, true
+}
+
+// BootstrapAdmin makes the configured admin account an admin, creating it first if it doesn't exist
+func (r *Routes) BootstrapAdmin(ctx context.Context) error {
+	if r.cfg.Admin.Username == "" {
+		return nil
+	}
+
+	admin, err := r.Users.FindUsername(ctx, r.cfg.Admin.Username)
+
+	if err == sql.ErrNoRows {
+		if r.cfg.Admin.Password == "" {
+			return errors.Errorf("admin %s doesn't exist and there's no password to create it with", r.cfg.Admin.Username)
+		}
+
+		hash, err := argon2id.CreateHash(r.cfg.Admin.Password, argon2id.DefaultParams)
+
+		if err != nil {
+			return errors.Wrap(err, "failed to hash password")
+		}
+
+		admin = &models.User{Username: r.cfg.Admin.Username, Password: hash, Role: policy.RoleAdmin}
+
+		if err := r.Users.Create(ctx, admin, boil.Whitelist(models.UserColumns.Username, models.UserColumns.Password, models.UserColumns.Role)); err != nil {
+			return errors.Wrap(err, "failed to create admin")
+		}
+
+		log.WithField("username", admin.Username).Info("Created admin")
+
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to find admin")
+	}
+
+	if admin.Role == policy.RoleAdmin && !admin.Disabled {
+		return nil
+	}
+
+	admin.Role = policy.RoleAdmin
+	admin.Disabled = false
+
+	if err := r.Users.Update(ctx, admin, boil.Whitelist(models.UserColumns.Role, models.UserColumns.Disabled)); err != nil {
+		return errors.Wrap(err, "failed to make user an admin")
+	}
+
+	log.WithField("username", admin.Username).Info("Made user an admin")
+
+	return nil
+}
+
+// AdminGetUsers lists every user, including disabled ones
+//
+// GET /admin/users
+func (r *Routes) AdminGetUsers(user *models.User, req *http.Request) (int, []byte, error) {
+	if code, ok := adminOnly(user); !ok {
+		return code, nil, nil
+	}
+
+	users, err := r.Users.FindMany(req.Context(), getPaginationMods(req, models.UserColumns.JoinedAt, models.TableNames.User, models.UserColumns.ID)...)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find users")
+	}
+
+	if len(users) == 0 {
+		return http.StatusNoContent, nil, nil
+	}
+
+	return modelsx.UserFromModelBatch(users...).Marshal()
+}
+
+// AdminUpdateUser changes the role of a user, disables them or turns off their two-factor authentication
+// Admins can't change their own account, so there's always an admin left to undo mistakes
+//
+// PATCH /admin/users/{user id}
+func (r *Routes) AdminUpdateUser(user *models.User, req *http.Request) (int, []byte, error) {
+	if code, ok := adminOnly(user); !ok {
+		return code, nil, nil
+	}
+
+	vars := vars(req)
+
+	if vars.UID == user.ID {
+		return http.StatusConflict, []byte("Admins can't change their own account"), nil
+	}
+
+	edit, err := modelsx.ParseUserAdminEdit(req)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	target, err := r.Users.Find(req.Context(), vars.UID)
+
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, nil, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find user")
+	}
+
+	if columns := edit.Apply(target); len(columns) > 0 {
+		if err := r.Users.Update(req.Context(), target, boil.Whitelist(columns...)); err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to update user")
+		}
+	}
+
+	return modelsx.UserFromModel(target).Marshal()
+}
+
+// AdminDeleteUser deletes a user along with all of their clips
+// Users with clips that are still processing can't be deleted until they're done
+//
+// DELETE /admin/users/{user id}
+func (r *Routes) AdminDeleteUser(user *models.User, req *http.Request) (int, []byte, error) {
+	if code, ok := adminOnly(user); !ok {
+		return code, nil, nil
+	}
+
+	vars := vars(req)
+
+	if vars.UID == user.ID {
+		return http.StatusConflict, []byte("Admins can't delete their own account"), nil
+	}
+
+	target, err := r.Users.Find(req.Context(), vars.UID)
+
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, nil, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find user")
+	}
+
+	clips, err := r.Clips.FindMany(req.Context(), policy.System(), models.ClipWhere.CreatorID.EQ(target.ID))
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find clips")
+	}
+
+	for _, clip := range clips {
+		if clip.Processing {
+			return http.StatusConflict, []byte("user has clips that are still processing"), nil
+		}
+	}
+
+	for _, clip := range clips {
+		if err := r.Clips.Delete(req.Context(), clip); err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete clip")
+		}
+	}
+
+	if err := r.Users.Delete(req.Context(), target); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete user")
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// AdminGetQueue lists the clips being transcoded
+// Clips the transcoder hasn't picked up yet don't have any progress
+//
+// GET /admin/queue
+func (r *Routes) AdminGetQueue(user *models.User, req *http.Request) (int, []byte, error) {
+	if code, ok := adminOnly(user); !ok {
+		return code, nil, nil
+	}
+
+	clips, err := r.Clips.FindMany(req.Context(), policy.System(), models.ClipWhere.Processing.EQ(true))
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find clips")
+	}
+
+	queue := &modelsx.Queue{
+		Clips:    modelsx.ClipFromModelBatch(clips...),
+		Progress: make(map[modelsx.HashID]int),
+	}
+
+	for _, clip := range clips {
+		if progress, ok := r.Transcoder.GetProgress(clip.ID); ok {
+			queue.Progress[modelsx.HashID(clip.ID)] = progress
+		}
+	}
+
+	return queue.Marshal()
+}
@@ -0,0 +1,394 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/gc"
+	"webserver/services/mock"
+	"webserver/services/policy"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func TestRoutes_AdminUpdateUser(t *testing.T) {
+	admin := &models.User{ID: 1, Role: policy.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		vars     *RouteVars
+		payload  string
+		expected int
+		hasBody  bool
+		hasError bool
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusOK,
+			hasBody:  true,
+			user:     admin,
+			vars:     &RouteVars{UID: 2},
+			payload:  `{"role": "moderator", "disabled": true, "two_factor": false}`,
+		},
+		{
+			name:     "Handle turning two-factor authentication on",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			user:     admin,
+			vars:     &RouteVars{UID: 2},
+			payload:  `{"two_factor": true}`,
+		},
+		{
+			name:     "Handle unknown role",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			user:     admin,
+			vars:     &RouteVars{UID: 2},
+			payload:  `{"role": "superuser"}`,
+		},
+		{
+			name:     "Handle unknown user",
+			expected: http.StatusNotFound,
+			user:     admin,
+			vars:     &RouteVars{UID: 3},
+			payload:  `{"disabled": true}`,
+		},
+		{
+			name:     "Deny admin changing themselves",
+			expected: http.StatusConflict,
+			hasBody:  true,
+			user:     admin,
+			vars:     &RouteVars{UID: 1},
+			payload:  `{"role": "user"}`,
+		},
+		{
+			name:     "Deny moderator",
+			expected: http.StatusForbidden,
+			user:     &models.User{ID: 2, Role: policy.RoleModerator},
+			vars:     &RouteVars{UID: 3},
+			payload:  `{"role": "admin"}`,
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			vars:     &RouteVars{UID: 2},
+			payload:  `{"role": "admin"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *models.User
+
+			r := &Routes{Group: &services.Group{
+				Users: &mock.UserProvider{
+					FindHook: func(ctx context.Context, uid int64) (*models.User, error) {
+						if uid != 2 {
+							return nil, sql.ErrNoRows
+						}
+
+						return &models.User{ID: 2, Role: policy.RoleUser, TotpEnabled: true, TotpSecret: null.StringFrom("secret")}, nil
+					},
+					UpdateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+						updated = user
+						return nil
+					},
+				},
+			}}
+
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.payload))
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, tt.vars))
+
+			code, body, err := r.AdminUpdateUser(tt.user, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if (body != nil) != tt.hasBody {
+				t.Errorf("Received unexpected body during %s test.", tt.name)
+			}
+
+			if (err != nil) != tt.hasError {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+
+			if tt.expected == http.StatusOK {
+				require.NotNil(t, updated)
+				assert.Equal(t, policy.RoleModerator, updated.Role)
+				assert.True(t, updated.Disabled)
+				assert.False(t, updated.TotpEnabled)
+				assert.False(t, updated.TotpSecret.Valid)
+			}
+		})
+	}
+}
+
+func TestRoutes_AdminDeleteUser(t *testing.T) {
+	admin := &models.User{ID: 1, Role: policy.RoleAdmin}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		vars       *RouteVars
+		clips      models.ClipSlice
+		expected   int
+		hasBody    bool
+		hasError   bool
+		deleted    []int64
+		deleteUser bool
+	}{
+		{
+			name:       "Success",
+			expected:   http.StatusNoContent,
+			user:       admin,
+			vars:       &RouteVars{UID: 2},
+			clips:      models.ClipSlice{{ID: 10, CreatorID: 2}, {ID: 11, CreatorID: 2}},
+			deleted:    []int64{10, 11},
+			deleteUser: true,
+		},
+		{
+			name:     "Handle clip still processing",
+			expected: http.StatusConflict,
+			hasBody:  true,
+			user:     admin,
+			vars:     &RouteVars{UID: 2},
+			clips:    models.ClipSlice{{ID: 10, CreatorID: 2}, {ID: 11, CreatorID: 2, Processing: true}},
+		},
+		{
+			name:     "Handle unknown user",
+			expected: http.StatusNotFound,
+			user:     admin,
+			vars:     &RouteVars{UID: 3},
+		},
+		{
+			name:     "Deny admin deleting themselves",
+			expected: http.StatusConflict,
+			hasBody:  true,
+			user:     admin,
+			vars:     &RouteVars{UID: 1},
+		},
+		{
+			name:     "Deny user",
+			expected: http.StatusForbidden,
+			user:     &models.User{ID: 3, Role: policy.RoleUser},
+			vars:     &RouteVars{UID: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []int64
+			var deleteUser bool
+
+			r := &Routes{Group: &services.Group{
+				Users: &mock.UserProvider{
+					FindHook: func(ctx context.Context, uid int64) (*models.User, error) {
+						if uid != 2 {
+							return nil, sql.ErrNoRows
+						}
+
+						return &models.User{ID: 2}, nil
+					},
+					DeleteHook: func(ctx context.Context, user *models.User) error {
+						// Clips don't cascade with their creator, they have to be gone first
+						assert.Len(t, deleted, len(tt.clips))
+						deleteUser = true
+						return nil
+					},
+				},
+				Clips: &mock.ClipsProvider{
+					FindManyHook: func(ctx context.Context, user *models.User, mods ...qm.QueryMod) (models.ClipSlice, error) {
+						assert.True(t, policy.IsSystem(user))
+						return tt.clips, nil
+					},
+					DeleteHook: func(ctx context.Context, clip *models.Clip) error {
+						deleted = append(deleted, clip.ID)
+						return nil
+					},
+				},
+			}}
+
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, tt.vars))
+
+			code, body, err := r.AdminDeleteUser(tt.user, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if (body != nil) != tt.hasBody {
+				t.Errorf("Received unexpected body during %s test.", tt.name)
+			}
+
+			if (err != nil) != tt.hasError {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+
+			assert.Equal(t, tt.deleted, deleted)
+			assert.Equal(t, tt.deleteUser, deleteUser)
+		})
+	}
+}
+
+func TestRoutes_AdminGetQueue(t *testing.T) {
+	r := &Routes{Group: &services.Group{
+		Clips: &mock.ClipsProvider{
+			FindManyHook: func(ctx context.Context, user *models.User, mods ...qm.QueryMod) (models.ClipSlice, error) {
+				return models.ClipSlice{{ID: 1, Processing: true}, {ID: 2, Processing: true}}, nil
+			},
+		},
+		Transcoder: &mock.TranscoderProvider{
+			GetProgressHook: func(cid int64) (int, bool) {
+				// The second clip is still waiting for the transcoder
+				return 42, cid == 1
+			},
+		},
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	code, _, _ := r.AdminGetQueue(&models.User{ID: 1, Role: policy.RoleModerator}, req)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, body, err := r.AdminGetQueue(&models.User{ID: 1, Role: policy.RoleAdmin}, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	queue := struct {
+		Clips    []map[string]interface{} `json:"clips"`
+		Progress map[string]int           `json:"progress"`
+	}{}
+
+	require.NoError(t, jsoniter.Unmarshal(body, &queue))
+	assert.Len(t, queue.Clips, 2)
+	assert.Len(t, queue.Progress, 1)
+	assert.Equal(t, 42, queue.Progress[queue.Clips[0]["id"].(string)])
+}
+
+func TestRoutes_AdminCollectGarbage(t *testing.T) {
+	store := newTestStore(t)
+	_, err := store.PutObject(context.Background(), 3, "dash.mpd", strings.NewReader("<MPD/>"))
+	require.NoError(t, err)
+
+	g := &services.Group{
+		ObjectStore: store,
+		Clips: &mock.ClipsProvider{
+			FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+				return nil, sql.ErrNoRows
+			},
+		},
+		Uploads: &mock.UploadsProvider{
+			StagingHook: func(ctx context.Context, cid int64) (bool, error) {
+				return false, nil
+			},
+		},
+	}
+
+	r := &Routes{Group: g, Collector: gc.New(&config.Config{}, g)}
+	admin := &models.User{ID: 1, Role: policy.RoleAdmin}
+
+	code, _, _, _ := r.AdminCollectGarbage(nil, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _, _, _ = r.AdminCollectGarbage(&models.User{ID: 1, Role: policy.RoleModerator}, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.True(t, store.HasObject(context.Background(), 3, "dash.mpd"))
+
+	// A GET is a dry run
+	code, body, _, err := r.AdminCollectGarbage(admin, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	garbage := modelsx.Garbage{}
+	require.NoError(t, jsoniter.NewDecoder(body).Decode(&garbage))
+	assert.True(t, garbage.DryRun)
+	assert.Len(t, garbage.Objects, 1)
+	assert.True(t, store.HasObject(context.Background(), 3, "dash.mpd"))
+
+	code, _, _, err = r.AdminCollectGarbage(admin, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, store.HasObject(context.Background(), 3, "dash.mpd"))
+}
+
+func TestRoutes_AdminVerifyObjects(t *testing.T) {
+	r := &Routes{}
+
+	code, _, _, _ := r.AdminVerifyObjects(nil, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _, _, _ = r.AdminVerifyObjects(&models.User{ID: 1, Role: policy.RoleModerator}, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestRoutes_BootstrapAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *models.User
+		password string
+		created  bool
+		updated  bool
+		hasError bool
+	}{
+		{name: "Create missing admin", password: "password", created: true},
+		{name: "Handle missing admin without password", hasError: true},
+		{name: "Promote existing user", existing: &models.User{ID: 1, Username: "root", Role: policy.RoleUser, Disabled: true}, updated: true},
+		{name: "Leave existing admin alone", existing: &models.User{ID: 1, Username: "root", Role: policy.RoleAdmin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Admin.Username = "root"
+			cfg.Admin.Password = tt.password
+
+			var created, updated *models.User
+
+			r := &Routes{cfg: cfg, Group: &services.Group{
+				Users: &mock.UserProvider{
+					FindUsernameHook: func(ctx context.Context, username string) (*models.User, error) {
+						if tt.existing == nil {
+							return nil, sql.ErrNoRows
+						}
+
+						return tt.existing, nil
+					},
+					CreateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+						created = user
+						return nil
+					},
+					UpdateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+						updated = user
+						return nil
+					},
+				},
+			}}
+
+			err := r.BootstrapAdmin(context.Background())
+			assert.Equal(t, tt.hasError, err != nil, err)
+
+			assert.Equal(t, tt.created, created != nil)
+			assert.Equal(t, tt.updated, updated != nil)
+
+			for _, user := range []*models.User{created, updated} {
+				if user != nil {
+					assert.Equal(t, "root", user.Username)
+					assert.True(t, policy.IsAdmin(user))
+				}
+			}
+		})
+	}
+}
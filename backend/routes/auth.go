@@ -15,6 +15,9 @@ const SESSION_KEY_UID = "uid"
 const SESSION_KEY_OAUTH_STATE = "oauth-state"
 const SESSION_KEY_ID = "id"
 
+// SESSION_KEY_PENDING_ID holds the user who sent the right password, but still has to send a code to be logged in
+const SESSION_KEY_PENDING_ID = "pending-id"
+
 func (r *Routes) AllowRegistration(resp http.ResponseWriter, req *http.Request) (int, []byte, error) {
 	if !r.cfg.AllowRegistration {
 		return http.StatusForbidden, nil, nil
@@ -50,6 +53,23 @@ func (r *Routes) Login(resp http.ResponseWriter, req *http.Request) (int, []byte
 		return http.StatusUnauthorized, []byte("Invalid username/password combination"), nil
 	}
 
+	if user.Disabled {
+		return http.StatusForbidden, []byte("Account is disabled"), nil
+	}
+
+	// Users with two-factor authentication aren't logged in until they send a code to /auth/login/totp
+	// 202 tells clients to ask for it
+	if user.TotpEnabled {
+		delete(session.Values, SESSION_KEY_ID)
+
+		session.Values[SESSION_KEY_PENDING_ID] = user.ID
+		if err := session.Save(req, resp); err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to save session")
+		}
+
+		return http.StatusAccepted, nil, nil
+	}
+
 	session.Values[SESSION_KEY_ID] = user.ID
 	if err := session.Save(req, resp); err != nil {
 		return http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to save session")
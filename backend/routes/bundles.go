@@ -0,0 +1,378 @@
+package routes
+
+import (
+	"archive/tar"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services/policy"
+
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// Prefixes of objects that only exist while a clip is being uploaded or transcoded, they're never exported
+var temporaryObjectPrefixes = []string{uploadPartPrefix, "chunk-"}
+
+// bundleObjectName matches the objects the transcoder writes for a clip, a bundle can't bring anything else along
+// Those are the manifests, renditions, thumbnail, downloads and the kept original
+var bundleObjectName = regexp.MustCompile(`^(?:dash\.mpd|thumbnail\.jpg|raw|download-(?:\d+p\.mp4|audio\.m4a)|[a-zA-Z0-9_-]+\.(?:m3u8|m4s|mp4|webm))$`)
+
+// ExportClip streams a tar of the clip's metadata and every object needed to play it, see modelsx.ClipBundle
+// The bundle can be imported on another instance without transcoding the clip again
+//
+// GET /clips/{clip id}/export
+func (r *Routes) ExportClip(user *models.User, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil, nil
+	}
+
+	vars := vars(req)
+
+	clip, err := r.Clips.Find(req.Context(), vars.CID)
+
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, nil, nil, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to get clip")
+	}
+
+	if !policy.CanManageClip(user, clip) {
+		return http.StatusForbidden, nil, nil, nil
+	}
+
+	if clip.Processing {
+		return http.StatusConflict, StringToStream("clip is still processing"), nil, nil
+	}
+
+	chapters, err := r.Chapters.FindMany(req.Context(), clip.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to find chapters")
+	}
+
+	objects, err := r.exportedObjects(req.Context(), clip.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, err
+	}
+
+	names := make([]string, 0, len(objects))
+
+	for _, object := range objects {
+		names = append(names, strings.TrimPrefix(object.Name, modelsx.ClipBundleObjects))
+	}
+
+	metadata, err := modelsx.NewClipBundle(clip, chapters, names).Marshal()
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to marshal bundle metadata")
+	}
+
+	metadataHeader := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     modelsx.ClipBundleMetadata,
+		Mode:     0o644,
+		Size:     int64(len(metadata)),
+		ModTime:  time.Now(),
+	}
+
+	// Responses without a length only get a few seconds to be written, so the size of the tar is worked out up front
+	size := tarEntrySize(metadataHeader)
+
+	for _, object := range objects {
+		size += tarEntrySize(object)
+	}
+
+	// Two empty blocks end the archive
+	size += 2 * 512
+
+	body, w := io.Pipe()
+
+	go func() {
+		tw := tar.NewWriter(w)
+
+		if err := writeTarEntry(tw, metadataHeader, strings.NewReader(string(metadata))); err != nil {
+			w.CloseWithError(err)
+			return
+		}
+
+		for _, object := range objects {
+			reader, _, err := r.ObjectStore.GetObject(context.Background(), clip.ID, strings.TrimPrefix(object.Name, modelsx.ClipBundleObjects))
+
+			if err != nil {
+				w.CloseWithError(errors.Wrap(err, "failed to get object"))
+				return
+			}
+
+			err = writeTarEntry(tw, object, reader)
+			reader.Close()
+
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+
+		w.CloseWithError(tw.Close())
+	}()
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/x-tar")
+	headers.Set("Content-Length", fmt.Sprint(size))
+
+	if name, err := modelsx.HashEncode(clip.ID); err == nil {
+		headers.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="clip-%s.tar"`, name))
+	}
+
+	return http.StatusOK, body, headers, nil
+}
+
+// exportedObjects returns a tar header for every object of a clip that's part of its bundle
+// The sizes of the listing aren't those of the contents with every store, so every object is opened once to get them
+func (r *Routes) exportedObjects(ctx context.Context, cid int64) ([]*tar.Header, error) {
+	listed, err := r.ObjectStore.ListObjects(ctx, cid)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+
+	var objects []*tar.Header
+
+	for _, object := range listed {
+		if isTemporaryObject(object.Name) {
+			continue
+		}
+
+		reader, info, err := r.ObjectStore.GetObject(ctx, cid, object.Name)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get object %s", object.Name)
+		}
+
+		reader.Close()
+
+		objects = append(objects, &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     modelsx.ClipBundleObjects + object.Name,
+			Mode:     0o644,
+			Size:     info.Size,
+			ModTime:  info.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+func isTemporaryObject(name string) bool {
+	for _, prefix := range temporaryObjectPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tarEntrySize is how many bytes an entry takes up in a tar, the header blocks plus the contents padded to a whole block
+func tarEntrySize(header *tar.Header) int64 {
+	var counter countingWriter
+
+	// Long names need extra header blocks, writing the header is the only reliable way to count them
+	tar.NewWriter(&counter).WriteHeader(header)
+
+	return int64(counter) + (header.Size+511)/512*512
+}
+
+func writeTarEntry(tw *tar.Writer, header *tar.Header, r io.Reader) error {
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.Wrap(err, "failed to write tar header")
+	}
+
+	// An object that changed since it was measured would corrupt the archive, so it's cut off or fails instead
+	if _, err := io.CopyN(tw, r, header.Size); err != nil {
+		return errors.Wrapf(err, "failed to write %s", header.Name)
+	}
+
+	return nil
+}
+
+// importBundle recreates a clip from a bundle made by ExportClip, as a clip of the current user
+func (r *Routes) importBundle(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	limit, byQuota, err := r.uploadLimit(req.Context(), user, 0)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	// Content-Length can't be trusted, so the bundle is cut off once it's larger than what may be stored
+	body := &io.LimitedReader{R: req.Body, N: limit}
+
+	tooLarge := func() (int, []byte, error) {
+		if byQuota {
+			return http.StatusForbidden, []byte("Storage quota exceeded"), nil
+		}
+
+		return http.StatusBadRequest, []byte("Bundle too large"), nil
+	}
+
+	tr := tar.NewReader(body)
+
+	header, err := tr.Next()
+
+	if err != nil || header.Name != modelsx.ClipBundleMetadata {
+		return http.StatusBadRequest, []byte("Bundle must start with " + modelsx.ClipBundleMetadata), nil
+	}
+
+	bundle, err := modelsx.ParseClipBundle(tr)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	missing := make(map[string]bool, len(bundle.Objects))
+
+	for _, name := range bundle.Objects {
+		missing[name] = true
+	}
+
+	if !missing[manifestFilename] {
+		return http.StatusBadRequest, []byte("Bundle has no manifest"), nil
+	}
+
+	for name := range missing {
+		if !bundleObjectName.MatchString(name) {
+			return http.StatusBadRequest, []byte(fmt.Sprintf("Unexpected object %s in bundle", name)), nil
+		}
+	}
+
+	model, columns := bundle.ToModel()
+
+	tx, err := r.Clips.Create(req.Context(), model, user, columns)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to create clip")
+	}
+
+	committed := false
+
+	defer func() {
+		if committed {
+			return
+		}
+
+		tx.Rollback()
+
+		// Whatever was stored before the import failed would otherwise be left to the garbage collector
+		r.ObjectStore.DeleteObjects(context.Background(), model.ID, "")
+	}()
+
+	var stored int64
+
+	for {
+		header, err := tr.Next()
+
+		if body.N == 0 {
+			return tooLarge()
+		} else if err == io.EOF {
+			break
+		} else if err != nil {
+			return http.StatusBadRequest, []byte("Invalid bundle"), nil
+		}
+
+		name := strings.TrimPrefix(header.Name, modelsx.ClipBundleObjects)
+
+		if header.Typeflag != tar.TypeReg || !strings.HasPrefix(header.Name, modelsx.ClipBundleObjects) || !missing[name] {
+			return http.StatusBadRequest, []byte(fmt.Sprintf("Unexpected file %s in bundle", header.Name)), nil
+		}
+
+		n, err := r.ObjectStore.PutObject(req.Context(), model.ID, name, tr)
+
+		if body.N == 0 {
+			return tooLarge()
+		} else if err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrapf(err, "failed to store object %s", name)
+		}
+
+		delete(missing, name)
+		stored += n
+	}
+
+	if len(missing) > 0 {
+		return http.StatusBadRequest, []byte("Bundle is missing objects"), nil
+	}
+
+	if err := r.checkManifest(req.Context(), model.ID); err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	// Other uploads may have used up the quota in the meantime, the clip itself isn't counted until it's committed
+	exceeded, err := r.exceedsQuota(req.Context(), user, stored)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if exceeded {
+		return http.StatusForbidden, []byte("Storage quota exceeded"), nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	committed = true
+
+	if err := r.Chapters.Replace(req.Context(), model.ID, bundle.ChaptersToModel(model.ID)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to import chapters")
+	}
+
+	model.SizeBytes = stored
+
+	if err := r.Clips.Update(req.Context(), model, boil.Whitelist(models.ClipColumns.SizeBytes)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to store clip size")
+	}
+
+	return modelsx.ClipFromModel(model).Marshal()
+}
+
+// checkManifest makes sure the imported manifest of a clip is a DASH manifest, as it's served to players as one
+func (r *Routes) checkManifest(ctx context.Context, cid int64) error {
+	manifest, _, err := r.ObjectStore.GetObject(ctx, cid, manifestFilename)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to get manifest")
+	}
+
+	defer manifest.Close()
+
+	decoder := xml.NewDecoder(manifest)
+
+	for {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return errors.New("Manifest isn't valid XML")
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "MPD" {
+				return errors.New("Manifest isn't a DASH manifest")
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package routes
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/object"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func newTestStore(t *testing.T) services.ObjectStore {
+	cfg := &config.Config{}
+	cfg.Storage.Path = t.TempDir()
+
+	store, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	return store
+}
+
+func TestRoutes_ExportImportClip(t *testing.T) {
+	ctx := context.Background()
+	source := newTestStore(t)
+
+	for name, data := range map[string]string{
+		"dash.mpd":         "<MPD/>",
+		"init-stream0.m4s": "init",
+		"dash-stream0.m4s": "segment",
+		"thumbnail.jpg":    "jpeg",
+		// Leftovers of the transcode and upload aren't part of the clip
+		"chunk-0.ts": "chunk",
+		"upload-0":   "part",
+	} {
+		_, err := source.PutObject(ctx, 1, name, strings.NewReader(data))
+		assert.NoError(t, err)
+	}
+
+	clip := &models.Clip{
+		ID:          1,
+		Title:       "My clip",
+		Description: null.StringFrom("A description"),
+		CreatorID:   1,
+		CreatedAt:   time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC),
+		Views:       42,
+		Unlisted:    true,
+		MediaType:   modelsx.MediaTypeVideo,
+		ContentHash: null.StringFrom("abc"),
+	}
+
+	chapters := models.ClipChapterSlice{{ID: 3, ClipID: 1, Title: "Intro", StartMS: 0, EndMS: 1000}}
+
+	exporter := &Routes{
+		cfg: &config.Config{},
+		Group: &services.Group{
+			ObjectStore: source,
+			Clips: &mock.ClipsProvider{
+				FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+					return clip, nil
+				},
+			},
+			Chapters: &mock.ChaptersProvider{
+				FindManyHook: func(ctx context.Context, cid int64) (models.ClipChapterSlice, error) {
+					return chapters, nil
+				},
+			},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{CID: 1}))
+
+	code, _, _, _ := exporter.ExportClip(&models.User{ID: 2}, req)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, body, headers, err := exporter.ExportClip(&models.User{ID: 1}, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "application/x-tar", headers.Get("Content-Type"))
+
+	bundle, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+
+	// The length is promised before anything is written
+	assert.Equal(t, strconv.Itoa(len(bundle)), headers.Get("Content-Length"))
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(bundle))
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		assert.NoError(t, err)
+		names = append(names, header.Name)
+	}
+
+	assert.Equal(t, modelsx.ClipBundleMetadata, names[0])
+	assert.ElementsMatch(t, []string{
+		modelsx.ClipBundleMetadata,
+		"objects/dash-stream0.m4s",
+		"objects/dash.mpd",
+		"objects/init-stream0.m4s",
+		"objects/thumbnail.jpg",
+	}, names)
+
+	// Importing on another instance recreates the clip without transcoding it
+	target := newTestStore(t)
+
+	var created *models.Clip
+	var createdColumns boil.Columns
+	var replaced models.ClipChapterSlice
+	var committed bool
+
+	importer := &Routes{
+		cfg: &config.Config{MaxUploadSizeBytes: 1 << 20},
+		Group: &services.Group{
+			ObjectStore: target,
+			Clips: &mock.ClipsProvider{
+				CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+					clip.ID = 7
+					clip.CreatorID = creator.ID
+					created, createdColumns = clip, columns
+
+					return &mock.ClipTxProvider{
+						CommitHook: func() error {
+							committed = true
+							return nil
+						},
+						RollbackHook: func() error { return nil },
+					}, nil
+				},
+				UpdateHook: func(ctx context.Context, clip *models.Clip, columns boil.Columns) error {
+					assert.Equal(t, []string{models.ClipColumns.SizeBytes}, columns.Cols)
+					return nil
+				},
+			},
+			Chapters: &mock.ChaptersProvider{
+				ReplaceHook: func(ctx context.Context, cid int64, chapters models.ClipChapterSlice) error {
+					assert.Equal(t, int64(7), cid)
+					replaced = chapters
+					return nil
+				},
+			},
+		},
+	}
+
+	code, _, err = importer.importBundle(&models.User{ID: 5}, httptest.NewRequest("POST", "/", bytes.NewReader(bundle)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, committed)
+
+	assert.Equal(t, "My clip", created.Title)
+	assert.Equal(t, "A description", created.Description.String)
+	assert.Equal(t, clip.CreatedAt, created.CreatedAt.UTC())
+	// Views stay with the instance they were counted on
+	assert.Equal(t, int64(0), created.Views)
+	assert.Contains(t, createdColumns.Cols, models.ClipColumns.Views)
+	assert.True(t, created.Unlisted)
+	assert.False(t, created.Processing)
+	assert.Contains(t, createdColumns.Cols, models.ClipColumns.Processing)
+	assert.Equal(t, "abc", created.ContentHash.String)
+	assert.Equal(t, int64(len("<MPD/>init")+len("segmentjpeg")), created.SizeBytes)
+
+	assert.Len(t, replaced, 1)
+	assert.Equal(t, "Intro", replaced[0].Title)
+	assert.Equal(t, int64(7), replaced[0].ClipID)
+
+	for _, name := range []string{"dash.mpd", "init-stream0.m4s", "dash-stream0.m4s", "thumbnail.jpg"} {
+		assert.True(t, target.HasObject(ctx, 7, name), name)
+	}
+
+	assert.False(t, target.HasObject(ctx, 7, "chunk-0.ts"))
+}
+
+func TestRoutes_ImportClipInvalid(t *testing.T) {
+	metadata := func(objects ...string) []byte {
+		data, _ := modelsx.NewClipBundle(&models.Clip{Title: "My clip", MediaType: modelsx.MediaTypeVideo}, nil, objects).Marshal()
+		return data
+	}
+
+	type entry struct {
+		name string
+		data []byte
+	}
+
+	tests := []struct {
+		name     string
+		entries  []entry
+		maxSize  int64
+		quota    int64
+		expected int
+	}{
+		{
+			name:     "Handle missing metadata",
+			entries:  []entry{{"objects/dash.mpd", []byte("<MPD/>")}},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Handle bundle without manifest",
+			entries:  []entry{{modelsx.ClipBundleMetadata, metadata("thumbnail.jpg")}},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Handle object name leaving the clip",
+			entries:  []entry{{modelsx.ClipBundleMetadata, metadata("dash.mpd", "../1/dash.mpd")}},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "Handle unlisted object",
+			entries: []entry{
+				{modelsx.ClipBundleMetadata, metadata("dash.mpd")},
+				{"objects/dash.mpd", []byte("<MPD/>")},
+				{"objects/other.m4s", []byte("segment")},
+			},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Handle object the transcoder doesn't write",
+			entries:  []entry{{modelsx.ClipBundleMetadata, metadata("dash.mpd", "page.html")}},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "Handle manifest that isn't one",
+			entries: []entry{
+				{modelsx.ClipBundleMetadata, metadata("dash.mpd")},
+				{"objects/dash.mpd", []byte("<html><script>alert(1)</script></html>")},
+			},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "Handle bundle too large",
+			entries: []entry{
+				{modelsx.ClipBundleMetadata, metadata("dash.mpd", "dash-stream0.m4s")},
+				{"objects/dash.mpd", []byte("<MPD/>")},
+				{"objects/dash-stream0.m4s", bytes.Repeat([]byte("s"), 4096)},
+			},
+			maxSize:  4096,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "Deny bundle over quota",
+			entries: []entry{
+				{modelsx.ClipBundleMetadata, metadata("dash.mpd", "dash-stream0.m4s")},
+				{"objects/dash.mpd", []byte("<MPD/>")},
+				{"objects/dash-stream0.m4s", bytes.Repeat([]byte("s"), 4096)},
+			},
+			quota:    4096,
+			expected: http.StatusForbidden,
+		},
+		{
+			name: "Handle missing object",
+			entries: []entry{
+				{modelsx.ClipBundleMetadata, metadata("dash.mpd", "init-stream0.m4s")},
+				{"objects/dash.mpd", []byte("<MPD/>")},
+			},
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bundle bytes.Buffer
+			tw := tar.NewWriter(&bundle)
+
+			for _, e := range tt.entries {
+				assert.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: e.name, Mode: 0o644, Size: int64(len(e.data))}))
+				_, err := tw.Write(e.data)
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, tw.Close())
+
+			store := newTestStore(t)
+			rolledBack := false
+
+			maxSize := tt.maxSize
+
+			if maxSize == 0 {
+				maxSize = 1 << 20
+			}
+
+			r := &Routes{
+				cfg: &config.Config{MaxUploadSizeBytes: maxSize},
+				Group: &services.Group{
+					ObjectStore: store,
+					Users: &mock.UserProvider{
+						UsageHook: func(ctx context.Context, uid int64) (int64, error) {
+							return 0, nil
+						},
+					},
+					Clips: &mock.ClipsProvider{
+						CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+							clip.ID = 7
+
+							return &mock.ClipTxProvider{
+								RollbackHook: func() error {
+									rolledBack = true
+									return nil
+								},
+							}, nil
+						},
+					},
+				},
+			}
+
+			code, _, err := r.importBundle(&models.User{ID: 1, QuotaBytes: null.NewInt64(tt.quota, tt.quota > 0)}, httptest.NewRequest("POST", "/", &bundle))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, code)
+
+			// Nothing of a failed import is kept
+			assert.False(t, store.HasObject(context.Background(), 7, "dash.mpd"))
+
+			if len(tt.entries) > 2 {
+				assert.True(t, rolledBack)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services/policy"
+
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// GetChapters returns the chapters of a clip, ordered by their start time
+//
+// GET /clips/{clip id}/chapters
+func (r *Routes) GetChapters(user *models.User, req *http.Request) (int, []byte, error) {
+	vars := vars(req)
+
+	exists, err := r.Clips.Exists(req.Context(), vars.CID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to check if clip exists")
+	}
+
+	if !exists {
+		return http.StatusNotFound, nil, nil
+	}
+
+	chapters, err := r.Chapters.FindMany(req.Context(), vars.CID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find chapters")
+	}
+
+	if len(chapters) == 0 {
+		return http.StatusNoContent, nil, nil
+	}
+
+	return modelsx.ChapterFromModelBatch(chapters...).Marshal()
+}
+
+// GetChaptersVTT returns the chapters of a clip as a WebVTT chapters track
+// Clips without chapters get an empty track so players don't have to special case them
+//
+// GET /clips/{clip id}/chapters.vtt
+func (r *Routes) GetChaptersVTT(user *models.User, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	vars := vars(req)
+
+	exists, err := r.Clips.Exists(req.Context(), vars.CID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to check if clip exists")
+	}
+
+	if !exists {
+		return http.StatusNotFound, nil, nil, nil
+	}
+
+	chapters, err := r.Chapters.FindMany(req.Context(), vars.CID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to find chapters")
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "text/vtt; charset=utf-8")
+
+	return http.StatusOK, io.NopCloser(bytes.NewReader(modelsx.ChapterFromModelBatch(chapters...).WebVTT())), headers, nil
+}
+
+// CreateChapter adds a chapter to a clip
+//
+// POST /clips/{clip id}/chapters
+func (r *Routes) CreateChapter(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	vars := vars(req)
+
+	clip, err := r.Clips.Find(req.Context(), vars.CID)
+
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, nil, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to get clip")
+	}
+
+	if !policy.CanManageClip(user, clip) {
+		return http.StatusForbidden, nil, nil
+	}
+
+	// The transcoder replaces the chapters with the ones of the file once it's done, edits made before that would be lost
+	if clip.Processing {
+		return http.StatusConflict, []byte("clip is still processing"), nil
+	}
+
+	chapterx, err := modelsx.ParseChapter(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	if !chapterx.Title.Valid || !chapterx.StartMS.Valid || !chapterx.EndMS.Valid {
+		return http.StatusBadRequest, []byte("title, start_ms and end_ms are required"), nil
+	}
+
+	model := chapterx.ToModel()
+	model.ClipID = clip.ID
+
+	if model.EndMS <= model.StartMS {
+		return http.StatusBadRequest, []byte("end_ms must be after start_ms"), nil
+	}
+
+	if err := r.Chapters.Create(req.Context(), model, boil.Whitelist(append(chapterx.GetUpdateWhitelist(), models.ClipChapterColumns.ClipID)...)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to create chapter")
+	}
+
+	return modelsx.ChapterFromModel(model).Marshal()
+}
+
+// UpdateChapter changes the title or times of a chapter
+//
+// PATCH /clips/{clip id}/chapters/{chapter id}
+func (r *Routes) UpdateChapter(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	clip, chapter, code, err := r.findChapter(req)
+
+	if clip == nil || chapter == nil {
+		return code, nil, err
+	}
+
+	if !policy.CanManageClip(user, clip) {
+		return http.StatusForbidden, nil, nil
+	}
+
+	// The transcoder replaces the chapters with the ones of the file once it's done, edits made before that would be lost
+	if clip.Processing {
+		return http.StatusConflict, []byte("clip is still processing"), nil
+	}
+
+	chapterx, err := modelsx.ParseChapter(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	if chapterx.Title.Valid {
+		chapter.Title = chapterx.Title.String
+	}
+
+	if chapterx.StartMS.Valid {
+		chapter.StartMS = chapterx.StartMS.Int64
+	}
+
+	if chapterx.EndMS.Valid {
+		chapter.EndMS = chapterx.EndMS.Int64
+	}
+
+	if chapter.EndMS <= chapter.StartMS {
+		return http.StatusBadRequest, []byte("end_ms must be after start_ms"), nil
+	}
+
+	if err := r.Chapters.Update(req.Context(), chapter, boil.Whitelist(chapterx.GetUpdateWhitelist()...)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to update chapter")
+	}
+
+	return modelsx.ChapterFromModel(chapter).Marshal()
+}
+
+// DeleteChapter removes a chapter from a clip
+//
+// DELETE /clips/{clip id}/chapters/{chapter id}
+func (r *Routes) DeleteChapter(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	clip, chapter, code, err := r.findChapter(req)
+
+	if clip == nil || chapter == nil {
+		return code, nil, err
+	}
+
+	if !policy.CanManageClip(user, clip) {
+		return http.StatusForbidden, nil, nil
+	}
+
+	// The transcoder replaces the chapters with the ones of the file once it's done, edits made before that would be lost
+	if clip.Processing {
+		return http.StatusConflict, []byte("clip is still processing"), nil
+	}
+
+	if err := r.Chapters.Delete(req.Context(), chapter); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete chapter")
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// findChapter looks up the clip and chapter of the request, making sure the chapter belongs to the clip
+// If either can't be found, the status code and error to respond with are returned instead
+func (r *Routes) findChapter(req *http.Request) (*models.Clip, *models.ClipChapter, int, error) {
+	vars := vars(req)
+
+	clip, err := r.Clips.Find(req.Context(), vars.CID)
+
+	if err == sql.ErrNoRows {
+		return nil, nil, http.StatusNotFound, nil
+	} else if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(err, "failed to get clip")
+	}
+
+	chapter, err := r.Chapters.Find(req.Context(), vars.CHID)
+
+	if err == sql.ErrNoRows || (err == nil && chapter.ClipID != clip.ID) {
+		return nil, nil, http.StatusNotFound, nil
+	} else if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(err, "failed to get chapter")
+	}
+
+	return clip, chapter, http.StatusOK, nil
+}
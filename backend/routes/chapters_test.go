@@ -0,0 +1,392 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/policy"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestRoutes_CreateChapter(t *testing.T) {
+	clips := &mock.ClipsProvider{
+		FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+			if cid != 1 {
+				return nil, sql.ErrNoRows
+			}
+
+			return &models.Clip{ID: 1, CreatorID: 1}, nil
+		},
+	}
+
+	processing := &mock.ClipsProvider{
+		FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+			return &models.Clip{ID: cid, CreatorID: 1, Processing: true}, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		group    *services.Group
+		user     *models.User
+		vars     *RouteVars
+		payload  string
+		expected int
+		hasBody  bool
+		hasError bool
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusOK,
+			hasBody:  true,
+			group: &services.Group{
+				Clips: clips,
+				Chapters: &mock.ChaptersProvider{
+					CreateHook: func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+						if chapter.ClipID != 1 || chapter.Title != "Intro" || chapter.StartMS != 0 || chapter.EndMS != 5000 {
+							t.Errorf("Received unexpected chapter %+v", chapter)
+						}
+
+						return nil
+					},
+				},
+			},
+			user:    &models.User{ID: 1},
+			vars:    &RouteVars{CID: 1},
+			payload: `{"title": "Intro", "start_ms": 0, "end_ms": 5000}`,
+		},
+		{
+			name:     "Handle missing fields",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			group:    &services.Group{Clips: clips},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 1},
+			payload:  `{"title": "Intro"}`,
+		},
+		{
+			name:     "Handle end before start",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			group:    &services.Group{Clips: clips},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 1},
+			payload:  `{"title": "Intro", "start_ms": 5000, "end_ms": 1000}`,
+		},
+		{
+			name:     "Handle unknown clip",
+			expected: http.StatusNotFound,
+			group:    &services.Group{Clips: clips},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 2},
+		},
+		{
+			name:     "Deny user editing another user's clip",
+			expected: http.StatusForbidden,
+			group:    &services.Group{Clips: clips},
+			user:     &models.User{ID: 2},
+			vars:     &RouteVars{CID: 1},
+		},
+		{
+			name:     "Allow moderator editing another user's clip",
+			expected: http.StatusOK,
+			hasBody:  true,
+			group: &services.Group{
+				Clips: clips,
+				Chapters: &mock.ChaptersProvider{
+					CreateHook: func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+						return nil
+					},
+				},
+			},
+			user:    &models.User{ID: 2, Role: policy.RoleModerator},
+			vars:    &RouteVars{CID: 1},
+			payload: `{"title": "Intro", "start_ms": 0, "end_ms": 5000}`,
+		},
+		{
+			name:     "Handle processing clip",
+			expected: http.StatusConflict,
+			hasBody:  true,
+			group:    &services.Group{Clips: processing},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 1},
+			payload:  `{"title": "Intro", "start_ms": 0, "end_ms": 5000}`,
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			group:    &services.Group{},
+			vars:     &RouteVars{CID: 1},
+		},
+		{
+			name:     "Handle create error",
+			expected: http.StatusInternalServerError,
+			hasError: true,
+			group: &services.Group{
+				Clips: clips,
+				Chapters: &mock.ChaptersProvider{
+					CreateHook: func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+						return sql.ErrConnDone
+					},
+				},
+			},
+			user:    &models.User{ID: 1},
+			vars:    &RouteVars{CID: 1},
+			payload: `{"title": "Intro", "start_ms": 0, "end_ms": 5000}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Routes{
+				Group: tt.group,
+			}
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.payload))
+
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, tt.vars))
+
+			code, body, err := r.CreateChapter(tt.user, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if (body != nil) != tt.hasBody {
+				t.Errorf("Received unexpected body during %s test.", tt.name)
+			}
+
+			if (err != nil) != tt.hasError {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+		})
+	}
+}
+
+func TestRoutes_UpdateChapter(t *testing.T) {
+	clips := &mock.ClipsProvider{
+		FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+			return &models.Clip{ID: cid, CreatorID: 1}, nil
+		},
+	}
+
+	processing := &mock.ClipsProvider{
+		FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+			return &models.Clip{ID: cid, CreatorID: 1, Processing: true}, nil
+		},
+	}
+
+	chapters := func(update func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error) *mock.ChaptersProvider {
+		return &mock.ChaptersProvider{
+			FindHook: func(ctx context.Context, chid int64) (*models.ClipChapter, error) {
+				if chid != 1 {
+					return nil, sql.ErrNoRows
+				}
+
+				return &models.ClipChapter{ID: 1, ClipID: 1, Title: "Intro", StartMS: 0, EndMS: 5000}, nil
+			},
+			UpdateHook: update,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		group    *services.Group
+		user     *models.User
+		vars     *RouteVars
+		payload  string
+		expected int
+		hasBody  bool
+		hasError bool
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusOK,
+			hasBody:  true,
+			group: &services.Group{
+				Clips: clips,
+				Chapters: chapters(func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+					if chapter.Title != "Outro" || chapter.EndMS != 5000 {
+						t.Errorf("Received unexpected chapter %+v", chapter)
+					}
+
+					return nil
+				}),
+			},
+			user:    &models.User{ID: 1},
+			vars:    &RouteVars{CID: 1, CHID: 1},
+			payload: `{"title": "Outro"}`,
+		},
+		{
+			name:     "Handle start moved past end",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			group:    &services.Group{Clips: clips, Chapters: chapters(nil)},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 1, CHID: 1},
+			payload:  `{"start_ms": 6000}`,
+		},
+		{
+			name:     "Handle chapter of another clip",
+			expected: http.StatusNotFound,
+			group:    &services.Group{Clips: clips, Chapters: chapters(nil)},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 2, CHID: 1},
+		},
+		{
+			name:     "Handle unknown chapter",
+			expected: http.StatusNotFound,
+			group:    &services.Group{Clips: clips, Chapters: chapters(nil)},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 1, CHID: 2},
+		},
+		{
+			name:     "Deny user editing another user's clip",
+			expected: http.StatusForbidden,
+			group:    &services.Group{Clips: clips, Chapters: chapters(nil)},
+			user:     &models.User{ID: 2},
+			vars:     &RouteVars{CID: 1, CHID: 1},
+		},
+		{
+			name:     "Handle processing clip",
+			expected: http.StatusConflict,
+			hasBody:  true,
+			group:    &services.Group{Clips: processing, Chapters: chapters(nil)},
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{CID: 1, CHID: 1},
+			payload:  `{"title": "Outro"}`,
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			group:    &services.Group{},
+			vars:     &RouteVars{CID: 1, CHID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Routes{
+				Group: tt.group,
+			}
+
+			req := httptest.NewRequest("PATCH", "/", strings.NewReader(tt.payload))
+
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, tt.vars))
+
+			code, body, err := r.UpdateChapter(tt.user, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if (body != nil) != tt.hasBody {
+				t.Errorf("Received unexpected body during %s test.", tt.name)
+			}
+
+			if (err != nil) != tt.hasError {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+		})
+	}
+}
+
+func TestRoutes_GetChaptersVTT(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    *services.Group
+		expected int
+		body     string
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusOK,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					ExistsHook: func(ctx context.Context, cid int64) (bool, error) {
+						return true, nil
+					},
+				},
+				Chapters: &mock.ChaptersProvider{
+					FindManyHook: func(ctx context.Context, cid int64) (models.ClipChapterSlice, error) {
+						return models.ClipChapterSlice{
+							{ID: 1, Title: "Intro", StartMS: 0, EndMS: 5000},
+							{ID: 2, Title: "The\nmain --> part", StartMS: 5000, EndMS: 3723004},
+						}, nil
+					},
+				},
+			},
+			body: "WEBVTT\n" +
+				"\n1\n00:00:00.000 --> 00:00:05.000\nIntro\n" +
+				"\n2\n00:00:05.000 --> 01:02:03.004\nThe main -> part\n",
+		},
+		{
+			name:     "Success - no chapters",
+			expected: http.StatusOK,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					ExistsHook: func(ctx context.Context, cid int64) (bool, error) {
+						return true, nil
+					},
+				},
+				Chapters: &mock.ChaptersProvider{
+					FindManyHook: func(ctx context.Context, cid int64) (models.ClipChapterSlice, error) {
+						return nil, nil
+					},
+				},
+			},
+			body: "WEBVTT\n",
+		},
+		{
+			name:     "Handle unknown clip",
+			expected: http.StatusNotFound,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					ExistsHook: func(ctx context.Context, cid int64) (bool, error) {
+						return false, nil
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Routes{
+				Group: tt.group,
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{CID: 1}))
+
+			code, body, _, err := r.GetChaptersVTT(nil, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if err != nil {
+				t.Errorf("Received unexpected error during %s test. %s", tt.name, err)
+			}
+
+			if body == nil {
+				if tt.body != "" {
+					t.Errorf("Received no body during %s test.", tt.name)
+				}
+				return
+			}
+
+			data, _ := io.ReadAll(body)
+
+			if string(data) != tt.body {
+				t.Errorf("Received unexpected body during %s test. Wanted: %q Got: %q", tt.name, tt.body, data)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"webserver/models"
 	"webserver/modelsx"
+	"webserver/services/policy"
+	"webserver/services/transcoder"
 
 	"github.com/friendsofgo/errors"
 	"github.com/volatiletech/sqlboiler/v4/boil"
@@ -21,6 +23,23 @@ func (r *Routes) UploadClip(user *models.User, req *http.Request) (int, []byte,
 		return http.StatusUnauthorized, nil, nil
 	}
 
+	size := req.ContentLength
+
+	if size < 0 {
+		size = 0
+	}
+
+	// The request is only an estimate of the video's size, but it's all there is before reading it
+	exceeded, err := r.exceedsQuota(req.Context(), user, size)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if exceeded {
+		return http.StatusForbidden, []byte("Storage quota exceeded"), nil
+	}
+
 	// Get media type information from the content type header
 	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
 
@@ -75,39 +94,107 @@ func (r *Routes) UploadClip(user *models.User, req *http.Request) (int, []byte,
 
 	model := clip.ToModel()
 
-	// Create the clip
-	tx, err := r.Clips.Create(req.Context(), model, user, boil.Whitelist(clip.GetUpdateWhitelist()...))
+	created, code, body, err := r.createClip(req.Context(), user, model, boil.Whitelist(clip.GetUpdateWhitelist()...), videoPart, 0)
+
+	if created == nil {
+		return code, body, err
+	}
+
+	// Duplicates aren't transcoded again, the existing clip is returned instead
+	if created != model {
+		code, body, err := modelsx.ClipFromModel(created).Marshal()
+
+		if r.cfg.Dedupe.Conflict && err == nil {
+			code = http.StatusConflict
+		}
+
+		return code, body, err
+	}
+
+	if err := r.Transcoder.Queue(context.Background(), model); errors.Is(err, transcoder.ErrStopping) {
+		return http.StatusServiceUnavailable, []byte("Server is shutting down, the clip is processed once it's back"), nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to queue clip for transcoding")
+	}
+
+	return modelsx.ClipFromModel(model).Marshal()
+}
+
+// createClip inserts model and stores video as its raw object, leaving it to the caller to queue it for transcoding
+// Videos in a container that isn't supported, or that exceed the Media limits, are refused with a modelsx.MediaError
+// If the video turns out to be a duplicate nothing is kept and the existing clip is returned instead of model
+// When no clip is returned, the status code, body and error describe why
+// reserved is what the user's usage already counts for video, see uploadLimit
+func (r *Routes) createClip(ctx context.Context, user *models.User, model *models.Clip, columns boil.Columns, video io.Reader, reserved int64) (*models.Clip, int, []byte, error) {
+	video, head := peekHead(video)
+
+	if sniffContainer(head) == "" {
+		code, body, err := unsupportedFormat().Marshal()
+		return nil, code, body, err
+	}
+
+	// The size the request claimed was only checked against the quota as an estimate, chunked requests don't claim any
+	limit, byQuota, err := r.uploadLimit(ctx, user, reserved)
+
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, err
+	}
+
+	tx, err := r.Clips.Create(ctx, model, user, columns)
 
 	if err != nil {
-		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to create clip")
+		return nil, http.StatusInternalServerError, nil, errors.Wrap(err, "failed to create clip")
 	}
 
 	// Always attempt to rollback, even if it succeeds, if the tx is committed, this is a no-op
 	defer tx.Rollback()
 
-	len, err := tx.UploadVideo(req.Context(), io.LimitReader(videoPart, r.cfg.MaxUploadSizeBytes))
+	len, err := tx.UploadVideo(ctx, io.LimitReader(video, limit))
 
 	// LimitReader will return io.EOF once the limit is reached, so if we read exactly our limit
 	// there was more data to read, and the video was too large
-	if len == r.cfg.MaxUploadSizeBytes {
-		return http.StatusBadRequest, []byte("Video too large"), nil
+	if len == limit {
+		if byQuota {
+			return nil, http.StatusForbidden, []byte("Storage quota exceeded"), nil
+		}
+
+		return nil, http.StatusBadRequest, []byte("Video too large"), nil
 	}
 
 	if e, ok := err.(net.Error); ok && e.Timeout() {
-		return http.StatusRequestTimeout, nil, errors.Wrap(err, "upload timed out")
+		return nil, http.StatusRequestTimeout, nil, errors.Wrap(err, "upload timed out")
 	} else if err != nil {
-		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to upload video")
+		return nil, http.StatusInternalServerError, nil, errors.Wrap(err, "failed to upload video")
 	}
 
-	if err := tx.Commit(); err != nil {
-		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to commit transaction")
+	if r.cfg.Dedupe.Enabled {
+		existing, err := r.Clips.FindDuplicate(ctx, user, model.ContentHash.String)
+
+		if err == nil {
+			// The deferred rollback throws away the upload, so nothing gets transcoded twice
+			return existing, http.StatusOK, nil, nil
+		} else if err != sql.ErrNoRows {
+			return nil, http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find duplicate clip")
+		}
 	}
 
-	if err := r.Transcoder.Queue(context.Background(), model); err != nil {
-		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to queue clip for transcoding")
+	// The transcoder would fail on it minutes from now, the uploader is still around to be told why
+	mediaErr, err := r.checkMedia(ctx, model.ID)
+
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil, err
 	}
 
-	return modelsx.ClipFromModel(model).Marshal()
+	if mediaErr != nil {
+		code, body, err := mediaErr.Marshal()
+		return nil, code, body, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, nil, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return model, http.StatusOK, nil, nil
 }
 
 func (r *Routes) GetClip(user *models.User, req *http.Request) (int, []byte, error) {
@@ -124,6 +211,33 @@ func (r *Routes) GetClip(user *models.User, req *http.Request) (int, []byte, err
 	return modelsx.ClipFromModel(clip).Marshal()
 }
 
+// GetDuplicates returns clips with the same content, or a similar perceptual hash, as the requested clip
+//
+// GET /clips/{clip id}/duplicates
+func (r *Routes) GetDuplicates(user *models.User, req *http.Request) (int, []byte, error) {
+	vars := vars(req)
+
+	clip, err := r.Clips.Find(req.Context(), vars.CID)
+
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, nil, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to get clip")
+	}
+
+	clips, err := r.Clips.FindSimilar(req.Context(), user, clip, r.cfg.Dedupe.PerceptualThreshold)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find similar clips")
+	}
+
+	if len(clips) == 0 {
+		return http.StatusNoContent, nil, nil
+	}
+
+	return modelsx.ClipFromModelBatch(clips...).Marshal()
+}
+
 // GetClipProgress returns the progress of a clip, if it's being processed
 // Reports -1 if the processing has not yet begun, or a number between 0 and 100 based on the progress
 // Returns 204 if the clip is not being processed, or is done processing
@@ -199,7 +313,7 @@ func (r *Routes) UpdateClip(user *models.User, req *http.Request) (int, []byte,
 		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to get clip")
 	}
 
-	if clip.CreatorID != user.ID {
+	if !policy.CanManageClip(user, clip) {
 		return http.StatusForbidden, nil, nil
 	}
 
@@ -238,7 +352,7 @@ func (r *Routes) DeleteClip(user *models.User, req *http.Request) (int, []byte,
 		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to get clip")
 	}
 
-	if clip.CreatorID != user.ID {
+	if !policy.CanManageClip(user, clip) {
 		return http.StatusForbidden, nil, nil
 	}
 
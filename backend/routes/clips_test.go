@@ -0,0 +1,325 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/transcoder"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func newUploadRequest(t *testing.T, json string, video []byte) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	jsonPart, err := mw.CreateFormField("json")
+	assert.NoError(t, err)
+	jsonPart.Write([]byte(json))
+
+	videoPart, err := mw.CreateFormFile("video", "video.mp4")
+	assert.NoError(t, err)
+	videoPart.Write(video)
+
+	assert.NoError(t, mw.Close())
+
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return req
+}
+
+func newClipTx(clip **models.Clip, contentHash string) *mock.ClipTxProvider {
+	return &mock.ClipTxProvider{
+		UploadVideoHook: func(ctx context.Context, r io.Reader) (int64, error) {
+			n, err := io.Copy(io.Discard, r)
+			(*clip).ContentHash = null.StringFrom(contentHash)
+			return n, err
+		},
+		CommitHook: func() error {
+			return nil
+		},
+		RollbackHook: func() error {
+			return nil
+		},
+	}
+}
+
+func TestRoutes_UploadClip(t *testing.T) {
+	var created *models.Clip
+
+	tests := []struct {
+		name     string
+		group    *services.Group
+		user     *models.User
+		conflict bool
+		expected int
+		hasBody  bool
+		hasError bool
+		queued   bool
+		video    string
+		media    *services.MediaInfo
+		probeErr error
+		code     string
+		chunked  bool
+		queueErr error
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusOK,
+			hasBody:  true,
+			queued:   true,
+			user:     &models.User{ID: 1},
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						assert.Equal(t, "abc", contentHash)
+						return nil, sql.ErrNoRows
+					},
+				},
+			},
+		},
+		{
+			name:     "Duplicate returns the existing clip",
+			expected: http.StatusOK,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return &models.Clip{ID: 2, Title: "Existing"}, nil
+					},
+				},
+			},
+		},
+		{
+			name:     "Duplicate conflicts when configured",
+			expected: http.StatusConflict,
+			hasBody:  true,
+			conflict: true,
+			user:     &models.User{ID: 1},
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return &models.Clip{ID: 2, Title: "Existing"}, nil
+					},
+				},
+			},
+		},
+		{
+			name:     "Handle failure to find duplicates",
+			expected: http.StatusInternalServerError,
+			hasError: true,
+			user:     &models.User{ID: 1},
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return nil, assert.AnError
+					},
+				},
+			},
+		},
+		{
+			name:     "Refuse files that aren't media",
+			expected: http.StatusUnsupportedMediaType,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			video:    "<!DOCTYPE html><html></html>",
+			code:     modelsx.MediaUnsupportedFormat,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						t.Fatal("clip shouldn't be created")
+						return nil, nil
+					},
+				},
+			},
+		},
+		{
+			name:     "Refuse files ffprobe can't read",
+			expected: http.StatusUnprocessableEntity,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			probeErr: transcoder.ErrUnreadableMedia,
+			code:     modelsx.MediaUnreadable,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return nil, sql.ErrNoRows
+					},
+				},
+			},
+		},
+		{
+			name:     "Refuse videos over the limits",
+			expected: http.StatusUnprocessableEntity,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			media:    &services.MediaInfo{Duration: 2 * time.Hour, Width: 1920, Height: 1080, VideoStreams: 1, Streams: 1},
+			code:     modelsx.MediaTooLong,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return nil, sql.ErrNoRows
+					},
+				},
+			},
+		},
+		{
+			name:     "Handle failure to probe",
+			expected: http.StatusInternalServerError,
+			hasError: true,
+			user:     &models.User{ID: 1},
+			probeErr: assert.AnError,
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return nil, sql.ErrNoRows
+					},
+				},
+			},
+		},
+		{
+			name:     "Refuse while shutting down",
+			expected: http.StatusServiceUnavailable,
+			hasBody:  true,
+			queued:   true,
+			queueErr: transcoder.ErrStopping,
+			user:     &models.User{ID: 1},
+			group: &services.Group{
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+					FindDuplicateHook: func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+						return nil, sql.ErrNoRows
+					},
+				},
+			},
+		},
+		{
+			name:     "Deny chunked upload over quota",
+			expected: http.StatusForbidden,
+			hasBody:  true,
+			chunked:  true,
+			user:     &models.User{ID: 1, QuotaBytes: null.Int64From(100)},
+			group: &services.Group{
+				Users: &mock.UserProvider{
+					UsageHook: func(ctx context.Context, uid int64) (int64, error) {
+						return 95, nil
+					},
+				},
+				Clips: &mock.ClipsProvider{
+					CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+						created = clip
+						return newClipTx(&created, "abc"), nil
+					},
+				},
+			},
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			group:    &services.Group{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queued := false
+
+			tt.group.Transcoder = &mock.TranscoderProvider{
+				QueueHook: func(ctx context.Context, clip *models.Clip) error {
+					queued = true
+					return tt.queueErr
+				},
+				ProbeHook: func(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+					if tt.media != nil || tt.probeErr != nil {
+						return tt.media, tt.probeErr
+					}
+
+					return &services.MediaInfo{Duration: time.Minute, Width: 1920, Height: 1080, VideoStreams: 1, AudioStreams: 1, Streams: 2}, nil
+				},
+			}
+
+			cfg := &config.Config{MaxUploadSizeBytes: 1024}
+			cfg.Dedupe.Enabled = true
+			cfg.Dedupe.Conflict = tt.conflict
+			cfg.Media.MaxDuration = time.Hour
+
+			r := &Routes{
+				Group: tt.group,
+				cfg:   cfg,
+			}
+
+			video := tt.video
+
+			if video == "" {
+				video = mp4Header + "video"
+			}
+
+			req := newUploadRequest(t, `{"title": "Test clip"}`, []byte(video))
+
+			// Requests without a Content-Length can't be checked against the quota up front
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+
+			code, body, err := r.UploadClip(tt.user, req)
+
+			assert.Equal(t, tt.expected, code)
+			assert.Equal(t, tt.hasBody, body != nil)
+			assert.Equal(t, tt.hasError, err != nil)
+			assert.Equal(t, tt.queued, queued)
+
+			if tt.code != "" {
+				mediaErr := &modelsx.MediaError{}
+				assert.NoError(t, jsoniter.Unmarshal(body, mediaErr))
+				assert.Equal(t, tt.code, mediaErr.Code)
+			}
+		})
+	}
+}
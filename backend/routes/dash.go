@@ -1,93 +1,159 @@
 package routes
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 	"webserver/models"
+	"webserver/services"
+	"webserver/services/object"
+	"webserver/services/tiering"
 
 	"github.com/friendsofgo/errors"
-	"github.com/gotd/contrib/http_range"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
+// Delivery modes that can be selected with Delivery.Mode
+const (
+	DeliveryProxy   = "proxy"
+	DeliveryPresign = "presign"
+	DeliveryAccel   = "accel"
+)
+
+// manifestFilename is the one file of a clip that's always served by the backend, as requesting it counts as a view
+const manifestFilename = "dash.mpd"
+
 func (r *Routes) GetStreamFile(u *models.User, req *http.Request) (int, io.ReadCloser, http.Header, error) {
 	vars := vars(req)
 
+	// Redirects are only handed out for objects that exist, anything else is a 404 rather than an error of S3 or nginx
 	if !r.ObjectStore.HasObject(req.Context(), vars.CID, vars.Filename) {
 		return http.StatusNotFound, nil, nil, nil
 	}
 
-	// Get the object from the minio server
-	objReader, size, etag, err := r.ObjectStore.GetObject(req.Context(), vars.CID, vars.Filename)
+	if vars.Filename != manifestFilename && (r.cfg.Delivery.Mode == DeliveryPresign || r.cfg.Delivery.Mode == DeliveryAccel) {
+		return r.redirectStreamFile(req.Context(), vars.CID, vars.Filename)
+	}
+
+	var (
+		objReader io.ReadSeekCloser
+		info      *services.ObjectInfo
+		err       error
+	)
+
+	// Manifests are small and a player can't do anything without an intact one, so they're checked on every read
+	if vars.Filename == manifestFilename {
+		objReader, info, err = object.GetVerified(req.Context(), r.ObjectStore, vars.CID, vars.Filename)
+	} else {
+		objReader, info, err = r.ObjectStore.GetObject(req.Context(), vars.CID, vars.Filename)
+	}
 
 	if err != nil {
 		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to get object")
 	}
 
-	deferClose := false
+	cacheControl := segmentCacheControl
 
-	// The underlying handler will close the reader if we return it
-	// but if we return early due to an error we need to close it ourselves
-	defer func() {
-		if !deferClose {
-			objReader.Close()
-		}
-	}()
+	if vars.Filename == manifestFilename {
+		cacheControl = manifestCacheControl
 
-	if vars.Filename == "dash.mpd" {
 		// Get the clip to increment views by cid
 		clip, err := r.Clips.Find(req.Context(), vars.CID)
 
 		if err != nil {
+			objReader.Close()
 			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to find clip")
 		}
 
 		clip.Views++
+		clip.LastViewedAt = null.TimeFrom(time.Now())
 
-		if err := r.Clips.Update(req.Context(), clip, boil.Whitelist(models.ClipColumns.Views)); err != nil {
+		if err := r.Clips.Update(req.Context(), clip, boil.Whitelist(models.ClipColumns.Views, models.ClipColumns.LastViewedAt)); err != nil {
+			objReader.Close()
 			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to update clip")
 		}
+
+		if store, ok := r.ObjectStore.(services.TieredObjectStore); ok && r.cfg.Tiering.DropFromManifest {
+			objReader, info, err = dropColdRenditions(req.Context(), store, vars.CID, objReader, info)
+
+			if err != nil {
+				return http.StatusInternalServerError, nil, nil, err
+			}
+		}
 	}
 
-	ranges, err := http_range.ParseRange(req.Header.Get("Range"), size)
+	return serveObject(req, objReader, info, cacheControl)
+}
+
+// dropColdRenditions leaves the renditions in the cold tier out of a manifest, it takes ownership of manifest
+func dropColdRenditions(ctx context.Context, store services.TieredObjectStore, cid int64, manifest io.ReadSeekCloser, info *services.ObjectInfo) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	data, err := io.ReadAll(manifest)
+	manifest.Close()
 
 	if err != nil {
-		return http.StatusBadRequest, StringToStream(errors.Wrap(err, "Invalid Range").Error()), nil, nil
+		return nil, nil, errors.Wrap(err, "failed to read manifest")
 	}
 
-	if len(ranges) > 1 {
-		return http.StatusRequestedRangeNotSatisfiable, StringToStream("Multiple ranges not supported"), nil, nil
+	edited := tiering.DropRenditions(data, func(rendition tiering.Rendition) bool {
+		return store.IsCold(ctx, cid, rendition.Filename)
+	})
+
+	if bytes.Equal(edited, data) {
+		return nopSeekCloser{bytes.NewReader(data)}, info, nil
 	}
 
-	headers := make(http.Header)
+	// The edited manifest is a different representation of the object, so it can't share its ETag
+	hash := sha256.Sum256(edited)
+	editedInfo := *info
+	editedInfo.Size = int64(len(edited))
+	editedInfo.ETag = hex.EncodeToString(hash[:])
 
-	if len(ranges) == 0 {
-		// Set the content length
-		headers.Set("Content-Length", fmt.Sprint(size))
-		headers.Set("ETag", etag)
+	return nopSeekCloser{bytes.NewReader(edited)}, &editedInfo, nil
+}
 
-		deferClose = true
+// nopSeekCloser is a ReadSeekCloser of something that doesn't need closing
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
 
-		if req.Header.Get("If-None-Match") == etag {
-			return http.StatusNotModified, nil, headers, nil
-		}
+func (nopSeekCloser) Close() error {
+	return nil
+}
 
-		return http.StatusOK, objReader, headers, nil
-	} else {
-		// Accept ranges
-		headers.Set("Accept-Ranges", "bytes")
-		headers.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", ranges[0].Start, ranges[0].Start+ranges[0].Length-1, size))
-		headers.Set("Content-Length", fmt.Sprint(ranges[0].Length))
+// redirectStreamFile sends the client somewhere else for the bytes of a file, so they don't have to pass through the backend
+func (r *Routes) redirectStreamFile(ctx context.Context, cid int64, filename string) (int, io.ReadCloser, http.Header, error) {
+	headers := make(http.Header)
 
-		// Seek to the start of the range
-		_, err = objReader.Seek(ranges[0].Start, io.SeekStart)
+	u, err := r.ObjectStore.PresignObject(ctx, cid, filename, r.cfg.Delivery.PresignExpiry)
 
-		if err != nil {
-			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to seek to start of range")
+	if r.cfg.Delivery.Mode == DeliveryAccel {
+		// nginx serves files of the filesystem backend from disk, S3 objects are proxied with the presigned query so nginx doesn't need credentials
+		if errors.Is(err, object.ErrPresignUnsupported) {
+			headers.Set("X-Accel-Redirect", fmt.Sprintf("%s%d/%s", r.cfg.Delivery.AccelPrefix, cid, url.PathEscape(filename)))
+			return http.StatusOK, nil, headers, nil
+		} else if err != nil {
+			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to presign object")
 		}
 
-		deferClose = true
-		return http.StatusPartialContent, NewLimitedReadCloser(objReader, ranges[0].Length), headers, nil
+		headers.Set("X-Accel-Redirect", r.cfg.Delivery.AccelPrefix+strings.TrimPrefix(u.EscapedPath(), "/")+"?"+u.RawQuery)
+		return http.StatusOK, nil, headers, nil
 	}
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to presign object")
+	}
+
+	// Players can reuse the redirect for a while, as long as the URL is still valid by the time they follow it
+	headers.Set("Location", u.String())
+	headers.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(r.cfg.Delivery.PresignExpiry.Seconds()/2)))
+
+	return http.StatusFound, nil, headers, nil
 }
@@ -3,14 +3,20 @@ package routes
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
+	"webserver/config"
 	"webserver/models"
 	"webserver/services"
 	"webserver/services/mock"
+	"webserver/services/object"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/volatiletech/sqlboiler/v4/boil"
@@ -70,14 +76,18 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 				CID:      1,
 				Filename: "test.mp4",
 			},
+			expectedHeaders: map[string]string{
+				"ETag":          `"asd123"`,
+				"Cache-Control": segmentCacheControl,
+			},
 			group: &services.Group{
 				ObjectStore: &mock.ObjectStoreProvider{
 					HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "asd123", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4, ETag: "asd123"}, nil
 					},
 				},
 			},
@@ -104,8 +114,8 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4}, nil
 					},
 				},
 			},
@@ -120,7 +130,7 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 				Filename: "test.mp4",
 			},
 			headers: map[string]string{
-				"If-None-Match": "asd123",
+				"If-None-Match": `"asd123"`,
 			},
 			group: &services.Group{
 				ObjectStore: &mock.ObjectStoreProvider{
@@ -128,8 +138,8 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "asd123", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4, ETag: "asd123"}, nil
 					},
 				},
 			},
@@ -166,8 +176,8 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return nil, 0, "", assert.AnError
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return nil, nil, assert.AnError
 					},
 				},
 			},
@@ -191,9 +201,12 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 					HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
 						return true
 					},
+					ChecksumHook: func(ctx context.Context, cid int64, filename string) (string, error) {
+						return "", object.ErrNoChecksum
+					},
 
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4}, nil
 					},
 				},
 			},
@@ -220,9 +233,36 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 					HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
 						return true
 					},
+					ChecksumHook: func(ctx context.Context, cid int64, filename string) (string, error) {
+						return "", object.ErrNoChecksum
+					},
 
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4}, nil
+					},
+				},
+			},
+		},
+		{
+			name:       "Handle corrupted manifest",
+			expected:   http.StatusInternalServerError,
+			hasError:   true,
+			bodyLength: -1,
+			vars: &RouteVars{
+				CID:      1,
+				Filename: "dash.mpd",
+			},
+			group: &services.Group{
+				ObjectStore: &mock.ObjectStoreProvider{
+					HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
+						return true
+					},
+					ChecksumHook: func(ctx context.Context, cid int64, filename string) (string, error) {
+						// The SHA-256 of "test", the manifest read back is something else
+						return "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", nil
+					},
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("tesT")), &services.ObjectInfo{Name: filename, Size: 4}, nil
 					},
 				},
 			},
@@ -245,15 +285,15 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4}, nil
 					},
 				},
 			},
 		},
 		{
-			name:       "Handle too many ranges",
-			expected:   http.StatusRequestedRangeNotSatisfiable,
+			name:       "Success - multiple ranges",
+			expected:   http.StatusPartialContent,
 			hasBody:    true,
 			bodyLength: -1,
 			vars: &RouteVars{
@@ -269,8 +309,8 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewNopReadSeekCloser([]byte("test")), 4, "", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewNopReadSeekCloser([]byte("test")), &services.ObjectInfo{Name: filename, Size: 4}, nil
 					},
 				},
 			},
@@ -293,8 +333,8 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 						assert.Equal(t, "1/test.mp4", fmt.Sprintf("%d/%s", cid, filename))
 						return true
 					},
-					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-						return NewErrorReadSeekCloser(assert.AnError, nil), 4, "", nil
+					GetObjectHook: func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+						return NewErrorReadSeekCloser(assert.AnError, nil), &services.ObjectInfo{Name: filename, Size: 4}, nil
 					},
 				},
 			},
@@ -304,6 +344,7 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &Routes{
 				Group: tt.group,
+				cfg:   &config.Config{},
 			}
 
 			req := httptest.NewRequest("GET", "/", bytes.NewReader(tt.payload))
@@ -328,3 +369,210 @@ func TestRoutes_GetStreamFile(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutes_GetStreamFileRedirect(t *testing.T) {
+	presigned, _ := url.Parse("http://s3.example.com/clips/1/chunk-stream0-00001.m4s?X-Amz-Signature=abc")
+
+	s3 := &mock.ObjectStoreProvider{
+		PresignObjectHook: func(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+			assert.Equal(t, "1/chunk-stream0-00001.m4s", fmt.Sprintf("%d/%s", cid, filename))
+			assert.Equal(t, 5*time.Minute, expiry)
+			return presigned, nil
+		},
+		HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
+			return filename == "chunk-stream0-00001.m4s"
+		},
+	}
+
+	filesystem := &mock.ObjectStoreProvider{
+		PresignObjectHook: func(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+			return nil, object.ErrPresignUnsupported
+		},
+		HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
+			return true
+		},
+	}
+
+	tests := []struct {
+		name            string
+		mode            string
+		store           *mock.ObjectStoreProvider
+		filename        string
+		expected        int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:     "Presign",
+			mode:     DeliveryPresign,
+			store:    s3,
+			filename: "chunk-stream0-00001.m4s",
+			expected: http.StatusFound,
+			expectedHeaders: map[string]string{
+				"Location":      presigned.String(),
+				"Cache-Control": "private, max-age=150",
+			},
+		},
+		{
+			name:     "Presign - manifest is still served by the backend",
+			mode:     DeliveryPresign,
+			store:    s3,
+			filename: "dash.mpd",
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Presign - missing object",
+			mode:     DeliveryPresign,
+			store:    s3,
+			filename: "chunk-stream0-00002.m4s",
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Accel - missing object",
+			mode:     DeliveryAccel,
+			store:    s3,
+			filename: "chunk-stream0-00002.m4s",
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Accel - s3",
+			mode:     DeliveryAccel,
+			store:    s3,
+			filename: "chunk-stream0-00001.m4s",
+			expected: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Accel-Redirect": "/internal/objects/clips/1/chunk-stream0-00001.m4s?X-Amz-Signature=abc",
+			},
+		},
+		{
+			name:     "Accel - filesystem",
+			mode:     DeliveryAccel,
+			store:    filesystem,
+			filename: "chunk-stream0-00001.m4s",
+			expected: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Accel-Redirect": "/internal/objects/1/chunk-stream0-00001.m4s",
+			},
+		},
+		{
+			name: "Handle presign error",
+			mode: DeliveryPresign,
+			store: &mock.ObjectStoreProvider{
+				PresignObjectHook: func(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+					return nil, errors.New("presign failed")
+				},
+				HasObjectHook: func(ctx context.Context, cid int64, filename string) bool {
+					return true
+				},
+			},
+			filename: "thumbnail.jpg",
+			expected: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Delivery.Mode = tt.mode
+			cfg.Delivery.PresignExpiry = 5 * time.Minute
+			cfg.Delivery.AccelPrefix = "/internal/objects/"
+
+			r := &Routes{
+				Group: &services.Group{ObjectStore: tt.store},
+				cfg:   cfg,
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{CID: 1, Filename: tt.filename}))
+
+			code, body, headers, _ := r.GetStreamFile(nil, req)
+
+			assert.Equal(t, tt.expected, code)
+			assert.Nil(t, body)
+
+			for k, v := range tt.expectedHeaders {
+				assert.Equal(t, v, headers.Get(k))
+			}
+		})
+	}
+}
+
+func TestRoutes_GetStreamFileDropsColdRenditions(t *testing.T) {
+	ctx := context.Background()
+	manifest := `<MPD>
+	<AdaptationSet id="0" contentType="video">
+		<Representation id="0" height="2160"><BaseURL>dash-stream0.m4s</BaseURL></Representation>
+		<Representation id="1" height="720"><BaseURL>dash-stream1.m4s</BaseURL></Representation>
+	</AdaptationSet>
+</MPD>`
+
+	newStore := func() services.ObjectStore {
+		cfg := &config.Config{}
+		cfg.Storage.Path = t.TempDir()
+
+		store, err := object.NewFilesystemStore(cfg)
+		assert.NoError(t, err)
+
+		return store
+	}
+
+	store := object.NewTieredStore(newStore(), newStore())
+
+	for name, content := range map[string]string{"dash.mpd": manifest, "dash-stream0.m4s": "4k", "dash-stream1.m4s": "720p"} {
+		_, err := store.PutObject(ctx, 1, name, strings.NewReader(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, store.Freeze(ctx, 1, "dash-stream0.m4s"))
+
+	var updated boil.Columns
+
+	cfg := &config.Config{}
+	cfg.Tiering.DropFromManifest = true
+
+	r := &Routes{
+		cfg: cfg,
+		Group: &services.Group{
+			ObjectStore: store,
+			Clips: &mock.ClipsProvider{
+				FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+					return &models.Clip{ID: cid}, nil
+				},
+				UpdateHook: func(ctx context.Context, clip *models.Clip, columns boil.Columns) error {
+					assert.True(t, clip.LastViewedAt.Valid)
+					updated = columns
+					return nil
+				},
+			},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{CID: 1, Filename: "dash.mpd"}))
+
+	status, body, headers, err := r.GetStreamFile(nil, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, boil.Whitelist(models.ClipColumns.Views, models.ClipColumns.LastViewedAt), updated)
+
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "dash-stream0.m4s")
+	assert.Contains(t, string(data), "dash-stream1.m4s")
+	assert.Equal(t, fmt.Sprint(len(data)), headers.Get("Content-Length"))
+
+	// The full manifest has an ETag of its own
+	_, info, err := store.GetObject(ctx, 1, "dash.mpd")
+	assert.NoError(t, err)
+	assert.NotEqual(t, `"`+info.ETag+`"`, headers.Get("ETag"))
+
+	// Cold renditions can still be fetched by players that kept the full manifest around
+	req = httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{CID: 1, Filename: "dash-stream0.m4s"}))
+
+	status, body, _, err = r.GetStreamFile(nil, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	data, _ = io.ReadAll(body)
+	assert.Equal(t, "4k", string(data))
+}
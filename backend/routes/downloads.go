@@ -0,0 +1,198 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"webserver/models"
+	"webserver/services/policy"
+
+	"github.com/friendsofgo/errors"
+)
+
+// Qualities of downloads that aren't a progressive MP4 of a certain height
+const (
+	downloadOriginal = "original"
+	downloadAudio    = "audio"
+)
+
+// Extensions of the containers an original can be sniffed as, anything else is downloaded without one
+var originalExtensions = map[string]string{
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"video/avi":       ".avi",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"audio/aiff":      ".aiff",
+	"application/ogg": ".ogg",
+}
+
+// DownloadClip serves a clip as a single file, either one of the progressive MP4s encoded next to the DASH renditions or the original upload
+// Without a quality the best progressive download is served, falling back to the original if there's none
+//
+// GET /clips/{clip id}/download?quality={1080p, 720p, ..., audio, original}
+func (r *Routes) DownloadClip(user *models.User, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	vars := vars(req)
+
+	clip, err := r.Clips.Find(req.Context(), vars.CID)
+
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, nil, nil, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to get clip")
+	}
+
+	if !clip.AllowDownloads && !policy.CanManageClip(user, clip) {
+		return http.StatusForbidden, StringToStream("Downloads are disabled for this clip"), nil, nil
+	}
+
+	if clip.Processing {
+		return http.StatusConflict, StringToStream("clip is still processing"), nil, nil
+	}
+
+	quality := req.URL.Query().Get("quality")
+
+	if quality == "" {
+		quality, err = r.bestDownload(req.Context(), clip.ID)
+
+		if err != nil {
+			return http.StatusInternalServerError, nil, nil, err
+		}
+	}
+
+	name, ok := downloadObject(quality)
+
+	if !ok {
+		return http.StatusBadRequest, StringToStream("Invalid quality"), nil, nil
+	}
+
+	if !r.ObjectStore.HasObject(req.Context(), clip.ID, name) {
+		return http.StatusNotFound, StringToStream("Download not available"), nil, nil
+	}
+
+	object, info, err := r.ObjectStore.GetObject(req.Context(), clip.ID, name)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to get object")
+	}
+
+	contentType, extension := "video/mp4", ".mp4"
+
+	switch quality {
+	case downloadAudio:
+		contentType, extension = "audio/mp4", ".m4a"
+	case downloadOriginal:
+		if contentType, err = sniffContentType(object); err != nil {
+			object.Close()
+			return http.StatusInternalServerError, nil, nil, err
+		}
+
+		extension = originalExtensions[contentType]
+	}
+
+	code, body, headers, err := serveObject(req, object, info, "")
+
+	if headers != nil {
+		headers.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadName(clip.Title) + extension}))
+	}
+
+	// Multiple ranges set their own type
+	if (code == http.StatusOK || code == http.StatusPartialContent) && !strings.HasPrefix(headers.Get("Content-Type"), "multipart/") {
+		headers.Set("Content-Type", contentType)
+	}
+
+	return code, body, headers, err
+}
+
+// downloadObject is the object a download of the given quality is stored as, transcoder.downloadFilename has to match it
+func downloadObject(quality string) (string, bool) {
+	switch quality {
+	case downloadOriginal:
+		return "raw", true
+	case downloadAudio:
+		return "download-audio.m4a", true
+	}
+
+	height, err := strconv.Atoi(strings.TrimSuffix(quality, "p"))
+
+	if err != nil || !strings.HasSuffix(quality, "p") || height <= 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("download-%dp.mp4", height), true
+}
+
+// bestDownload picks the quality of the largest download of a clip
+func (r *Routes) bestDownload(ctx context.Context, cid int64) (string, error) {
+	objects, err := r.ObjectStore.ListObjects(ctx, cid)
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list objects")
+	}
+
+	best := 0
+	quality := downloadOriginal
+
+	for _, object := range objects {
+		if object.Name == "download-audio.m4a" {
+			return downloadAudio, nil
+		}
+
+		var height int
+
+		if _, err := fmt.Sscanf(object.Name, "download-%dp.mp4", &height); err != nil || fmt.Sprintf("download-%dp.mp4", height) != object.Name {
+			continue
+		}
+
+		if height > best {
+			best = height
+			quality = fmt.Sprintf("%dp", height)
+		}
+	}
+
+	return quality, nil
+}
+
+// sniffContentType detects the type of an original from its first bytes, then rewinds it
+func sniffContentType(object io.ReadSeeker) (string, error) {
+	buf := make([]byte, 512)
+
+	n, err := io.ReadFull(object, buf)
+
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", errors.Wrap(err, "failed to read original")
+	}
+
+	if _, err := object.Seek(0, io.SeekStart); err != nil {
+		return "", errors.Wrap(err, "failed to rewind original")
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+
+	return contentType, nil
+}
+
+// downloadName turns the title of a clip into a file name, without anything that would make it a path or trip up a file system
+func downloadName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+
+		return r
+	}, strings.TrimSpace(title))
+
+	name = strings.Trim(name, ". ")
+
+	if name == "" {
+		return "clip"
+	}
+
+	return name
+}
@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webserver/config"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/policy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutes_DownloadClip(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	for name, data := range map[string]string{
+		"dash.mpd":          "<MPD/>",
+		"download-480p.mp4": "480p",
+		"download-720p.mp4": "720p",
+		"raw":               "\x1aE\xdf\xa3 original webm",
+	} {
+		_, err := store.PutObject(ctx, 1, name, strings.NewReader(data))
+		assert.NoError(t, err)
+	}
+
+	clips := map[int64]*models.Clip{
+		1: {ID: 1, CreatorID: 1, Title: `My "best" clip/take 2`, AllowDownloads: true},
+		2: {ID: 2, CreatorID: 1, Title: "Private", AllowDownloads: false},
+		3: {ID: 3, CreatorID: 1, Title: "Busy", AllowDownloads: true, Processing: true},
+	}
+
+	r := &Routes{
+		cfg: &config.Config{},
+		Group: &services.Group{
+			ObjectStore: store,
+			Clips: &mock.ClipsProvider{
+				FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+					return clips[cid], nil
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		cid         int64
+		user        *models.User
+		query       string
+		headers     map[string]string
+		expected    int
+		body        string
+		contentType string
+		filename    string
+	}{
+		{
+			name:        "Success - best quality",
+			cid:         1,
+			expected:    http.StatusOK,
+			body:        "720p",
+			contentType: "video/mp4",
+			filename:    `attachment; filename="My _best_ clip_take 2.mp4"`,
+		},
+		{
+			name:        "Success - quality",
+			cid:         1,
+			query:       "?quality=480p",
+			expected:    http.StatusOK,
+			body:        "480p",
+			contentType: "video/mp4",
+		},
+		{
+			name:        "Success - original",
+			cid:         1,
+			query:       "?quality=original",
+			expected:    http.StatusOK,
+			body:        "\x1aE\xdf\xa3 original webm",
+			contentType: "video/webm",
+			filename:    `attachment; filename="My _best_ clip_take 2.webm"`,
+		},
+		{
+			name:        "Success - range",
+			cid:         1,
+			query:       "?quality=720p",
+			headers:     map[string]string{"Range": "bytes=1-"},
+			expected:    http.StatusPartialContent,
+			body:        "20p",
+			contentType: "video/mp4",
+		},
+		{
+			name:     "Success - creator ignores disallowed downloads",
+			cid:      2,
+			user:     &models.User{ID: 1},
+			query:    "?quality=720p",
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Success - moderator ignores disallowed downloads",
+			cid:      2,
+			user:     &models.User{ID: 3, Role: policy.RoleModerator},
+			query:    "?quality=720p",
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Handle disallowed downloads",
+			cid:      2,
+			user:     &models.User{ID: 2},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "Handle processing clip",
+			cid:      3,
+			expected: http.StatusConflict,
+		},
+		{
+			name:     "Handle missing quality",
+			cid:      1,
+			query:    "?quality=1080p",
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Handle invalid quality",
+			cid:      1,
+			query:    "?quality=../raw",
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{CID: tt.cid}))
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			code, body, headers, err := r.DownloadClip(tt.user, req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, code)
+
+			if tt.body != "" {
+				data, err := io.ReadAll(body)
+				assert.NoError(t, err)
+				assert.NoError(t, body.Close())
+				assert.Equal(t, tt.body, string(data))
+			}
+
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, headers.Get("Content-Type"))
+			}
+
+			if tt.filename != "" {
+				assert.Equal(t, tt.filename, headers.Get("Content-Disposition"))
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/friendsofgo/errors"
+)
+
+// ErrForbiddenAddress is returned when a remote file resolves to an address imports may not reach
+var ErrForbiddenAddress = errors.New("address is not allowed")
+
+// newImportClient is the client remote files are fetched with
+// Every connection is checked once the name is resolved, so neither redirects nor DNS tricks can point it at an internal service
+func newImportClient(allowPrivate bool, maxRedirects int) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || (!allowPrivate && !isPublicIP(ip)) {
+				return errors.Wrap(ErrForbiddenAddress, host)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			// A proxy would make the connection for us, without the check
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: time.Minute,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       time.Minute,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return errors.New("too many redirects")
+			}
+
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+
+			return nil
+		},
+	}
+}
+
+// isPublicIP says if ip is reachable on the internet, rather than somewhere only the server can reach
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// sharedAddressSpace is used for carrier-grade NAT, it isn't covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
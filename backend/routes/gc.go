@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+	"webserver/models"
+
+	"github.com/friendsofgo/errors"
+)
+
+// AdminCollectGarbage deletes orphaned objects right away, a GET only reports what a collection would delete
+//
+// GET, POST /admin/gc
+func (r *Routes) AdminCollectGarbage(user *models.User, w http.ResponseWriter, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	if code, ok := adminOnly(user); !ok {
+		return code, nil, nil, nil
+	}
+
+	// Listing every clip takes longer than responses usually get to be written
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	garbage, err := r.Collector.Collect(req.Context(), req.Method == http.MethodGet)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to collect garbage")
+	}
+
+	code, data, err := garbage.Marshal()
+
+	return code, io.NopCloser(bytes.NewReader(data)), nil, err
+}
@@ -0,0 +1,257 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services/transcoder"
+
+	. "github.com/docker/go-units"
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// remoteClipPath matches the page or API URL of a clip on another instance
+var remoteClipPath = regexp.MustCompile(`^/(?:api/)?clips/([a-zA-Z0-9-]{4,})/?$`)
+
+// ImportClip creates a clip from a bundle made by ExportClip, or from a file on another server when the request is json, see modelsx.ClipImport
+//
+// POST /clips/import
+func (r *Routes) ImportClip(user *models.User, w http.ResponseWriter, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	var code int
+	var body []byte
+	var err error
+
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == "application/json" {
+		code, body, err = r.importURL(user, w, req)
+	} else {
+		code, body, err = r.importBundle(user, req)
+	}
+
+	return code, io.NopCloser(bytes.NewReader(body)), nil, err
+}
+
+// importURL streams a remote file into a new clip, so the client doesn't have to relay it
+// The request stays open until the file is stored, like an upload would
+func (r *Routes) importURL(user *models.User, w http.ResponseWriter, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	clipImport, err := modelsx.ParseClipImport(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	source, err := url.Parse(clipImport.URL)
+
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") || source.Host == "" {
+		return http.StatusBadRequest, []byte("URL must be http or https"), nil
+	}
+
+	// The request is tiny, but fetching the file can take as long as uploading it would
+	deadline := time.Now().Add(r.cfg.Imports.Timeout)
+	ctr := http.NewResponseController(w)
+	ctr.SetReadDeadline(deadline)
+	ctr.SetWriteDeadline(deadline)
+
+	ctx, cancel := context.WithDeadline(req.Context(), deadline)
+	defer cancel()
+
+	resp, remote, err := r.fetchImport(ctx, source)
+
+	if errors.Is(err, ErrForbiddenAddress) {
+		return http.StatusBadRequest, []byte("URL points at an address that can't be imported from"), nil
+	} else if err != nil {
+		return http.StatusBadGateway, []byte(err.Error()), nil
+	}
+
+	defer resp.Body.Close()
+
+	if resp.ContentLength > r.cfg.MaxUploadSizeBytes {
+		return http.StatusBadRequest, []byte("Video too large"), nil
+	}
+
+	size := resp.ContentLength
+
+	if size < 0 {
+		size = 0
+	}
+
+	exceeded, err := r.exceedsQuota(ctx, user, size)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if exceeded {
+		return http.StatusForbidden, []byte("Storage quota exceeded"), nil
+	}
+
+	title := importTitle(resp)
+
+	if remote != nil {
+		title = remote.Title
+
+		if !clipImport.Description.Valid {
+			clipImport.Description = remote.Description
+		}
+	}
+
+	clip, err := clipImport.ToClip(title)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	model := clip.ToModel()
+
+	// What the server claims to send says little, createClip refuses anything that isn't media
+	created, code, body, err := r.createClip(ctx, user, model, boil.Whitelist(clip.GetUpdateWhitelist()...), resp.Body, 0)
+
+	if created == nil {
+		return code, body, err
+	}
+
+	// Duplicates aren't transcoded again, the existing clip is returned instead
+	if created != model {
+		code, body, err := modelsx.ClipFromModel(created).Marshal()
+
+		if r.cfg.Dedupe.Conflict && err == nil {
+			code = http.StatusConflict
+		}
+
+		return code, body, err
+	}
+
+	if err := r.Transcoder.Queue(context.Background(), model); errors.Is(err, transcoder.ErrStopping) {
+		return http.StatusServiceUnavailable, []byte("Server is shutting down, the clip is processed once it's back"), nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to queue clip for transcoding")
+	}
+
+	return modelsx.ClipFromModel(model).Marshal()
+}
+
+// fetchImport requests the file behind source, if source is a clip on another instance its original or best download is requested instead
+// The metadata of such a clip is returned along with the response
+func (r *Routes) fetchImport(ctx context.Context, source *url.URL) (*http.Response, *modelsx.Clip, error) {
+	if match := remoteClipPath.FindStringSubmatch(source.Path); match != nil {
+		api := &url.URL{Scheme: source.Scheme, Host: source.Host, Path: "/api/clips/" + match[1]}
+
+		if remote := r.fetchRemoteClip(ctx, api); remote != nil {
+			for _, quality := range []string{downloadOriginal, ""} {
+				download := *api
+				download.Path += "/download"
+
+				if quality != "" {
+					download.RawQuery = url.Values{"quality": {quality}}.Encode()
+				}
+
+				resp, err := r.getImport(ctx, &download)
+
+				if err != nil {
+					return nil, nil, err
+				}
+
+				if resp.StatusCode == http.StatusOK {
+					return resp, remote, nil
+				}
+
+				resp.Body.Close()
+			}
+
+			return nil, nil, errors.New("remote clip can't be downloaded")
+		}
+	}
+
+	resp, err := r.getImport(ctx, source)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, nil, errors.Errorf("remote server responded with %d", resp.StatusCode)
+	}
+
+	return resp, nil, nil
+}
+
+// fetchRemoteClip gets the metadata of a clip on another instance, or nothing if api isn't one
+func (r *Routes) fetchRemoteClip(ctx context.Context, api *url.URL) *modelsx.Clip {
+	resp, err := r.getImport(ctx, api)
+
+	if err != nil {
+		return nil
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*KiB))
+
+	if err != nil {
+		return nil
+	}
+
+	// IDs are hashed with the other instance's salt, so only what's needed is decoded
+	var remote struct {
+		Title       string      `out:"title"`
+		Description null.String `out:"description"`
+	}
+
+	if err := modelsx.ClipSerialize.Unmarshal(data, &remote); err != nil || remote.Title == "" {
+		return nil
+	}
+
+	return &modelsx.Clip{Title: remote.Title, Description: remote.Description}
+}
+
+func (r *Routes) getImport(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+
+	resp, err := r.importClient.Do(req)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch URL")
+	}
+
+	return resp, nil
+}
+
+// importTitle makes a title out of the name of a remote file, as the title of an upload falls back to the file's name
+func importTitle(resp *http.Response) string {
+	name := path.Base(resp.Request.URL.Path)
+
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = path.Base(params["filename"])
+	}
+
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	if name == "/" || name == "." {
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,228 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// mp4Header is enough of an MP4 to be sniffed as one
+const mp4Header = "\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"
+
+func newImportSource(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/files/My Video.mp4", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, mp4Header+"video")
+	})
+	mux.HandleFunc("/files/attachment", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="From header.webm"`)
+		io.WriteString(w, "\x1aE\xdf\xa3 webm")
+	})
+	mux.HandleFunc("/files/page.html", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "<!DOCTYPE html><html><body>Not a video</body></html>")
+	})
+	mux.HandleFunc("/files/large.mp4", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "4096")
+		io.WriteString(w, mp4Header+strings.Repeat("a", 4096-len(mp4Header)))
+	})
+	mux.HandleFunc("/files/missing.mp4", http.NotFound)
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "/files/My%20Video.mp4", http.StatusFound)
+	})
+
+	// A clip on another instance, that only offers its best download
+	mux.HandleFunc("/api/clips/abcd", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, `{"id":"abcd","title":"Remote clip","description":"From elsewhere","processing":false}`)
+	})
+	mux.HandleFunc("/api/clips/abcd/download", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("quality") == "original" {
+			http.NotFound(w, req)
+			return
+		}
+
+		io.WriteString(w, mp4Header+"remote")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRoutes_ImportClipURL(t *testing.T) {
+	source := newImportSource(t)
+
+	tests := []struct {
+		name         string
+		json         string
+		allowPrivate bool
+		expected     int
+		title        string
+		description  string
+		video        string
+	}{
+		{
+			name:         "Success - title from the file name",
+			json:         `{"url": "%s/files/My%%20Video.mp4"}`,
+			allowPrivate: true,
+			expected:     http.StatusOK,
+			title:        "My Video",
+			video:        mp4Header + "video",
+		},
+		{
+			name:         "Success - title from Content-Disposition",
+			json:         `{"url": "%s/files/attachment", "description": "Mine"}`,
+			allowPrivate: true,
+			expected:     http.StatusOK,
+			title:        "From header",
+			description:  "Mine",
+			video:        "\x1aE\xdf\xa3 webm",
+		},
+		{
+			name:         "Success - follows redirects",
+			json:         `{"url": "%s/redirect", "title": "Given title"}`,
+			allowPrivate: true,
+			expected:     http.StatusOK,
+			title:        "Given title",
+			video:        mp4Header + "video",
+		},
+		{
+			name:         "Success - clip on another instance",
+			json:         `{"url": "%s/clips/abcd"}`,
+			allowPrivate: true,
+			expected:     http.StatusOK,
+			title:        "Remote clip",
+			description:  "From elsewhere",
+			video:        mp4Header + "remote",
+		},
+		{
+			name:     "Handle private address",
+			json:     `{"url": "%s/files/My%%20Video.mp4"}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:         "Handle unsupported scheme",
+			json:         `{"url": "file:///etc/passwd", "title": "Nope"}`,
+			allowPrivate: true,
+			expected:     http.StatusBadRequest,
+		},
+		{
+			name:         "Handle something other than media",
+			json:         `{"url": "%s/files/page.html"}`,
+			allowPrivate: true,
+			expected:     http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "Handle file too large",
+			json:         `{"url": "%s/files/large.mp4"}`,
+			allowPrivate: true,
+			expected:     http.StatusBadRequest,
+		},
+		{
+			name:         "Handle missing file",
+			json:         `{"url": "%s/files/missing.mp4"}`,
+			allowPrivate: true,
+			expected:     http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *models.Clip
+			var uploaded string
+			queued := false
+
+			cfg := &config.Config{MaxUploadSizeBytes: 1024}
+			cfg.Imports.Timeout = time.Minute
+
+			r := &Routes{
+				cfg:          cfg,
+				importClient: newImportClient(tt.allowPrivate, 5),
+				Group: &services.Group{
+					Clips: &mock.ClipsProvider{
+						CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+							created = clip
+
+							tx := newClipTx(&created, "abc")
+							tx.UploadVideoHook = func(ctx context.Context, r io.Reader) (int64, error) {
+								data, err := io.ReadAll(r)
+								uploaded = string(data)
+								return int64(len(data)), err
+							}
+
+							return tx, nil
+						},
+					},
+					Transcoder: &mock.TranscoderProvider{
+						QueueHook: func(ctx context.Context, clip *models.Clip) error {
+							queued = true
+							return nil
+						},
+						ProbeHook: func(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+							return &services.MediaInfo{VideoStreams: 1, Streams: 1}, nil
+						},
+					},
+				},
+			}
+
+			json := tt.json
+
+			if strings.Contains(json, "%s") {
+				json = fmt.Sprintf(json, source.URL)
+			}
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(json))
+			req.Header.Set("Content-Type", "application/json")
+
+			code, body, _, err := r.ImportClip(&models.User{ID: 1}, httptest.NewRecorder(), req)
+			assert.NoError(t, err)
+
+			data, _ := io.ReadAll(body)
+			assert.Equal(t, tt.expected, code, string(data))
+
+			if tt.expected != http.StatusOK {
+				assert.False(t, queued)
+				return
+			}
+
+			assert.True(t, queued)
+			assert.Equal(t, tt.title, created.Title)
+			assert.Equal(t, tt.description, created.Description.String)
+			assert.Equal(t, tt.video, uploaded)
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		assert.Equal(t, public, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
@@ -4,14 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/gotd/contrib/http_range"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -39,6 +38,19 @@ func (r *Routes) SetProgress(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Chunked transcodes report the progress of every chunk separately
+	chunk := -1
+
+	if rawChunk, ok := vars["chunk"]; ok {
+		chunk, err = strconv.Atoi(rawChunk)
+
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			log.WithError(err).Error("Failed to parse chunk")
+			return
+		}
+	}
+
 	reader := bufio.NewScanner(req.Body)
 
 	data := make(map[string]string)
@@ -58,7 +70,7 @@ func (r *Routes) SetProgress(w http.ResponseWriter, req *http.Request) {
 
 		if parts[0] == "progress" {
 			// Get the current progress
-			frame, err := strconv.Atoi(data["frame"])
+			frame, err := frameFromProgress(data)
 
 			if err != nil {
 				http.Error(w, "Bad Request", http.StatusBadRequest)
@@ -66,11 +78,36 @@ func (r *Routes) SetProgress(w http.ResponseWriter, req *http.Request) {
 				return
 			}
 
-			r.Transcoder.ReportProgress(cid, frame)
+			if chunk >= 0 {
+				r.Transcoder.ReportChunkProgress(cid, chunk, frame)
+			} else {
+				r.Transcoder.ReportProgress(cid, frame)
+			}
 		}
 	}
 }
 
+// frameFromProgress reads the current frame out of an ffmpeg progress block
+// Audio only transcodes never report a frame, so it's estimated from the output time at the 30 fps the transcoder measures progress in
+func frameFromProgress(data map[string]string) (int, error) {
+	if rawFrame, ok := data["frame"]; ok {
+		frame, err := strconv.Atoi(rawFrame)
+
+		if err != nil || frame > 0 {
+			return frame, err
+		}
+	}
+
+	outTime, err := strconv.ParseInt(data["out_time_us"], 10, 64)
+
+	// out_time_us is N/A until ffmpeg has written something
+	if err != nil {
+		return 0, nil
+	}
+
+	return int(outTime * 30 / int64(time.Second/time.Microsecond)), nil
+}
+
 func (r *Routes) UploadObject(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	cid, err := strconv.ParseInt(vars["cid"], 10, 64)
@@ -127,7 +164,7 @@ func (r *Routes) ReadObject(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Get the object from the minio server
-	objReader, size, _, err := r.ObjectStore.GetObject(context.Background(), cid, vars["file"])
+	objReader, info, err := r.ObjectStore.GetObject(context.Background(), cid, vars["file"])
 
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -135,52 +172,27 @@ func (r *Routes) ReadObject(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	defer objReader.Close()
-
-	ranges, err := http_range.ParseRange(req.Header.Get("Range"), size)
+	code, body, headers, err := serveObject(req, objReader, info, "")
 
 	if err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.WithError(err).Error("Failed to serve object")
 		return
 	}
 
-	if len(ranges) > 1 {
-		http.Error(w, "Requested Range Not Satisfiable", http.StatusRequestedRangeNotSatisfiable)
-		return
+	for k, v := range headers {
+		w.Header()[k] = v
 	}
 
-	if len(ranges) == 1 {
-		if ranges[0].Start > size || ranges[0].Start+ranges[0].Length > size {
-			http.Error(w, "Requested Range Not Satisfiable", http.StatusRequestedRangeNotSatisfiable)
-			return
-		}
-
-		// Accept ranges
-		w.Header().Set("Accept-Ranges", "bytes")
-		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", ranges[0].Start, ranges[0].Start+ranges[0].Length-1, size))
-		w.Header().Set("Content-Length", fmt.Sprint(ranges[0].Length))
-
-		// Set the status code
-		w.WriteHeader(http.StatusPartialContent)
+	w.WriteHeader(code)
 
-		// Seek to the start of the range
-		_, err = objReader.Seek(ranges[0].Start, io.SeekStart)
+	if body == nil {
+		return
+	}
 
-		if err != nil {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			log.WithError(err).Error("Failed to seek to start of range")
-			return
-		}
+	defer body.Close()
 
-		io.CopyN(w, objReader, ranges[0].Length)
-	} else {
-		w.Header().Set("Content-Length", fmt.Sprint(size))
-		// Copy the object to the response writer
-		_, err := io.Copy(w, objReader)
-		if err != nil {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			log.WithError(err).Error("Failed to copy object to response writer")
-			return
-		}
+	if _, err := io.Copy(w, body); err != nil {
+		log.WithError(err).Error("Failed to copy object to response writer")
 	}
 }
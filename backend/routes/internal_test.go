@@ -51,6 +51,53 @@ func TestRoutes_SetProgress(t *testing.T) {
 				"progress=continue\n",
 			),
 		},
+		{
+			name:     "Success - audio only",
+			expected: http.StatusOK,
+			hasBody:  false,
+			group: &services.Group{
+				Transcoder: &mock.TranscoderProvider{
+					ReportProgressHook: func(cid int64, frame int) {
+						assert.Equal(t, frame, 584)
+						assert.Equal(t, cid, int64(1))
+					},
+				},
+			},
+			url: "/progress/1",
+			payload: []byte("bitrate=N/A\n" +
+				"total_size=N/A\n" +
+				"out_time_us=19466732\n" +
+				"out_time_ms=19466732\n" +
+				"out_time=00:00:19.466732\n" +
+				"speed=2.11x\n" +
+				"progress=continue\n",
+			),
+		},
+		{
+			name:     "Success - chunk",
+			expected: http.StatusOK,
+			hasBody:  false,
+			group: &services.Group{
+				Transcoder: &mock.TranscoderProvider{
+					ReportChunkProgressHook: func(cid int64, chunk int, frame int) {
+						assert.Equal(t, frame, 686)
+						assert.Equal(t, chunk, 3)
+						assert.Equal(t, cid, int64(1))
+					},
+				},
+			},
+			url: "/progress/1/3",
+			payload: []byte("frame=686\n" +
+				"out_time_us=19466732\n" +
+				"progress=continue\n",
+			),
+		},
+		{
+			name:     "Handle invalid chunk",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			url:      "/progress/1/invalid",
+		},
 		{
 			name:     "Handle invalid CID",
 			expected: http.StatusBadRequest,
@@ -80,6 +127,7 @@ func TestRoutes_SetProgress(t *testing.T) {
 
 			m := mux.NewRouter()
 			m.HandleFunc("/progress/{cid}", r.SetProgress)
+			m.HandleFunc("/progress/{cid}/{chunk}", r.SetProgress)
 			req := httptest.NewRequest("POST", tt.url, bytes.NewReader(tt.payload))
 
 			resp := httptest.NewRecorder()
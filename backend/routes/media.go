@@ -0,0 +1,167 @@
+package routes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"webserver/modelsx"
+	"webserver/services/transcoder"
+
+	"github.com/friendsofgo/errors"
+)
+
+// sniffLength is how much of a file is looked at to tell its container, MPEG-TS needs a second packet to be sure
+const sniffLength = 512
+
+// containerSignatures are the magic bytes of the containers uploads are accepted in, found at offset in the file
+// Anything else is refused before it's stored, instead of failing once the transcoder gets to it
+var containerSignatures = []struct {
+	name   string
+	offset int
+	magic  []byte
+}{
+	{"mp4", 4, []byte("ftyp")},
+	{"quicktime", 4, []byte("moov")},
+	{"quicktime", 4, []byte("mdat")},
+	{"quicktime", 4, []byte("wide")},
+	{"quicktime", 4, []byte("free")},
+	{"quicktime", 4, []byte("skip")},
+	{"matroska", 0, []byte("\x1a\x45\xdf\xa3")},
+	{"flv", 0, []byte("FLV\x01")},
+	{"ogg", 0, []byte("OggS")},
+	{"mpeg-ps", 0, []byte("\x00\x00\x01\xba")},
+	{"asf", 0, []byte("\x30\x26\xb2\x75\x8e\x66\xcf\x11\xa6\xd9\x00\xaa\x00\x62\xce\x6c")},
+	{"flac", 0, []byte("fLaC")},
+	{"mp3", 0, []byte("ID3")},
+}
+
+// sniffContainer names the container of a file from its first bytes, or returns nothing if it isn't one uploads are accepted in
+func sniffContainer(head []byte) string {
+	for _, signature := range containerSignatures {
+		if len(head) >= signature.offset+len(signature.magic) && bytes.Equal(head[signature.offset:signature.offset+len(signature.magic)], signature.magic) {
+			return signature.name
+		}
+	}
+
+	if len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) {
+		switch string(head[8:12]) {
+		case "AVI ":
+			return "avi"
+		case "WAVE":
+			return "wav"
+		}
+	}
+
+	if len(head) >= 12 && bytes.Equal(head[:4], []byte("FORM")) && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC") {
+		return "aiff"
+	}
+
+	// Transport streams have no header, but every 188 byte packet starts with a sync byte. M2TS adds a 4 byte timestamp to each
+	for _, packet := range []struct{ offset, size int }{{0, 188}, {4, 192}} {
+		if len(head) > packet.offset+packet.size && head[packet.offset] == 0x47 && head[packet.offset+packet.size] == 0x47 {
+			return "mpeg-ts"
+		}
+	}
+
+	// Raw MPEG audio and ADTS AAC start straight away with a frame, the first 11 bits of which are set
+	if len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0 {
+		return "mpeg-audio"
+	}
+
+	return ""
+}
+
+// peekHead returns the first sniffLength bytes of r, or fewer if that's all there is. The returned reader still reads all of r
+func peekHead(r io.Reader) (io.Reader, []byte) {
+	buffered := bufio.NewReaderSize(r, sniffLength)
+	head, _ := buffered.Peek(sniffLength)
+
+	return buffered, head
+}
+
+func unsupportedFormat() *modelsx.MediaError {
+	return &modelsx.MediaError{
+		Status:  http.StatusUnsupportedMediaType,
+		Code:    modelsx.MediaUnsupportedFormat,
+		Message: "File is not a video or audio file in a supported format",
+	}
+}
+
+// checkMedia probes the staged raw video of a clip, returning why it's refused if it is
+func (r *Routes) checkMedia(ctx context.Context, cid int64) (*modelsx.MediaError, error) {
+	info, err := r.Transcoder.Probe(ctx, cid)
+
+	if errors.Is(err, transcoder.ErrUnreadableMedia) {
+		return &modelsx.MediaError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    modelsx.MediaUnreadable,
+			Message: "File could not be read as video or audio",
+		}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to probe video")
+	}
+
+	limits := r.cfg.Media
+
+	if info.VideoStreams == 0 && info.AudioStreams == 0 {
+		return &modelsx.MediaError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    modelsx.MediaNoStreams,
+			Message: "File has neither video nor audio",
+		}, nil
+	}
+
+	if limits.MaxDuration > 0 && info.Duration > limits.MaxDuration {
+		return &modelsx.MediaError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    modelsx.MediaTooLong,
+			Message: "Video is too long",
+			Limit:   limits.MaxDuration.String(),
+			Actual:  info.Duration.String(),
+		}, nil
+	}
+
+	long, short := info.Width, info.Height
+
+	if short > long {
+		long, short = short, long
+	}
+
+	if (limits.MaxWidth > 0 && long > limits.MaxWidth) || (limits.MaxHeight > 0 && short > limits.MaxHeight) {
+		return &modelsx.MediaError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    modelsx.MediaResolutionTooHigh,
+			Message: "Video resolution is too high",
+			Limit:   resolution(limits.MaxWidth, limits.MaxHeight),
+			Actual:  resolution(info.Width, info.Height),
+		}, nil
+	}
+
+	if limits.MaxStreams > 0 && info.Streams > limits.MaxStreams {
+		return &modelsx.MediaError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    modelsx.MediaTooManyStreams,
+			Message: "File has too many streams",
+			Limit:   fmt.Sprint(limits.MaxStreams),
+			Actual:  fmt.Sprint(info.Streams),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// resolution formats a width and height, either of which may be unlimited
+func resolution(width, height int) string {
+	format := func(n int) string {
+		if n <= 0 {
+			return "any"
+		}
+
+		return fmt.Sprint(n)
+	}
+
+	return format(width) + "x" + format(height)
+}
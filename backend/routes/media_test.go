@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"webserver/config"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffContainer(t *testing.T) {
+	ts := make([]byte, 189)
+	ts[0], ts[188] = 0x47, 0x47
+
+	for name, tt := range map[string]struct {
+		head     string
+		expected string
+	}{
+		"MP4":       {mp4Header, "mp4"},
+		"QuickTime": {"\x00\x00\x00\x08wide\x00\x00\x00\x00mdat", "quicktime"},
+		"WebM":      {"\x1aE\xdf\xa3\x9fB\x86\x81\x01", "matroska"},
+		"AVI":       {"RIFF\x00\x00\x00\x00AVI LIST", "avi"},
+		"WAV":       {"RIFF\x00\x00\x00\x00WAVEfmt ", "wav"},
+		"MPEG-TS":   {string(ts), "mpeg-ts"},
+		"MP3":       {"ID3\x04\x00\x00\x00\x00\x00\x00", "mp3"},
+		"ADTS":      {"\xff\xf1\x50\x80", "mpeg-audio"},
+		"Ogg":       {"OggS\x00\x02", "ogg"},
+		"HTML":      {"<!DOCTYPE html><html>", ""},
+		"PNG":       {"\x89PNG\r\n\x1a\n", ""},
+		"RIFF WebP": {"RIFF\x00\x00\x00\x00WEBPVP8 ", ""},
+		"Empty":     {"", ""},
+		"Truncated": {"\x00\x00\x00\x18ft", ""},
+	} {
+		assert.Equal(t, tt.expected, sniffContainer([]byte(tt.head)), name)
+	}
+}
+
+func TestRoutes_CheckMedia(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Media.MaxWidth = 1920
+	cfg.Media.MaxHeight = 1080
+	cfg.Media.MaxStreams = 4
+
+	tests := []struct {
+		name  string
+		media *services.MediaInfo
+		code  string
+	}{
+		{
+			name:  "Landscape within limits",
+			media: &services.MediaInfo{Width: 1920, Height: 1080, VideoStreams: 1, AudioStreams: 1, Streams: 2},
+		},
+		{
+			name:  "Portrait within limits",
+			media: &services.MediaInfo{Width: 1080, Height: 1920, VideoStreams: 1, Streams: 1},
+		},
+		{
+			name:  "Audio only",
+			media: &services.MediaInfo{AudioStreams: 1, Streams: 1},
+		},
+		{
+			name:  "Resolution too high",
+			media: &services.MediaInfo{Width: 2160, Height: 3840, VideoStreams: 1, Streams: 1},
+			code:  modelsx.MediaResolutionTooHigh,
+		},
+		{
+			name:  "Too many streams",
+			media: &services.MediaInfo{Width: 1280, Height: 720, VideoStreams: 1, AudioStreams: 4, Streams: 5},
+			code:  modelsx.MediaTooManyStreams,
+		},
+		{
+			name:  "Nothing to transcode",
+			media: &services.MediaInfo{Streams: 1},
+			code:  modelsx.MediaNoStreams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Routes{
+				cfg: cfg,
+				Group: &services.Group{
+					Transcoder: &mock.TranscoderProvider{
+						ProbeHook: func(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+							return tt.media, nil
+						},
+					},
+				},
+			}
+
+			mediaErr, err := r.checkMedia(context.Background(), 1)
+			assert.NoError(t, err)
+
+			if tt.code == "" {
+				assert.Nil(t, mediaErr)
+				return
+			}
+
+			assert.Equal(t, tt.code, mediaErr.Code)
+			assert.Equal(t, http.StatusUnprocessableEntity, mediaErr.Status)
+
+			if tt.code == modelsx.MediaResolutionTooHigh {
+				assert.Equal(t, "1920x1080", mediaErr.Limit)
+				assert.Equal(t, "2160x3840", mediaErr.Actual)
+			}
+		})
+	}
+}
@@ -63,7 +63,32 @@ func (r *Routes) FullHandler(handler func(u *models.User, w http.ResponseWriter,
 
 		raw, ok := s.Values[SESSION_KEY_ID]
 
-		if ok {
+		// A bearer token takes the place of the session, scripts sending one don't have a cookie to fall back to
+		if secret, isBearer := bearerToken(req); isBearer {
+			var token *models.Token
+
+			user, token, err = r.authenticateToken(req.Context(), secret)
+
+			if err != nil {
+				log.WithError(err).Errorln("Failed to authenticate token")
+				resp.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if user == nil {
+				resp.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				resp.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if !modelsx.TokenHasScope(token, requiredScope(req)) {
+				resp.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+requiredScope(req)+`"`)
+				resp.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			req = req.WithContext(context.WithValue(req.Context(), TokenKey, token))
+		} else if ok {
 			user, err = r.Users.Find(req.Context(), raw.(int64))
 
 			if err != nil {
@@ -71,9 +96,21 @@ func (r *Routes) FullHandler(handler func(u *models.User, w http.ResponseWriter,
 				user = nil
 				delete(s.Values, SESSION_KEY_ID)
 				r.store.Save(req, resp, s)
+			} else if user.Disabled {
+				// Disabling an account logs it out everywhere
+				user = nil
+				delete(s.Values, SESSION_KEY_ID)
+				r.store.Save(req, resp, s)
 			}
 		}
 
+		// Admins can require two-factor authentication of everyone, users who haven't set it up yet only get to do that
+		if r.needsTOTP(user, req) {
+			resp.WriteHeader(http.StatusForbidden)
+			resp.Write([]byte("Two-factor authentication has to be set up first"))
+			return
+		}
+
 		code, body, headers, err := handler(user, resp, req)
 
 		// If the handler returned headers, add them to the response
@@ -121,7 +158,12 @@ func (r *Routes) FullHandler(handler func(u *models.User, w http.ResponseWriter,
 type RouteVars struct {
 	UID      int64
 	CID      int64
+	CHID     int64
+	UPID     int64
+	TKID     int64
+	SID      int64
 	Filename string
+	Provider string
 }
 
 type QueryVars struct {
@@ -133,6 +175,9 @@ type key int
 const VarKey = key(0)
 const QueryKey = key(1)
 
+// TokenKey holds the personal access token a request was authenticated with, requests with a session don't have one
+const TokenKey = key(2)
+
 func vars(r *http.Request) *RouteVars {
 	return r.Context().Value(VarKey).(*RouteVars)
 }
@@ -170,10 +215,58 @@ func (r *Routes) ParseVars(next http.Handler) http.Handler {
 			}
 		}
 
+		if chid, ok := vars["chid"]; ok {
+			rv.CHID, err = modelsx.HashDecodeSingle(chid)
+
+			if err != nil {
+				log.WithError(err).Errorln("Failed to decode chid")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Invalid CHID"))
+				return
+			}
+		}
+
+		if upid, ok := vars["upid"]; ok {
+			rv.UPID, err = modelsx.HashDecodeSingle(upid)
+
+			if err != nil {
+				log.WithError(err).Errorln("Failed to decode upid")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Invalid UPID"))
+				return
+			}
+		}
+
+		if tkid, ok := vars["tkid"]; ok {
+			rv.TKID, err = modelsx.HashDecodeSingle(tkid)
+
+			if err != nil {
+				log.WithError(err).Errorln("Failed to decode tkid")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Invalid TKID"))
+				return
+			}
+		}
+
+		if sid, ok := vars["sid"]; ok {
+			rv.SID, err = modelsx.HashDecodeSingle(sid)
+
+			if err != nil {
+				log.WithError(err).Errorln("Failed to decode sid")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Invalid SID"))
+				return
+			}
+		}
+
 		if filename, ok := vars["filename"]; ok {
 			rv.Filename = filename
 		}
 
+		if provider, ok := vars["provider"]; ok {
+			rv.Provider = provider
+		}
+
 		qv := &QueryVars{}
 
 		if cids, ok := req.URL.Query()["cid"]; ok {
@@ -0,0 +1,266 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services/oidc"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/friendsofgo/errors"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// SESSION_NAME_OAUTH is the cookie a login with a provider is kept in until the provider sends the user back
+// The session cookie is SameSite=Strict, browsers don't send it along with the cross-site redirect back from a provider
+const SESSION_NAME_OAUTH = "webserver-oauth"
+
+// oauthStateMaxAge is how many seconds users have to log in at a provider
+const oauthStateMaxAge = 10 * 60
+
+// oauthState is what the callback checks the provider's response against
+type oauthState struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Redirect string `json:"redirect"`
+	// LinkUserID is the user who was logged in when the login started, the identity is linked to them instead of logging in with it
+	LinkUserID int64 `json:"link_user_id,omitempty"`
+}
+
+// localRedirect only lets users be sent back to paths on this site, so the login can't be used as an open redirect
+func localRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+
+	return redirect
+}
+
+// OIDCLogin sends the user to a provider to log in
+// Users who are already logged in get the identity they log in with linked to their account
+//
+// GET /auth/oidc/{provider}/login
+func (r *Routes) OIDCLogin(user *models.User, resp http.ResponseWriter, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	provider := vars(req).Provider
+
+	if !r.OIDC.Has(provider) {
+		return http.StatusNotFound, nil, nil, nil
+	}
+
+	state := &oauthState{Provider: provider, Redirect: localRedirect(req.URL.Query().Get("redirect"))}
+
+	// Only a session can link identities, like only a session can manage tokens
+	if user != nil && requestToken(req) == nil {
+		state.LinkUserID = user.ID
+	}
+
+	for _, secret := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		var err error
+
+		if *secret, err = oidc.NewSecret(); err != nil {
+			return http.StatusInternalServerError, nil, nil, err
+		}
+	}
+
+	authURL, err := r.OIDC.AuthCodeURL(req.Context(), provider, state.State, state.Nonce, state.Verifier)
+
+	if err != nil {
+		return http.StatusBadGateway, nil, nil, err
+	}
+
+	encoded, err := jsoniter.MarshalToString(state)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to encode oauth state")
+	}
+
+	session, _ := r.cookies.Get(req, SESSION_NAME_OAUTH)
+	session.Options.MaxAge = oauthStateMaxAge
+	session.Options.SameSite = http.SameSiteLaxMode
+	session.Values[SESSION_KEY_OAUTH_STATE] = encoded
+
+	if err := session.Save(req, resp); err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "Failed to save session")
+	}
+
+	headers := http.Header{}
+	headers.Set("Location", authURL)
+
+	return http.StatusFound, nil, headers, nil
+}
+
+// OIDCCallback is where providers send users back to after they logged in
+// The identity they logged in with is linked to the user who started the login, logs in the user it's linked to,
+// or gets an account of its own if the provider auto provisions them
+//
+// GET /auth/oidc/{provider}/callback
+func (r *Routes) OIDCCallback(user *models.User, resp http.ResponseWriter, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	provider := vars(req).Provider
+
+	if !r.OIDC.Has(provider) {
+		return http.StatusNotFound, nil, nil, nil
+	}
+
+	oauthSession, _ := r.cookies.Get(req, SESSION_NAME_OAUTH)
+	encoded, _ := oauthSession.Values[SESSION_KEY_OAUTH_STATE].(string)
+
+	// A state is only good for one try, whether it works out or not
+	oauthSession.Options.MaxAge = -1
+
+	if err := oauthSession.Save(req, resp); err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "Failed to save session")
+	}
+
+	state := &oauthState{}
+	query := req.URL.Query()
+
+	if encoded == "" || jsoniter.UnmarshalFromString(encoded, state) != nil || state.Provider != provider ||
+		subtle.ConstantTimeCompare([]byte(state.State), []byte(query.Get("state"))) != 1 {
+		return http.StatusBadRequest, StringToStream("Invalid OAuth state"), nil, nil
+	}
+
+	if e := query.Get("error"); e != "" {
+		return http.StatusUnauthorized, StringToStream("Login failed: " + e), nil, nil
+	}
+
+	identity, err := r.OIDC.Exchange(req.Context(), provider, query.Get("code"), state.Nonce, state.Verifier)
+
+	if errors.Is(err, oidc.ErrUnverified) {
+		return http.StatusUnauthorized, StringToStream("Login failed"), nil, err
+	} else if err != nil {
+		return http.StatusBadGateway, nil, nil, err
+	}
+
+	uid, code, body, err := r.identityUser(req, state, identity)
+
+	if uid == 0 {
+		return code, body, nil, err
+	}
+
+	loggedIn, err := r.Users.Find(req.Context(), uid)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "Failed to find user")
+	}
+
+	if loggedIn.Disabled {
+		return http.StatusForbidden, StringToStream("Account is disabled"), nil, nil
+	}
+
+	session, _ := r.store.Get(req, SESSION_NAME)
+	headers := http.Header{}
+
+	// A provider takes the place of the password, not the code, users who were logged in already to link an identity sent it before
+	if loggedIn.TotpEnabled && state.LinkUserID != uid {
+		delete(session.Values, SESSION_KEY_ID)
+
+		session.Values[SESSION_KEY_PENDING_ID] = uid
+		if err := session.Save(req, resp); err != nil {
+			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "Failed to save session")
+		}
+
+		headers.Set("Location", "/login?"+url.Values{"totp": {"required"}, "redirect": {state.Redirect}}.Encode())
+
+		return http.StatusFound, nil, headers, nil
+	}
+
+	session.Values[SESSION_KEY_ID] = uid
+	if err := session.Save(req, resp); err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "Failed to save session")
+	}
+
+	headers.Set("Location", state.Redirect)
+
+	return http.StatusFound, nil, headers, nil
+}
+
+// identityUser finds the ID of the user an identity logs in as, linking or provisioning them as needed
+// If there's no user to log in as, the status code, body and error to respond with are returned instead
+func (r *Routes) identityUser(req *http.Request, state *oauthState, identity *oidc.Identity) (int64, int, io.ReadCloser, error) {
+	linked, err := r.Identities.Find(req.Context(), identity.Provider, identity.Subject)
+
+	if err != nil && err != sql.ErrNoRows {
+		return 0, http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find identity")
+	}
+
+	if linked != nil {
+		if state.LinkUserID != 0 && linked.UserID != state.LinkUserID {
+			return 0, http.StatusConflict, StringToStream("Identity is already linked to another account"), nil
+		}
+
+		return linked.UserID, 0, nil, nil
+	}
+
+	uid := state.LinkUserID
+
+	if uid == 0 {
+		if !r.OIDC.AutoProvision(identity.Provider) {
+			return 0, http.StatusForbidden, StringToStream("No account is linked to this identity"), nil
+		}
+
+		var code int
+		var body io.ReadCloser
+
+		if uid, code, body, err = r.provisionUser(req, identity); uid == 0 {
+			return 0, code, body, err
+		}
+	}
+
+	if err := r.Identities.Create(req.Context(), &models.UserIdentity{UserID: uid, Provider: identity.Provider, Subject: identity.Subject}, boil.Whitelist(
+		models.UserIdentityColumns.UserID,
+		models.UserIdentityColumns.Provider,
+		models.UserIdentityColumns.Subject,
+	)); err != nil {
+		return 0, http.StatusInternalServerError, nil, errors.Wrap(err, "failed to link identity")
+	}
+
+	return uid, 0, nil, nil
+}
+
+// provisionUser creates an account for an identity, named after the provider's username claim
+// Existing accounts are never taken over by name, they have to link the identity themselves
+func (r *Routes) provisionUser(req *http.Request, identity *oidc.Identity) (int64, int, io.ReadCloser, error) {
+	// The same limits registering has
+	if err := modelsx.UserValidateRegister.Var(identity.Username, "min=2,max=64"); err != nil {
+		return 0, http.StatusBadRequest, StringToStream("The provider didn't send a valid username"), nil
+	}
+
+	exists, err := r.Users.ExistsUsername(req.Context(), identity.Username)
+
+	if err != nil {
+		return 0, http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to check if username exists")
+	}
+
+	if exists {
+		return 0, http.StatusConflict, StringToStream("Username already exists, log in and link the identity to it instead"), nil
+	}
+
+	// Nobody knows the password, the account can only be logged in to with the identity until one is set
+	password, err := oidc.NewSecret()
+
+	if err != nil {
+		return 0, http.StatusInternalServerError, nil, err
+	}
+
+	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+
+	if err != nil {
+		return 0, http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to hash password")
+	}
+
+	model := &models.User{Username: identity.Username, Password: hash, PasswordUnset: true}
+
+	if err := r.Users.Create(req.Context(), model, boil.Whitelist(models.UserColumns.Username, models.UserColumns.Password, models.UserColumns.PasswordUnset)); err != nil {
+		return 0, http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to create user")
+	}
+
+	return model.ID, 0, nil, nil
+}
@@ -0,0 +1,302 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"webserver/config"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/oidc"
+	"webserver/services/oidc/oidctest"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// oidcHarness serves the OIDC endpoints against the test provider, with users and identities kept in memory
+type oidcHarness struct {
+	idp    *oidctest.Server
+	server *httptest.Server
+	client *http.Client
+
+	lock       sync.Mutex
+	users      map[int64]*models.User
+	identities []*models.UserIdentity
+}
+
+func newOIDCHarness(t *testing.T, autoProvision bool) *oidcHarness {
+	h := &oidcHarness{
+		idp:   oidctest.NewServer("clipable", "secret"),
+		users: map[int64]*models.User{1: {ID: 1, Username: "existing"}},
+	}
+
+	t.Cleanup(h.idp.Close)
+
+	cfg := &config.Config{}
+
+	r := &Routes{
+		cfg:     cfg,
+		store:   sessions.NewCookieStore([]byte("key")),
+		cookies: sessions.NewCookieStore([]byte("key")),
+		OIDC:    oidc.New(cfg),
+		Group: &services.Group{
+			Users: &mock.UserProvider{
+				FindHook: func(ctx context.Context, uid int64) (*models.User, error) {
+					h.lock.Lock()
+					defer h.lock.Unlock()
+
+					if user, ok := h.users[uid]; ok {
+						return user, nil
+					}
+
+					return nil, sql.ErrNoRows
+				},
+				ExistsUsernameHook: func(ctx context.Context, username string) (bool, error) {
+					h.lock.Lock()
+					defer h.lock.Unlock()
+
+					for _, user := range h.users {
+						if user.Username == username {
+							return true, nil
+						}
+					}
+
+					return false, nil
+				},
+				CreateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+					h.lock.Lock()
+					defer h.lock.Unlock()
+
+					user.ID = int64(len(h.users) + 1)
+					h.users[user.ID] = user
+
+					return nil
+				},
+			},
+			Identities: &mock.IdentitiesProvider{
+				FindHook: func(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+					h.lock.Lock()
+					defer h.lock.Unlock()
+
+					for _, identity := range h.identities {
+						if identity.Provider == provider && identity.Subject == subject {
+							return identity, nil
+						}
+					}
+
+					return nil, sql.ErrNoRows
+				},
+				CreateHook: func(ctx context.Context, identity *models.UserIdentity, columns boil.Columns) error {
+					h.lock.Lock()
+					defer h.lock.Unlock()
+
+					h.identities = append(h.identities, identity)
+
+					return nil
+				},
+			},
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Use(r.ParseVars)
+	api := router.PathPrefix("/api").Subrouter()
+
+	api.Handle("/auth/oidc/{provider}/login", r.FullHandler(r.OIDCLogin)).Methods(http.MethodGet)
+	api.Handle("/auth/oidc/{provider}/callback", r.FullHandler(r.OIDCCallback)).Methods(http.MethodGet)
+
+	// Who the session belongs to, so tests can tell who they're logged in as
+	api.Handle("/whoami", r.Handler(func(user *models.User, req *http.Request) (int, []byte, error) {
+		if user == nil {
+			return http.StatusUnauthorized, nil, nil
+		}
+
+		return http.StatusOK, []byte(user.Username), nil
+	})).Methods(http.MethodGet)
+
+	// Standing in for the frontend users end up on
+	router.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	h.server = httptest.NewServer(router)
+	t.Cleanup(h.server.Close)
+
+	cfg.OIDC.RedirectURL = h.server.URL + "/api"
+	cfg.OIDC.Provider = map[string]*config.OIDCProvider{
+		"test": {
+			Issuer:        h.idp.URL,
+			ClientID:      "clipable",
+			ClientSecret:  "secret",
+			Scopes:        []string{"openid", "profile"},
+			UsernameClaim: "preferred_username",
+			AutoProvision: autoProvision,
+		},
+	}
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	h.client = &http.Client{Jar: jar}
+
+	return h
+}
+
+// login goes through the whole flow, from the login endpoint through the provider and back to the callback
+func (h *oidcHarness) login(t *testing.T, subject, username string) (*http.Response, string) {
+	h.idp.Login(subject, map[string]interface{}{"preferred_username": username})
+
+	resp, err := h.client.Get(h.server.URL + "/api/auth/oidc/test/login")
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(body)
+}
+
+func (h *oidcHarness) whoami(t *testing.T) string {
+	resp, err := h.client.Get(h.server.URL + "/api/whoami")
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return fmt.Sprintf("%d %s", resp.StatusCode, body)
+}
+
+func TestRoutes_OIDCAutoProvision(t *testing.T) {
+	h := newOIDCHarness(t, true)
+
+	resp, body := h.login(t, "subject-1", "alice")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, "/", resp.Request.URL.Path)
+
+	assert.Equal(t, "200 alice", h.whoami(t))
+	require.Len(t, h.identities, 1)
+	assert.Equal(t, &models.UserIdentity{UserID: 2, Provider: "test", Subject: "subject-1"}, h.identities[0])
+	// The account can set a password without knowing the random one it was created with
+	assert.True(t, h.users[2].PasswordUnset)
+
+	// Logging in again uses the linked account instead of provisioning another one
+	h.client.Jar, _ = cookiejar.New(nil)
+
+	resp, body = h.login(t, "subject-1", "alice")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	assert.Equal(t, "200 alice", h.whoami(t))
+	assert.Len(t, h.users, 2)
+	assert.Len(t, h.identities, 1)
+}
+
+func TestRoutes_OIDCNoTakeover(t *testing.T) {
+	h := newOIDCHarness(t, true)
+
+	// An identity claiming the name of an existing account doesn't get to log in to it
+	resp, body := h.login(t, "subject-1", "existing")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, body)
+
+	assert.Equal(t, "401 ", h.whoami(t))
+	assert.Empty(t, h.identities)
+}
+
+func TestRoutes_OIDCWithoutAutoProvision(t *testing.T) {
+	h := newOIDCHarness(t, false)
+
+	resp, body := h.login(t, "subject-1", "alice")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, body)
+
+	assert.Equal(t, "401 ", h.whoami(t))
+	assert.Len(t, h.users, 1)
+}
+
+func TestRoutes_OIDCLink(t *testing.T) {
+	h := newOIDCHarness(t, false)
+
+	// The existing user already has an identity to log in with
+	h.identities = append(h.identities, &models.UserIdentity{UserID: 1, Provider: "test", Subject: "existing-subject"})
+
+	resp, body := h.login(t, "existing-subject", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.Equal(t, "200 existing", h.whoami(t))
+
+	// Logging in with another identity while logged in links it
+	resp, body = h.login(t, "subject-2", "whatever")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	require.Len(t, h.identities, 2)
+	assert.Equal(t, &models.UserIdentity{UserID: 1, Provider: "test", Subject: "subject-2"}, h.identities[1])
+
+	// It now logs in to the same account
+	h.client.Jar, _ = cookiejar.New(nil)
+
+	resp, body = h.login(t, "subject-2", "whatever")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, "200 existing", h.whoami(t))
+}
+
+func TestRoutes_OIDCCallbackState(t *testing.T) {
+	h := newOIDCHarness(t, true)
+	h.idp.Login("subject-1", map[string]interface{}{"preferred_username": "alice"})
+
+	// Stop at the callback, so it can be replayed with the wrong state
+	h.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if strings.HasSuffix(req.URL.Path, "/callback") {
+			return http.ErrUseLastResponse
+		}
+
+		return nil
+	}
+
+	resp, err := h.client.Get(h.server.URL + "/api/auth/oidc/test/login")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	callback := resp.Header.Get("Location")
+	require.Contains(t, callback, "state=")
+
+	tests := []struct {
+		name     string
+		url      string
+		expected int
+	}{
+		{"Handle unknown provider", strings.Replace(callback, "/oidc/test/", "/oidc/other/", 1), http.StatusNotFound},
+		{"Deny tampered state", strings.Replace(callback, "state=", "state=x", 1), http.StatusBadRequest},
+		// The state was used up by the try before, even though it failed
+		{"Deny replayed state", callback, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := h.client.Get(tt.url)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+
+	assert.Equal(t, "401 ", h.whoami(t))
+}
+
+func TestLocalRedirect(t *testing.T) {
+	assert.Equal(t, "/clips/abcd", localRedirect("/clips/abcd"))
+	assert.Equal(t, "/", localRedirect(""))
+	assert.Equal(t, "/", localRedirect("https://evil.example"))
+	assert.Equal(t, "/", localRedirect("//evil.example"))
+	assert.Equal(t, "/", localRedirect("/\\evil.example"))
+}
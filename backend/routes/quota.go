@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"context"
+	"webserver/models"
+
+	"github.com/friendsofgo/errors"
+)
+
+// userQuota is how many bytes a user may store, users without a quota of their own get DefaultQuota
+// A quota of 0 means there's no limit
+func (r *Routes) userQuota(user *models.User) int64 {
+	if user.QuotaBytes.Valid {
+		return user.QuotaBytes.Int64
+	}
+
+	return r.cfg.DefaultQuotaBytes
+}
+
+// exceedsQuota reports whether storing size more bytes would take the user over their quota
+func (r *Routes) exceedsQuota(ctx context.Context, user *models.User, size int64) (bool, error) {
+	quota := r.userQuota(user)
+
+	if quota == 0 {
+		return false, nil
+	}
+
+	usage, err := r.Users.Usage(ctx, user.ID)
+
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get storage usage")
+	}
+
+	return usage+size > quota, nil
+}
+
+// uploadLimit is how many bytes of an upload can be read before it's refused, either MaxUploadSize or what's left of the user's quota
+// Reading all of it means there was more, byQuota tells whether that's over the quota rather than too large altogether
+// reserved is what the usage already counts for the upload, like the length of a resumable upload that's being completed
+func (r *Routes) uploadLimit(ctx context.Context, user *models.User, reserved int64) (limit int64, byQuota bool, err error) {
+	limit = r.cfg.MaxUploadSizeBytes
+	quota := r.userQuota(user)
+
+	if quota == 0 {
+		return limit, false, nil
+	}
+
+	usage, err := r.Users.Usage(ctx, user.ID)
+
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to get storage usage")
+	}
+
+	// One byte more than what's left, so an upload that fills the quota exactly still fits
+	remaining := quota - usage + reserved + 1
+
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if remaining < limit {
+		return remaining, true, nil
+	}
+
+	return limit, false, nil
+}
@@ -8,8 +8,12 @@ import (
 	"webserver/config"
 	"webserver/services"
 	"webserver/services/db"
+	"webserver/services/gc"
 	"webserver/services/object"
+	"webserver/services/oidc"
+	"webserver/services/tiering"
 	"webserver/services/transcoder"
+	"webserver/services/verify"
 
 	"github.com/friendsofgo/errors"
 	"github.com/gorilla/handlers"
@@ -27,21 +31,58 @@ import (
 // Routes contain pointers to resources needed in endpoint handlers
 type Routes struct {
 	listeners cmap.ConcurrentMap
+	uploading cmap.ConcurrentMap // IDs of the resumable uploads a request is currently working on
 	cfg       *config.Config
 	*services.Group
-	store sessions.Store
+	store   sessions.Store // Sessions of logged in users
+	cookies sessions.Store // State that only has to last until a login is done, kept in signed cookies
 
+	importClient *http.Client // Fetches the files of clips imported from a URL
+
+	Collector      *gc.Collector
+	Mover          *tiering.Mover
+	Verifier       *verify.Verifier
+	OIDC           *oidc.Providers
 	Router         http.Handler
 	InternalRouter http.Handler
 }
 
 // New configures the handler functions for each API endpoint
-func New(cfg *config.Config, g *services.Group, store sessions.Store) (*Routes, error) {
+// Sessions are kept in the database with the options of cookies, which keeps the state of logins in progress
+func New(cfg *config.Config, g *services.Group, cookies *sessions.CookieStore) (*Routes, error) {
 	r := &Routes{
 		listeners: cmap.New(),
+		uploading: cmap.New(),
 		cfg:       cfg,
 		Group:     g,
-		store:     store,
+		store:     NewSessionStore(g.Sessions, cookies.Options),
+		cookies:   cookies,
+		Collector: gc.New(cfg, g),
+		Mover:     tiering.New(cfg, g),
+		Verifier:  verify.New(cfg, g),
+		OIDC:      oidc.New(cfg),
+
+		importClient: newImportClient(cfg.Imports.AllowPrivate, cfg.Imports.MaxRedirects),
+	}
+
+	switch cfg.Delivery.Mode {
+	case DeliveryProxy, DeliveryAccel:
+	case DeliveryPresign:
+		if cfg.Storage.Backend != object.BackendS3 {
+			return nil, errors.New("presigned delivery needs the s3 storage backend")
+		}
+	default:
+		return nil, errors.Errorf("unknown delivery mode %q", cfg.Delivery.Mode)
+	}
+
+	// Objects that are only readable with a key the backend holds can't be served by anything else
+	if cfg.Delivery.Mode != DeliveryProxy && (cfg.Encryption.Mode == object.EncryptionSSEC || cfg.Encryption.Mode == object.EncryptionEnvelope) {
+		return nil, errors.Errorf("encryption mode %q needs the proxy delivery mode", cfg.Encryption.Mode)
+	}
+
+	// Nothing but the backend knows which objects were moved to a cold tier on disk
+	if cfg.Delivery.Mode != DeliveryProxy && cfg.Tiering.Enabled && cfg.Tiering.Backend == object.BackendFilesystem {
+		return nil, errors.New("a filesystem cold tier needs the proxy delivery mode")
 	}
 
 	logrus.SetLevel(logrus.InfoLevel)
@@ -78,29 +119,68 @@ func New(cfg *config.Config, g *services.Group, store sessions.Store) (*Routes,
 	internalEndpoint("/s3/{cid}/{file}", r.ReadObject, http.MethodGet)
 	internalEndpoint("/s3/{cid}/{file}", r.UploadObject, http.MethodPost)
 	internalEndpoint("/progress/{cid}", r.SetProgress, http.MethodPost)
+	internalEndpoint("/progress/{cid}/{chunk}", r.SetProgress, http.MethodPost)
 
 	// AUTH ENDPOINTS
 	endpoint("/auth/login", r.ResponseHandler(r.Login), http.MethodPost)
+	endpoint("/auth/login/totp", r.ResponseHandler(r.LoginTOTP), http.MethodPost)
 	endpoint("/auth/register", r.ResponseHandler(r.Register), http.MethodPost)
 	endpoint("/auth/register", r.ResponseHandler(r.AllowRegistration), http.MethodOptions)
 	endpoint("/auth/logout", r.ResponseHandler(r.Logout), http.MethodPost)
+	endpoint("/auth/oidc/{provider}/login", r.FullHandler(r.OIDCLogin), http.MethodGet)
+	endpoint("/auth/oidc/{provider}/callback", r.FullHandler(r.OIDCCallback), http.MethodGet)
 
 	// USER ENDPOINTS
 	endpoint("/users/search", r.Handler(r.SearchUsers), http.MethodGet)
 	endpoint("/users/me", r.Handler(r.GetCurrentUser), http.MethodGet)
+	endpoint("/users/me/tokens", r.Handler(r.GetTokens), http.MethodGet)
+	endpoint("/users/me/tokens", r.Handler(r.CreateToken), http.MethodPost)
+	endpoint("/users/me/tokens/{tkid:[a-zA-Z0-9-]{4,}}", r.Handler(r.DeleteToken), http.MethodDelete)
+	endpoint("/users/me/totp", r.Handler(r.EnrollTOTP), http.MethodPost)
+	endpoint("/users/me/totp", r.Handler(r.DisableTOTP), http.MethodDelete)
+	endpoint("/users/me/totp/confirm", r.Handler(r.ConfirmTOTP), http.MethodPost)
+	endpoint("/users/me/sessions", r.Handler(r.GetSessions), http.MethodGet)
+	endpoint("/users/me/sessions", r.Handler(r.DeleteSessions), http.MethodDelete)
+	endpoint("/users/me/sessions/{sid:[a-zA-Z0-9-]{4,}}", r.Handler(r.DeleteSession), http.MethodDelete)
 	endpoint("/users", r.Handler(r.GetUsers), http.MethodGet)
 	endpoint("/users/{uid:[a-zA-Z0-9-]{4,}}/clips", r.Handler(r.GetUsersClips), http.MethodGet)
 	endpoint("/users/{uid:[a-zA-Z0-9-]{4,}}", r.Handler(r.GetUser), http.MethodGet)
 	endpoint("/users/{uid:[a-zA-Z0-9-]{4,}}", r.Handler(r.UpdateUser), http.MethodPatch)
 
+	// ADMIN ENDPOINTS
+	endpoint("/admin/users", r.Handler(r.AdminGetUsers), http.MethodGet)
+	endpoint("/admin/users/{uid:[a-zA-Z0-9-]{4,}}", r.Handler(r.AdminUpdateUser), http.MethodPatch)
+	endpoint("/admin/users/{uid:[a-zA-Z0-9-]{4,}}", r.Handler(r.AdminDeleteUser), http.MethodDelete)
+	endpoint("/admin/queue", r.Handler(r.AdminGetQueue), http.MethodGet)
+	endpoint("/admin/gc", r.FullHandler(r.AdminCollectGarbage), http.MethodGet, http.MethodPost)
+	endpoint("/admin/verify", r.FullHandler(r.AdminVerifyObjects), http.MethodPost)
+
+	// RESUMABLE UPLOAD ENDPOINTS
+	endpoint("/uploads", r.TusHandler(r.GetUploadOptions), http.MethodOptions)
+	endpoint("/uploads", r.TusHandler(r.CreateUpload), http.MethodPost)
+	endpoint("/uploads/{upid:[a-zA-Z0-9-]{4,}}", r.TusHandler(r.GetUploadOffset), http.MethodHead)
+	endpoint("/uploads/{upid:[a-zA-Z0-9-]{4,}}", r.TusHandler(r.PatchUpload), http.MethodPatch)
+	endpoint("/uploads/{upid:[a-zA-Z0-9-]{4,}}", r.TusHandler(r.DeleteUpload), http.MethodDelete)
+
 	// CLIP ENDPOINTS
 	endpoint("/clips", r.Handler(r.UploadClip), http.MethodPost)
 	endpoint("/clips", r.Handler(r.GetClips), http.MethodGet)
 	endpoint("/clips/search", r.Handler(r.SearchClips), http.MethodGet)
 	endpoint("/clips/progress", r.Handler(r.GetProgress), http.MethodGet)
+	endpoint("/clips/import", r.FullHandler(r.ImportClip), http.MethodPost)
 	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}", r.Handler(r.GetClip), http.MethodGet)
 	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}", r.Handler(r.UpdateClip), http.MethodPatch)
 	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}", r.Handler(r.DeleteClip), http.MethodDelete)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/duplicates", r.Handler(r.GetDuplicates), http.MethodGet)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/export", r.StreamHandler(r.ExportClip), http.MethodGet)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/download", r.StreamHandler(r.DownloadClip), http.MethodGet)
+
+	// CHAPTER ENDPOINTS
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/chapters", r.Handler(r.GetChapters), http.MethodGet)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/chapters", r.Handler(r.CreateChapter), http.MethodPost)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/chapters.vtt", r.StreamHandler(r.GetChaptersVTT), http.MethodGet)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/chapters/{chid:[a-zA-Z0-9-]{4,}}", r.Handler(r.UpdateChapter), http.MethodPatch)
+	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/chapters/{chid:[a-zA-Z0-9-]{4,}}", r.Handler(r.DeleteChapter), http.MethodDelete)
 
 	// MPEG-DASH ENDPOINTS
 	endpoint("/clips/{cid:[a-zA-Z0-9-]{4,}}/{filename}", r.StreamHandler(r.GetStreamFile), http.MethodGet)
@@ -114,11 +194,24 @@ func New(cfg *config.Config, g *services.Group, store sessions.Store) (*Routes,
 			AllowedOrigins: []string{cfg.CORS.Origin, "https://reference.dashif.org", "https://shaka-player-demo.appspot.com", "https://csb-pygk8-mkhuda.vercel.app"},
 			AllowedMethods: []string{
 				http.MethodGet,
+				http.MethodHead,
 				http.MethodPost,
 				http.MethodPatch,
 				http.MethodDelete,
 			},
-			AllowedHeaders:   []string{"*"},
+			AllowedHeaders: []string{"*"},
+			// Resumable uploads are driven by these headers, browsers hide them from scripts otherwise
+			ExposedHeaders: []string{
+				"Location",
+				"Upload-Offset",
+				"Upload-Length",
+				"Upload-Expires",
+				"Tus-Resumable",
+				"Tus-Version",
+				"Tus-Extension",
+				"Tus-Max-Size",
+				"Clip-ID",
+			},
 			AllowCredentials: true,
 		}).Handler(router)
 	} else {
@@ -134,8 +227,34 @@ func New(cfg *config.Config, g *services.Group, store sessions.Store) (*Routes,
 func DefaultServiceGroup(cfg *config.Config, sdb *sql.DB, s3 *minio.Client) (*services.Group, error) {
 	var err error
 	group := &services.Group{
-		Users:       db.NewUsers(sdb),
-		ObjectStore: object.NewStore(s3, cfg),
+		Users:      db.NewUsers(sdb),
+		Chapters:   db.NewChapters(sdb),
+		Chunks:     db.NewChunks(sdb),
+		Uploads:    db.NewUploads(sdb),
+		Tokens:     db.NewTokens(sdb),
+		Identities: db.NewIdentities(sdb),
+		Sessions:   db.NewSessions(sdb),
+	}
+
+	group.ObjectStore, err = newObjectStore(cfg, cfg.Storage.Backend, s3)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Tiering.Enabled {
+		// The cold tier is configured like the hot one, only its bucket or directory differ
+		coldCfg := *cfg
+		coldCfg.S3.Bucket = cfg.Tiering.Bucket
+		coldCfg.Storage.Path = cfg.Tiering.Path
+
+		cold, err := newObjectStore(&coldCfg, cfg.Tiering.Backend, s3)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create cold tier")
+		}
+
+		group.ObjectStore = object.NewTieredStore(group.ObjectStore, cold)
 	}
 
 	group.Clips = db.NewClips(sdb, group.ObjectStore)
@@ -147,3 +266,18 @@ func DefaultServiceGroup(cfg *config.Config, sdb *sql.DB, s3 *minio.Client) (*se
 
 	return group, nil
 }
+
+func newObjectStore(cfg *config.Config, backend string, s3 *minio.Client) (services.ObjectStore, error) {
+	switch backend {
+	case object.BackendS3:
+		store, err := object.NewStore(s3, cfg)
+
+		return store, errors.Wrap(err, "failed to create s3 object store")
+	case object.BackendFilesystem:
+		store, err := object.NewFilesystemStore(cfg)
+
+		return store, errors.Wrap(err, "failed to create filesystem object store")
+	}
+
+	return nil, errors.Errorf("unknown storage backend %q", backend)
+}
@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path"
+	"strings"
+	"time"
+	"webserver/services"
+
+	"github.com/friendsofgo/errors"
+	"github.com/gotd/contrib/http_range"
+)
+
+// Cache-Control of the files of a clip, segments never change once written while the manifest is what counts views
+const (
+	manifestCacheControl = "public, max-age=10"
+	segmentCacheControl  = "public, max-age=31536000, immutable"
+)
+
+// Types of the objects of a clip by extension, anything else is served as application/octet-stream
+// They're set explicitly rather than guessed from the contents or the system's mime types
+var objectContentTypes = map[string]string{
+	".mpd":  "application/dash+xml",
+	".m3u8": "application/vnd.apple.mpegurl",
+	".m4s":  "video/mp4",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".m4a":  "audio/mp4",
+	".jpg":  "image/jpeg",
+}
+
+// serveObject answers a GET of an object, honouring conditional requests and single or multiple ranges
+// It takes ownership of object, which is closed once nothing is read from it anymore. An empty cacheControl sets none
+func serveObject(req *http.Request, object io.ReadSeekCloser, info *services.ObjectInfo, cacheControl string) (int, io.ReadCloser, http.Header, error) {
+	headers := make(http.Header)
+	headers.Set("Accept-Ranges", "bytes")
+	headers.Set("X-Content-Type-Options", "nosniff")
+
+	contentType := objectContentType(info.Name)
+
+	etag := quoteETag(info.ETag)
+
+	if etag != "" {
+		headers.Set("ETag", etag)
+	}
+
+	// HTTP dates only go down to the second
+	modified := info.LastModified.Truncate(time.Second)
+
+	if !modified.IsZero() {
+		headers.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if cacheControl != "" {
+		headers.Set("Cache-Control", cacheControl)
+	}
+
+	if notModified(req, etag, modified) {
+		object.Close()
+		return http.StatusNotModified, nil, headers, nil
+	}
+
+	rangeHeader := req.Header.Get("Range")
+
+	if !matchesIfRange(req.Header.Get("If-Range"), etag, modified) {
+		rangeHeader = ""
+	}
+
+	ranges, err := http_range.ParseRange(rangeHeader, info.Size)
+
+	if err == http_range.ErrNoOverlap {
+		object.Close()
+		headers.Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		return http.StatusRequestedRangeNotSatisfiable, StringToStream("Range not satisfiable"), headers, nil
+	} else if err != nil {
+		object.Close()
+		return http.StatusBadRequest, StringToStream(errors.Wrap(err, "Invalid Range").Error()), nil, nil
+	}
+
+	// Asking for more than the whole object in pieces is never worth answering as asked
+	if sumRanges(ranges) > info.Size {
+		ranges = nil
+	}
+
+	switch len(ranges) {
+	case 0:
+		headers.Set("Content-Type", contentType)
+		headers.Set("Content-Length", fmt.Sprint(info.Size))
+		return http.StatusOK, object, headers, nil
+	case 1:
+		if _, err := object.Seek(ranges[0].Start, io.SeekStart); err != nil {
+			object.Close()
+			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to seek to start of range")
+		}
+
+		headers.Set("Content-Type", contentType)
+		headers.Set("Content-Range", ranges[0].ContentRange(info.Size))
+		headers.Set("Content-Length", fmt.Sprint(ranges[0].Length))
+
+		return http.StatusPartialContent, NewLimitedReadCloser(object, ranges[0].Length), headers, nil
+	}
+
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	headers.Set("Content-Type", "multipart/byteranges; boundary="+boundary)
+	headers.Set("Content-Length", fmt.Sprint(multipartSize(ranges, boundary, contentType, info.Size)))
+
+	body, w := io.Pipe()
+
+	go func() {
+		defer object.Close()
+
+		parts := multipart.NewWriter(w)
+		parts.SetBoundary(boundary)
+
+		for _, ra := range ranges {
+			part, err := parts.CreatePart(rangeHeaders(ra, contentType, info.Size))
+
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+
+			if _, err := object.Seek(ra.Start, io.SeekStart); err != nil {
+				w.CloseWithError(errors.Wrap(err, "failed to seek to start of range"))
+				return
+			}
+
+			if _, err := io.CopyN(part, object, ra.Length); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+
+		w.CloseWithError(parts.Close())
+	}()
+
+	return http.StatusPartialContent, body, headers, nil
+}
+
+// objectContentType is the Content-Type an object of a clip is served with
+func objectContentType(name string) string {
+	if contentType, ok := objectContentTypes[path.Ext(name)]; ok {
+		return contentType
+	}
+
+	return "application/octet-stream"
+}
+
+// quoteETag turns the ETag of a store into an entity tag, S3 returns them without quotes
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+
+	return `"` + etag + `"`
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there's none
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+
+			// If-None-Match compares weakly
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+
+	return err == nil && !modified.IsZero() && !modified.After(ims)
+}
+
+// matchesIfRange says if a range may be served, If-Range only allows it if the object is still the one the client has part of
+func matchesIfRange(ifRange, etag string, modified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+
+	// Entity tags have to match strongly
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, `W/"`) {
+		return etag != "" && !strings.HasPrefix(ifRange, "W/") && ifRange == etag
+	}
+
+	date, err := http.ParseTime(ifRange)
+
+	return err == nil && !modified.IsZero() && modified.Equal(date)
+}
+
+func sumRanges(ranges []http_range.Range) int64 {
+	var size int64
+
+	for _, ra := range ranges {
+		size += ra.Length
+	}
+
+	return size
+}
+
+func rangeHeaders(ra http_range.Range, contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {ra.ContentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
+// multipartSize works out the Content-Length of a multipart/byteranges body before writing it
+func multipartSize(ranges []http_range.Range, boundary, contentType string, size int64) int64 {
+	var counter countingWriter
+
+	parts := multipart.NewWriter(&counter)
+	parts.SetBoundary(boundary)
+
+	for _, ra := range ranges {
+		parts.CreatePart(rangeHeaders(ra, contentType, size))
+		counter += countingWriter(ra.Length)
+	}
+
+	parts.Close()
+
+	return int64(counter)
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"webserver/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeObject(t *testing.T) {
+	modified := time.Date(2023, 4, 1, 12, 0, 0, 500, time.UTC)
+	info := &services.ObjectInfo{Name: "init-stream0.m4s", Size: 10, ETag: "asd123", LastModified: modified}
+
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		expected        int
+		body            string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:     "Success - full",
+			expected: http.StatusOK,
+			body:     "0123456789",
+			expectedHeaders: map[string]string{
+				"ETag":                   `"asd123"`,
+				"Last-Modified":          "Sat, 01 Apr 2023 12:00:00 GMT",
+				"Content-Length":         "10",
+				"Content-Type":           "video/mp4",
+				"X-Content-Type-Options": "nosniff",
+				"Cache-Control":          segmentCacheControl,
+			},
+		},
+		{
+			name:     "Not modified - weak etag",
+			expected: http.StatusNotModified,
+			headers:  map[string]string{"If-None-Match": `"other", W/"asd123"`},
+		},
+		{
+			name:     "Not modified - since",
+			expected: http.StatusNotModified,
+			headers:  map[string]string{"If-Modified-Since": "Sat, 01 Apr 2023 12:00:00 GMT"},
+		},
+		{
+			name:     "Modified since",
+			expected: http.StatusOK,
+			body:     "0123456789",
+			headers:  map[string]string{"If-Modified-Since": "Sat, 01 Apr 2023 11:59:59 GMT"},
+		},
+		{
+			name:     "If-None-Match takes precedence over If-Modified-Since",
+			expected: http.StatusOK,
+			body:     "0123456789",
+			headers:  map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Sat, 01 Apr 2023 12:00:00 GMT"},
+		},
+		{
+			name:            "Range",
+			expected:        http.StatusPartialContent,
+			body:            "234",
+			headers:         map[string]string{"Range": "bytes=2-4"},
+			expectedHeaders: map[string]string{"Content-Range": "bytes 2-4/10", "Content-Length": "3"},
+		},
+		{
+			name:     "Range - If-Range etag matches",
+			expected: http.StatusPartialContent,
+			body:     "89",
+			headers:  map[string]string{"Range": "bytes=-2", "If-Range": `"asd123"`},
+		},
+		{
+			name:     "Range - If-Range date matches",
+			expected: http.StatusPartialContent,
+			body:     "89",
+			headers:  map[string]string{"Range": "bytes=-2", "If-Range": "Sat, 01 Apr 2023 12:00:00 GMT"},
+		},
+		{
+			name:     "Range - If-Range is stale",
+			expected: http.StatusOK,
+			body:     "0123456789",
+			headers:  map[string]string{"Range": "bytes=-2", "If-Range": `"other"`},
+		},
+		{
+			name:     "Range - If-Range weak etags never match",
+			expected: http.StatusOK,
+			body:     "0123456789",
+			headers:  map[string]string{"Range": "bytes=-2", "If-Range": `W/"asd123"`},
+		},
+		{
+			name:     "Range - more than the object is served whole",
+			expected: http.StatusOK,
+			body:     "0123456789",
+			headers:  map[string]string{"Range": "bytes=0-9,0-9"},
+		},
+		{
+			name:            "Handle range not satisfiable",
+			expected:        http.StatusRequestedRangeNotSatisfiable,
+			body:            "Range not satisfiable",
+			headers:         map[string]string{"Range": "bytes=20-30"},
+			expectedHeaders: map[string]string{"Content-Range": "bytes */10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			code, body, headers, err := serveObject(req, NewNopReadSeekCloser([]byte("0123456789")), info, segmentCacheControl)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, code)
+
+			if body != nil {
+				data, err := io.ReadAll(body)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.body, string(data))
+			} else {
+				assert.Empty(t, tt.body)
+			}
+
+			for k, v := range tt.expectedHeaders {
+				assert.Equal(t, v, headers.Get(k))
+			}
+		})
+	}
+}
+
+func TestServeObject_MultipleRanges(t *testing.T) {
+	info := &services.ObjectInfo{Name: "init-stream0.m4s", Size: 10, ETag: "asd123"}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=0-1,5-6,-1")
+
+	code, body, headers, err := serveObject(req, NewNopReadSeekCloser([]byte("0123456789")), info, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, code)
+	assert.Empty(t, headers.Get("Cache-Control"))
+
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+
+	// The length is known before the body is written
+	assert.Equal(t, strconv.Itoa(len(data)), headers.Get("Content-Length"))
+
+	mediaType, params, err := mime.ParseMediaType(headers.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+
+	parts := multipart.NewReader(strings.NewReader(string(data)), params["boundary"])
+
+	for _, expected := range []struct{ contentRange, body string }{
+		{"bytes 0-1/10", "01"},
+		{"bytes 5-6/10", "56"},
+		{"bytes 9-9/10", "9"},
+	} {
+		part, err := parts.NextPart()
+		assert.NoError(t, err)
+		assert.Equal(t, expected.contentRange, part.Header.Get("Content-Range"))
+		assert.Equal(t, "video/mp4", part.Header.Get("Content-Type"))
+
+		partBody, err := io.ReadAll(part)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.body, string(partBody))
+	}
+
+	_, err = parts.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestObjectContentType(t *testing.T) {
+	for name, expected := range map[string]string{
+		"dash.mpd":           "application/dash+xml",
+		"master.m3u8":        "application/vnd.apple.mpegurl",
+		"dash-stream0.m4s":   "video/mp4",
+		"download-720p.mp4":  "video/mp4",
+		"download-audio.m4a": "audio/mp4",
+		"thumbnail.jpg":      "image/jpeg",
+		"raw":                "application/octet-stream",
+		"page.html":          "application/octet-stream",
+	} {
+		assert.Equal(t, expected, objectContentType(name), name)
+	}
+}
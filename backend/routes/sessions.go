@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"webserver/models"
+	"webserver/modelsx"
+
+	"github.com/friendsofgo/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetSessions lists where the requesting user is logged in
+//
+// GET /users/me/sessions
+func (r *Routes) GetSessions(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	// Sessions are managed with a session, like tokens are
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	sessions, err := r.Sessions.FindMany(req.Context(), user.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find sessions")
+	}
+
+	current := r.sessionHash(req)
+	sessionsx := modelsx.SessionArray{}
+
+	for _, session := range sessions {
+		sessionx := modelsx.SessionFromModel(session)
+		sessionx.Current = session.Hash == current
+
+		sessionsx = append(sessionsx, sessionx)
+	}
+
+	return sessionsx.Marshal()
+}
+
+// DeleteSessions logs the requesting user out everywhere but the session of the request
+//
+// DELETE /users/me/sessions
+func (r *Routes) DeleteSessions(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	if _, err := r.Sessions.DeleteAll(req.Context(), user.ID, r.sessionHash(req)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete sessions")
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// DeleteSession logs the requesting user out of one of their sessions
+//
+// DELETE /users/me/sessions/{session id}
+func (r *Routes) DeleteSession(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	deleted, err := r.Sessions.Delete(req.Context(), user.ID, vars(req).SID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete session")
+	}
+
+	// Sessions of other users are as good as missing
+	if !deleted {
+		return http.StatusNotFound, nil, nil
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// ExpireSessions periodically deletes sessions that expired, until ctx is done
+func (r *Routes) ExpireSessions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := r.Sessions.DeleteExpired(ctx)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to delete expired sessions")
+			continue
+		}
+
+		if deleted > 0 {
+			log.WithField("sessions", deleted).Debug("Deleted expired sessions")
+		}
+	}
+}
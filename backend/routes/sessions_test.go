@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/gorilla/sessions"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loggedIn logs uid in with a new session and returns the cookie it got
+func loggedIn(t *testing.T, r *Routes, uid int64) []*http.Cookie {
+	req := sessionRequest(nil)
+	session, err := r.store.Get(req, SESSION_NAME)
+	require.NoError(t, err)
+
+	session.Values[SESSION_KEY_ID] = uid
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, session.Save(req, rec))
+
+	return rec.Result().Cookies()
+}
+
+func newSessionRoutes() (*Routes, *memorySessions) {
+	memory, provider := newMemorySessions()
+
+	return &Routes{
+		store: NewSessionStore(provider, &sessions.Options{Path: "/", MaxAge: 3600}),
+		Group: &services.Group{Sessions: provider},
+	}, memory
+}
+
+func TestRoutes_GetSessions(t *testing.T) {
+	r, _ := newSessionRoutes()
+
+	current := loggedIn(t, r, 1)
+	loggedIn(t, r, 1)
+	loggedIn(t, r, 2)
+
+	code, body, err := r.GetSessions(&models.User{ID: 1}, sessionRequest(current))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	listed := []struct {
+		ID        string `json:"id"`
+		IP        string `json:"ip"`
+		UserAgent string `json:"user_agent"`
+		Current   bool   `json:"current"`
+	}{}
+
+	require.NoError(t, jsoniter.Unmarshal(body, &listed))
+	require.Len(t, listed, 2)
+	assert.NotContains(t, string(body), current[0].Value)
+
+	currents := 0
+
+	for _, session := range listed {
+		assert.Equal(t, "192.0.2.1", session.IP)
+		assert.Equal(t, "test", session.UserAgent)
+
+		if session.Current {
+			currents++
+		}
+	}
+
+	assert.Equal(t, 1, currents)
+}
+
+func TestRoutes_DeleteSessions(t *testing.T) {
+	r, memory := newSessionRoutes()
+
+	current := loggedIn(t, r, 1)
+	other := loggedIn(t, r, 1)
+	loggedIn(t, r, 2)
+
+	code, _, err := r.DeleteSessions(&models.User{ID: 1}, sessionRequest(current))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, code)
+
+	// Only the session the request was made with and the other user's are left
+	assert.Equal(t, 2, memory.len())
+
+	session, err := r.store.Get(sessionRequest(current), SESSION_NAME)
+	require.NoError(t, err)
+	assert.False(t, session.IsNew)
+
+	session, err = r.store.Get(sessionRequest(other), SESSION_NAME)
+	require.NoError(t, err)
+	assert.True(t, session.IsNew)
+}
+
+func TestRoutes_DeleteSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *models.User
+		token    *models.Token
+		vars     *RouteVars
+		expected int
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusNoContent,
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{SID: 1},
+		},
+		{
+			name:     "Handle session of another user",
+			expected: http.StatusNotFound,
+			user:     &models.User{ID: 2},
+			vars:     &RouteVars{SID: 1},
+		},
+		{
+			name:     "Deny tokens",
+			expected: http.StatusForbidden,
+			user:     &models.User{ID: 1},
+			token:    &models.Token{ID: 1, UserID: 1, Scopes: "manage"},
+			vars:     &RouteVars{SID: 1},
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			vars:     &RouteVars{SID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newSessionRoutes()
+			loggedIn(t, r, 1)
+
+			req := httptest.NewRequest(http.MethodDelete, "/", strings.NewReader(""))
+			ctx := context.WithValue(req.Context(), VarKey, tt.vars)
+
+			if tt.token != nil {
+				ctx = context.WithValue(ctx, TokenKey, tt.token)
+			}
+
+			code, _, err := r.DeleteSession(tt.user, req.WithContext(ctx))
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if err != nil {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+		})
+	}
+}
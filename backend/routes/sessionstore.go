@@ -0,0 +1,239 @@
+package routes
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"time"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/friendsofgo/errors"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	log "github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// sessionLastSeenInterval is how often the device and last seen time of a session in use are recorded
+const sessionLastSeenInterval = time.Minute
+
+// pendingSessionMaxAge is how long users have to send a code after their password, in seconds
+const pendingSessionMaxAge = 5 * 60
+
+// SessionStore keeps sessions in the database, so they can be listed and ended from anywhere
+// The cookie only holds a random secret, of which the SHA-256 hash is stored like a token's
+// Sessions nobody is logged in to aren't stored at all
+type SessionStore struct {
+	sessions services.Sessions
+	Options  *sessions.Options
+}
+
+// NewSessionStore creates a SessionStore, sessions expire after options.MaxAge
+func NewSessionStore(s services.Sessions, options *sessions.Options) *SessionStore {
+	return &SessionStore{s, options}
+}
+
+// Get returns the session of the request, it's only loaded once per request
+func (s *SessionStore) Get(req *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(req).Get(s, name)
+}
+
+// New loads the session of the request, or starts a new one if it doesn't have one that's still valid
+func (s *SessionStore) New(req *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := req.Cookie(name)
+
+	if err != nil {
+		return session, nil
+	}
+
+	model, err := s.sessions.FindHash(req.Context(), hashTokenSecret(cookie.Value))
+
+	if err == sql.ErrNoRows {
+		return session, nil
+	} else if err != nil {
+		return session, errors.Wrap(err, "failed to find session")
+	}
+
+	if !model.ExpiresAt.After(time.Now()) {
+		return session, nil
+	}
+
+	if err := (securecookie.GobEncoder{}).Deserialize(model.Data, &session.Values); err != nil {
+		return session, errors.Wrap(err, "failed to decode session")
+	}
+
+	session.ID = cookie.Value
+	session.IsNew = false
+
+	ip, userAgent := realIP(req), req.UserAgent()
+
+	if time.Since(model.LastSeenAt) > sessionLastSeenInterval || model.IP != ip || model.UserAgent != userAgent {
+		model.LastSeenAt = time.Now()
+		model.IP = ip
+		model.UserAgent = userAgent
+
+		// The session still works without it, so it's not worth failing the request over
+		if err := s.sessions.Update(req.Context(), model, boil.Whitelist(
+			models.SessionColumns.LastSeenAt,
+			models.SessionColumns.IP,
+			models.SessionColumns.UserAgent,
+		)); err != nil {
+			log.WithError(err).Warnln("Failed to record session use")
+		}
+	}
+
+	return session, nil
+}
+
+// Save stores the session and sets its cookie
+// Sessions with a MaxAge below 0 or without a user are ended instead
+// Sessions of users who still have to send a code are only kept for pendingSessionMaxAge
+func (s *SessionStore) Save(req *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	uid, pending := sessionUser(session.Values)
+
+	var model *models.Session
+
+	if session.ID != "" {
+		var err error
+
+		model, err = s.sessions.FindHash(req.Context(), hashTokenSecret(session.ID))
+
+		if err == sql.ErrNoRows {
+			model = nil
+		} else if err != nil {
+			return errors.Wrap(err, "failed to find session")
+		}
+
+		// Logging in to another account, or finishing a login, starts a new session
+		// A secret someone planted or saw before the login was done can't be used to ride along
+		if model != nil && (model.UserID != uid || session.Options.MaxAge < 0 || storedPending(model) != pending) {
+			if err := s.sessions.DeleteHash(req.Context(), model.Hash); err != nil {
+				return errors.Wrap(err, "failed to delete session")
+			}
+
+			model = nil
+		}
+	}
+
+	if session.Options.MaxAge < 0 || uid == 0 {
+		session.ID = ""
+
+		options := *session.Options
+		options.MaxAge = -1
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", &options))
+
+		return nil
+	}
+
+	data, err := securecookie.GobEncoder{}.Serialize(session.Values)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to encode session")
+	}
+
+	options := *session.Options
+
+	if pending && (options.MaxAge == 0 || options.MaxAge > pendingSessionMaxAge) {
+		options.MaxAge = pendingSessionMaxAge
+	}
+
+	expiresAt := time.Now().Add(time.Duration(options.MaxAge) * time.Second)
+
+	if model == nil {
+		secret, err := newSessionSecret()
+
+		if err != nil {
+			return err
+		}
+
+		model = &models.Session{
+			UserID:    uid,
+			Hash:      hashTokenSecret(secret),
+			Data:      data,
+			IP:        realIP(req),
+			UserAgent: req.UserAgent(),
+			ExpiresAt: expiresAt,
+		}
+
+		if err := s.sessions.Create(req.Context(), model, boil.Whitelist(
+			models.SessionColumns.UserID,
+			models.SessionColumns.Hash,
+			models.SessionColumns.Data,
+			models.SessionColumns.IP,
+			models.SessionColumns.UserAgent,
+			models.SessionColumns.ExpiresAt,
+		)); err != nil {
+			return errors.Wrap(err, "failed to create session")
+		}
+
+		session.ID = secret
+	} else {
+		model.Data = data
+		model.ExpiresAt = expiresAt
+		model.LastSeenAt = time.Now()
+
+		if err := s.sessions.Update(req.Context(), model, boil.Whitelist(
+			models.SessionColumns.Data,
+			models.SessionColumns.ExpiresAt,
+			models.SessionColumns.LastSeenAt,
+		)); err != nil {
+			return errors.Wrap(err, "failed to update session")
+		}
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), session.ID, &options))
+
+	return nil
+}
+
+// sessionUser returns the ID of the user a session belongs to, and whether they still have to send a code to be logged in
+func sessionUser(values map[interface{}]interface{}) (int64, bool) {
+	if uid, ok := values[SESSION_KEY_ID].(int64); ok {
+		return uid, false
+	}
+
+	uid, _ := values[SESSION_KEY_PENDING_ID].(int64)
+
+	return uid, uid != 0
+}
+
+// storedPending checks if a stored session was still waiting for a code, sessions that can't be decoded count as waiting
+func storedPending(model *models.Session) bool {
+	values := map[interface{}]interface{}{}
+
+	if err := (securecookie.GobEncoder{}).Deserialize(model.Data, &values); err != nil {
+		return true
+	}
+
+	_, pending := sessionUser(values)
+
+	return pending
+}
+
+func newSessionSecret() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate session")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sessionHash is the hash of the session a request was made with, requests without one have an empty hash
+func (r *Routes) sessionHash(req *http.Request) string {
+	session, err := r.store.Get(req, SESSION_NAME)
+
+	if err != nil || session.ID == "" {
+		return ""
+	}
+
+	return hashTokenSecret(session.ID)
+}
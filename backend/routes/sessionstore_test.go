@@ -0,0 +1,231 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"webserver/models"
+	"webserver/services/mock"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// memorySessions keeps sessions in a map, the way the database would
+type memorySessions struct {
+	lock     sync.Mutex
+	nextID   int64
+	sessions map[string]*models.Session
+}
+
+func newMemorySessions() (*memorySessions, *mock.SessionsProvider) {
+	m := &memorySessions{sessions: make(map[string]*models.Session)}
+
+	return m, &mock.SessionsProvider{
+		FindHashHook: func(ctx context.Context, hash string) (*models.Session, error) {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			if session, ok := m.sessions[hash]; ok {
+				copied := *session
+				return &copied, nil
+			}
+
+			return nil, sql.ErrNoRows
+		},
+		FindManyHook: func(ctx context.Context, uid int64) (models.SessionSlice, error) {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			var found models.SessionSlice
+
+			for _, session := range m.sessions {
+				if session.UserID == uid {
+					found = append(found, session)
+				}
+			}
+
+			return found, nil
+		},
+		CreateHook: func(ctx context.Context, session *models.Session, columns boil.Columns) error {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			m.nextID++
+			session.ID = m.nextID
+			session.CreatedAt = time.Now()
+			session.LastSeenAt = time.Now()
+
+			copied := *session
+			m.sessions[session.Hash] = &copied
+
+			return nil
+		},
+		UpdateHook: func(ctx context.Context, session *models.Session, columns boil.Columns) error {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			copied := *session
+			m.sessions[session.Hash] = &copied
+
+			return nil
+		},
+		DeleteHook: func(ctx context.Context, uid int64, id int64) (bool, error) {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			for hash, session := range m.sessions {
+				if session.ID == id && session.UserID == uid {
+					delete(m.sessions, hash)
+					return true, nil
+				}
+			}
+
+			return false, nil
+		},
+		DeleteHashHook: func(ctx context.Context, hash string) error {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			delete(m.sessions, hash)
+
+			return nil
+		},
+		DeleteAllHook: func(ctx context.Context, uid int64, except string) (int64, error) {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+
+			var deleted int64
+
+			for hash, session := range m.sessions {
+				if session.UserID == uid && hash != except {
+					delete(m.sessions, hash)
+					deleted++
+				}
+			}
+
+			return deleted, nil
+		},
+	}
+}
+
+func (m *memorySessions) len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.sessions)
+}
+
+// sessionRequest makes a request carrying the cookies a previous response set
+func sessionRequest(cookies []*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "test")
+
+	for _, cookie := range cookies {
+		if cookie.MaxAge >= 0 {
+			req.AddCookie(cookie)
+		}
+	}
+
+	return req
+}
+
+func TestSessionStore(t *testing.T) {
+	memory, provider := newMemorySessions()
+	store := NewSessionStore(provider, &sessions.Options{Path: "/", MaxAge: 3600, HttpOnly: true})
+
+	// Sessions nobody is logged in to aren't stored
+	req := sessionRequest(nil)
+	session, err := store.Get(req, SESSION_NAME)
+	require.NoError(t, err)
+	assert.True(t, session.IsNew)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, store.Save(req, rec, session))
+	assert.Equal(t, 0, memory.len())
+
+	// Logging in stores the session, the cookie has the secret and the database its hash
+	session.Values[SESSION_KEY_ID] = int64(1)
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, store.Save(req, rec, session))
+	require.Equal(t, 1, memory.len())
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	stored, err := provider.FindHash(context.Background(), hashTokenSecret(cookies[0].Value))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.UserID)
+	assert.Equal(t, "192.0.2.1", stored.IP)
+	assert.Equal(t, "test", stored.UserAgent)
+	assert.NotContains(t, stored.Hash, cookies[0].Value)
+
+	// The next request is logged in
+	session, err = store.Get(sessionRequest(cookies), SESSION_NAME)
+	require.NoError(t, err)
+	assert.False(t, session.IsNew)
+	assert.Equal(t, int64(1), session.Values[SESSION_KEY_ID])
+
+	// Logging in to another account starts over with a new secret
+	req = sessionRequest(cookies)
+	session, err = store.Get(req, SESSION_NAME)
+	require.NoError(t, err)
+	session.Values[SESSION_KEY_ID] = int64(2)
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, store.Save(req, rec, session))
+	require.Equal(t, 1, memory.len())
+
+	switched := rec.Result().Cookies()
+	require.Len(t, switched, 1)
+	assert.NotEqual(t, cookies[0].Value, switched[0].Value)
+
+	session, err = store.Get(sessionRequest(cookies), SESSION_NAME)
+	require.NoError(t, err)
+	assert.True(t, session.IsNew, "the old secret shouldn't be valid anymore")
+
+	// Logging out deletes it
+	req = sessionRequest(switched)
+	session, err = store.Get(req, SESSION_NAME)
+	require.NoError(t, err)
+	session.Options.MaxAge = -1
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, store.Save(req, rec, session))
+	assert.Equal(t, 0, memory.len())
+	assert.Equal(t, -1, rec.Result().Cookies()[0].MaxAge)
+
+	session, err = store.Get(sessionRequest(switched), SESSION_NAME)
+	require.NoError(t, err)
+	assert.True(t, session.IsNew)
+}
+
+func TestSessionStore_Expired(t *testing.T) {
+	memory, provider := newMemorySessions()
+	store := NewSessionStore(provider, &sessions.Options{Path: "/", MaxAge: 3600})
+
+	req := sessionRequest(nil)
+	session, err := store.Get(req, SESSION_NAME)
+	require.NoError(t, err)
+	session.Values[SESSION_KEY_ID] = int64(1)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, store.Save(req, rec, session))
+
+	for _, stored := range memory.sessions {
+		stored.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+
+	session, err = store.Get(sessionRequest(rec.Result().Cookies()), SESSION_NAME)
+	require.NoError(t, err)
+	assert.True(t, session.IsNew)
+	assert.Empty(t, session.Values)
+}
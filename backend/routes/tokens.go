@@ -0,0 +1,226 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+	"webserver/models"
+	"webserver/modelsx"
+
+	"github.com/friendsofgo/errors"
+	"github.com/gorilla/mux"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// tokenPrefix marks the secrets of personal access tokens, so they're easy to spot in logs and secret scanners
+const tokenPrefix = "clp_"
+
+// tokenLastUsedInterval is how stale a token's last use can get before a request records it again,
+// so scripts hammering the API don't turn every request into a write
+const tokenLastUsedInterval = time.Minute
+
+// newTokenSecret generates the secret of a personal access token, along with the hash that's stored in its place
+// and the start of the secret that's kept so users can tell their tokens apart
+func newTokenSecret() (secret, hash, prefix string, err error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", errors.Wrap(err, "failed to generate token")
+	}
+
+	secret = tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return secret, hashTokenSecret(secret), secret[:len(tokenPrefix)+6], nil
+}
+
+// hashTokenSecret is how tokens are looked up, the secrets have enough entropy that a plain SHA-256 is enough
+func hashTokenSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// bearerToken returns the secret in the Authorization header of a request, if it has one
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, secret, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	secret = strings.TrimSpace(secret)
+
+	return secret, secret != ""
+}
+
+// authenticateToken finds the user a bearer token belongs to
+// A nil user without an error means the token doesn't exist or has expired
+func (r *Routes) authenticateToken(ctx context.Context, secret string) (*models.User, *models.Token, error) {
+	token, err := r.Tokens.FindHash(ctx, hashTokenSecret(secret))
+
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to find token")
+	}
+
+	if token.ExpiresAt.Valid && !token.ExpiresAt.Time.After(time.Now()) {
+		return nil, nil, nil
+	}
+
+	user, err := r.Users.Find(ctx, token.UserID)
+
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to find token user")
+	}
+
+	// Disabling an account also stops its tokens from working
+	if user.Disabled {
+		return nil, nil, nil
+	}
+
+	if !token.LastUsedAt.Valid || time.Since(token.LastUsedAt.Time) > tokenLastUsedInterval {
+		token.LastUsedAt = null.TimeFrom(time.Now())
+
+		if err := r.Tokens.Update(ctx, token, boil.Whitelist(models.TokenColumns.LastUsedAt)); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to record token use")
+		}
+	}
+
+	return user, token, nil
+}
+
+// requiredScope is the scope a token needs for a request
+// Everything that's part of uploading a clip needs upload, other requests that only read need read and the rest need manage
+func requiredScope(req *http.Request) string {
+	var path string
+
+	if route := mux.CurrentRoute(req); route != nil {
+		path, _ = route.GetPathTemplate()
+	}
+
+	path = strings.TrimPrefix(path, "/api")
+
+	switch {
+	case path == "/uploads" || strings.HasPrefix(path, "/uploads/"):
+		return modelsx.ScopeUpload
+	case req.Method == http.MethodPost && (path == "/clips" || path == "/clips/import"):
+		return modelsx.ScopeUpload
+	case req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions:
+		return modelsx.ScopeRead
+	default:
+		return modelsx.ScopeManage
+	}
+}
+
+// requestToken returns the token a request was authenticated with, nil for requests with a session
+func requestToken(req *http.Request) *models.Token {
+	token, _ := req.Context().Value(TokenKey).(*models.Token)
+	return token
+}
+
+// GetTokens lists the personal access tokens of the requesting user, without their secrets
+//
+// GET /users/me/tokens
+func (r *Routes) GetTokens(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	// Tokens are managed with a session, so a leaked token can't be used to mint more of them
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	tokens, err := r.Tokens.FindMany(req.Context(), user.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to find tokens")
+	}
+
+	return modelsx.TokenFromModelBatch(tokens...).Marshal()
+}
+
+// CreateToken creates a personal access token for the requesting user
+//
+// # Success returns the token with its secret, which is never shown again
+//
+// POST /users/me/tokens
+func (r *Routes) CreateToken(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	tokenx, err := modelsx.ParseToken(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	secret, hash, prefix, err := newTokenSecret()
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	model := tokenx.ToModel()
+	model.UserID = user.ID
+	model.Hash = hash
+	model.Prefix = prefix
+
+	columns := []string{
+		models.TokenColumns.UserID,
+		models.TokenColumns.Name,
+		models.TokenColumns.Hash,
+		models.TokenColumns.Prefix,
+		models.TokenColumns.Scopes,
+		models.TokenColumns.ExpiresAt,
+	}
+
+	if err := r.Tokens.Create(req.Context(), model, boil.Whitelist(columns...)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to create token")
+	}
+
+	created := modelsx.TokenFromModel(model)
+	created.Secret = secret
+
+	return created.Marshal()
+}
+
+// DeleteToken revokes a personal access token of the requesting user
+//
+// DELETE /users/me/tokens/{token id}
+func (r *Routes) DeleteToken(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	deleted, err := r.Tokens.Delete(req.Context(), user.ID, vars(req).TKID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete token")
+	}
+
+	// Tokens of other users are as good as missing
+	if !deleted {
+		return http.StatusNotFound, nil, nil
+	}
+
+	return http.StatusNoContent, nil, nil
+}
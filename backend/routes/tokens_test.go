@@ -0,0 +1,281 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestRoutes_CreateToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *models.User
+		token    *models.Token
+		payload  string
+		expected int
+		hasBody  bool
+		hasError bool
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusOK,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			payload:  `{"name": "CI", "scopes": ["upload", "read"]}`,
+		},
+		{
+			name:     "Handle unknown scope",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			payload:  `{"name": "CI", "scopes": ["admin"]}`,
+		},
+		{
+			name:     "Handle missing scopes",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			payload:  `{"name": "CI"}`,
+		},
+		{
+			name:     "Handle expiry in the past",
+			expected: http.StatusBadRequest,
+			hasBody:  true,
+			user:     &models.User{ID: 1},
+			payload:  `{"name": "CI", "scopes": ["read"], "expires_at": "2000-01-01T00:00:00Z"}`,
+		},
+		{
+			name:     "Deny tokens creating tokens",
+			expected: http.StatusForbidden,
+			user:     &models.User{ID: 1},
+			token:    &models.Token{ID: 1, UserID: 1, Scopes: "manage"},
+			payload:  `{"name": "CI", "scopes": ["read"]}`,
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			payload:  `{"name": "CI", "scopes": ["read"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored *models.Token
+
+			r := &Routes{
+				Group: &services.Group{
+					Tokens: &mock.TokensProvider{
+						CreateHook: func(ctx context.Context, token *models.Token, columns boil.Columns) error {
+							stored = token
+							return nil
+						},
+					},
+				},
+			}
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.payload))
+
+			if tt.token != nil {
+				req = req.WithContext(context.WithValue(req.Context(), TokenKey, tt.token))
+			}
+
+			code, body, err := r.CreateToken(tt.user, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if (body != nil) != tt.hasBody {
+				t.Errorf("Received unexpected body during %s test.", tt.name)
+			}
+
+			if (err != nil) != tt.hasError {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+
+			if code != http.StatusOK {
+				return
+			}
+
+			created := struct {
+				Token  string   `json:"token"`
+				Prefix string   `json:"prefix"`
+				Scopes []string `json:"scopes"`
+			}{}
+
+			require.NoError(t, jsoniter.Unmarshal(body, &created))
+			require.NotNil(t, stored)
+
+			// Only the hash of the secret is stored, the response is the one place the secret itself shows up
+			assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+			assert.Equal(t, hashTokenSecret(created.Token), stored.Hash)
+			assert.NotContains(t, stored.Hash, created.Token)
+			assert.Equal(t, int64(1), stored.UserID)
+			assert.Equal(t, "upload read", stored.Scopes)
+			assert.Equal(t, []string{"upload", "read"}, created.Scopes)
+		})
+	}
+}
+
+func TestRoutes_DeleteToken(t *testing.T) {
+	tokens := &mock.TokensProvider{
+		DeleteHook: func(ctx context.Context, uid int64, id int64) (bool, error) {
+			if id == 3 {
+				return false, sql.ErrConnDone
+			}
+
+			return uid == 1 && id == 1, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		vars     *RouteVars
+		expected int
+		hasError bool
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusNoContent,
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{TKID: 1},
+		},
+		{
+			name:     "Handle token of another user",
+			expected: http.StatusNotFound,
+			user:     &models.User{ID: 2},
+			vars:     &RouteVars{TKID: 1},
+		},
+		{
+			name:     "Handle delete error",
+			expected: http.StatusInternalServerError,
+			hasError: true,
+			user:     &models.User{ID: 1},
+			vars:     &RouteVars{TKID: 3},
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			vars:     &RouteVars{TKID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Routes{
+				Group: &services.Group{Tokens: tokens},
+			}
+
+			req := httptest.NewRequest("DELETE", "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), VarKey, tt.vars))
+
+			code, _, err := r.DeleteToken(tt.user, req)
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+
+			if (err != nil) != tt.hasError {
+				t.Errorf("Received unexpected error during %s test.", tt.name)
+			}
+		})
+	}
+}
+
+func TestRoutes_BearerToken(t *testing.T) {
+	tokens := map[string]*models.Token{
+		"clp_uploader": {ID: 1, UserID: 1, Scopes: "upload"},
+		"clp_reader":   {ID: 2, UserID: 1, Scopes: "read", LastUsedAt: null.TimeFrom(time.Now())},
+		"clp_expired":  {ID: 3, UserID: 1, Scopes: "upload read manage", ExpiresAt: null.TimeFrom(time.Now().Add(-time.Hour))},
+		"clp_disabled": {ID: 4, UserID: 2, Scopes: "upload read manage"},
+	}
+
+	var used []int64
+
+	r := &Routes{
+		store: sessions.NewCookieStore([]byte("key")),
+		Group: &services.Group{
+			Users: &mock.UserProvider{
+				FindHook: func(ctx context.Context, uid int64) (*models.User, error) {
+					// The second user's account was disabled
+					return &models.User{ID: uid, Disabled: uid == 2}, nil
+				},
+			},
+			Tokens: &mock.TokensProvider{
+				FindHashHook: func(ctx context.Context, hash string) (*models.Token, error) {
+					for secret, token := range tokens {
+						if hashTokenSecret(secret) == hash {
+							copied := *token
+							return &copied, nil
+						}
+					}
+
+					return nil, sql.ErrNoRows
+				},
+				UpdateHook: func(ctx context.Context, token *models.Token, columns boil.Columns) error {
+					used = append(used, token.ID)
+					return nil
+				},
+			},
+		},
+	}
+
+	handler := func(user *models.User, req *http.Request) (int, []byte, error) {
+		if user == nil {
+			return http.StatusUnauthorized, nil, nil
+		}
+
+		return http.StatusOK, nil, nil
+	}
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.Handle("/clips", r.Handler(handler)).Methods(http.MethodPost, http.MethodGet)
+	api.Handle("/clips/{cid}", r.Handler(handler)).Methods(http.MethodDelete)
+
+	tests := []struct {
+		name          string
+		authorization string
+		method        string
+		path          string
+		expected      int
+	}{
+		{"Upload with upload scope", "Bearer clp_uploader", http.MethodPost, "/api/clips", http.StatusOK},
+		{"Deny read with upload scope", "Bearer clp_uploader", http.MethodGet, "/api/clips", http.StatusForbidden},
+		{"Read with read scope", "bearer clp_reader", http.MethodGet, "/api/clips", http.StatusOK},
+		{"Deny delete with read scope", "Bearer clp_reader", http.MethodDelete, "/api/clips/abcd", http.StatusForbidden},
+		{"Deny expired token", "Bearer clp_expired", http.MethodGet, "/api/clips", http.StatusUnauthorized},
+		{"Deny token of disabled user", "Bearer clp_disabled", http.MethodGet, "/api/clips", http.StatusUnauthorized},
+		{"Deny unknown token", "Bearer clp_unknown", http.MethodGet, "/api/clips", http.StatusUnauthorized},
+		{"Fall back to the session without a bearer token", "Basic dXNlcjpwYXNz", http.MethodGet, "/api/clips", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", tt.authorization)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+
+	// The reader token was used a moment ago, so only the uploader's use is recorded
+	assert.Equal(t, []int64{1, 1}, used)
+}
@@ -0,0 +1,306 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services/totp"
+
+	"github.com/friendsofgo/errors"
+	"github.com/gorilla/mux"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// maxTOTPAttempts is how many wrong codes in a row lock a user out for TOTP.Lockout, whichever sessions they're sent with
+const maxTOTPAttempts = 5
+
+// LoginTOTP finishes the login of a user who sent the right password, with a code of their authenticator app or a recovery code
+//
+// POST /auth/login/totp
+func (r *Routes) LoginTOTP(resp http.ResponseWriter, req *http.Request) (int, []byte, error) {
+	session, _ := r.store.Get(req, SESSION_NAME)
+
+	uid, pending := sessionUser(session.Values)
+
+	if !pending {
+		return http.StatusUnauthorized, []byte("No login to finish"), nil
+	}
+
+	code, err := modelsx.ParseTOTPCode(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	user, err := r.Users.Find(req.Context(), uid)
+
+	if err == sql.ErrNoRows {
+		return http.StatusUnauthorized, []byte("No login to finish"), nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to find user")
+	}
+
+	if user.Disabled {
+		return http.StatusForbidden, []byte("Account is disabled"), nil
+	}
+
+	if user.TotpLockedUntil.Valid && user.TotpLockedUntil.Time.After(time.Now()) {
+		return totpLockedOut(resp, user.TotpLockedUntil.Time)
+	}
+
+	ok, err := r.useTOTP(req.Context(), user, code)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if !ok {
+		lockedUntil, err := r.Users.FailTOTP(req.Context(), user.ID, maxTOTPAttempts, r.cfg.TOTP.Lockout)
+
+		if err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to count wrong code")
+		}
+
+		if lockedUntil.After(time.Now()) {
+			// Guessing has to start over with the password once the lockout is over
+			session.Options.MaxAge = -1
+
+			if err := session.Save(req, resp); err != nil {
+				return http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to save session")
+			}
+
+			return totpLockedOut(resp, lockedUntil)
+		}
+
+		return http.StatusUnauthorized, []byte("Invalid code"), nil
+	}
+
+	delete(session.Values, SESSION_KEY_PENDING_ID)
+
+	session.Values[SESSION_KEY_ID] = user.ID
+	if err := session.Save(req, resp); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "Failed to save session")
+	}
+
+	return modelsx.UserFromModel(user).Marshal()
+}
+
+func totpLockedOut(resp http.ResponseWriter, until time.Time) (int, []byte, error) {
+	resp.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+	return http.StatusTooManyRequests, []byte("Too many wrong codes, try again later"), nil
+}
+
+// EnrollTOTP generates a new secret for the requesting user to set up their authenticator app with
+// Two-factor authentication isn't enabled until a code of it is confirmed
+//
+// POST /users/me/totp
+func (r *Routes) EnrollTOTP(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	// Like sessions, two-factor authentication is managed with a session
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	if user.TotpEnabled {
+		return http.StatusConflict, []byte("Two-factor authentication is already enabled"), nil
+	}
+
+	key, err := totp.Generate(r.cfg.TOTP.Issuer, user.Username)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	user.TotpSecret = null.StringFrom(key.Secret)
+
+	if err := r.Users.Update(req.Context(), user, boil.Whitelist(models.UserColumns.TotpSecret)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to update user")
+	}
+
+	return (&modelsx.TOTPKey{Secret: key.Secret, URI: key.URI}).Marshal()
+}
+
+// ConfirmTOTP enables two-factor authentication once the requesting user sends a code of their newly set up authenticator app
+// The recovery codes are only ever sent in the response, every other session of the user is logged out
+//
+// POST /users/me/totp/confirm
+func (r *Routes) ConfirmTOTP(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	if user.TotpEnabled {
+		return http.StatusConflict, []byte("Two-factor authentication is already enabled"), nil
+	}
+
+	if !user.TotpSecret.Valid {
+		return http.StatusConflict, []byte("Two-factor authentication has to be set up first"), nil
+	}
+
+	code, err := modelsx.ParseTOTPCode(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	step, ok := totp.Validate(user.TotpSecret.String, code.Code.String, time.Now(), 0)
+
+	if !ok {
+		return http.StatusBadRequest, []byte("Invalid code"), nil
+	}
+
+	codes, hashes, err := totp.NewRecoveryCodes(totp.RecoveryCodes)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	user.TotpEnabled = true
+	user.TotpLastStep = step
+	user.TotpRecoveryCodes = hashes
+
+	if err := r.Users.Update(req.Context(), user, boil.Whitelist(
+		models.UserColumns.TotpEnabled,
+		models.UserColumns.TotpLastStep,
+		models.UserColumns.TotpRecoveryCodes,
+	)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to update user")
+	}
+
+	// Sessions that were logged in with just the password don't get to skip the code
+	if _, err := r.Sessions.DeleteAll(req.Context(), user.ID, r.sessionHash(req)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete sessions")
+	}
+
+	return (&modelsx.TOTPRecoveryCodes{Codes: codes}).Marshal()
+}
+
+// DisableTOTP turns two-factor authentication of the requesting user off, it takes a code or recovery code to do so
+//
+// DELETE /users/me/totp
+func (r *Routes) DisableTOTP(user *models.User, req *http.Request) (int, []byte, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil
+	}
+
+	if requestToken(req) != nil {
+		return http.StatusForbidden, nil, nil
+	}
+
+	if !user.TotpEnabled {
+		return http.StatusConflict, []byte("Two-factor authentication isn't enabled"), nil
+	}
+
+	if r.cfg.TOTP.Required {
+		return http.StatusForbidden, []byte("Two-factor authentication is required"), nil
+	}
+
+	code, err := modelsx.ParseTOTPCode(req.Body)
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil
+	}
+
+	ok, err := r.useTOTP(req.Context(), user, code)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	if !ok {
+		return http.StatusBadRequest, []byte("Invalid code"), nil
+	}
+
+	user.TotpSecret = null.String{}
+	user.TotpEnabled = false
+	user.TotpRecoveryCodes = ""
+	user.TotpLastStep = 0
+
+	if err := r.Users.Update(req.Context(), user, boil.Whitelist(
+		models.UserColumns.TotpSecret,
+		models.UserColumns.TotpEnabled,
+		models.UserColumns.TotpRecoveryCodes,
+		models.UserColumns.TotpLastStep,
+	)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to update user")
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// useTOTP checks a code or recovery code of a user with two-factor authentication enabled
+// Codes that worked are recorded, so neither kind can be used twice
+func (r *Routes) useTOTP(ctx context.Context, user *models.User, code *modelsx.TOTPCode) (bool, error) {
+	if !user.TotpEnabled || !user.TotpSecret.Valid {
+		return false, nil
+	}
+
+	var column string
+
+	if code.Code.Valid {
+		step, ok := totp.Validate(user.TotpSecret.String, code.Code.String, time.Now(), user.TotpLastStep)
+
+		if !ok {
+			return false, nil
+		}
+
+		user.TotpLastStep = step
+		column = models.UserColumns.TotpLastStep
+	} else {
+		remaining, ok := totp.UseRecoveryCode(user.TotpRecoveryCodes, code.RecoveryCode.String)
+
+		if !ok {
+			return false, nil
+		}
+
+		user.TotpRecoveryCodes = remaining
+		column = models.UserColumns.TotpRecoveryCodes
+	}
+
+	// A right code is what ends a run of wrong ones
+	user.TotpFailedAttempts = 0
+
+	if err := r.Users.Update(ctx, user, boil.Whitelist(column, models.UserColumns.TotpFailedAttempts)); err != nil {
+		return false, errors.Wrap(err, "failed to record code use")
+	}
+
+	return true, nil
+}
+
+// needsTOTP checks if user has to set up two-factor authentication before they can make a request
+// Logging in and out and managing their own account is all they can do until then
+func (r *Routes) needsTOTP(user *models.User, req *http.Request) bool {
+	if r.cfg == nil || !r.cfg.TOTP.Required || user == nil || user.TotpEnabled {
+		return false
+	}
+
+	var path string
+
+	if route := mux.CurrentRoute(req); route != nil {
+		path, _ = route.GetPathTemplate()
+	}
+
+	path = strings.TrimPrefix(path, "/api")
+
+	switch {
+	case strings.HasPrefix(path, "/auth/"):
+		return false
+	case path == "/users/me" || path == "/users/me/totp" || strings.HasPrefix(path, "/users/me/totp/") || strings.HasPrefix(path, "/users/me/sessions"):
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,375 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/totp"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
+	jsoniter "github.com/json-iterator/go"
+	gototp "github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := gototp.GenerateCode(secret, at)
+	require.NoError(t, err)
+
+	return code
+}
+
+// totpRequest makes a POST request with body, carrying the cookies a previous response set
+func totpRequest(cookies []*http.Cookie, body string) *http.Request {
+	req := sessionRequest(cookies)
+	req.Method = http.MethodPost
+	req.Body = io.NopCloser(strings.NewReader(body))
+
+	return req
+}
+
+// newTOTPRoutes keeps users in memory, updates are applied to them the way the database would
+func newTOTPRoutes(users ...*models.User) (*Routes, *memorySessions) {
+	memory, provider := newMemorySessions()
+
+	find := func(match func(*models.User) bool) (*models.User, error) {
+		for _, user := range users {
+			if match(user) {
+				copied := *user
+				return &copied, nil
+			}
+		}
+
+		return nil, sql.ErrNoRows
+	}
+
+	cfg := &config.Config{}
+	cfg.TOTP.Issuer = "Clipable"
+	cfg.TOTP.Lockout = time.Minute
+
+	return &Routes{
+		cfg:   cfg,
+		store: NewSessionStore(provider, &sessions.Options{Path: "/", MaxAge: 3600}),
+		Group: &services.Group{
+			Sessions: provider,
+			Users: &mock.UserProvider{
+				FindHook: func(ctx context.Context, uid int64) (*models.User, error) {
+					return find(func(u *models.User) bool { return u.ID == uid })
+				},
+				FindUsernameHook: func(ctx context.Context, username string) (*models.User, error) {
+					return find(func(u *models.User) bool { return u.Username == username })
+				},
+				UpdateHook: func(ctx context.Context, updated *models.User, columns boil.Columns) error {
+					for _, user := range users {
+						if user.ID == updated.ID {
+							*user = *updated
+						}
+					}
+
+					return nil
+				},
+				FailTOTPHook: func(ctx context.Context, uid int64, maxAttempts int, lockout time.Duration) (time.Time, error) {
+					for _, user := range users {
+						if user.ID != uid {
+							continue
+						}
+
+						user.TotpFailedAttempts++
+
+						if user.TotpFailedAttempts >= maxAttempts {
+							user.TotpFailedAttempts = 0
+							user.TotpLockedUntil = null.TimeFrom(time.Now().Add(lockout))
+						}
+
+						return user.TotpLockedUntil.Time, nil
+					}
+
+					return time.Time{}, sql.ErrNoRows
+				},
+			},
+		},
+	}, memory
+}
+
+func TestRoutes_LoginTOTP(t *testing.T) {
+	hash, err := argon2id.CreateHash("password", argon2id.DefaultParams)
+	require.NoError(t, err)
+
+	recovery, hashes, err := totp.NewRecoveryCodes(2)
+	require.NoError(t, err)
+
+	user := &models.User{
+		ID:                1,
+		Username:          "alice",
+		Password:          hash,
+		TotpSecret:        null.StringFrom(testTOTPSecret),
+		TotpEnabled:       true,
+		TotpRecoveryCodes: hashes,
+	}
+
+	r, memory := newTOTPRoutes(user)
+
+	login := func() []*http.Cookie {
+		rec := httptest.NewRecorder()
+		code, _, err := r.Login(rec, totpRequest(nil, `{"username": "alice", "password": "password"}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, code)
+
+		return rec.Result().Cookies()
+	}
+
+	// The password alone only gets a short-lived session that isn't logged in
+	pending := login()
+	require.Len(t, pending, 1)
+	assert.Equal(t, pendingSessionMaxAge, pending[0].MaxAge)
+
+	for _, stored := range memory.sessions {
+		assert.WithinDuration(t, time.Now().Add(pendingSessionMaxAge*time.Second), stored.ExpiresAt, time.Minute)
+	}
+
+	session, err := r.store.Get(sessionRequest(pending), SESSION_NAME)
+	require.NoError(t, err)
+	assert.NotContains(t, session.Values, SESSION_KEY_ID)
+	assert.Equal(t, int64(1), session.Values[SESSION_KEY_PENDING_ID])
+
+	code, _, err := r.LoginTOTP(httptest.NewRecorder(), totpRequest(pending, fmt.Sprintf(`{"code": %q}`, totpCode(t, testTOTPSecret, time.Now().Add(-time.Hour)))))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// The right code finishes the login with a new secret
+	used := totpCode(t, testTOTPSecret, time.Now())
+
+	rec := httptest.NewRecorder()
+	code, _, err = r.LoginTOTP(rec, totpRequest(pending, fmt.Sprintf(`{"code": %q}`, used)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	loggedIn := rec.Result().Cookies()
+	require.Len(t, loggedIn, 1)
+	assert.NotEqual(t, pending[0].Value, loggedIn[0].Value)
+	assert.Equal(t, 3600, loggedIn[0].MaxAge)
+
+	session, err = r.store.Get(sessionRequest(loggedIn), SESSION_NAME)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), session.Values[SESSION_KEY_ID])
+	assert.NotContains(t, session.Values, SESSION_KEY_PENDING_ID)
+
+	session, err = r.store.Get(sessionRequest(pending), SESSION_NAME)
+	require.NoError(t, err)
+	assert.True(t, session.IsNew, "the pending secret shouldn't be valid anymore")
+
+	// Codes only work once
+	pending = login()
+
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(pending, fmt.Sprintf(`{"code": %q}`, used)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// So do recovery codes
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(pending, fmt.Sprintf(`{"recovery_code": %q}`, recovery[1])))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	pending = login()
+
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(pending, fmt.Sprintf(`{"recovery_code": %q}`, recovery[1])))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	assert.Len(t, strings.Fields(user.TotpRecoveryCodes), 1)
+
+	// A right code starts the count of wrong ones over
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(pending, `{"code": "000000"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, 2, user.TotpFailedAttempts)
+
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(pending, fmt.Sprintf(`{"recovery_code": %q}`, recovery[0])))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+	assert.Zero(t, user.TotpFailedAttempts)
+
+	// Too many wrong codes lock the user out, however many logins they're spread over
+	for i := 1; i < maxTOTPAttempts; i++ {
+		code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(login(), `{"code": "000000"}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, code)
+	}
+
+	pending = login()
+
+	rec = httptest.NewRecorder()
+	code, _, err = r.LoginTOTP(rec, totpRequest(pending, `{"code": "000000"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// Even the right code is refused until the lockout is over
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(login(), fmt.Sprintf(`{"code": %q}`, totpCode(t, testTOTPSecret, time.Now().Add(30*time.Second)))))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+
+	user.TotpLockedUntil = null.TimeFrom(time.Now().Add(-time.Second))
+	user.TotpLastStep = 0
+
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(login(), fmt.Sprintf(`{"code": %q}`, totpCode(t, testTOTPSecret, time.Now()))))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Without a pending session there's nothing to finish
+	code, _, err = r.LoginTOTP(httptest.NewRecorder(), totpRequest(nil, fmt.Sprintf(`{"recovery_code": %q}`, recovery[0])))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestRoutes_EnrollTOTP(t *testing.T) {
+	user := &models.User{ID: 1, Username: "alice"}
+	r, memory := newTOTPRoutes(user)
+
+	current := func() *models.User {
+		copied := *user
+		return &copied
+	}
+
+	cookies := loggedIn(t, r, 1)
+	loggedIn(t, r, 1)
+
+	// Tokens can't set up two-factor authentication
+	req := totpRequest(cookies, "")
+	code, _, err := r.EnrollTOTP(current(), req.WithContext(context.WithValue(req.Context(), TokenKey, &models.Token{ID: 1, UserID: 1, Scopes: "manage"})))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _, err = r.ConfirmTOTP(current(), totpRequest(cookies, `{"code": "123456"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, body, err := r.EnrollTOTP(current(), totpRequest(cookies, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	key := struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}{}
+
+	require.NoError(t, jsoniter.Unmarshal(body, &key))
+	assert.Equal(t, key.Secret, user.TotpSecret.String)
+	assert.Contains(t, key.URI, "otpauth://totp/Clipable:alice")
+	assert.False(t, user.TotpEnabled, "it shouldn't be enabled before a code was confirmed")
+
+	code, _, err = r.ConfirmTOTP(current(), totpRequest(cookies, fmt.Sprintf(`{"code": %q}`, totpCode(t, key.Secret, time.Now().Add(-time.Hour)))))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.False(t, user.TotpEnabled)
+
+	code, body, err = r.ConfirmTOTP(current(), totpRequest(cookies, fmt.Sprintf(`{"code": %q}`, totpCode(t, key.Secret, time.Now()))))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	codes := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{}
+
+	require.NoError(t, jsoniter.Unmarshal(body, &codes))
+	assert.Len(t, codes.RecoveryCodes, totp.RecoveryCodes)
+	assert.True(t, user.TotpEnabled)
+	assert.NotZero(t, user.TotpLastStep)
+
+	// The other session was only logged in with a password
+	assert.Equal(t, 1, memory.len())
+
+	code, _, err = r.EnrollTOTP(current(), totpRequest(cookies, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// Admins requiring it keep it from being turned off
+	r.cfg.TOTP.Required = true
+
+	code, _, err = r.DisableTOTP(current(), totpRequest(cookies, fmt.Sprintf(`{"recovery_code": %q}`, codes.RecoveryCodes[0])))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	r.cfg.TOTP.Required = false
+
+	code, _, err = r.DisableTOTP(current(), totpRequest(cookies, `{"recovery_code": "aaaaaaaa-aaaaaaaa"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.True(t, user.TotpEnabled)
+
+	code, _, err = r.DisableTOTP(current(), totpRequest(cookies, fmt.Sprintf(`{"recovery_code": %q}`, codes.RecoveryCodes[0])))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.False(t, user.TotpEnabled)
+	assert.False(t, user.TotpSecret.Valid)
+	assert.Empty(t, user.TotpRecoveryCodes)
+}
+
+func TestRoutes_RequireTOTP(t *testing.T) {
+	r, _ := newTOTPRoutes(
+		&models.User{ID: 1, Username: "alice"},
+		&models.User{ID: 2, Username: "bob", TotpEnabled: true, TotpSecret: null.StringFrom(testTOTPSecret)},
+	)
+	r.cfg.TOTP.Required = true
+
+	handler := func(user *models.User, req *http.Request) (int, []byte, error) {
+		if user == nil {
+			return http.StatusUnauthorized, nil, nil
+		}
+
+		return http.StatusOK, nil, nil
+	}
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.Handle("/clips", r.Handler(handler)).Methods(http.MethodGet)
+	api.Handle("/users/me", r.Handler(handler)).Methods(http.MethodGet)
+	api.Handle("/users/me/totp", r.Handler(handler)).Methods(http.MethodPost)
+
+	alice := loggedIn(t, r, 1)
+	bob := loggedIn(t, r, 2)
+
+	tests := []struct {
+		name     string
+		cookies  []*http.Cookie
+		method   string
+		path     string
+		expected int
+	}{
+		{"Deny users without it", alice, http.MethodGet, "/api/clips", http.StatusForbidden},
+		{"Let users without it see their account", alice, http.MethodGet, "/api/users/me", http.StatusOK},
+		{"Let users without it set it up", alice, http.MethodPost, "/api/users/me/totp", http.StatusOK},
+		{"Let users with it through", bob, http.MethodGet, "/api/clips", http.StatusOK},
+		{"Leave requests without a user to the handler", nil, http.MethodGet, "/api/clips", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sessionRequest(tt.cookies)
+			req.Method = tt.method
+			req.URL.Path = tt.path
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,521 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/transcoder"
+
+	"github.com/friendsofgo/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// Resumable uploads implement tus 1.0 (https://tus.io/protocols/resumable-upload) with the creation, termination and expiration extensions
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+
+	// uploadPartPrefix is what the chunks of an upload are staged as in the ObjectStore, under the ID reserved for its clip
+	uploadPartPrefix = "upload-"
+)
+
+func uploadPartName(part int) string {
+	return fmt.Sprintf("%s%d", uploadPartPrefix, part)
+}
+
+// TusHandler wraps tus endpoints, rejecting clients speaking another version of the protocol and tagging every response with ours
+func (r *Routes) TusHandler(handler func(u *models.User, r *http.Request) (int, []byte, http.Header, error)) http.HandlerFunc {
+	return r.StreamHandler(func(u *models.User, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+		headers := http.Header{}
+		headers.Set("Tus-Resumable", tusVersion)
+
+		// OPTIONS is how clients find out which versions we support, so it's the only request that doesn't need to say which one it speaks
+		if req.Method != http.MethodOptions && req.Header.Get("Tus-Resumable") != tusVersion {
+			headers.Set("Tus-Version", tusVersion)
+			return http.StatusPreconditionFailed, nil, headers, nil
+		}
+
+		code, body, h, err := handler(u, req)
+
+		for k, v := range h {
+			headers[k] = v
+		}
+
+		var rc io.ReadCloser
+
+		if body != nil {
+			rc = io.NopCloser(bytes.NewReader(body))
+		}
+
+		return code, rc, headers, err
+	})
+}
+
+// GetUploadOptions advertises the tus version and extensions we support
+//
+// OPTIONS /uploads
+func (r *Routes) GetUploadOptions(user *models.User, req *http.Request) (int, []byte, http.Header, error) {
+	headers := http.Header{}
+	headers.Set("Tus-Version", tusVersion)
+	headers.Set("Tus-Extension", tusExtensions)
+	// Uploads of exactly MaxUploadSize are rejected, just like the multipart upload does
+	headers.Set("Tus-Max-Size", strconv.FormatInt(r.cfg.MaxUploadSizeBytes-1, 10))
+
+	return http.StatusNoContent, nil, headers, nil
+}
+
+// CreateUpload starts a resumable upload, the clip is described by the Upload-Metadata header
+// The clip only gets created once all of the video has been uploaded
+//
+// POST /uploads
+func (r *Routes) CreateUpload(user *models.User, req *http.Request) (int, []byte, http.Header, error) {
+	if user == nil {
+		return http.StatusUnauthorized, nil, nil, nil
+	}
+
+	if req.Header.Get("Upload-Defer-Length") != "" {
+		return http.StatusBadRequest, []byte("Deferred upload length is not supported"), nil, nil
+	}
+
+	length, err := strconv.ParseInt(req.Header.Get("Upload-Length"), 10, 64)
+
+	if err != nil || length <= 0 {
+		return http.StatusBadRequest, []byte("Invalid Upload-Length"), nil, nil
+	}
+
+	if length >= r.cfg.MaxUploadSizeBytes {
+		return http.StatusRequestEntityTooLarge, []byte("Video too large"), nil, nil
+	}
+
+	exceeded, err := r.exceedsQuota(req.Context(), user, length)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, err
+	}
+
+	if exceeded {
+		return http.StatusForbidden, []byte("Storage quota exceeded"), nil, nil
+	}
+
+	clip, err := modelsx.ParseClipMetadata(req.Header.Get("Upload-Metadata"))
+
+	if err != nil {
+		return http.StatusBadRequest, []byte(err.Error()), nil, nil
+	}
+
+	upload := &models.Upload{
+		UserID:       user.ID,
+		Title:        clip.Title,
+		Description:  clip.Description,
+		Unlisted:     clip.Unlisted.Bool,
+		UploadLength: length,
+		ExpiresAt:    time.Now().Add(r.cfg.Uploads.Expiry),
+	}
+
+	err = r.Uploads.Create(req.Context(), upload, boil.Whitelist(
+		models.UploadColumns.UserID,
+		models.UploadColumns.Title,
+		models.UploadColumns.Description,
+		models.UploadColumns.Unlisted,
+		models.UploadColumns.UploadLength,
+		models.UploadColumns.ExpiresAt,
+	))
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to create upload")
+	}
+
+	id, err := modelsx.HashEncode(upload.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to encode upload id")
+	}
+
+	headers := uploadHeaders(upload)
+	headers.Set("Location", "/api/uploads/"+id)
+
+	return http.StatusCreated, nil, headers, nil
+}
+
+// GetUploadOffset tells the client where to resume the upload from
+//
+// HEAD /uploads/{upload id}
+func (r *Routes) GetUploadOffset(user *models.User, req *http.Request) (int, []byte, http.Header, error) {
+	upload, code, err := r.findUpload(user, req)
+
+	if upload == nil {
+		return code, nil, nil, err
+	}
+
+	headers := uploadHeaders(upload)
+	headers.Set("Upload-Length", strconv.FormatInt(upload.UploadLength, 10))
+	headers.Set("Cache-Control", "no-store")
+
+	return http.StatusOK, nil, headers, nil
+}
+
+// PatchUpload appends the request body to the upload at Upload-Offset
+// Every request is staged as its own object, once the last one arrives they're joined into the clip's raw video
+//
+// PATCH /uploads/{upload id}
+func (r *Routes) PatchUpload(user *models.User, req *http.Request) (int, []byte, http.Header, error) {
+	if req.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		return http.StatusUnsupportedMediaType, nil, nil, nil
+	}
+
+	offset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+
+	if err != nil || offset < 0 {
+		return http.StatusBadRequest, []byte("Invalid Upload-Offset"), nil, nil
+	}
+
+	vars := vars(req)
+
+	// Two requests writing the same part would overwrite each other
+	if !r.uploading.SetIfAbsent(strconv.FormatInt(vars.UPID, 10), true) {
+		return http.StatusLocked, []byte("Upload is already in progress"), nil, nil
+	}
+
+	defer r.uploading.Remove(strconv.FormatInt(vars.UPID, 10))
+
+	upload, code, err := r.findUpload(user, req)
+
+	if upload == nil {
+		return code, nil, nil, err
+	}
+
+	if offset != upload.UploadOffset {
+		return http.StatusConflict, []byte("Upload-Offset does not match the upload"), uploadHeaders(upload), nil
+	}
+
+	if upload.Completed {
+		return http.StatusNoContent, nil, uploadHeaders(upload), nil
+	}
+
+	if req.ContentLength != 0 && upload.UploadOffset < upload.UploadLength {
+		body := &truncatingReader{r: req.Body}
+		part := io.LimitReader(body, upload.UploadLength-upload.UploadOffset)
+
+		// Refusing a file that isn't media on its first chunk saves uploading the rest of it
+		// Chunks too short to tell are left to the check once the upload is complete
+		if upload.UploadOffset == 0 {
+			var head []byte
+			part, head = peekHead(part)
+
+			if sniffContainer(head) == "" && (len(head) == sniffLength || int64(len(head)) == upload.UploadLength) {
+				code, body, err := unsupportedFormat().Marshal()
+				return code, body, nil, err
+			}
+		}
+
+		// A dropped connection cancels the request, what arrived before it is still stored so the client can resume after it
+		ctx := withoutCancel{req.Context()}
+		n, err := r.ObjectStore.PutObject(ctx, upload.ClipID, uploadPartName(upload.Parts), part)
+
+		if err != nil {
+			return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to store upload part")
+		}
+
+		if n > 0 {
+			upload.UploadOffset += n
+			upload.Parts++
+			upload.ExpiresAt = time.Now().Add(r.cfg.Uploads.Expiry)
+
+			err = r.Uploads.Update(ctx, upload, boil.Whitelist(
+				models.UploadColumns.UploadOffset,
+				models.UploadColumns.Parts,
+				models.UploadColumns.ExpiresAt,
+			))
+
+			// The part is overwritten by the next attempt, so there's nothing to clean up
+			if err != nil {
+				return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to update upload")
+			}
+		}
+
+		if e, ok := body.err.(net.Error); ok && e.Timeout() {
+			return http.StatusRequestTimeout, nil, uploadHeaders(upload), errors.Wrap(body.err, "upload timed out")
+		} else if body.err != nil {
+			return http.StatusInternalServerError, nil, uploadHeaders(upload), errors.Wrap(body.err, "failed to read upload part")
+		}
+	}
+
+	if upload.UploadOffset == upload.UploadLength {
+		if code, body, err := r.completeUpload(req.Context(), user, upload); code != http.StatusNoContent {
+			return code, body, nil, err
+		}
+	}
+
+	return http.StatusNoContent, nil, uploadHeaders(upload), nil
+}
+
+// completeUpload joins the parts of an upload into a clip and queues it for transcoding
+func (r *Routes) completeUpload(ctx context.Context, user *models.User, upload *models.Upload) (int, []byte, error) {
+	model := &models.Clip{
+		ID:          upload.ClipID,
+		Title:       upload.Title,
+		Description: upload.Description,
+		Unlisted:    upload.Unlisted,
+	}
+
+	columns := boil.Whitelist(
+		models.ClipColumns.ID,
+		models.ClipColumns.Title,
+		models.ClipColumns.Description,
+		models.ClipColumns.Unlisted,
+	)
+
+	parts := newPartsReader(ctx, r.ObjectStore, upload)
+	defer parts.Close()
+
+	// The upload counts with its full length until it's completed
+	clip, code, body, err := r.createClip(ctx, user, model, columns, parts, upload.UploadLength)
+
+	if clip == nil {
+		return code, body, err
+	}
+
+	staged := upload.ClipID
+
+	// Duplicates point the upload at the existing clip, so clients can still find it
+	upload.Completed = true
+	upload.ClipID = clip.ID
+
+	if err := r.Uploads.Update(ctx, upload, boil.Whitelist(models.UploadColumns.Completed, models.UploadColumns.ClipID)); err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to complete upload")
+	}
+
+	if err := r.ObjectStore.DeleteObjects(ctx, staged, uploadPartPrefix); err != nil {
+		log.WithError(err).WithField("upload", upload.ID).Warn("Failed to delete upload parts")
+	}
+
+	if clip != model {
+		return http.StatusNoContent, nil, nil
+	}
+
+	// The clip is still marked as processing, so the transcoder picks it up on the next start if this fails
+	if err := r.Transcoder.Queue(context.Background(), model); errors.Is(err, transcoder.ErrStopping) {
+		return http.StatusServiceUnavailable, []byte("Server is shutting down, the clip is processed once it's back"), nil
+	} else if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to queue clip for transcoding")
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// DeleteUpload cancels an upload, throwing away everything uploaded so far
+// Clips of completed uploads are left alone
+//
+// DELETE /uploads/{upload id}
+func (r *Routes) DeleteUpload(user *models.User, req *http.Request) (int, []byte, http.Header, error) {
+	vars := vars(req)
+
+	if !r.uploading.SetIfAbsent(strconv.FormatInt(vars.UPID, 10), true) {
+		return http.StatusLocked, []byte("Upload is in progress"), nil, nil
+	}
+
+	defer r.uploading.Remove(strconv.FormatInt(vars.UPID, 10))
+
+	upload, code, err := r.findUpload(user, req)
+
+	if upload == nil {
+		return code, nil, nil, err
+	}
+
+	if err := r.deleteUpload(req.Context(), upload); err != nil {
+		return http.StatusInternalServerError, nil, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil, nil
+}
+
+func (r *Routes) deleteUpload(ctx context.Context, upload *models.Upload) error {
+	if !upload.Completed {
+		if err := r.ObjectStore.DeleteObjects(ctx, upload.ClipID, uploadPartPrefix); err != nil {
+			return errors.Wrap(err, "failed to delete upload parts")
+		}
+	}
+
+	return errors.Wrap(r.Uploads.Delete(ctx, upload), "failed to delete upload")
+}
+
+// ExpireUploads periodically deletes uploads that haven't been touched in Uploads.Expiry, until ctx is done
+func (r *Routes) ExpireUploads(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		uploads, err := r.Uploads.FindExpired(ctx)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to find expired uploads")
+			continue
+		}
+
+		for _, upload := range uploads {
+			// An upload that's receiving a chunk right now gets its expiry extended once it's done
+			if !r.uploading.SetIfAbsent(strconv.FormatInt(upload.ID, 10), true) {
+				continue
+			}
+
+			if err := r.deleteUpload(ctx, upload); err != nil {
+				log.WithError(err).WithField("upload", upload.ID).Error("Failed to delete expired upload")
+			}
+
+			r.uploading.Remove(strconv.FormatInt(upload.ID, 10))
+		}
+	}
+}
+
+// findUpload finds the upload of the request, as long as it belongs to the user and hasn't expired
+// If no upload is returned, the status code and error describe why
+func (r *Routes) findUpload(user *models.User, req *http.Request) (*models.Upload, int, error) {
+	if user == nil {
+		return nil, http.StatusUnauthorized, nil
+	}
+
+	upload, err := r.Uploads.Find(req.Context(), vars(req).UPID)
+
+	if err == sql.ErrNoRows {
+		return nil, http.StatusNotFound, nil
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "failed to get upload")
+	}
+
+	// Other users' uploads are reported missing, so upload IDs can't be probed
+	if upload.UserID != user.ID {
+		return nil, http.StatusNotFound, nil
+	}
+
+	if !upload.Completed && upload.ExpiresAt.Before(time.Now()) {
+		return nil, http.StatusGone, nil
+	}
+
+	return upload, http.StatusOK, nil
+}
+
+// uploadHeaders describes the state of an upload, completed uploads say which clip they turned into
+func uploadHeaders(upload *models.Upload) http.Header {
+	headers := http.Header{}
+	headers.Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+
+	if upload.Completed {
+		if id, err := modelsx.HashEncode(upload.ClipID); err == nil {
+			headers.Set("Clip-ID", id)
+		}
+	} else {
+		headers.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+
+	return headers
+}
+
+// truncatingReader ends at the first error of r as if it was the end of the data, keeping the error for later
+// The ObjectStore throws away objects whose reader fails, this way it keeps whatever was read before
+type truncatingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (t *truncatingReader) Read(b []byte) (int, error) {
+	if t.err != nil {
+		return 0, io.EOF
+	}
+
+	n, err := t.r.Read(b)
+
+	if err != nil && err != io.EOF {
+		t.err = err
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// withoutCancel keeps the values of a context but not its deadline or cancellation
+type withoutCancel struct {
+	context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (withoutCancel) Done() <-chan struct{} {
+	return nil
+}
+
+func (withoutCancel) Err() error {
+	return nil
+}
+
+// partsReader reads the staged parts of an upload one after another, only keeping one of them open at a time
+type partsReader struct {
+	ctx     context.Context
+	store   services.ObjectStore
+	upload  *models.Upload
+	next    int
+	current io.ReadCloser
+}
+
+func newPartsReader(ctx context.Context, store services.ObjectStore, upload *models.Upload) *partsReader {
+	return &partsReader{ctx: ctx, store: store, upload: upload}
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.current == nil {
+			if p.next == p.upload.Parts {
+				return 0, io.EOF
+			}
+
+			part, _, err := p.store.GetObject(p.ctx, p.upload.ClipID, uploadPartName(p.next))
+
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to open upload part %d", p.next)
+			}
+
+			p.current = part
+			p.next++
+		}
+
+		n, err := p.current.Read(b)
+
+		if err == io.EOF {
+			p.current.Close()
+			p.current = nil
+
+			if n == 0 {
+				continue
+			}
+
+			err = nil
+		}
+
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.current == nil {
+		return nil
+	}
+
+	return p.current.Close()
+}
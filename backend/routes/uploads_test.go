@@ -0,0 +1,418 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/object"
+
+	"github.com/gorilla/sessions"
+	cmap "github.com/orcaman/concurrent-map"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestRoutes_CreateUpload(t *testing.T) {
+	uploads := &mock.UploadsProvider{
+		CreateHook: func(ctx context.Context, upload *models.Upload, columns boil.Columns) error {
+			if upload.UserID != 1 || upload.Title != "My clip" || upload.UploadLength != 100 {
+				t.Errorf("Received unexpected upload %+v", upload)
+			}
+
+			upload.ID = 1
+			upload.ClipID = 5
+
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "Success",
+			expected: http.StatusCreated,
+			user:     &models.User{ID: 1},
+			// title "My clip", unlisted "true"
+			headers: map[string]string{"Upload-Length": "100", "Upload-Metadata": "title TXkgY2xpcA==,unlisted dHJ1ZQ=="},
+		},
+		{
+			name:     "Success - title from filename",
+			expected: http.StatusCreated,
+			user:     &models.User{ID: 1},
+			// filename "My clip.mp4"
+			headers: map[string]string{"Upload-Length": "100", "Upload-Metadata": "filename TXkgY2xpcC5tcDQ="},
+		},
+		{
+			name:     "Handle missing length",
+			expected: http.StatusBadRequest,
+			user:     &models.User{ID: 1},
+			headers:  map[string]string{"Upload-Metadata": "title TXkgY2xpcA=="},
+		},
+		{
+			name:     "Handle deferred length",
+			expected: http.StatusBadRequest,
+			user:     &models.User{ID: 1},
+			headers:  map[string]string{"Upload-Defer-Length": "1", "Upload-Metadata": "title TXkgY2xpcA=="},
+		},
+		{
+			name:     "Handle video too large",
+			expected: http.StatusRequestEntityTooLarge,
+			user:     &models.User{ID: 1},
+			headers:  map[string]string{"Upload-Length": "1024", "Upload-Metadata": "title TXkgY2xpcA=="},
+		},
+		{
+			name:     "Handle missing title",
+			expected: http.StatusBadRequest,
+			user:     &models.User{ID: 1},
+			headers:  map[string]string{"Upload-Length": "100"},
+		},
+		{
+			name:     "Handle invalid metadata",
+			expected: http.StatusBadRequest,
+			user:     &models.User{ID: 1},
+			headers:  map[string]string{"Upload-Length": "100", "Upload-Metadata": "title !!!"},
+		},
+		{
+			name:     "Success - within quota",
+			expected: http.StatusCreated,
+			user:     &models.User{ID: 1, QuotaBytes: null.Int64From(200)},
+			headers:  map[string]string{"Upload-Length": "100", "Upload-Metadata": "title TXkgY2xpcA=="},
+		},
+		{
+			name:     "Deny upload over quota",
+			expected: http.StatusForbidden,
+			user:     &models.User{ID: 1, QuotaBytes: null.Int64From(150)},
+			headers:  map[string]string{"Upload-Length": "100", "Upload-Metadata": "title TXkgY2xpcA=="},
+		},
+		{
+			name:     "Deny when not authorized",
+			expected: http.StatusUnauthorized,
+			headers:  map[string]string{"Upload-Length": "100", "Upload-Metadata": "title TXkgY2xpcA=="},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MaxUploadSizeBytes: 1024}
+			cfg.Uploads.Expiry = time.Hour
+
+			r := &Routes{
+				Group: &services.Group{
+					Uploads: uploads,
+					Users: &mock.UserProvider{
+						UsageHook: func(ctx context.Context, uid int64) (int64, error) {
+							return 100, nil
+						},
+					},
+				},
+				cfg: cfg,
+			}
+
+			req := httptest.NewRequest("POST", "/", nil)
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			code, _, headers, err := r.CreateUpload(tt.user, req)
+
+			assert.Equal(t, tt.expected, code)
+			assert.NoError(t, err)
+
+			if code == http.StatusCreated {
+				assert.True(t, strings.HasPrefix(headers.Get("Location"), "/api/uploads/"))
+				assert.Equal(t, "0", headers.Get("Upload-Offset"))
+				assert.NotEmpty(t, headers.Get("Upload-Expires"))
+			}
+		})
+	}
+}
+
+func TestRoutes_TusHandler(t *testing.T) {
+	r := &Routes{
+		store: sessions.NewCookieStore([]byte("key")),
+		cfg:   &config.Config{MaxUploadSizeBytes: 1024},
+	}
+
+	handler := r.TusHandler(r.GetUploadOptions)
+
+	// Clients discover the version with OPTIONS, so it doesn't need to state one
+	resp := httptest.NewRecorder()
+	handler(resp, httptest.NewRequest("OPTIONS", "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "1.0.0", resp.Header().Get("Tus-Resumable"))
+	assert.Equal(t, "1023", resp.Header().Get("Tus-Max-Size"))
+	assert.Equal(t, "creation,termination,expiration", resp.Header().Get("Tus-Extension"))
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Tus-Resumable", "0.2.2")
+
+	resp = httptest.NewRecorder()
+	r.TusHandler(r.CreateUpload)(resp, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code)
+	assert.Equal(t, "1.0.0", resp.Header().Get("Tus-Version"))
+}
+
+func TestRoutes_PatchUpload(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &config.Config{MaxUploadSizeBytes: 1024}
+	cfg.Uploads.Expiry = time.Hour
+	cfg.Storage.Path = t.TempDir()
+
+	store, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	upload := &models.Upload{ID: 1, UserID: 1, ClipID: 5, Title: "My clip", UploadLength: int64(len(mp4Header)) + 11, ExpiresAt: time.Now().Add(time.Hour)}
+
+	var created *models.Clip
+	var raw bytes.Buffer
+	var queued bool
+
+	r := &Routes{
+		uploading: cmap.New(),
+		cfg:       cfg,
+		Group: &services.Group{
+			ObjectStore: store,
+			Uploads: &mock.UploadsProvider{
+				FindHook: func(ctx context.Context, id int64) (*models.Upload, error) {
+					if id != upload.ID {
+						return nil, sql.ErrNoRows
+					}
+
+					copied := *upload
+					return &copied, nil
+				},
+				UpdateHook: func(ctx context.Context, u *models.Upload, columns boil.Columns) error {
+					upload = u
+					return nil
+				},
+			},
+			Clips: &mock.ClipsProvider{
+				CreateHook: func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error) {
+					created = clip
+
+					return &mock.ClipTxProvider{
+						UploadVideoHook: func(ctx context.Context, r io.Reader) (int64, error) {
+							return io.Copy(&raw, r)
+						},
+						CommitHook:   func() error { return nil },
+						RollbackHook: func() error { return nil },
+					}, nil
+				},
+			},
+			Transcoder: &mock.TranscoderProvider{
+				QueueHook: func(ctx context.Context, clip *models.Clip) error {
+					queued = true
+					return nil
+				},
+				ProbeHook: func(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+					return &services.MediaInfo{VideoStreams: 1, Streams: 1}, nil
+				},
+			},
+		},
+	}
+
+	patch := func(user *models.User, upid int64, offset string, body string) (int, http.Header) {
+		req := httptest.NewRequest("PATCH", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", offset)
+		req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{UPID: upid}))
+
+		code, _, headers, err := r.PatchUpload(user, req)
+		assert.NoError(t, err)
+
+		return code, headers
+	}
+
+	user := &models.User{ID: 1}
+	first := mp4Header + "hello "
+	offset := fmt.Sprint(len(first))
+	length := fmt.Sprint(len(first) + 5)
+
+	code, headers := patch(user, 1, "0", first)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, offset, headers.Get("Upload-Offset"))
+	assert.True(t, store.HasObject(ctx, 5, "upload-0"))
+	assert.Nil(t, created)
+
+	// A retry of a chunk that already arrived is rejected, the client has to resume from the current offset
+	code, headers = patch(user, 1, "0", first)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, offset, headers.Get("Upload-Offset"))
+
+	code, _ = patch(&models.User{ID: 2}, 1, offset, "world")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = patch(user, 2, offset, "world")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Anything past the announced length is ignored
+	code, headers = patch(user, 1, offset, "world and more")
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, length, headers.Get("Upload-Offset"))
+	assert.NotEmpty(t, headers.Get("Clip-ID"))
+
+	clipID := headers.Get("Clip-ID")
+
+	// The clip is created with the ID reserved for the upload, from all of its parts
+	assert.Equal(t, int64(5), created.ID)
+	assert.Equal(t, "My clip", created.Title)
+	assert.Equal(t, mp4Header+"hello world", raw.String())
+	assert.True(t, queued)
+	assert.True(t, upload.Completed)
+	assert.False(t, store.HasObject(ctx, 5, "upload-0"))
+	assert.False(t, store.HasObject(ctx, 5, "upload-1"))
+
+	// Completed uploads keep reporting where the video went
+	code, headers = patch(user, 1, length, "")
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, clipID, headers.Get("Clip-ID"))
+
+	req := httptest.NewRequest("PATCH", "/", strings.NewReader("data"))
+	req.Header.Set("Upload-Offset", length)
+
+	code, _, _, _ = r.PatchUpload(user, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, code)
+}
+
+// cutReader is a request body of a client whose connection drops after data
+type cutReader struct {
+	data   io.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cutReader) Read(b []byte) (int, error) {
+	n, err := c.data.Read(b)
+
+	if err == io.EOF {
+		// The server cancels the context of a request whose client went away
+		c.cancel()
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+func TestRoutes_PatchUploadInterrupted(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &config.Config{MaxUploadSizeBytes: 1 << 20}
+	cfg.Uploads.Expiry = time.Hour
+	cfg.Storage.Path = t.TempDir()
+
+	store, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	video := mp4Header + strings.Repeat("v", 1000)
+	upload := &models.Upload{ID: 1, UserID: 1, ClipID: 5, UploadLength: int64(len(video)), ExpiresAt: time.Now().Add(time.Hour)}
+
+	r := &Routes{
+		uploading: cmap.New(),
+		cfg:       cfg,
+		Group: &services.Group{
+			ObjectStore: store,
+			Uploads: &mock.UploadsProvider{
+				FindHook: func(ctx context.Context, id int64) (*models.Upload, error) {
+					copied := *upload
+					return &copied, nil
+				},
+				UpdateHook: func(ctx context.Context, u *models.Upload, columns boil.Columns) error {
+					assert.NoError(t, ctx.Err())
+					upload = u
+					return nil
+				},
+			},
+		},
+	}
+
+	user := &models.User{ID: 1}
+	received := len(video) / 2
+
+	// The client sends all of the video in one request, which is cut off halfway through
+	reqCtx, cancel := context.WithCancel(context.WithValue(ctx, VarKey, &RouteVars{UPID: 1}))
+	defer cancel()
+
+	req := httptest.NewRequest("PATCH", "/", &cutReader{data: strings.NewReader(video[:received]), cancel: cancel}).WithContext(reqCtx)
+	req.ContentLength = int64(len(video))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+
+	code, _, _, err := r.PatchUpload(user, req)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	// What did arrive is kept, so the client resumes after it instead of starting over
+	req = httptest.NewRequest("HEAD", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{UPID: 1}))
+
+	code, _, headers, err := r.GetUploadOffset(user, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, fmt.Sprint(received), headers.Get("Upload-Offset"))
+
+	assert.Equal(t, 1, upload.Parts)
+	assert.True(t, store.HasObject(ctx, 5, "upload-0"))
+
+	part, _, err := store.GetObject(ctx, 5, "upload-0")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(part)
+	assert.NoError(t, err)
+	assert.NoError(t, part.Close())
+	assert.Equal(t, video[:received], string(data))
+}
+
+func TestRoutes_PatchUploadRefusesNonMedia(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &config.Config{MaxUploadSizeBytes: 1 << 20}
+	cfg.Storage.Path = t.TempDir()
+
+	store, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	r := &Routes{
+		uploading: cmap.New(),
+		cfg:       cfg,
+		Group: &services.Group{
+			ObjectStore: store,
+			Uploads: &mock.UploadsProvider{
+				FindHook: func(ctx context.Context, id int64) (*models.Upload, error) {
+					return &models.Upload{ID: 1, UserID: 1, ClipID: 5, UploadLength: 4096, ExpiresAt: time.Now().Add(time.Hour)}, nil
+				},
+			},
+		},
+	}
+
+	req := httptest.NewRequest("PATCH", "/", strings.NewReader("<!DOCTYPE html>"+strings.Repeat(" ", 1024)))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{UPID: 1}))
+
+	code, body, _, err := r.PatchUpload(&models.User{ID: 1}, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, code)
+	assert.Contains(t, string(body), modelsx.MediaUnsupportedFormat)
+
+	// Nothing of the file is kept
+	assert.False(t, store.HasObject(ctx, 5, "upload-0"))
+}
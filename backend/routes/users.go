@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"webserver/models"
 	"webserver/modelsx"
+	"webserver/services/policy"
 
+	"github.com/alexedwards/argon2id"
 	"github.com/pkg/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
@@ -14,6 +17,7 @@ import (
 //
 // Success when provided valid User json; returns updated User json
 // Normal users can only update themselves
+// Changing a password takes the current password of the requesting user, unless they never had one, and can't be done with a token
 //
 // PATCH /users/{user id}
 func (r *Routes) UpdateUser(user *models.User, req *http.Request) (int, []byte, error) {
@@ -23,8 +27,8 @@ func (r *Routes) UpdateUser(user *models.User, req *http.Request) (int, []byte,
 
 	vars := vars(req)
 
-	// Users shouldn't be able to update another users
-	if vars.UID != user.ID {
+	// Only admins can update other users
+	if !policy.CanEditUser(user, vars.UID) {
 		return http.StatusForbidden, nil, nil
 	}
 
@@ -36,12 +40,56 @@ func (r *Routes) UpdateUser(user *models.User, req *http.Request) (int, []byte,
 
 	updateUser.ID = modelsx.HashID(vars.UID)
 
+	if updateUser.Password.Valid {
+		if requestToken(req) != nil {
+			return http.StatusForbidden, []byte("Passwords can't be changed with a token"), nil
+		}
+
+		// Accounts provisioned through an identity provider get a password nobody knows, so they couldn't set one otherwise
+		if !user.PasswordUnset {
+			if !updateUser.CurrentPassword.Valid {
+				return http.StatusBadRequest, []byte("current_password is required to change the password"), nil
+			}
+
+			match, err := argon2id.ComparePasswordAndHash(updateUser.CurrentPassword.String, user.Password)
+
+			if err != nil {
+				return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to compare password")
+			}
+
+			if !match {
+				return http.StatusForbidden, []byte("Current password is incorrect"), nil
+			}
+		}
+
+		hash, err := argon2id.CreateHash(updateUser.Password.String, argon2id.DefaultParams)
+
+		if err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to hash password")
+		}
+
+		updateUser.Password.String = hash
+	}
+
 	model := updateUser.ToModel()
 
 	if err := r.Users.Update(req.Context(), model, boil.Whitelist(updateUser.GetUpdateWhitelist()...)); err != nil {
 		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to update user")
 	}
 
+	// A new password logs the user out everywhere else, so a stolen session doesn't outlive it
+	if updateUser.Password.Valid {
+		except := ""
+
+		if vars.UID == user.ID {
+			except = r.sessionHash(req)
+		}
+
+		if _, err := r.Sessions.DeleteAll(req.Context(), vars.UID, except); err != nil {
+			return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to delete sessions")
+		}
+	}
+
 	return modelsx.UserFromModel(model).Marshal()
 }
 
@@ -93,7 +141,23 @@ func (r *Routes) GetCurrentUser(user *models.User, req *http.Request) (int, []by
 	if user == nil {
 		return http.StatusUnauthorized, nil, nil
 	}
-	return modelsx.UserFromModel(user).Marshal()
+
+	usage, err := r.Users.Usage(req.Context(), user.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, errors.Wrap(err, "failed to get storage usage")
+	}
+
+	u := modelsx.UserFromModel(user)
+	u.Usage = null.Int64From(usage)
+	u.TwoFactor = user.TotpEnabled
+
+	// Users without a limit don't get a quota at all
+	if quota := r.userQuota(user); quota != 0 {
+		u.Quota = null.Int64From(quota)
+	}
+
+	return u.Marshal()
 }
 
 // GetCurrentUserClips returns list of clips created by requesting user
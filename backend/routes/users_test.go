@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"webserver/config"
 	"webserver/models"
 	"webserver/modelsx"
 	"webserver/services"
 	"webserver/services/mock"
+	"webserver/services/policy"
 
+	"github.com/alexedwards/argon2id"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -60,6 +67,41 @@ func TestRoutes_UpdateUser(t *testing.T) {
 				UID: 2,
 			},
 		},
+		{
+			name:     "Allow admin updating another user",
+			expected: http.StatusOK,
+			hasBody:  true,
+			hasError: false,
+			group: &services.Group{
+				Users: &mock.UserProvider{
+					UpdateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+						return nil
+					},
+				},
+			},
+			user: &models.User{
+				ID:   1,
+				Role: policy.RoleAdmin,
+			},
+			vars: &RouteVars{
+				UID: 2,
+			},
+		},
+		{
+			name:     "Deny disabled admin updating another user",
+			expected: http.StatusForbidden,
+			hasBody:  false,
+			hasError: false,
+			group:    &services.Group{},
+			user: &models.User{
+				ID:       1,
+				Role:     policy.RoleAdmin,
+				Disabled: true,
+			},
+			vars: &RouteVars{
+				UID: 2,
+			},
+		},
 		{
 			name:     "Deny when not authorized",
 			expected: http.StatusUnauthorized,
@@ -320,7 +362,29 @@ func TestRoutes_GetCurrentUser(t *testing.T) {
 			expected: http.StatusOK,
 			hasBody:  true,
 			hasError: false,
-			group:    &services.Group{},
+			group: &services.Group{
+				Users: &mock.UserProvider{
+					UsageHook: func(ctx context.Context, uid int64) (int64, error) {
+						return 50, nil
+					},
+				},
+			},
+			user: &models.User{
+				ID: 1,
+			},
+		},
+		{
+			name:     "Handle usage error",
+			expected: http.StatusInternalServerError,
+			hasBody:  false,
+			hasError: true,
+			group: &services.Group{
+				Users: &mock.UserProvider{
+					UsageHook: func(ctx context.Context, uid int64) (int64, error) {
+						return 0, sql.ErrConnDone
+					},
+				},
+			},
 			user: &models.User{
 				ID: 1,
 			},
@@ -340,6 +404,7 @@ func TestRoutes_GetCurrentUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &Routes{
 				Group: tt.group,
+				cfg:   &config.Config{DefaultQuotaBytes: 100},
 			}
 
 			req := httptest.NewRequest("GET", "/", &bytes.Buffer{})
@@ -525,3 +590,131 @@ func TestRoutes_GetUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutes_UpdateUserPassword(t *testing.T) {
+	r, memory := newSessionRoutes()
+
+	var stored *models.User
+
+	r.Users = &mock.UserProvider{
+		UpdateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+			stored = user
+			return nil
+		},
+	}
+
+	current := loggedIn(t, r, 1)
+	loggedIn(t, r, 1)
+	loggedIn(t, r, 2)
+
+	hash, err := argon2id.CreateHash("old password", argon2id.DefaultParams)
+	require.NoError(t, err)
+
+	req := sessionRequest(current)
+	req.Body = io.NopCloser(strings.NewReader(`{"password": "new password", "current_password": "old password"}`))
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{UID: 1}))
+
+	code, _, err := r.UpdateUser(&models.User{ID: 1, Password: hash}, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	// The password is stored hashed, like registering stores it
+	require.NotNil(t, stored)
+	match, err := argon2id.ComparePasswordAndHash("new password", stored.Password)
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	// Everywhere else the user was logged in is logged out, the other user isn't affected
+	assert.Equal(t, 2, memory.len())
+
+	session, err := r.store.Get(sessionRequest(current), SESSION_NAME)
+	require.NoError(t, err)
+	assert.False(t, session.IsNew)
+}
+
+func TestRoutes_UpdateUserPasswordUnset(t *testing.T) {
+	r, _ := newSessionRoutes()
+
+	var stored *models.User
+	var storedColumns boil.Columns
+
+	r.Users = &mock.UserProvider{
+		UpdateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+			stored, storedColumns = user, columns
+			return nil
+		},
+	}
+
+	// Accounts provisioned through an identity provider have no password to confirm
+	req := sessionRequest(loggedIn(t, r, 1))
+	req.Body = io.NopCloser(strings.NewReader(`{"password": "new password"}`))
+	req = req.WithContext(context.WithValue(req.Context(), VarKey, &RouteVars{UID: 1}))
+
+	code, _, err := r.UpdateUser(&models.User{ID: 1, PasswordUnset: true}, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	// From then on it's a password like any other
+	require.NotNil(t, stored)
+	assert.False(t, stored.PasswordUnset)
+	assert.Contains(t, storedColumns.Cols, models.UserColumns.PasswordUnset)
+}
+
+func TestRoutes_UpdateUserPasswordDenied(t *testing.T) {
+	hash, err := argon2id.CreateHash("old password", argon2id.DefaultParams)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		body     string
+		token    *models.Token
+		expected int
+	}{
+		{
+			name:     "Deny without current password",
+			body:     `{"password": "new password"}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Deny with wrong current password",
+			body:     `{"password": "new password", "current_password": "wrong password"}`,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "Deny with a token",
+			body:     `{"password": "new password", "current_password": "old password"}`,
+			token:    &models.Token{ID: 1, UserID: 1, Scopes: "manage"},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Routes{
+				Group: &services.Group{
+					Users: &mock.UserProvider{
+						UpdateHook: func(ctx context.Context, user *models.User, columns boil.Columns) error {
+							t.Error("password was changed")
+							return nil
+						},
+					},
+				},
+			}
+
+			ctx := context.WithValue(context.Background(), VarKey, &RouteVars{UID: 1})
+
+			if tt.token != nil {
+				ctx = context.WithValue(ctx, TokenKey, tt.token)
+			}
+
+			req := httptest.NewRequest("PATCH", "/", strings.NewReader(tt.body)).WithContext(ctx)
+
+			code, _, err := r.UpdateUser(&models.User{ID: 1, Password: hash}, req)
+			assert.NoError(t, err)
+
+			if code != tt.expected {
+				t.Errorf("Received unexpected error code during %s test. Wanted: %d Got: %d", tt.name, tt.expected, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+	"webserver/models"
+
+	"github.com/friendsofgo/errors"
+)
+
+// AdminVerifyObjects reads every stored object back and reports the ones that don't match their checksums
+//
+// POST /admin/verify
+func (r *Routes) AdminVerifyObjects(user *models.User, w http.ResponseWriter, req *http.Request) (int, io.ReadCloser, http.Header, error) {
+	if code, ok := adminOnly(user); !ok {
+		return code, nil, nil, nil
+	}
+
+	// Reading everything back takes as long as the storage needs, not as long as responses usually get to be written
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	verification, err := r.Verifier.Verify(req.Context())
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, nil, errors.Wrap(err, "failed to verify objects")
+	}
+
+	code, data, err := verification.Marshal()
+
+	return code, io.NopCloser(bytes.NewReader(data)), nil, err
+}
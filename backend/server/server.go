@@ -2,14 +2,20 @@
 package server
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 	"webserver/config"
 	"webserver/modelsx"
 	"webserver/routes"
+	"webserver/services/object"
 
 	"github.com/gorilla/sessions"
 	"github.com/pkg/errors"
@@ -79,17 +85,22 @@ func New(cfg *config.Config) (*Server, error) {
 	cookieStore.Options.Domain = cfg.Cookie.Domain
 	cookieStore.MaxAge(int((30 * (24 * time.Hour)).Seconds())) // 30 Days
 
-	s3, err := minio.New(cfg.S3.Address, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.S3.Access, cfg.S3.Secret, ""),
-		Secure: cfg.S3.Secure,
-	})
+	var s3 *minio.Client
 
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create s3 client")
-	}
+	// The filesystem backend doesn't need S3 at all, unless the cold tier is kept there
+	if cfg.Storage.Backend == object.BackendS3 || (cfg.Tiering.Enabled && cfg.Tiering.Backend == object.BackendS3) {
+		s3, err = minio.New(cfg.S3.Address, &minio.Options{
+			Creds:  credentials.NewStaticV4(cfg.S3.Access, cfg.S3.Secret, ""),
+			Secure: cfg.S3.Secure,
+		})
 
-	if s3.IsOffline() {
-		return nil, errors.New("unable to connect to S3")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create s3 client")
+		}
+
+		if s3.IsOffline() {
+			return nil, errors.New("unable to connect to S3")
+		}
 	}
 
 	group, err := routes.DefaultServiceGroup(cfg, db, s3)
@@ -104,6 +115,10 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, errors.Wrap(err, "failed to create routes")
 	}
 
+	if err := r.BootstrapAdmin(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "failed to bootstrap admin")
+	}
+
 	return &Server{
 		routes: r,
 		cfg:    cfg,
@@ -111,12 +126,22 @@ func New(cfg *config.Config) (*Server, error) {
 }
 
 // Start starts the hosting of routes on the address specified in cfg
+// It blocks until a SIGINT or SIGTERM is received, then shuts everything down within the configured grace period
 func (s *Server) Start() error {
 	log.Infoln("Listening on", s.cfg.ListenAddr, "and", s.cfg.MetricsListenAddr)
 
-	go http.ListenAndServe(s.cfg.MetricsListenAddr, promhttp.Handler())
-	go http.ListenAndServe("127.0.0.1:12786", s.routes.InternalRouter)
-	go s.routes.Transcoder.Start()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	metricsSrv := &http.Server{
+		Addr:    s.cfg.MetricsListenAddr,
+		Handler: promhttp.Handler(),
+	}
+
+	internalSrv := &http.Server{
+		Addr:    "127.0.0.1:12786",
+		Handler: s.routes.InternalRouter,
+	}
 
 	srv := &http.Server{
 		Addr:              s.cfg.ListenAddr,
@@ -126,5 +151,87 @@ func (s *Server) Start() error {
 		ReadHeaderTimeout: 5 * time.Second,
 		MaxHeaderBytes:    1 * MB,
 	}
-	return srv.ListenAndServe()
+
+	serveErr := make(chan error, 3)
+
+	for _, hs := range []*http.Server{metricsSrv, internalSrv, srv} {
+		go func(hs *http.Server) {
+			if err := hs.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serveErr <- errors.Wrapf(err, "failed to listen on %s", hs.Addr)
+			}
+		}(hs)
+	}
+
+	go func() {
+		if err := s.routes.Transcoder.Start(); err != nil {
+			log.WithError(err).Error("Failed to resume processing clips")
+		}
+	}()
+
+	go s.routes.ExpireUploads(ctx, 15*time.Minute)
+	go s.routes.ExpireSessions(ctx, time.Hour)
+	go s.routes.Collector.Run(ctx)
+	go s.routes.Mover.Run(ctx)
+	go s.routes.Verifier.Run(ctx)
+
+	var err error
+
+	select {
+	case <-ctx.Done():
+		log.Infoln("Received shutdown signal, waiting up to", s.cfg.ShutdownGracePeriod, "for requests and transcodes to finish")
+	case err = <-serveErr:
+	}
+
+	// Restore the default signal behaviour, so a second signal kills the process right away
+	stop()
+
+	graceCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownGracePeriod)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var srvErr, transcoderErr error
+
+	wg.Add(2)
+
+	// Stop accepting uploads while the running transcodes finish
+	go func() {
+		defer wg.Done()
+
+		if srvErr = srv.Shutdown(graceCtx); srvErr != nil {
+			srv.Close()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		transcoderErr = s.routes.Transcoder.Stop(graceCtx)
+	}()
+
+	wg.Wait()
+
+	// ffmpeg writes its output through the internal router, so it can only go away once the transcoder is stopped
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelClose()
+
+	if err := internalSrv.Shutdown(closeCtx); err != nil {
+		log.WithError(err).Warn("Failed to shut down internal router")
+	}
+
+	if err := metricsSrv.Shutdown(closeCtx); err != nil {
+		log.WithError(err).Warn("Failed to shut down metrics listener")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if srvErr != nil {
+		return errors.Wrap(srvErr, "failed to finish requests within the grace period")
+	}
+
+	if transcoderErr != nil {
+		return errors.Wrap(transcoderErr, "failed to finish transcodes within the grace period")
+	}
+
+	return nil
 }
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+type chapters struct {
+	db *sql.DB
+}
+
+// NewChapters Comment for linter
+func NewChapters(db *sql.DB) services.Chapters {
+	return &chapters{db}
+}
+
+func (c *chapters) Find(ctx context.Context, chid int64) (*models.ClipChapter, error) {
+	return models.FindClipChapter(ctx, c.db, chid)
+}
+
+func (c *chapters) FindMany(ctx context.Context, cid int64) (models.ClipChapterSlice, error) {
+	return models.ClipChapters(
+		models.ClipChapterWhere.ClipID.EQ(cid),
+		qm.OrderBy(models.ClipChapterColumns.StartMS),
+	).All(ctx, c.db)
+}
+
+func (c *chapters) Update(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+	_, err := chapter.Update(ctx, c.db, columns)
+
+	return err
+}
+
+func (c *chapters) Create(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+	return chapter.Insert(ctx, c.db, columns)
+}
+
+func (c *chapters) Delete(ctx context.Context, chapter *models.ClipChapter) error {
+	_, err := chapter.Delete(ctx, c.db)
+
+	return err
+}
+
+func (c *chapters) Replace(ctx context.Context, cid int64, chapters models.ClipChapterSlice) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if _, err := models.ClipChapters(models.ClipChapterWhere.ClipID.EQ(cid)).DeleteAll(ctx, tx); err != nil {
+		return errors.Wrap(err, "failed to delete existing chapters")
+	}
+
+	for _, chapter := range chapters {
+		chapter.ClipID = cid
+
+		if err := chapter.Insert(ctx, tx, boil.Whitelist(
+			models.ClipChapterColumns.ClipID,
+			models.ClipChapterColumns.Title,
+			models.ClipChapterColumns.StartMS,
+			models.ClipChapterColumns.EndMS,
+		)); err != nil {
+			return errors.Wrap(err, "failed to insert chapter")
+		}
+	}
+
+	return tx.Commit()
+}
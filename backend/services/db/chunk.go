@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+type chunks struct {
+	db *sql.DB
+}
+
+// NewChunks Comment for linter
+func NewChunks(db *sql.DB) services.Chunks {
+	return &chunks{db}
+}
+
+func (c *chunks) FindMany(ctx context.Context, cid int64) (models.TranscodeChunkSlice, error) {
+	return models.TranscodeChunks(
+		models.TranscodeChunkWhere.ClipID.EQ(cid),
+		qm.OrderBy(models.TranscodeChunkColumns.ChunkIndex),
+	).All(ctx, c.db)
+}
+
+func (c *chunks) Create(ctx context.Context, chunk *models.TranscodeChunk) error {
+	// Upsert, a chunk can be encoded again if its object went missing
+	return chunk.Upsert(ctx, c.db, true,
+		[]string{models.TranscodeChunkColumns.ClipID, models.TranscodeChunkColumns.ChunkIndex},
+		boil.Whitelist(models.TranscodeChunkColumns.StartMS, models.TranscodeChunkColumns.DurationMS),
+		boil.Infer(),
+	)
+}
+
+func (c *chunks) DeleteAll(ctx context.Context, cid int64) error {
+	_, err := models.TranscodeChunks(models.TranscodeChunkWhere.ClipID.EQ(cid)).DeleteAll(ctx, c.db)
+
+	return err
+}
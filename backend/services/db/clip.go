@@ -2,13 +2,17 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"io"
 	"webserver/models"
 	"webserver/modelsx"
 	"webserver/services"
+	"webserver/services/policy"
 
 	"github.com/pkg/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
@@ -34,8 +38,8 @@ func (c *clips) FindMany(ctx context.Context, user *models.User, mods ...qm.Quer
 	return models.Clips(modelsx.NewBuilder().
 		Add(mods...).
 		// If there was a user associated with the query, also show them their unlisted clips.
-		// If the user ID is -1, it's the system trying to get all clips and we shouldn't filter anything
-		IfCb(user != nil && user.ID != -1, func() []qm.QueryMod {
+		// Moderators and the system see every clip, so nothing is filtered for them
+		IfCb(user != nil && !policy.CanViewAll(user), func() []qm.QueryMod {
 			return []qm.QueryMod{
 				models.ClipWhere.CreatorID.EQ(user.ID),
 				qm.Or(models.ClipColumns.Unlisted+"=?", false),
@@ -72,8 +76,8 @@ func (c *clips) SearchMany(ctx context.Context, user *models.User, query string)
 			qm.Load(models.ClipRels.Creator),
 		).
 		// If there was a user associated with the query, also show them their unlisted clips.
-		// If the user ID is -1, it's the system trying to get all clips and we shouldn't filter anything
-		IfCb(user != nil && user.ID != -1, func() []qm.QueryMod {
+		// Moderators and the system see every clip, so nothing is filtered for them
+		IfCb(user != nil && !policy.CanViewAll(user), func() []qm.QueryMod {
 			return []qm.QueryMod{
 				models.ClipWhere.CreatorID.EQ(user.ID),
 				qm.Or(models.ClipColumns.Unlisted+"=?", false),
@@ -84,6 +88,45 @@ func (c *clips) SearchMany(ctx context.Context, user *models.User, query string)
 	).All(ctx, c.db)
 }
 
+func (c *clips) FindDuplicate(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+	return models.Clips(
+		models.ClipWhere.ContentHash.EQ(null.StringFrom(contentHash)),
+		// Only the uploader's own clips are handed back in place of an upload, matches of other users are left to FindSimilar
+		models.ClipWhere.CreatorID.EQ(user.ID),
+		qm.OrderBy(models.ClipColumns.CreatedAt),
+		qm.Load(models.ClipRels.Creator),
+	).One(ctx, c.db)
+}
+
+func (c *clips) FindSimilar(ctx context.Context, user *models.User, clip *models.Clip, threshold int) (models.ClipSlice, error) {
+	// Hamming distance between the two perceptual hashes, a NULL hash on either side never matches
+	distance := `length(replace((perceptual_hash # ?)::bit(64)::text, '0', ''))`
+
+	return models.Clips(modelsx.NewBuilder().
+		Add(
+			models.ClipWhere.ID.NEQ(clip.ID),
+			qm.Expr(
+				qm.Where(distance+" <= ?", clip.PerceptualHash, threshold),
+				qm.Or2(models.ClipWhere.ContentHash.EQ(clip.ContentHash)),
+			),
+		).
+		// Moderators and the system see every clip, so nothing is filtered for them
+		IfCb(user != nil && !policy.CanViewAll(user), func() []qm.QueryMod {
+			return []qm.QueryMod{qm.Expr(
+				models.ClipWhere.CreatorID.EQ(user.ID),
+				qm.Or2(models.ClipWhere.Unlisted.EQ(false)),
+			)}
+		}).
+		// If there was no user, don't show unlisted clips
+		If(user == nil, models.ClipWhere.Unlisted.EQ(false)).
+		Add(
+			qm.OrderBy(distance+" NULLS FIRST", clip.PerceptualHash),
+			qm.Limit(10),
+			qm.Load(models.ClipRels.Creator),
+		)...,
+	).All(ctx, c.db)
+}
+
 func (c *clips) Update(ctx context.Context, clip *models.Clip, columns boil.Columns) error {
 	_, err := clip.Update(ctx, c.db, columns)
 	return err
@@ -121,7 +164,23 @@ type clipTx struct {
 }
 
 func (c *clipTx) UploadVideo(ctx context.Context, r io.Reader) (int64, error) {
-	return c.os.PutObject(ctx, c.clip.ID, "raw", r)
+	hash := sha256.New()
+
+	n, err := c.os.PutObject(ctx, c.clip.ID, "raw", io.TeeReader(r, hash))
+
+	if err != nil {
+		return n, err
+	}
+
+	c.clip.ContentHash = null.StringFrom(hex.EncodeToString(hash.Sum(nil)))
+	// Until it's transcoded, the raw video is what the clip takes up
+	c.clip.SizeBytes = n
+
+	if _, err := c.clip.Update(ctx, c.tx, boil.Whitelist(models.ClipColumns.ContentHash, models.ClipColumns.SizeBytes)); err != nil {
+		return n, errors.Wrap(err, "failed to store clip content hash")
+	}
+
+	return n, nil
 }
 
 func (c *clipTx) Commit() error {
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+type identities struct {
+	db *sql.DB
+}
+
+// NewIdentities Comment for linter
+func NewIdentities(db *sql.DB) services.Identities {
+	return &identities{db}
+}
+
+func (i *identities) Find(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	return models.UserIdentities(
+		models.UserIdentityWhere.Provider.EQ(provider),
+		models.UserIdentityWhere.Subject.EQ(subject),
+	).One(ctx, i.db)
+}
+
+func (i *identities) Create(ctx context.Context, identity *models.UserIdentity, columns boil.Columns) error {
+	return identity.Insert(ctx, i.db, columns)
+}
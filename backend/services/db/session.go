@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+type sessions struct {
+	db *sql.DB
+}
+
+// NewSessions Comment for linter
+func NewSessions(db *sql.DB) services.Sessions {
+	return &sessions{db}
+}
+
+func (s *sessions) FindHash(ctx context.Context, hash string) (*models.Session, error) {
+	return models.Sessions(models.SessionWhere.Hash.EQ(hash)).One(ctx, s.db)
+}
+
+func (s *sessions) FindMany(ctx context.Context, uid int64) (models.SessionSlice, error) {
+	return models.Sessions(
+		models.SessionWhere.UserID.EQ(uid),
+		models.SessionWhere.ExpiresAt.GT(time.Now()),
+		qm.OrderBy(models.SessionColumns.LastSeenAt+" DESC"),
+	).All(ctx, s.db)
+}
+
+func (s *sessions) Update(ctx context.Context, session *models.Session, columns boil.Columns) error {
+	_, err := session.Update(ctx, s.db, columns)
+	return err
+}
+
+func (s *sessions) Create(ctx context.Context, session *models.Session, columns boil.Columns) error {
+	return session.Insert(ctx, s.db, columns)
+}
+
+func (s *sessions) Delete(ctx context.Context, uid int64, id int64) (bool, error) {
+	deleted, err := models.Sessions(models.SessionWhere.ID.EQ(id), models.SessionWhere.UserID.EQ(uid)).DeleteAll(ctx, s.db)
+	return deleted > 0, err
+}
+
+func (s *sessions) DeleteHash(ctx context.Context, hash string) error {
+	_, err := models.Sessions(models.SessionWhere.Hash.EQ(hash)).DeleteAll(ctx, s.db)
+	return err
+}
+
+func (s *sessions) DeleteAll(ctx context.Context, uid int64, except string) (int64, error) {
+	return models.Sessions(
+		models.SessionWhere.UserID.EQ(uid),
+		models.SessionWhere.Hash.NEQ(except),
+	).DeleteAll(ctx, s.db)
+}
+
+func (s *sessions) DeleteExpired(ctx context.Context) (int64, error) {
+	return models.Sessions(models.SessionWhere.ExpiresAt.LTE(time.Now())).DeleteAll(ctx, s.db)
+}
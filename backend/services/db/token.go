@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+type tokens struct {
+	db *sql.DB
+}
+
+// NewTokens Comment for linter
+func NewTokens(db *sql.DB) services.Tokens {
+	return &tokens{db}
+}
+
+func (t *tokens) FindHash(ctx context.Context, hash string) (*models.Token, error) {
+	return models.Tokens(models.TokenWhere.Hash.EQ(hash)).One(ctx, t.db)
+}
+
+func (t *tokens) FindMany(ctx context.Context, uid int64) (models.TokenSlice, error) {
+	return models.Tokens(models.TokenWhere.UserID.EQ(uid), qm.OrderBy(models.TokenColumns.CreatedAt+" DESC")).All(ctx, t.db)
+}
+
+func (t *tokens) Update(ctx context.Context, token *models.Token, columns boil.Columns) error {
+	_, err := token.Update(ctx, t.db, columns)
+	return err
+}
+
+func (t *tokens) Create(ctx context.Context, token *models.Token, columns boil.Columns) error {
+	return token.Insert(ctx, t.db, columns)
+}
+
+func (t *tokens) Delete(ctx context.Context, uid int64, id int64) (bool, error) {
+	deleted, err := models.Tokens(models.TokenWhere.ID.EQ(id), models.TokenWhere.UserID.EQ(uid)).DeleteAll(ctx, t.db)
+	return deleted > 0, err
+}
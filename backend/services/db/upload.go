@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"webserver/models"
+	"webserver/services"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+type uploads struct {
+	db *sql.DB
+}
+
+// NewUploads Comment for linter
+func NewUploads(db *sql.DB) services.Uploads {
+	return &uploads{db}
+}
+
+func (u *uploads) Find(ctx context.Context, id int64) (*models.Upload, error) {
+	return models.FindUpload(ctx, u.db, id)
+}
+
+func (u *uploads) FindExpired(ctx context.Context) (models.UploadSlice, error) {
+	return models.Uploads(models.UploadWhere.ExpiresAt.LT(time.Now())).All(ctx, u.db)
+}
+
+func (u *uploads) Update(ctx context.Context, upload *models.Upload, columns boil.Columns) error {
+	_, err := upload.Update(ctx, u.db, columns)
+	return err
+}
+
+func (u *uploads) Create(ctx context.Context, upload *models.Upload, columns boil.Columns) error {
+	var reserved struct {
+		ID int64 `boil:"id"`
+	}
+
+	// Taking the ID from the clips sequence means the clip can be inserted with it later without ever colliding
+	if err := queries.Raw(`SELECT nextval('clips_id_seq') AS id`).Bind(ctx, u.db, &reserved); err != nil {
+		return errors.Wrap(err, "failed to reserve clip id")
+	}
+
+	upload.ClipID = reserved.ID
+	columns.Cols = append(columns.Cols, models.UploadColumns.ClipID)
+
+	return upload.Insert(ctx, u.db, columns)
+}
+
+func (u *uploads) Delete(ctx context.Context, upload *models.Upload) error {
+	_, err := upload.Delete(ctx, u.db)
+	return err
+}
+
+func (u *uploads) Staging(ctx context.Context, cid int64) (bool, error) {
+	return models.Uploads(
+		models.UploadWhere.ClipID.EQ(cid),
+		models.UploadWhere.Completed.EQ(false),
+	).Exists(ctx, u.db)
+}
@@ -3,10 +3,14 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 	"webserver/models"
 	"webserver/services"
 
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
@@ -58,3 +62,38 @@ func (u *users) Update(ctx context.Context, user *models.User, columns boil.Colu
 func (u *users) Create(ctx context.Context, user *models.User, columns boil.Columns) error {
 	return user.Insert(ctx, u.db, columns)
 }
+
+func (u *users) Delete(ctx context.Context, user *models.User) error {
+	_, err := user.Delete(ctx, u.db)
+
+	return err
+}
+
+func (u *users) Usage(ctx context.Context, uid int64) (int64, error) {
+	var usage struct {
+		Bytes int64 `boil:"bytes"`
+	}
+
+	// Unfinished uploads count with their full length, otherwise starting many of them at once would get around the quota
+	err := queries.Raw(fmt.Sprintf(
+		`SELECT (SELECT COALESCE(SUM(%s), 0) FROM "%s" WHERE %s = $1) + (SELECT COALESCE(SUM(%s), 0) FROM "%s" WHERE %s = $1 AND NOT %s) AS bytes`,
+		models.ClipColumns.SizeBytes, models.TableNames.Clips, models.ClipColumns.CreatorID,
+		models.UploadColumns.UploadLength, models.TableNames.Uploads, models.UploadColumns.UserID, models.UploadColumns.Completed,
+	), uid).Bind(ctx, u.db, &usage)
+
+	return usage.Bytes, err
+}
+
+func (u *users) FailTOTP(ctx context.Context, uid int64, maxAttempts int, lockout time.Duration) (time.Time, error) {
+	var locked struct {
+		Until null.Time `boil:"until"`
+	}
+
+	// Counting in the database keeps concurrent guesses from all reading the same count, the count starts over once it locks the user out
+	err := queries.Raw(fmt.Sprintf(
+		`UPDATE "%[1]s" SET %[2]s = CASE WHEN %[2]s + 1 >= $2 THEN 0 ELSE %[2]s + 1 END, %[3]s = CASE WHEN %[2]s + 1 >= $2 THEN now() + make_interval(secs => $3) ELSE %[3]s END WHERE %[4]s = $1 RETURNING %[3]s AS until`,
+		models.TableNames.User, models.UserColumns.TotpFailedAttempts, models.UserColumns.TotpLockedUntil, models.UserColumns.ID,
+	), uid, maxAttempts, lockout.Seconds()).Bind(ctx, u.db, &locked)
+
+	return locked.Until.Time, err
+}
@@ -0,0 +1,227 @@
+// Package gc deletes objects that are left behind in storage without a clip or upload referring to them
+package gc
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Object name prefixes of the temporary objects of uploads and transcodes
+const (
+	uploadPartPrefix     = "upload-"
+	transcodeChunkPrefix = "chunk-"
+)
+
+// composePart matches the {name}-N parts older versions uploaded objects in before composing them into one
+// Nothing writes them anymore, but a failed upload or cleanup left them behind for good
+var composePart = regexp.MustCompile(`^.+-\d+$`)
+
+// Collector reconciles the objects in storage with the clips and uploads in the database
+// Nothing younger than GC.GracePeriod is touched, so objects of clips that are still being created are safe
+type Collector struct {
+	cfg *config.Config
+	*services.Group
+}
+
+// New creates a Collector for the storage of g
+func New(cfg *config.Config, g *services.Group) *Collector {
+	return &Collector{cfg, g}
+}
+
+// Run collects garbage every GC.Interval until ctx is done
+func (c *Collector) Run(ctx context.Context) {
+	if c.cfg.GC.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.cfg.GC.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		garbage, err := c.Collect(ctx, c.cfg.GC.DryRun)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to collect garbage")
+			continue
+		}
+
+		for _, object := range garbage.Objects {
+			log.WithField("clip", object.ClipID).
+				WithField("object", object.Name).
+				WithField("reason", object.Reason).
+				WithField("dry_run", garbage.DryRun).
+				Info("Collected garbage object")
+		}
+	}
+}
+
+// Collect deletes every object that's garbage, during a dry run nothing is deleted and it only reports what would be
+func (c *Collector) Collect(ctx context.Context, dryRun bool) (*modelsx.Garbage, error) {
+	cids, err := c.ObjectStore.ListPrefixes(ctx)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list clip prefixes")
+	}
+
+	garbage := &modelsx.Garbage{DryRun: dryRun, Objects: []*modelsx.GarbageObject{}}
+	cutoff := time.Now().Add(-c.cfg.GC.GracePeriod)
+
+	for _, cid := range cids {
+		// Anything being written right now is in use, whatever the database says
+		if c.ObjectStore.HasActiveUploads(ctx, cid) {
+			continue
+		}
+
+		objects, err := c.ObjectStore.ListObjects(ctx, cid)
+
+		if err != nil {
+			return garbage, errors.Wrapf(err, "failed to list objects of clip %d", cid)
+		}
+
+		stale, orphaned, err := c.findStale(ctx, cid, objects, cutoff)
+
+		if err != nil {
+			return garbage, err
+		}
+
+		if len(stale) == 0 {
+			continue
+		}
+
+		if !dryRun {
+			if err := c.delete(ctx, cid, stale, orphaned); err != nil {
+				return garbage, err
+			}
+		}
+
+		for _, object := range stale {
+			garbage.Objects = append(garbage.Objects, object)
+			garbage.Bytes += object.Size
+		}
+	}
+
+	return garbage, nil
+}
+
+// findStale picks the objects of a clip that are garbage, orphaned is set when all of them are because the clip is gone
+func (c *Collector) findStale(ctx context.Context, cid int64, objects []services.ObjectInfo, cutoff time.Time) ([]*modelsx.GarbageObject, bool, error) {
+	clip, err := c.Clips.Find(ctx, cid)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, errors.Wrapf(err, "failed to find clip %d", cid)
+	}
+
+	if err == sql.ErrNoRows {
+		staging, err := c.Uploads.Staging(ctx, cid)
+
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to find upload of clip %d", cid)
+		}
+
+		if staging {
+			return nil, false, nil
+		}
+
+		// Deleting the objects of a clip can fail after they're gone but before their checksums are
+		if len(objects) == 0 {
+			return []*modelsx.GarbageObject{{ClipID: cid, Reason: modelsx.GarbageChecksums}}, true, nil
+		}
+
+		// A single recent object means the clip may just not be committed yet
+		for _, object := range objects {
+			if object.LastModified.After(cutoff) {
+				return nil, false, nil
+			}
+		}
+
+		stale := make([]*modelsx.GarbageObject, 0, len(objects))
+
+		for _, object := range objects {
+			stale = append(stale, newGarbageObject(cid, object, modelsx.GarbageOrphaned))
+		}
+
+		return stale, true, nil
+	}
+
+	var stale []*modelsx.GarbageObject
+
+	for _, object := range objects {
+		if object.LastModified.After(cutoff) {
+			continue
+		}
+
+		if reason := c.leftoverReason(clip, object.Name); reason != "" {
+			stale = append(stale, newGarbageObject(cid, object, reason))
+		}
+	}
+
+	return stale, false, nil
+}
+
+// leftoverReason says why an object of an existing clip is garbage, or nothing if it's still needed
+func (c *Collector) leftoverReason(clip *models.Clip, name string) string {
+	// Parts are only needed until the upload turns into its clip
+	if strings.HasPrefix(name, uploadPartPrefix) {
+		return modelsx.GarbageUploadPart
+	}
+
+	if composePart.MatchString(name) {
+		return modelsx.GarbageComposePart
+	}
+
+	// Chunks and the raw video are kept around until transcoding is done, so it can be resumed
+	if clip.Processing {
+		return ""
+	}
+
+	if strings.HasPrefix(name, transcodeChunkPrefix) {
+		return modelsx.GarbageTranscodeChunk
+	}
+
+	// The raw video is the original download when it's kept
+	if name == "raw" && !c.cfg.Downloads.KeepOriginal {
+		return modelsx.GarbageRawVideo
+	}
+
+	return ""
+}
+
+func (c *Collector) delete(ctx context.Context, cid int64, stale []*modelsx.GarbageObject, orphaned bool) error {
+	if orphaned {
+		return errors.Wrapf(c.ObjectStore.DeleteObjects(ctx, cid, ""), "failed to delete objects of clip %d", cid)
+	}
+
+	for _, object := range stale {
+		if err := c.ObjectStore.DeleteObject(ctx, cid, object.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete object %s of clip %d", object.Name, cid)
+		}
+	}
+
+	return nil
+}
+
+func newGarbageObject(cid int64, object services.ObjectInfo, reason string) *modelsx.GarbageObject {
+	return &modelsx.GarbageObject{
+		ClipID:       cid,
+		Name:         object.Name,
+		Size:         object.Size,
+		LastModified: object.LastModified,
+		Reason:       reason,
+	}
+}
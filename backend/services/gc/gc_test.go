@@ -0,0 +1,141 @@
+package gc
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &config.Config{}
+	cfg.Storage.Path = t.TempDir()
+	cfg.GC.GracePeriod = time.Hour
+
+	store, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	put := func(cid int64, name string, age time.Duration) {
+		_, err := store.PutObject(ctx, cid, name, strings.NewReader(name))
+		assert.NoError(t, err)
+
+		modified := time.Now().Add(-age)
+		assert.NoError(t, os.Chtimes(filepath.Join(cfg.Storage.Path, strconv.FormatInt(cid, 10), name), modified, modified))
+	}
+
+	// 1 is a finished clip with leftovers, 2 is still transcoding
+	put(1, "dash.mpd", 2*time.Hour)
+	put(1, "raw", 2*time.Hour)
+	put(1, "chunk-0.ts", 2*time.Hour)
+	put(1, "upload-0", 2*time.Hour)
+	put(1, "upload-1", time.Minute)
+	put(1, "raw-0", 2*time.Hour)
+	put(1, "raw-1", time.Minute)
+	put(2, "raw", 2*time.Hour)
+	put(2, "chunk-0.ts", 2*time.Hour)
+	put(2, "dash.mpd-3", 2*time.Hour)
+	// 3 was deleted, 4 is an upload that hasn't turned into a clip yet, 5 is gone but was only just written to
+	put(3, "dash.mpd", 2*time.Hour)
+	put(4, "upload-0", 2*time.Hour)
+	put(5, "raw", 2*time.Hour)
+	put(5, "dash.mpd", time.Minute)
+	// 6 is gone, but deleting it stopped after its objects
+	put(6, "dash.mpd", 2*time.Hour)
+	assert.NoError(t, os.RemoveAll(filepath.Join(cfg.Storage.Path, "6")))
+
+	c := New(cfg, &services.Group{
+		ObjectStore: store,
+		Clips: &mock.ClipsProvider{
+			FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+				switch cid {
+				case 1:
+					return &models.Clip{ID: 1}, nil
+				case 2:
+					return &models.Clip{ID: 2, Processing: true}, nil
+				}
+
+				return nil, sql.ErrNoRows
+			},
+		},
+		Uploads: &mock.UploadsProvider{
+			StagingHook: func(ctx context.Context, cid int64) (bool, error) {
+				return cid == 4, nil
+			},
+		},
+	})
+
+	expected := map[string]string{
+		"1/raw":        modelsx.GarbageRawVideo,
+		"1/chunk-0.ts": modelsx.GarbageTranscodeChunk,
+		"1/upload-0":   modelsx.GarbageUploadPart,
+		"1/raw-0":      modelsx.GarbageComposePart,
+		"2/dash.mpd-3": modelsx.GarbageComposePart,
+		"3/dash.mpd":   modelsx.GarbageOrphaned,
+		"6/":           modelsx.GarbageChecksums,
+	}
+
+	collected := func(garbage *modelsx.Garbage) map[string]string {
+		reasons := map[string]string{}
+
+		for _, object := range garbage.Objects {
+			reasons[strconv.FormatInt(object.ClipID, 10)+"/"+object.Name] = object.Reason
+		}
+
+		return reasons
+	}
+
+	// A dry run only reports
+	garbage, err := c.Collect(ctx, true)
+	assert.NoError(t, err)
+	assert.True(t, garbage.DryRun)
+	assert.Equal(t, expected, collected(garbage))
+	assert.Equal(t, int64(len("raw")+len("chunk-0.ts")+len("upload-0")+len("raw-0")+len("dash.mpd-3")+len("dash.mpd")), garbage.Bytes)
+	assert.True(t, store.HasObject(ctx, 3, "dash.mpd"))
+
+	garbage, err = c.Collect(ctx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, collected(garbage))
+
+	assert.True(t, store.HasObject(ctx, 1, "dash.mpd"))
+	assert.False(t, store.HasObject(ctx, 1, "raw"))
+	assert.False(t, store.HasObject(ctx, 1, "chunk-0.ts"))
+	assert.False(t, store.HasObject(ctx, 1, "upload-0"))
+	assert.True(t, store.HasObject(ctx, 1, "upload-1"))
+	assert.False(t, store.HasObject(ctx, 1, "raw-0"))
+	assert.True(t, store.HasObject(ctx, 1, "raw-1"))
+	assert.False(t, store.HasObject(ctx, 2, "dash.mpd-3"))
+	assert.True(t, store.HasObject(ctx, 2, "raw"))
+	assert.True(t, store.HasObject(ctx, 2, "chunk-0.ts"))
+	assert.False(t, store.HasObject(ctx, 3, "dash.mpd"))
+	assert.True(t, store.HasObject(ctx, 4, "upload-0"))
+	assert.True(t, store.HasObject(ctx, 5, "raw"))
+	assert.NoDirExists(t, filepath.Join(cfg.Storage.Path, ".meta", "6"))
+
+	// Everything left is in use
+	garbage, err = c.Collect(ctx, false)
+	assert.NoError(t, err)
+	assert.Empty(t, garbage.Objects)
+
+	// Kept originals are still needed once the clip is done
+	cfg.Downloads.KeepOriginal = true
+	put(1, "raw", 2*time.Hour)
+
+	garbage, err = c.Collect(ctx, false)
+	assert.NoError(t, err)
+	assert.Empty(t, garbage.Objects)
+	assert.True(t, store.HasObject(ctx, 1, "raw"))
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"io"
+	"net/url"
+	"time"
 	"webserver/models"
 
 	"github.com/volatiletech/sqlboiler/v4/boil"
@@ -15,6 +17,12 @@ type Group struct {
 	ObjectStore ObjectStore
 	Users       Users
 	Clips       Clips
+	Chapters    Chapters
+	Chunks      Chunks
+	Uploads     Uploads
+	Tokens      Tokens
+	Identities  Identities
+	Sessions    Sessions
 }
 
 // Users Comment for linter
@@ -30,16 +38,57 @@ type Users interface {
 
 	Update(ctx context.Context, user *models.User, columns boil.Columns) error
 	Create(ctx context.Context, user *models.User, columns boil.Columns) error
+	// Delete removes a user along with their uploads, tokens and identities, their clips have to be deleted first
+	Delete(ctx context.Context, user *models.User) error
+
+	// Usage is how many bytes a user's clips take up, including uploads that haven't finished yet
+	Usage(ctx context.Context, uid int64) (int64, error)
+
+	// FailTOTP counts a wrong two-factor code of a user, maxAttempts of them in a row lock the user out for lockout
+	// It returns until when the user is locked out, which is in the past when they aren't
+	FailTOTP(ctx context.Context, uid int64, maxAttempts int, lockout time.Duration) (time.Time, error)
+}
+
+// ObjectInfo describes a stored object, Name is relative to its clip
+type ObjectInfo struct {
+	Name         string
+	Size         int64
+	ETag         string
+	LastModified time.Time
 }
 
 type ObjectStore interface {
 	PutObject(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error)
-	GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error)
+	GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *ObjectInfo, error)
+	// Checksum is the hex encoded SHA-256 of an object's contents, computed by PutObject while it was written
+	Checksum(ctx context.Context, cid int64, filename string) (string, error)
+	// PresignObject makes a URL the object can be downloaded from directly until expiry
+	PresignObject(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error)
 
 	DeleteObject(ctx context.Context, cid int64, filename string) error
 	DeleteObjects(ctx context.Context, cid int64, path string) error
 	HasObject(ctx context.Context, cid int64, filename string) bool
 	HasActiveUploads(ctx context.Context, cid int64) bool
+	// Usage is the total size of the objects stored for a clip
+	Usage(ctx context.Context, cid int64) (int64, error)
+
+	// ListPrefixes returns the IDs of all clips that have objects stored, or only their checksums left
+	ListPrefixes(ctx context.Context) ([]int64, error)
+	ListObjects(ctx context.Context, cid int64) ([]ObjectInfo, error)
+}
+
+// TieredObjectStore spreads objects over a hot tier and a cheaper cold one, reads find an object in whichever tier it's in
+type TieredObjectStore interface {
+	ObjectStore
+
+	// Freeze moves an object to the cold tier, Thaw moves it back. Neither fails for objects already in that tier
+	Freeze(ctx context.Context, cid int64, filename string) error
+	Thaw(ctx context.Context, cid int64, filename string) error
+	IsCold(ctx context.Context, cid int64, filename string) bool
+
+	// ListColdPrefixes returns the IDs of all clips that have objects in the cold tier
+	ListColdPrefixes(ctx context.Context) ([]int64, error)
+	ListColdObjects(ctx context.Context, cid int64) ([]ObjectInfo, error)
 }
 
 // NewGroup Comment for linter
@@ -50,20 +99,109 @@ type Clips interface {
 	Delete(ctx context.Context, clip *models.Clip) error
 
 	SearchMany(ctx context.Context, user *models.User, query string) (models.ClipSlice, error)
+	FindDuplicate(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error)
+	FindSimilar(ctx context.Context, user *models.User, clip *models.Clip, threshold int) (models.ClipSlice, error)
 
 	Update(ctx context.Context, clip *models.Clip, columns boil.Columns) error
 	Create(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (ClipTx, error)
 }
 
 type ClipTx interface {
+	// UploadVideo stores the raw video and records its SHA-256 on the clip
 	UploadVideo(ctx context.Context, r io.Reader) (int64, error)
 	Commit() error
 	Rollback() error
 }
 
+// Chapters Comment for linter
+type Chapters interface {
+	Find(ctx context.Context, chid int64) (*models.ClipChapter, error)
+	FindMany(ctx context.Context, cid int64) (models.ClipChapterSlice, error)
+
+	Update(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error
+	Create(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error
+	Delete(ctx context.Context, chapter *models.ClipChapter) error
+
+	// Replace swaps all chapters of a clip for the given ones
+	Replace(ctx context.Context, cid int64, chapters models.ClipChapterSlice) error
+}
+
+// Chunks keeps track of the chunks of a transcode that are already encoded, so they can be skipped after a restart
+type Chunks interface {
+	FindMany(ctx context.Context, cid int64) (models.TranscodeChunkSlice, error)
+	Create(ctx context.Context, chunk *models.TranscodeChunk) error
+	DeleteAll(ctx context.Context, cid int64) error
+}
+
+// Uploads keeps track of resumable uploads whose video is staged in the ObjectStore until it's complete
+type Uploads interface {
+	Find(ctx context.Context, id int64) (*models.Upload, error)
+	FindExpired(ctx context.Context) (models.UploadSlice, error)
+
+	Update(ctx context.Context, upload *models.Upload, columns boil.Columns) error
+	// Create reserves the ID of the clip the upload turns into, its parts are staged under that ID
+	Create(ctx context.Context, upload *models.Upload, columns boil.Columns) error
+	Delete(ctx context.Context, upload *models.Upload) error
+
+	// Staging reports whether an unfinished upload stages its parts under the clip ID
+	Staging(ctx context.Context, cid int64) (bool, error)
+}
+
+// Sessions stores who is logged in where, the cookie only holds a secret of which the SHA-256 hash is kept
+type Sessions interface {
+	// FindHash finds a session by the hex SHA-256 hash of its secret, whether it expired or not
+	FindHash(ctx context.Context, hash string) (*models.Session, error)
+	// FindMany finds the sessions of a user that haven't expired, the most recently seen first
+	FindMany(ctx context.Context, uid int64) (models.SessionSlice, error)
+
+	Update(ctx context.Context, session *models.Session, columns boil.Columns) error
+	Create(ctx context.Context, session *models.Session, columns boil.Columns) error
+
+	// Delete ends a session of the user, it reports whether there was one to end
+	Delete(ctx context.Context, uid int64, id int64) (bool, error)
+	DeleteHash(ctx context.Context, hash string) error
+	// DeleteAll ends every session of the user except the one with the hash except, which can be left empty
+	DeleteAll(ctx context.Context, uid int64, except string) (int64, error)
+	// DeleteExpired removes the sessions that expired, it reports how many there were
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Tokens stores the personal access tokens users authenticate scripts with, only their SHA-256 hashes are kept
+type Tokens interface {
+	// FindHash finds a token by the hex SHA-256 hash of its secret
+	FindHash(ctx context.Context, hash string) (*models.Token, error)
+	FindMany(ctx context.Context, uid int64) (models.TokenSlice, error)
+
+	Update(ctx context.Context, token *models.Token, columns boil.Columns) error
+	Create(ctx context.Context, token *models.Token, columns boil.Columns) error
+	// Delete revokes a token of the user, it reports whether there was one to revoke
+	Delete(ctx context.Context, uid int64, id int64) (bool, error)
+}
+
+// Identities links users to the accounts they log in with at OpenID Connect providers
+type Identities interface {
+	Find(ctx context.Context, provider string, subject string) (*models.UserIdentity, error)
+	Create(ctx context.Context, identity *models.UserIdentity, columns boil.Columns) error
+}
+
+// MediaInfo describes the streams of a clip's raw video, Width and Height are 0 for audio
+type MediaInfo struct {
+	Duration     time.Duration
+	Width        int
+	Height       int
+	VideoStreams int
+	AudioStreams int
+	Streams      int
+}
+
 type Transcoder interface {
 	Start() error
+	// Stop lets running transcodes finish, any it has to kill once ctx is done are resumed by the next Start
+	Stop(ctx context.Context) error
 	Queue(ctx context.Context, clip *models.Clip) error
+	// Probe reads the raw video of a clip, it works before the clip is committed
+	Probe(ctx context.Context, cid int64) (*MediaInfo, error)
 	GetProgress(cid int64) (int, bool)
 	ReportProgress(cid int64, frame int)
+	ReportChunkProgress(cid int64, chunk int, frame int)
 }
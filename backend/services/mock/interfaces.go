@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/url"
+	"time"
 
 	"webserver/models"
 	"webserver/services"
@@ -36,6 +38,9 @@ type UserProvider struct {
 	SearchManyHook     func(ctx context.Context, query string) (models.UserSlice, error)
 	UpdateHook         func(ctx context.Context, user *models.User, columns boil.Columns) error
 	CreateHook         func(ctx context.Context, user *models.User, columns boil.Columns) error
+	DeleteHook         func(ctx context.Context, user *models.User) error
+	UsageHook          func(ctx context.Context, uid int64) (int64, error)
+	FailTOTPHook       func(ctx context.Context, uid int64, maxAttempts int, lockout time.Duration) (time.Time, error)
 }
 
 func (m *UserProvider) Find(ctx context.Context, uid int64) (*models.User, error) {
@@ -62,24 +67,46 @@ func (m *UserProvider) Update(ctx context.Context, user *models.User, columns bo
 func (m *UserProvider) Create(ctx context.Context, user *models.User, columns boil.Columns) error {
 	return m.CreateHook(ctx, user, columns)
 }
+func (m *UserProvider) Delete(ctx context.Context, user *models.User) error {
+	return m.DeleteHook(ctx, user)
+}
+func (m *UserProvider) Usage(ctx context.Context, uid int64) (int64, error) {
+	return m.UsageHook(ctx, uid)
+}
+func (m *UserProvider) FailTOTP(ctx context.Context, uid int64, maxAttempts int, lockout time.Duration) (time.Time, error) {
+	return m.FailTOTPHook(ctx, uid, maxAttempts, lockout)
+}
 
 type ObjectStoreProvider struct {
 	PutObjectHook        func(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error)
-	GetObjectHook        func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error)
+	GetObjectHook        func(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error)
+	ChecksumHook         func(ctx context.Context, cid int64, filename string) (string, error)
+	PresignObjectHook    func(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error)
 	DeleteObjectHook     func(ctx context.Context, cid int64, filename string) error
 	DeleteObjectsHook    func(ctx context.Context, cid int64, path string) error
 	HasObjectHook        func(ctx context.Context, cid int64, filename string) bool
 	HasActiveUploadsHook func(ctx context.Context, cid int64) bool
+	UsageHook            func(ctx context.Context, cid int64) (int64, error)
+	ListPrefixesHook     func(ctx context.Context) ([]int64, error)
+	ListObjectsHook      func(ctx context.Context, cid int64) ([]services.ObjectInfo, error)
 }
 
 func (m *ObjectStoreProvider) PutObject(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error) {
 	return m.PutObjectHook(ctx, cid, filename, r)
 }
 
-func (m *ObjectStoreProvider) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
+func (m *ObjectStoreProvider) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
 	return m.GetObjectHook(ctx, cid, filename)
 }
 
+func (m *ObjectStoreProvider) Checksum(ctx context.Context, cid int64, filename string) (string, error) {
+	return m.ChecksumHook(ctx, cid, filename)
+}
+
+func (m *ObjectStoreProvider) PresignObject(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+	return m.PresignObjectHook(ctx, cid, filename, expiry)
+}
+
 func (m *ObjectStoreProvider) DeleteObject(ctx context.Context, cid int64, filename string) error {
 	return m.DeleteObjectHook(ctx, cid, filename)
 }
@@ -95,14 +122,28 @@ func (m *ObjectStoreProvider) HasActiveUploads(ctx context.Context, cid int64) b
 	return m.HasActiveUploadsHook(ctx, cid)
 }
 
+func (m *ObjectStoreProvider) Usage(ctx context.Context, cid int64) (int64, error) {
+	return m.UsageHook(ctx, cid)
+}
+
+func (m *ObjectStoreProvider) ListPrefixes(ctx context.Context) ([]int64, error) {
+	return m.ListPrefixesHook(ctx)
+}
+
+func (m *ObjectStoreProvider) ListObjects(ctx context.Context, cid int64) ([]services.ObjectInfo, error) {
+	return m.ListObjectsHook(ctx, cid)
+}
+
 type ClipsProvider struct {
-	FindHook       func(ctx context.Context, cid int64) (*models.Clip, error)
-	FindManyHook   func(ctx context.Context, user *models.User, mods ...qm.QueryMod) (models.ClipSlice, error)
-	ExistsHook     func(ctx context.Context, cid int64) (bool, error)
-	DeleteHook     func(ctx context.Context, clip *models.Clip) error
-	SearchManyHook func(ctx context.Context, user *models.User, query string) (models.ClipSlice, error)
-	UpdateHook     func(ctx context.Context, clip *models.Clip, columns boil.Columns) error
-	CreateHook     func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error)
+	FindHook          func(ctx context.Context, cid int64) (*models.Clip, error)
+	FindManyHook      func(ctx context.Context, user *models.User, mods ...qm.QueryMod) (models.ClipSlice, error)
+	ExistsHook        func(ctx context.Context, cid int64) (bool, error)
+	DeleteHook        func(ctx context.Context, clip *models.Clip) error
+	SearchManyHook    func(ctx context.Context, user *models.User, query string) (models.ClipSlice, error)
+	FindDuplicateHook func(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error)
+	FindSimilarHook   func(ctx context.Context, user *models.User, clip *models.Clip, threshold int) (models.ClipSlice, error)
+	UpdateHook        func(ctx context.Context, clip *models.Clip, columns boil.Columns) error
+	CreateHook        func(ctx context.Context, clip *models.Clip, creator *models.User, columns boil.Columns) (services.ClipTx, error)
 }
 
 func (m *ClipsProvider) Find(ctx context.Context, cid int64) (*models.Clip, error) {
@@ -125,6 +166,14 @@ func (m *ClipsProvider) SearchMany(ctx context.Context, user *models.User, query
 	return m.SearchManyHook(ctx, user, query)
 }
 
+func (m *ClipsProvider) FindDuplicate(ctx context.Context, user *models.User, contentHash string) (*models.Clip, error) {
+	return m.FindDuplicateHook(ctx, user, contentHash)
+}
+
+func (m *ClipsProvider) FindSimilar(ctx context.Context, user *models.User, clip *models.Clip, threshold int) (models.ClipSlice, error) {
+	return m.FindSimilarHook(ctx, user, clip, threshold)
+}
+
 func (m *ClipsProvider) Update(ctx context.Context, clip *models.Clip, columns boil.Columns) error {
 	return m.UpdateHook(ctx, clip, columns)
 }
@@ -134,13 +183,13 @@ func (m *ClipsProvider) Create(ctx context.Context, clip *models.Clip, creator *
 }
 
 type ClipTxProvider struct {
-	UploadVideoHook func(ctx context.Context, r io.Reader, size int64) (int64, error)
+	UploadVideoHook func(ctx context.Context, r io.Reader) (int64, error)
 	CommitHook      func() error
 	RollbackHook    func() error
 }
 
-func (m *ClipTxProvider) UploadVideo(ctx context.Context, r io.Reader, size int64) (int64, error) {
-	return m.UploadVideoHook(ctx, r, size)
+func (m *ClipTxProvider) UploadVideo(ctx context.Context, r io.Reader) (int64, error) {
+	return m.UploadVideoHook(ctx, r)
 }
 
 func (m *ClipTxProvider) Commit() error {
@@ -151,21 +200,201 @@ func (m *ClipTxProvider) Rollback() error {
 	return m.RollbackHook()
 }
 
+type ChaptersProvider struct {
+	FindHook     func(ctx context.Context, chid int64) (*models.ClipChapter, error)
+	FindManyHook func(ctx context.Context, cid int64) (models.ClipChapterSlice, error)
+	UpdateHook   func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error
+	CreateHook   func(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error
+	DeleteHook   func(ctx context.Context, chapter *models.ClipChapter) error
+	ReplaceHook  func(ctx context.Context, cid int64, chapters models.ClipChapterSlice) error
+}
+
+func (m *ChaptersProvider) Find(ctx context.Context, chid int64) (*models.ClipChapter, error) {
+	return m.FindHook(ctx, chid)
+}
+
+func (m *ChaptersProvider) FindMany(ctx context.Context, cid int64) (models.ClipChapterSlice, error) {
+	return m.FindManyHook(ctx, cid)
+}
+
+func (m *ChaptersProvider) Update(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+	return m.UpdateHook(ctx, chapter, columns)
+}
+
+func (m *ChaptersProvider) Create(ctx context.Context, chapter *models.ClipChapter, columns boil.Columns) error {
+	return m.CreateHook(ctx, chapter, columns)
+}
+
+func (m *ChaptersProvider) Delete(ctx context.Context, chapter *models.ClipChapter) error {
+	return m.DeleteHook(ctx, chapter)
+}
+
+func (m *ChaptersProvider) Replace(ctx context.Context, cid int64, chapters models.ClipChapterSlice) error {
+	return m.ReplaceHook(ctx, cid, chapters)
+}
+
+type ChunksProvider struct {
+	FindManyHook  func(ctx context.Context, cid int64) (models.TranscodeChunkSlice, error)
+	CreateHook    func(ctx context.Context, chunk *models.TranscodeChunk) error
+	DeleteAllHook func(ctx context.Context, cid int64) error
+}
+
+func (m *ChunksProvider) FindMany(ctx context.Context, cid int64) (models.TranscodeChunkSlice, error) {
+	return m.FindManyHook(ctx, cid)
+}
+
+func (m *ChunksProvider) Create(ctx context.Context, chunk *models.TranscodeChunk) error {
+	return m.CreateHook(ctx, chunk)
+}
+
+func (m *ChunksProvider) DeleteAll(ctx context.Context, cid int64) error {
+	return m.DeleteAllHook(ctx, cid)
+}
+
+type UploadsProvider struct {
+	FindHook        func(ctx context.Context, id int64) (*models.Upload, error)
+	FindExpiredHook func(ctx context.Context) (models.UploadSlice, error)
+	UpdateHook      func(ctx context.Context, upload *models.Upload, columns boil.Columns) error
+	CreateHook      func(ctx context.Context, upload *models.Upload, columns boil.Columns) error
+	DeleteHook      func(ctx context.Context, upload *models.Upload) error
+	StagingHook     func(ctx context.Context, cid int64) (bool, error)
+}
+
+func (m *UploadsProvider) Find(ctx context.Context, id int64) (*models.Upload, error) {
+	return m.FindHook(ctx, id)
+}
+
+func (m *UploadsProvider) FindExpired(ctx context.Context) (models.UploadSlice, error) {
+	return m.FindExpiredHook(ctx)
+}
+
+func (m *UploadsProvider) Update(ctx context.Context, upload *models.Upload, columns boil.Columns) error {
+	return m.UpdateHook(ctx, upload, columns)
+}
+
+func (m *UploadsProvider) Create(ctx context.Context, upload *models.Upload, columns boil.Columns) error {
+	return m.CreateHook(ctx, upload, columns)
+}
+
+func (m *UploadsProvider) Delete(ctx context.Context, upload *models.Upload) error {
+	return m.DeleteHook(ctx, upload)
+}
+
+func (m *UploadsProvider) Staging(ctx context.Context, cid int64) (bool, error) {
+	return m.StagingHook(ctx, cid)
+}
+
+type TokensProvider struct {
+	FindHashHook func(ctx context.Context, hash string) (*models.Token, error)
+	FindManyHook func(ctx context.Context, uid int64) (models.TokenSlice, error)
+	UpdateHook   func(ctx context.Context, token *models.Token, columns boil.Columns) error
+	CreateHook   func(ctx context.Context, token *models.Token, columns boil.Columns) error
+	DeleteHook   func(ctx context.Context, uid int64, id int64) (bool, error)
+}
+
+func (m *TokensProvider) FindHash(ctx context.Context, hash string) (*models.Token, error) {
+	return m.FindHashHook(ctx, hash)
+}
+
+func (m *TokensProvider) FindMany(ctx context.Context, uid int64) (models.TokenSlice, error) {
+	return m.FindManyHook(ctx, uid)
+}
+
+func (m *TokensProvider) Update(ctx context.Context, token *models.Token, columns boil.Columns) error {
+	return m.UpdateHook(ctx, token, columns)
+}
+
+func (m *TokensProvider) Create(ctx context.Context, token *models.Token, columns boil.Columns) error {
+	return m.CreateHook(ctx, token, columns)
+}
+
+func (m *TokensProvider) Delete(ctx context.Context, uid int64, id int64) (bool, error) {
+	return m.DeleteHook(ctx, uid, id)
+}
+
+type SessionsProvider struct {
+	FindHashHook      func(ctx context.Context, hash string) (*models.Session, error)
+	FindManyHook      func(ctx context.Context, uid int64) (models.SessionSlice, error)
+	UpdateHook        func(ctx context.Context, session *models.Session, columns boil.Columns) error
+	CreateHook        func(ctx context.Context, session *models.Session, columns boil.Columns) error
+	DeleteHook        func(ctx context.Context, uid int64, id int64) (bool, error)
+	DeleteHashHook    func(ctx context.Context, hash string) error
+	DeleteAllHook     func(ctx context.Context, uid int64, except string) (int64, error)
+	DeleteExpiredHook func(ctx context.Context) (int64, error)
+}
+
+func (m *SessionsProvider) FindHash(ctx context.Context, hash string) (*models.Session, error) {
+	return m.FindHashHook(ctx, hash)
+}
+
+func (m *SessionsProvider) FindMany(ctx context.Context, uid int64) (models.SessionSlice, error) {
+	return m.FindManyHook(ctx, uid)
+}
+
+func (m *SessionsProvider) Update(ctx context.Context, session *models.Session, columns boil.Columns) error {
+	return m.UpdateHook(ctx, session, columns)
+}
+
+func (m *SessionsProvider) Create(ctx context.Context, session *models.Session, columns boil.Columns) error {
+	return m.CreateHook(ctx, session, columns)
+}
+
+func (m *SessionsProvider) Delete(ctx context.Context, uid int64, id int64) (bool, error) {
+	return m.DeleteHook(ctx, uid, id)
+}
+
+func (m *SessionsProvider) DeleteHash(ctx context.Context, hash string) error {
+	return m.DeleteHashHook(ctx, hash)
+}
+
+func (m *SessionsProvider) DeleteAll(ctx context.Context, uid int64, except string) (int64, error) {
+	return m.DeleteAllHook(ctx, uid, except)
+}
+
+func (m *SessionsProvider) DeleteExpired(ctx context.Context) (int64, error) {
+	return m.DeleteExpiredHook(ctx)
+}
+
+type IdentitiesProvider struct {
+	FindHook   func(ctx context.Context, provider string, subject string) (*models.UserIdentity, error)
+	CreateHook func(ctx context.Context, identity *models.UserIdentity, columns boil.Columns) error
+}
+
+func (m *IdentitiesProvider) Find(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	return m.FindHook(ctx, provider, subject)
+}
+
+func (m *IdentitiesProvider) Create(ctx context.Context, identity *models.UserIdentity, columns boil.Columns) error {
+	return m.CreateHook(ctx, identity, columns)
+}
+
 type TranscoderProvider struct {
 	StartHook          func() error
+	StopHook           func(ctx context.Context) error
 	QueueHook          func(ctx context.Context, clip *models.Clip) error
+	ProbeHook          func(ctx context.Context, cid int64) (*services.MediaInfo, error)
 	GetProgressHook    func(cid int64) (int, bool)
 	ReportProgressHook func(cid int64, progress int)
+
+	ReportChunkProgressHook func(cid int64, chunk int, progress int)
 }
 
 func (m *TranscoderProvider) Start() error {
 	return m.StartHook()
 }
 
+func (m *TranscoderProvider) Stop(ctx context.Context) error {
+	return m.StopHook(ctx)
+}
+
 func (m *TranscoderProvider) Queue(ctx context.Context, clip *models.Clip) error {
 	return m.QueueHook(ctx, clip)
 }
 
+func (m *TranscoderProvider) Probe(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+	return m.ProbeHook(ctx, cid)
+}
+
 func (m *TranscoderProvider) GetProgress(cid int64) (int, bool) {
 	return m.GetProgressHook(cid)
 }
@@ -173,3 +402,7 @@ func (m *TranscoderProvider) GetProgress(cid int64) (int, bool) {
 func (m *TranscoderProvider) ReportProgress(cid int64, progress int) {
 	m.ReportProgressHook(cid, progress)
 }
+
+func (m *TranscoderProvider) ReportChunkProgress(cid int64, chunk int, progress int) {
+	m.ReportChunkProgressHook(cid, chunk, progress)
+}
@@ -0,0 +1,91 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"webserver/services"
+
+	"github.com/friendsofgo/errors"
+)
+
+var (
+	// ErrNoChecksum is returned for objects that were stored before checksums were, or copied into storage by hand
+	ErrNoChecksum = errors.New("object has no checksum")
+	// ErrChecksumMismatch is returned when the contents of an object no longer match the checksum stored with it
+	ErrChecksumMismatch = errors.New("object doesn't match its checksum")
+)
+
+// Verify reads an object in full and compares it with the checksum stored when it was written
+// expected and actual are both returned on a mismatch, so they can be reported
+func Verify(ctx context.Context, store services.ObjectStore, cid int64, filename string) (expected, actual string, err error) {
+	expected, err = store.Checksum(ctx, cid, filename)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	object, _, err := store.GetObject(ctx, cid, filename)
+
+	if err != nil {
+		return expected, "", errors.Wrap(err, "failed to get object")
+	}
+
+	defer object.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, object); err != nil {
+		return expected, "", errors.Wrap(err, "failed to read object")
+	}
+
+	actual = hex.EncodeToString(hash.Sum(nil))
+
+	if actual != expected {
+		return expected, actual, ErrChecksumMismatch
+	}
+
+	return expected, actual, nil
+}
+
+// GetVerified is GetObject for small objects like manifests, it reads the whole object into memory
+// and fails with ErrChecksumMismatch instead of returning corrupted contents. Objects without a checksum are returned as they are
+func GetVerified(ctx context.Context, store services.ObjectStore, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	expected, err := store.Checksum(ctx, cid, filename)
+
+	if err != nil && !errors.Is(err, ErrNoChecksum) {
+		return nil, nil, err
+	}
+
+	object, info, err := store.GetObject(ctx, cid, filename)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := io.ReadAll(object)
+	object.Close()
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read object")
+	}
+
+	if expected != "" {
+		if hash := sha256.Sum256(data); hex.EncodeToString(hash[:]) != expected {
+			return nil, nil, ErrChecksumMismatch
+		}
+	}
+
+	return nopSeekCloser{bytes.NewReader(data)}, info, nil
+}
+
+// nopSeekCloser is a ReadSeekCloser of something that doesn't need closing
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
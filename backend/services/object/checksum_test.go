@@ -0,0 +1,76 @@
+package object
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// corrupt overwrites an object on disk without going through the store, like a bad disk would
+func corrupt(t *testing.T, f *filesystem, cid int64, filename, contents string) {
+	assert.NoError(t, os.WriteFile(filepath.Join(f.clipDir(cid), filename), []byte(contents), 0o644))
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFilesystem(t)
+
+	_, err := f.PutObject(ctx, 1, "dash.mpd", strings.NewReader("manifest"))
+	assert.NoError(t, err)
+
+	hash := sha256.Sum256([]byte("manifest"))
+
+	expected, actual, err := Verify(ctx, f, 1, "dash.mpd")
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(hash[:]), expected)
+	assert.Equal(t, expected, actual)
+
+	corrupt(t, f, 1, "dash.mpd", "manifesT")
+
+	expected, actual, err = Verify(ctx, f, 1, "dash.mpd")
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.Equal(t, hex.EncodeToString(hash[:]), expected)
+	assert.NotEqual(t, expected, actual)
+
+	_, _, err = Verify(ctx, f, 1, "missing")
+	assert.ErrorIs(t, err, ErrNoChecksum)
+}
+
+func TestGetVerified(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFilesystem(t)
+
+	_, err := f.PutObject(ctx, 1, "dash.mpd", strings.NewReader("manifest"))
+	assert.NoError(t, err)
+
+	r, info, err := GetVerified(ctx, f, 1, "dash.mpd")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("manifest")), info.Size)
+
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "manifest", string(data))
+
+	corrupt(t, f, 1, "dash.mpd", "manifesT")
+
+	_, _, err = GetVerified(ctx, f, 1, "dash.mpd")
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	// Objects copied in by hand have nothing to be checked against until they've been read once
+	assert.NoError(t, os.MkdirAll(f.clipDir(2), 0o755))
+	corrupt(t, f, 2, "dash.mpd", "copied")
+
+	r, _, err = GetVerified(ctx, f, 2, "dash.mpd")
+	assert.NoError(t, err)
+
+	data, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "copied", string(data))
+}
@@ -0,0 +1,61 @@
+package object
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"webserver/config"
+
+	"github.com/friendsofgo/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Encryption modes that can be selected with Encryption.Mode
+const (
+	EncryptionNone     = "none"
+	EncryptionSSES3    = "sse-s3"
+	EncryptionSSEC     = "sse-c"
+	EncryptionEnvelope = "envelope"
+)
+
+// checkEncryption makes sure the encryption mode works with backend and has the key it needs
+func checkEncryption(cfg *config.Config, backend string) error {
+	switch cfg.Encryption.Mode {
+	case "", EncryptionNone:
+		return nil
+	case EncryptionSSES3, EncryptionSSEC:
+		if backend != BackendS3 {
+			return errors.Errorf("encryption mode %q needs the s3 storage backend", cfg.Encryption.Mode)
+		}
+	case EncryptionEnvelope:
+		if backend != BackendFilesystem {
+			return errors.Errorf("encryption mode %q needs the filesystem storage backend", cfg.Encryption.Mode)
+		}
+	default:
+		return errors.Errorf("unknown encryption mode %q", cfg.Encryption.Mode)
+	}
+
+	if cfg.Encryption.Mode != EncryptionSSES3 && len(cfg.Encryption.KeyBytes) == 0 {
+		return errors.Errorf("encryption mode %q needs an encryption key", cfg.Encryption.Mode)
+	}
+
+	return nil
+}
+
+// deriveKey derives the key objects of a clip are encrypted with from Encryption.Key
+// The key itself is never used directly, so it's never sent to S3 with SSE-C either
+func deriveKey(cfg *config.Config, cid int64) ([]byte, error) {
+	info := "clipable objects"
+
+	if cfg.Encryption.PerClip {
+		info = fmt.Sprintf("clipable clip %d", cid)
+	}
+
+	key := make([]byte, 32)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, cfg.Encryption.KeyBytes, nil, []byte(info)), key); err != nil {
+		return nil, errors.Wrap(err, "failed to derive key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,277 @@
+package object
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/friendsofgo/errors"
+)
+
+// Envelope encrypted files start with a header holding the file's own data key, wrapped with the clip's key:
+//
+//	magic (8) | nonce (12) | wrapped data key (32 + 16)
+//
+// followed by the contents sealed in chunks of envelopeChunkSize. Every chunk is sealed on its own,
+// so a range can be read without decrypting anything before it
+const (
+	envelopeChunkSize  = 64 * 1024
+	envelopeOverhead   = 16 // GCM tag of every chunk
+	envelopeSealedSize = envelopeChunkSize + envelopeOverhead
+	envelopeHeaderSize = len(envelopeMagic) + 12 + 32 + envelopeOverhead
+)
+
+const envelopeMagic = "\x00CLPENV1"
+
+// ErrCorruptEnvelope is returned when an envelope encrypted file can't be decrypted
+var ErrCorruptEnvelope = errors.New("envelope encrypted object is corrupt")
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// envelopeNonce gives every chunk of a file its own nonce, the last chunk is marked so a truncated file doesn't decrypt
+// Data keys are never reused between files, so a counter is enough
+func envelopeNonce(chunk int64, final bool) []byte {
+	nonce := make([]byte, 12)
+
+	if final {
+		nonce[0] = 1
+	}
+
+	binary.BigEndian.PutUint64(nonce[4:], uint64(chunk))
+
+	return nonce
+}
+
+// envelopeWriter encrypts everything written to it, Close has to be called to seal the last chunk
+type envelopeWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	buffer []byte
+	sealed []byte
+	chunk  int64
+}
+
+func newEnvelopeWriter(w io.Writer, key []byte) (*envelopeWriter, error) {
+	wrap, err := newGCM(key)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create key cipher")
+	}
+
+	dataKey := make([]byte, 32)
+	nonce := make([]byte, wrap.NonceSize())
+
+	for _, b := range [][]byte{dataKey, nonce} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, errors.Wrap(err, "failed to generate data key")
+		}
+	}
+
+	header := append([]byte(envelopeMagic), nonce...)
+	header = wrap.Seal(header, nonce, dataKey, []byte(envelopeMagic))
+
+	aead, err := newGCM(dataKey)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create data cipher")
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &envelopeWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buffer: make([]byte, 0, envelopeChunkSize),
+		sealed: make([]byte, 0, envelopeSealedSize),
+	}, nil
+}
+
+func (e *envelopeWriter) Write(p []byte) (int, error) {
+	written := 0
+
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data shows up, until then it might still be the last one
+		if len(e.buffer) == envelopeChunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(e.buffer[len(e.buffer):envelopeChunkSize], p)
+		e.buffer = e.buffer[:len(e.buffer)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close seals the last chunk, it doesn't close the underlying writer
+func (e *envelopeWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *envelopeWriter) seal(final bool) error {
+	e.sealed = e.aead.Seal(e.sealed[:0], envelopeNonce(e.chunk, final), e.buffer, e.header)
+
+	if _, err := e.w.Write(e.sealed); err != nil {
+		return err
+	}
+
+	e.chunk++
+	e.buffer = e.buffer[:0]
+
+	return nil
+}
+
+// envelopeReader decrypts an envelope encrypted file, one chunk at a time as it's read
+type envelopeReader struct {
+	file   *os.File
+	aead   cipher.AEAD
+	header []byte
+
+	fileSize int64
+	size     int64 // Size of the decrypted contents
+	chunks   int64
+	offset   int64
+
+	chunk  int64 // Index of the chunk in plain, -1 if there's none
+	plain  []byte
+	sealed []byte
+}
+
+// openEnvelope starts decrypting file, ok is false if the file isn't envelope encrypted at all
+func openEnvelope(file *os.File, fileSize int64, key []byte) (reader *envelopeReader, ok bool, err error) {
+	header := make([]byte, envelopeHeaderSize)
+
+	// Files stored before encryption was enabled are read as they are
+	if n, _ := file.ReadAt(header, 0); n < len(header) || !bytes.HasPrefix(header, []byte(envelopeMagic)) {
+		return nil, false, nil
+	}
+
+	wrap, err := newGCM(key)
+
+	if err != nil {
+		return nil, true, errors.Wrap(err, "failed to create key cipher")
+	}
+
+	nonce := header[len(envelopeMagic) : len(envelopeMagic)+wrap.NonceSize()]
+
+	dataKey, err := wrap.Open(nil, nonce, header[len(envelopeMagic)+len(nonce):], []byte(envelopeMagic))
+
+	if err != nil {
+		return nil, true, errors.Wrap(ErrCorruptEnvelope, "failed to unwrap data key")
+	}
+
+	aead, err := newGCM(dataKey)
+
+	if err != nil {
+		return nil, true, errors.Wrap(err, "failed to create data cipher")
+	}
+
+	// The last chunk is always written, even if it's empty
+	body := fileSize - int64(envelopeHeaderSize)
+	chunks := (body + envelopeSealedSize - 1) / envelopeSealedSize
+
+	if chunks == 0 || body-(chunks-1)*envelopeSealedSize < envelopeOverhead {
+		return nil, true, errors.Wrap(ErrCorruptEnvelope, "file is truncated")
+	}
+
+	return &envelopeReader{
+		file:     file,
+		aead:     aead,
+		header:   header,
+		fileSize: fileSize,
+		size:     body - chunks*envelopeOverhead,
+		chunks:   chunks,
+		chunk:    -1,
+		plain:    make([]byte, 0, envelopeChunkSize),
+		sealed:   make([]byte, envelopeSealedSize),
+	}, true, nil
+}
+
+func (e *envelopeReader) Read(p []byte) (int, error) {
+	if e.offset >= e.size {
+		return 0, io.EOF
+	}
+
+	chunk := e.offset / envelopeChunkSize
+
+	if err := e.load(chunk); err != nil {
+		return 0, err
+	}
+
+	n := copy(p, e.plain[e.offset-chunk*envelopeChunkSize:])
+	e.offset += int64(n)
+
+	return n, nil
+}
+
+func (e *envelopeReader) load(chunk int64) error {
+	if chunk == e.chunk {
+		return nil
+	}
+
+	start := int64(envelopeHeaderSize) + chunk*envelopeSealedSize
+	length := e.fileSize - start
+
+	if length > envelopeSealedSize {
+		length = envelopeSealedSize
+	}
+
+	if _, err := e.file.ReadAt(e.sealed[:length], start); err != nil && err != io.EOF {
+		return errors.Wrap(err, "failed to read chunk")
+	}
+
+	plain, err := e.aead.Open(e.plain[:0], envelopeNonce(chunk, chunk == e.chunks-1), e.sealed[:length], e.header)
+
+	if err != nil {
+		e.chunk = -1
+		return errors.Wrapf(ErrCorruptEnvelope, "failed to decrypt chunk %d", chunk)
+	}
+
+	e.plain = plain
+	e.chunk = chunk
+
+	return nil
+}
+
+func (e *envelopeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += e.offset
+	case io.SeekEnd:
+		offset += e.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	e.offset = offset
+
+	return offset, nil
+}
+
+func (e *envelopeReader) Close() error {
+	return e.file.Close()
+}
@@ -0,0 +1,460 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"webserver/config"
+	"webserver/services"
+
+	"github.com/friendsofgo/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Storage backends that can be selected with Storage.Backend
+const (
+	BackendS3         = "s3"
+	BackendFilesystem = "filesystem"
+)
+
+var (
+	// ErrInvalidObjectName is returned for object names that would escape the clip's directory
+	ErrInvalidObjectName = errors.New("invalid object name")
+	// ErrPresignUnsupported is returned by stores that objects can't be fetched from without going through the backend
+	ErrPresignUnsupported = errors.New("presigned urls are not supported by this storage backend")
+)
+
+// filesystem stores objects as files in {root}/{cid}/{filename}, with the ETag of every object kept next to it in {root}/.meta
+// The ETag is the SHA-256 of the object, so it doubles as its checksum
+// With envelope encryption the files are encrypted, the ETags are still those of the contents
+type filesystem struct {
+	*uploadTracker
+	root string
+	cfg  *config.Config
+}
+
+// NewFilesystemStore creates an ObjectStore rooted at Storage.Path, for installs that don't want to run S3
+func NewFilesystemStore(cfg *config.Config) (services.ObjectStore, error) {
+	if err := checkEncryption(cfg, BackendFilesystem); err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(cfg.Storage.Path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve storage path")
+	}
+
+	f := &filesystem{newUploadTracker(), root, cfg}
+
+	// Uploads are written to .tmp first, it has to be on the same filesystem as the objects for the rename to be atomic
+	for _, dir := range []string{root, f.tmpDir(), f.metaDir()} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, errors.Wrap(err, "failed to create storage directory")
+		}
+	}
+
+	return f, nil
+}
+
+func (f *filesystem) tmpDir() string {
+	return filepath.Join(f.root, ".tmp")
+}
+
+func (f *filesystem) metaDir() string {
+	return filepath.Join(f.root, ".meta")
+}
+
+func (f *filesystem) clipDir(cid int64) string {
+	return filepath.Join(f.root, strconv.FormatInt(cid, 10))
+}
+
+func (f *filesystem) clipMetaDir(cid int64) string {
+	return filepath.Join(f.metaDir(), strconv.FormatInt(cid, 10))
+}
+
+// validName rejects anything that isn't a plain file name, so objects can't be read or written outside of their clip
+func validName(filename string) bool {
+	return filename != "" && filename != "." && filename != ".." && !strings.ContainsAny(filename, `/\`+"\x00")
+}
+
+func (f *filesystem) PutObject(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error) {
+	if !validName(filename) {
+		return 0, ErrInvalidObjectName
+	}
+
+	f.AddActiveUpload(ctx, cid)
+	defer f.RemoveActiveUpload(ctx, cid)
+
+	tmp, err := os.CreateTemp(f.tmpDir(), "upload-*")
+
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create temporary file")
+	}
+
+	// Once renamed this is a no-op, otherwise it cleans up the partial upload
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+
+	n, err := f.write(cid, tmp, io.TeeReader(r, hash))
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to write object")
+	}
+
+	if ctx.Err() != nil {
+		return 0, errors.Wrap(ctx.Err(), "context error")
+	}
+
+	if err := os.MkdirAll(f.clipDir(cid), 0o755); err != nil {
+		return 0, errors.Wrap(err, "failed to create clip directory")
+	}
+
+	// The old ETag goes first, if the new one can't be written the object is left without one rather than with one of other contents
+	if err := os.Remove(filepath.Join(f.clipMetaDir(cid), filename)); err != nil && !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "failed to delete old ETag")
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(f.clipDir(cid), filename)); err != nil {
+		return 0, errors.Wrap(err, "failed to move object into place")
+	}
+
+	if err := f.writeETag(cid, filename, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// write copies r into file, encrypting it if envelope encryption is enabled
+func (f *filesystem) write(cid int64, file *os.File, r io.Reader) (int64, error) {
+	if f.cfg.Encryption.Mode != EncryptionEnvelope {
+		return io.Copy(file, r)
+	}
+
+	key, err := deriveKey(f.cfg, cid)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Buffered, so chunks are written to the file in one go
+	buffered := bufio.NewWriterSize(file, envelopeSealedSize)
+
+	w, err := newEnvelopeWriter(buffered, key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(w, r)
+
+	if err != nil {
+		return n, err
+	}
+
+	if err := w.Close(); err != nil {
+		return n, err
+	}
+
+	return n, buffered.Flush()
+}
+
+// open returns a reader of the contents of file, decrypting them if they're envelope encrypted
+// The size in info is that of the contents, not of the file
+func (f *filesystem) open(cid int64, file *os.File) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	stat, err := file.Stat()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &services.ObjectInfo{Size: stat.Size(), LastModified: stat.ModTime()}
+
+	if f.cfg.Encryption.Mode != EncryptionEnvelope {
+		return file, info, nil
+	}
+
+	key, err := deriveKey(f.cfg, cid)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, ok, err := openEnvelope(file, stat.Size(), key)
+
+	if err != nil {
+		return nil, nil, err
+	} else if !ok {
+		return file, info, nil
+	}
+
+	info.Size = reader.size
+
+	return reader, info, nil
+}
+
+// writeETag atomically replaces the stored ETag of an object
+func (f *filesystem) writeETag(cid int64, filename, etag string) error {
+	if err := os.MkdirAll(f.clipMetaDir(cid), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create clip metadata directory")
+	}
+
+	tmp, err := os.CreateTemp(f.tmpDir(), "meta-*")
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(etag)
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to write etag")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), filepath.Join(f.clipMetaDir(cid), filename)), "failed to move etag into place")
+}
+
+func (f *filesystem) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	if !validName(filename) {
+		return nil, nil, ErrInvalidObjectName
+	}
+
+	file, err := os.Open(filepath.Join(f.clipDir(cid), filename))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, info, err := f.open(cid, file)
+
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	etag, err := os.ReadFile(filepath.Join(f.clipMetaDir(cid), filename))
+
+	// Objects copied into the directory by hand don't have an ETag yet, so hash them once
+	if err != nil {
+		hash := sha256.New()
+
+		if _, err := io.Copy(hash, reader); err != nil {
+			file.Close()
+			return nil, nil, errors.Wrap(err, "failed to hash object")
+		}
+
+		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, nil, errors.Wrap(err, "failed to seek object")
+		}
+
+		etag = []byte(hex.EncodeToString(hash.Sum(nil)))
+
+		// Not being able to cache the ETag only means hashing it again next time
+		if err := f.writeETag(cid, filename, string(etag)); err != nil {
+			log.WithError(err).Warn("Failed to store object etag")
+		}
+	}
+
+	info.Name = filename
+	info.ETag = string(etag)
+
+	return reader, info, nil
+}
+
+// Checksum is the stored ETag, which is the SHA-256 of the contents
+// Objects copied in by hand get theirs the first time they're read, so only later changes to them are caught
+func (f *filesystem) Checksum(ctx context.Context, cid int64, filename string) (string, error) {
+	if !validName(filename) {
+		return "", ErrInvalidObjectName
+	}
+
+	checksum, err := os.ReadFile(filepath.Join(f.clipMetaDir(cid), filename))
+
+	if os.IsNotExist(err) {
+		return "", ErrNoChecksum
+	} else if err != nil {
+		return "", errors.Wrap(err, "failed to read checksum")
+	}
+
+	return string(checksum), nil
+}
+
+func (f *filesystem) PresignObject(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+	return nil, ErrPresignUnsupported
+}
+
+func (f *filesystem) DeleteObject(ctx context.Context, cid int64, filename string) error {
+	if !validName(filename) {
+		return ErrInvalidObjectName
+	}
+
+	for _, dir := range []string{f.clipDir(cid), f.clipMetaDir(cid)} {
+		if err := os.Remove(filepath.Join(dir, filename)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to delete object")
+		}
+	}
+
+	return nil
+}
+
+func (f *filesystem) DeleteObjects(ctx context.Context, cid int64, path string) error {
+	if path != "" && !validName(path) {
+		return ErrInvalidObjectName
+	}
+
+	for _, dir := range []string{f.clipDir(cid), f.clipMetaDir(cid)} {
+		if path == "" {
+			if err := os.RemoveAll(dir); err != nil {
+				return errors.Wrap(err, "failed to delete objects")
+			}
+
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "failed to list objects")
+		}
+
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), path) {
+				continue
+			}
+
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "failed to delete object")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *filesystem) HasObject(ctx context.Context, cid int64, filename string) bool {
+	if !validName(filename) {
+		return false
+	}
+
+	info, err := os.Stat(filepath.Join(f.clipDir(cid), filename))
+
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (f *filesystem) Usage(ctx context.Context, cid int64) (int64, error) {
+	entries, err := os.ReadDir(f.clipDir(cid))
+
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.Wrap(err, "failed to list objects")
+	}
+
+	var size int64
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+
+		// Deleted since it was listed
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, errors.Wrap(err, "failed to stat object")
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+	}
+
+	return size, nil
+}
+
+func (f *filesystem) ListPrefixes(ctx context.Context) ([]int64, error) {
+	entries, err := os.ReadDir(f.root)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list prefixes")
+	}
+
+	// Clips whose objects are gone but not their ETags are listed too, so they can be cleaned up
+	metaEntries, err := os.ReadDir(f.metaDir())
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to list prefixes")
+	}
+
+	var cids []int64
+	seen := make(map[int64]bool, len(entries))
+
+	for _, entry := range append(entries, metaEntries...) {
+		// Skips .tmp and .meta along with anything else that isn't a clip's directory
+		cid, err := strconv.ParseInt(entry.Name(), 10, 64)
+
+		if err != nil || !entry.IsDir() || seen[cid] {
+			continue
+		}
+
+		seen[cid] = true
+		cids = append(cids, cid)
+	}
+
+	return cids, nil
+}
+
+func (f *filesystem) ListObjects(ctx context.Context, cid int64) ([]services.ObjectInfo, error) {
+	entries, err := os.ReadDir(f.clipDir(cid))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+
+	var objects []services.ObjectInfo
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to stat object")
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		objects = append(objects, services.ObjectInfo{
+			Name:         entry.Name(),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
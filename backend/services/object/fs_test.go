@@ -0,0 +1,238 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"webserver/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFilesystem(t *testing.T) *filesystem {
+	cfg := &config.Config{}
+	cfg.Storage.Path = t.TempDir()
+
+	store, err := NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	return store.(*filesystem)
+}
+
+func TestFilesystem_PutGetObject(t *testing.T) {
+	f := newTestFilesystem(t)
+	ctx := context.Background()
+
+	n, err := f.PutObject(ctx, 1, "dash.mpd", strings.NewReader("first"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	r, info, err := f.GetObject(ctx, 1, "dash.mpd")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+	// sha256 of "first"
+	assert.Equal(t, "a7937b64b8caa58f03721bb6bacf5c78cb235febe0e70b1b84cd99541461a08e", info.ETag)
+
+	// Overwriting an object doesn't affect readers that already have it open
+	_, err = f.PutObject(ctx, 1, "dash.mpd", strings.NewReader("second"))
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+	assert.NoError(t, r.Close())
+
+	r, newInfo, err := f.GetObject(ctx, 1, "dash.mpd")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), newInfo.Size)
+	assert.NotEqual(t, info.ETag, newInfo.ETag)
+
+	// Reads are seekable for range requests
+	_, err = r.Seek(3, io.SeekStart)
+	assert.NoError(t, err)
+
+	data, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "ond", string(data))
+	assert.NoError(t, r.Close())
+
+	// Nothing is left behind in the temporary directory
+	entries, err := os.ReadDir(f.tmpDir())
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFilesystem_GetObjectWithoutETag(t *testing.T) {
+	f := newTestFilesystem(t)
+	ctx := context.Background()
+
+	assert.NoError(t, os.MkdirAll(f.clipDir(1), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(f.clipDir(1), "raw"), []byte("first"), 0o644))
+
+	r, info, err := f.GetObject(ctx, 1, "raw")
+	assert.NoError(t, err)
+	assert.Equal(t, "a7937b64b8caa58f03721bb6bacf5c78cb235febe0e70b1b84cd99541461a08e", info.ETag)
+
+	// The hash is computed before handing the file out, so it has to be rewound
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+	assert.NoError(t, r.Close())
+}
+
+func TestFilesystem_DeleteObjects(t *testing.T) {
+	f := newTestFilesystem(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"chunk-0.ts", "chunk-1.ts", "dash.mpd"} {
+		_, err := f.PutObject(ctx, 1, name, strings.NewReader(name))
+		assert.NoError(t, err)
+	}
+
+	_, err := f.PutObject(ctx, 2, "chunk-0.ts", strings.NewReader("other clip"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, f.DeleteObjects(ctx, 1, "chunk-"))
+
+	assert.False(t, f.HasObject(ctx, 1, "chunk-0.ts"))
+	assert.False(t, f.HasObject(ctx, 1, "chunk-1.ts"))
+	assert.True(t, f.HasObject(ctx, 1, "dash.mpd"))
+	assert.True(t, f.HasObject(ctx, 2, "chunk-0.ts"))
+
+	assert.NoError(t, f.DeleteObjects(ctx, 1, ""))
+
+	assert.False(t, f.HasObject(ctx, 1, "dash.mpd"))
+	assert.True(t, f.HasObject(ctx, 2, "chunk-0.ts"))
+
+	// Deleting objects of a clip that has none isn't an error
+	assert.NoError(t, f.DeleteObjects(ctx, 3, "chunk-"))
+	assert.NoError(t, f.DeleteObject(ctx, 3, "raw"))
+}
+
+func TestFilesystem_Usage(t *testing.T) {
+	f := newTestFilesystem(t)
+	ctx := context.Background()
+
+	size, err := f.Usage(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), size)
+
+	for name, data := range map[string]string{"dash.mpd": "manifest", "thumbnail.jpg": "jpg"} {
+		_, err := f.PutObject(ctx, 1, name, strings.NewReader(data))
+		assert.NoError(t, err)
+	}
+
+	_, err = f.PutObject(ctx, 2, "dash.mpd", strings.NewReader("other clip"))
+	assert.NoError(t, err)
+
+	// ETags kept next to the objects don't count
+	size, err = f.Usage(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), size)
+}
+
+func TestFilesystem_RejectsTraversal(t *testing.T) {
+	f := newTestFilesystem(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"", ".", "..", "../1/raw", "a/b", `a\b`} {
+		_, err := f.PutObject(ctx, 1, name, strings.NewReader("data"))
+		assert.ErrorIs(t, err, ErrInvalidObjectName, name)
+
+		_, _, err = f.GetObject(ctx, 1, name)
+		assert.ErrorIs(t, err, ErrInvalidObjectName, name)
+
+		assert.ErrorIs(t, f.DeleteObject(ctx, 1, name), ErrInvalidObjectName, name)
+		assert.False(t, f.HasObject(ctx, 1, name), name)
+	}
+
+	assert.ErrorIs(t, f.DeleteObjects(ctx, 1, "../"), ErrInvalidObjectName)
+}
+
+func TestFilesystem_Envelope(t *testing.T) {
+	f := newTestFilesystem(t)
+	ctx := context.Background()
+
+	// Stored before encryption was enabled
+	_, err := f.PutObject(ctx, 1, "dash.mpd", strings.NewReader("plain"))
+	assert.NoError(t, err)
+
+	f.cfg.Encryption.Mode = EncryptionEnvelope
+	f.cfg.Encryption.KeyBytes = bytes.Repeat([]byte{1}, 32)
+
+	// Spans a few chunks, with the last one partially filled
+	data := make([]byte, 3*envelopeChunkSize+100)
+
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	n, err := f.PutObject(ctx, 1, "raw", bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+
+	stored, err := os.ReadFile(filepath.Join(f.clipDir(1), "raw"))
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(stored, data[:envelopeChunkSize]))
+
+	r, info, err := f.GetObject(ctx, 1, "raw")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), info.Size)
+
+	// The ETag is that of the contents, not of the encrypted file
+	hash := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(hash[:]), info.ETag)
+
+	read, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, data, read)
+
+	// Ranges across chunk boundaries only decrypt the chunks they touch
+	_, err = r.Seek(envelopeChunkSize-10, io.SeekStart)
+	assert.NoError(t, err)
+
+	part := make([]byte, 20)
+	_, err = io.ReadFull(r, part)
+	assert.NoError(t, err)
+	assert.Equal(t, data[envelopeChunkSize-10:envelopeChunkSize+10], part)
+	assert.NoError(t, r.Close())
+
+	// Empty objects still get a sealed chunk
+	_, err = f.PutObject(ctx, 1, "empty", strings.NewReader(""))
+	assert.NoError(t, err)
+
+	r, info, err = f.GetObject(ctx, 1, "empty")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Size)
+	assert.NoError(t, r.Close())
+
+	r, _, err = f.GetObject(ctx, 1, "dash.mpd")
+	assert.NoError(t, err)
+
+	read, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", string(read))
+	assert.NoError(t, r.Close())
+
+	// Dropping the last chunk doesn't go unnoticed
+	assert.NoError(t, os.Truncate(filepath.Join(f.clipDir(1), "raw"), int64(envelopeHeaderSize+3*envelopeSealedSize)))
+
+	r, _, err = f.GetObject(ctx, 1, "raw")
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrCorruptEnvelope)
+	assert.NoError(t, r.Close())
+
+	// Neither does a different key
+	f.cfg.Encryption.PerClip = true
+
+	_, _, err = f.GetObject(ctx, 1, "empty")
+	assert.ErrorIs(t, err, ErrCorruptEnvelope)
+}
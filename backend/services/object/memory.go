@@ -0,0 +1,190 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"webserver/services"
+
+	"github.com/friendsofgo/errors"
+)
+
+// memory keeps objects in a map, so tests can run against a real ObjectStore without S3 or a directory to clean up
+type memory struct {
+	*uploadTracker
+	lock    sync.RWMutex
+	objects map[int64]map[string]*memoryObject
+}
+
+type memoryObject struct {
+	data         []byte
+	checksum     string
+	lastModified time.Time
+}
+
+// NewMemoryStore creates an empty ObjectStore that forgets everything once it's garbage collected
+func NewMemoryStore() services.ObjectStore {
+	return &memory{uploadTracker: newUploadTracker(), objects: make(map[int64]map[string]*memoryObject)}
+}
+
+func (m *memory) PutObject(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error) {
+	if !validName(filename) {
+		return 0, ErrInvalidObjectName
+	}
+
+	m.AddActiveUpload(ctx, cid)
+	defer m.RemoveActiveUpload(ctx, cid)
+
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read object")
+	}
+
+	if ctx.Err() != nil {
+		return 0, errors.Wrap(ctx.Err(), "context error")
+	}
+
+	hash := sha256.Sum256(data)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.objects[cid] == nil {
+		m.objects[cid] = make(map[string]*memoryObject)
+	}
+
+	m.objects[cid][filename] = &memoryObject{data, hex.EncodeToString(hash[:]), time.Now()}
+
+	return int64(len(data)), nil
+}
+
+// get returns an object, objects are never changed once stored so it can be read without holding the lock
+func (m *memory) get(cid int64, filename string) (*memoryObject, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	object, ok := m.objects[cid][filename]
+
+	return object, ok
+}
+
+func (m *memory) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	object, ok := m.get(cid, filename)
+
+	if !ok {
+		return nil, nil, os.ErrNotExist
+	}
+
+	return nopSeekCloser{bytes.NewReader(object.data)}, object.info(filename), nil
+}
+
+func (o *memoryObject) info(filename string) *services.ObjectInfo {
+	return &services.ObjectInfo{Name: filename, Size: int64(len(o.data)), ETag: o.checksum, LastModified: o.lastModified}
+}
+
+func (m *memory) Checksum(ctx context.Context, cid int64, filename string) (string, error) {
+	object, ok := m.get(cid, filename)
+
+	if !ok {
+		return "", ErrNoChecksum
+	}
+
+	return object.checksum, nil
+}
+
+func (m *memory) PresignObject(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+	return nil, ErrPresignUnsupported
+}
+
+func (m *memory) DeleteObject(ctx context.Context, cid int64, filename string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.objects[cid], filename)
+
+	if len(m.objects[cid]) == 0 {
+		delete(m.objects, cid)
+	}
+
+	return nil
+}
+
+func (m *memory) DeleteObjects(ctx context.Context, cid int64, path string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for filename := range m.objects[cid] {
+		if strings.HasPrefix(filename, path) {
+			delete(m.objects[cid], filename)
+		}
+	}
+
+	if len(m.objects[cid]) == 0 {
+		delete(m.objects, cid)
+	}
+
+	return nil
+}
+
+func (m *memory) HasObject(ctx context.Context, cid int64, filename string) bool {
+	_, ok := m.get(cid, filename)
+
+	return ok
+}
+
+func (m *memory) Usage(ctx context.Context, cid int64) (int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var size int64
+
+	for _, object := range m.objects[cid] {
+		size += int64(len(object.data))
+	}
+
+	return size, nil
+}
+
+// ListPrefixes is sorted like the listings of the other backends
+func (m *memory) ListPrefixes(ctx context.Context) ([]int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	cids := make([]int64, 0, len(m.objects))
+
+	for cid := range m.objects {
+		cids = append(cids, cid)
+	}
+
+	sort.Slice(cids, func(i, j int) bool {
+		return cids[i] < cids[j]
+	})
+
+	return cids, nil
+}
+
+func (m *memory) ListObjects(ctx context.Context, cid int64) ([]services.ObjectInfo, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var objects []services.ObjectInfo
+
+	for filename, object := range m.objects[cid] {
+		objects = append(objects, *object.info(filename))
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+
+	return objects, nil
+}
@@ -0,0 +1,71 @@
+package object
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Objects(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	for _, name := range []string{"dash.mpd", "dash-stream0.m4s", "chunk-0.ts", "chunk-1.ts"} {
+		_, err := m.PutObject(ctx, 2, name, strings.NewReader(name))
+		assert.NoError(t, err)
+	}
+
+	n, err := m.PutObject(ctx, 1, "raw", strings.NewReader("first"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	_, err = m.PutObject(ctx, 1, "../raw", strings.NewReader("first"))
+	assert.ErrorIs(t, err, ErrInvalidObjectName)
+
+	r, info, err := m.GetObject(ctx, 1, "raw")
+	assert.NoError(t, err)
+	// sha256 of "first", the same ETag the filesystem backend gives it
+	assert.Equal(t, "a7937b64b8caa58f03721bb6bacf5c78cb235febe0e70b1b84cd99541461a08e", info.ETag)
+
+	// Overwriting an object doesn't affect readers that already have it open
+	_, err = m.PutObject(ctx, 1, "raw", strings.NewReader("second"))
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	_, _, err = Verify(ctx, m, 1, "raw")
+	assert.NoError(t, err)
+
+	cids, err := m.ListPrefixes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, cids)
+
+	usage, err := m.Usage(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("dash.mpd")+len("dash-stream0.m4s")+2*len("chunk-0.ts")), usage)
+
+	assert.NoError(t, m.DeleteObjects(ctx, 2, "chunk-"))
+
+	objects, err := m.ListObjects(ctx, 2)
+	assert.NoError(t, err)
+
+	if assert.Len(t, objects, 2) {
+		assert.Equal(t, "dash-stream0.m4s", objects[0].Name)
+		assert.Equal(t, "dash.mpd", objects[1].Name)
+	}
+
+	assert.NoError(t, m.DeleteObject(ctx, 1, "raw"))
+	assert.False(t, m.HasObject(ctx, 1, "raw"))
+
+	_, err = m.Checksum(ctx, 1, "raw")
+	assert.ErrorIs(t, err, ErrNoChecksum)
+
+	cids, err = m.ListPrefixes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, cids)
+}
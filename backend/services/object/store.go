@@ -3,65 +3,64 @@ package object
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"math"
-	"sync/atomic"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 	"webserver/config"
 	"webserver/services"
 
-	. "github.com/docker/go-units"
 	"github.com/friendsofgo/errors"
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	log "github.com/sirupsen/logrus"
 )
 
 type store struct {
-	s3            *minio.Client
-	cfg           *config.Config
-	contexts      cmap.ConcurrentMap[string, context.CancelFunc]
-	activeUploads cmap.ConcurrentMap[int64, *int64]
+	*uploadTracker
+	s3       *minio.Client
+	core     *minio.Core
+	presign  *minio.Client
+	cfg      *config.Config
+	contexts cmap.ConcurrentMap[string, context.CancelFunc]
 }
 
-func NewStore(c *minio.Client, cfg *config.Config) services.ObjectStore {
-	return &store{c, cfg, cmap.New[context.CancelFunc](), cmap.NewWithCustomShardingFunction[int64, *int64](func(key int64) uint32 {
-		// Copilot recommended this i have no idea if its correct
-		return uint32(key % 10)
-	})}
-}
-
-func (s *store) HasActiveUploads(ctx context.Context, cid int64) bool {
-	return s.activeUploads.Has(cid)
-}
-
-func (s *store) AddActiveUpload(ctx context.Context, cid int64) {
-	if s.activeUploads.Has(cid) {
-		count, _ := s.activeUploads.Get(cid)
-
-		// Atomically increment and set the value
-		atomic.AddInt64(count, 1)
-		return
+func NewStore(c *minio.Client, cfg *config.Config) (services.ObjectStore, error) {
+	if err := checkEncryption(cfg, BackendS3); err != nil {
+		return nil, err
 	}
 
-	count := int64(1)
+	// S3 refuses SSE-C keys sent in the clear
+	if cfg.Encryption.Mode == EncryptionSSEC && !cfg.S3.Secure {
+		return nil, errors.New("sse-c encryption needs a secure s3 connection")
+	}
 
-	s.activeUploads.Set(cid, &count)
-}
+	address, secure := cfg.S3.Address, cfg.S3.Secure
 
-func (s *store) RemoveActiveUpload(ctx context.Context, cid int64) {
-	if !s.activeUploads.Has(cid) {
-		return
+	if cfg.S3.PublicAddress != "" {
+		address, secure = cfg.S3.PublicAddress, cfg.S3.PublicSecure
 	}
 
-	count, _ := s.activeUploads.Get(cid)
+	// Presigned URLs are signed for the host clients use, knowing the region up front keeps signing from ever making a request
+	presign, err := minio.New(address, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3.Access, cfg.S3.Secret, ""),
+		Secure: secure,
+		Region: cfg.S3.Region,
+	})
 
-	// Atomically decrement and set the value
-	atomic.AddInt64(count, -1)
-
-	if atomic.LoadInt64(count) == 0 {
-		s.activeUploads.Remove(cid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create s3 presign client")
 	}
+
+	return &store{newUploadTracker(), c, &minio.Core{Client: c}, presign, cfg, cmap.New[context.CancelFunc]()}, nil
 }
 
 func (s *store) PutObject(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error) {
@@ -79,99 +78,390 @@ func (s *store) PutObject(ctx context.Context, cid int64, filename string, r io.
 	s.contexts.Set(objectPath, cancel)
 	defer s.contexts.Remove(objectPath)
 
-	buffer := make([]byte, 16*MB)
-	parts := make([]minio.CopySrcOptions, int(math.Round(float64(s.cfg.MaxUploadSizeBytes)/float64(16*MB)))+1)
+	sse, err := s.sse(cid)
 
-	defer s.DeleteObjects(ctx, cid, filename+"-")
+	if err != nil {
+		return 0, err
+	}
 
-	for i := 0; i < len(parts); i++ {
-		if ctx.Err() != nil {
-			return 0, errors.Wrap(ctx.Err(), "context error")
-		}
-		n, err := io.ReadFull(r, buffer)
-		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+	// The old checksum goes first, if the new one can't be stored the object is left without one rather than with one of other contents
+	if err := s.s3.RemoveObject(ctx, s.cfg.S3.Bucket, checksumPath(cid, filename), minio.RemoveObjectOptions{}); err != nil {
+		return 0, errors.Wrap(err, "failed to delete old checksum")
+	}
+
+	// Every byte passes through the hash on its way to S3, whichever way it's uploaded
+	hash := sha256.New()
+	r = io.TeeReader(r, hash)
+
+	opts := minio.PutObjectOptions{ServerSideEncryption: sse}
+
+	// Most objects are much smaller than a part, so the buffer only grows as large as the object
+	var first bytes.Buffer
+	var size int64
+
+	n, err := io.CopyN(&first, r, s.cfg.S3.PartSizeBytes)
+
+	// Anything that fits in a single part isn't worth the extra round trips of a multipart upload
+	if err == io.EOF {
+		if _, err := s.s3.PutObject(ctx, s.cfg.S3.Bucket, objectPath, &first, n, opts); err != nil {
 			return 0, err
 		}
 
-		if n == 0 {
-			parts = parts[:i]
+		size = n
+	} else if err != nil {
+		return 0, err
+	} else if size, err = s.putMultipart(ctx, objectPath, opts, first.Bytes(), r); err != nil {
+		return 0, err
+	}
+
+	if err := s.putChecksum(ctx, cid, filename, hex.EncodeToString(hash.Sum(nil)), sse); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// checksumPrefix is where checksums are kept, outside of {cid}/ so they aren't listed or counted as objects of the clip
+const checksumPrefix = ".meta/"
+
+// checksumPath is where the checksum of an object is kept
+// Completing a multipart upload can't set metadata that's only known once every part is sent, so it's a small object of its own
+func checksumPath(cid int64, filename string) string {
+	return fmt.Sprintf("%s%d/%s", checksumPrefix, cid, filename)
+}
+
+func (s *store) putChecksum(ctx context.Context, cid int64, filename, checksum string, sse encrypt.ServerSide) error {
+	_, err := s.s3.PutObject(ctx, s.cfg.S3.Bucket, checksumPath(cid, filename), strings.NewReader(checksum), int64(len(checksum)), minio.PutObjectOptions{
+		ServerSideEncryption: sse,
+		ContentType:          "text/plain",
+	})
+
+	return errors.Wrap(err, "failed to store checksum")
+}
+
+func (s *store) Checksum(ctx context.Context, cid int64, filename string) (string, error) {
+	sse, err := s.sse(cid)
+
+	if err != nil {
+		return "", err
+	}
+
+	obj, _, _, err := s.core.GetObject(ctx, s.cfg.S3.Bucket, checksumPath(cid, filename), minio.GetObjectOptions{ServerSideEncryption: sse})
+
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return "", ErrNoChecksum
+	} else if err != nil {
+		return "", errors.Wrap(err, "failed to get checksum")
+	}
+
+	defer obj.Close()
+
+	// A hex encoded SHA-256 is 64 bytes, anything longer isn't one
+	checksum, err := io.ReadAll(io.LimitReader(obj, 2*sha256.Size+1))
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read checksum")
+	}
+
+	return string(checksum), nil
+}
+
+// putMultipart uploads the object in parts of S3.PartSize, with up to S3.UploadConcurrency parts in flight at once
+// first is the already read first part. If anything fails the upload is aborted, so no parts are left behind
+func (s *store) putMultipart(ctx context.Context, objectPath string, opts minio.PutObjectOptions, first []byte, r io.Reader) (int64, error) {
+	uploadID, err := s.core.NewMultipartUpload(ctx, s.cfg.S3.Bucket, objectPath, opts)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to start multipart upload")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		once    sync.Once
+		partErr error
+		parts   []minio.CompletePart
+		size    int64
+	)
+
+	fail := func(err error) {
+		once.Do(func() {
+			partErr = err
+			cancel()
+		})
+	}
+
+	// Every part in flight holds on to its own buffer, so handing them out bounds both memory and parallelism
+	buffers := make(chan []byte, s.cfg.S3.UploadConcurrency)
+
+	for i := 1; i < s.cfg.S3.UploadConcurrency; i++ {
+		buffers <- nil
+	}
+
+	buffer := first
+	n := len(first)
+
+	for partID := 1; n > 0; partID++ {
+		size += int64(n)
+
+		wg.Add(1)
+
+		go func(partID int, buffer []byte, n int) {
+			defer wg.Done()
+			defer func() { buffers <- buffer }()
+
+			part, err := s.core.PutObjectPart(ctx, s.cfg.S3.Bucket, objectPath, uploadID, partID, bytes.NewReader(buffer[:n]), int64(n), "", "", opts.ServerSideEncryption)
+
+			if err != nil {
+				fail(errors.Wrapf(err, "failed to upload part %d", partID))
+				return
+			}
+
+			lock.Lock()
+			parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+			lock.Unlock()
+		}(partID, buffer, n)
+
+		select {
+		case buffer = <-buffers:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
 			break
 		}
 
-		nid := fmt.Sprintf("%s-%d", objectPath, i)
+		if buffer == nil {
+			buffer = make([]byte, s.cfg.S3.PartSizeBytes)
+		}
+
+		n, err = io.ReadFull(r, buffer)
 
-		parts[i] = minio.CopySrcOptions{
-			Bucket: s.cfg.S3.Bucket,
-			Object: nid,
-			Start:  0,
-			End:    int64(n),
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			fail(err)
+			break
 		}
+	}
 
-		if _, err = s.s3.PutObject(ctx, s.cfg.S3.Bucket, nid, bytes.NewReader(buffer[:n]), int64(n), minio.PutObjectOptions{}); err != nil {
-			return 0, err
+	wg.Wait()
+
+	if partErr == nil && ctx.Err() != nil {
+		partErr = errors.Wrap(ctx.Err(), "context error")
+	}
+
+	if partErr != nil {
+		// The request context may be what got cancelled, the abort still has to go through
+		if err := s.core.AbortMultipartUpload(context.Background(), s.cfg.S3.Bucket, objectPath, uploadID); err != nil {
+			log.WithError(err).
+				WithField("object", objectPath).
+				Error("Failed to abort multipart upload")
 		}
+
+		return 0, partErr
 	}
 
-	final, err := s.s3.ComposeObject(ctx, minio.CopyDestOptions{
-		Bucket: s.cfg.S3.Bucket,
-		Object: objectPath,
-	}, parts...)
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
 
-	if err != nil {
-		return 0, err
+	if _, err := s.core.CompleteMultipartUpload(ctx, s.cfg.S3.Bucket, objectPath, uploadID, parts, opts); err != nil {
+		s.core.AbortMultipartUpload(context.Background(), s.cfg.S3.Bucket, objectPath, uploadID)
+		return 0, errors.Wrap(err, "failed to complete multipart upload")
 	}
 
-	return final.Size, err
+	return size, nil
 }
 
-func (s *store) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, int64, string, error) {
-	obj, err := s.s3.GetObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), minio.GetObjectOptions{})
+func (s *store) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	sse, err := s.sse(cid)
+
 	if err != nil {
-		return nil, 0, "", err
+		return nil, nil, err
+	}
+
+	obj, err := s.s3.GetObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), minio.GetObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	inf, err := obj.Stat()
 	if err != nil {
-		return nil, 0, "", err
+		obj.Close()
+		return nil, nil, err
+	}
+
+	return obj, &services.ObjectInfo{Name: filename, Size: inf.Size, ETag: inf.ETag, LastModified: inf.LastModified}, nil
+}
+
+func (s *store) PresignObject(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+	// Reading SSE-C objects takes the key, which can't go into a url
+	if s.cfg.Encryption.Mode == EncryptionSSEC {
+		return nil, ErrPresignUnsupported
 	}
 
-	return obj, inf.Size, inf.ETag, nil
+	return s.presign.PresignedGetObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), expiry, nil)
 }
 
 func (s *store) DeleteObject(ctx context.Context, cid int64, filename string) error {
-	return s.s3.RemoveObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), minio.RemoveObjectOptions{})
+	if err := s.s3.RemoveObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), minio.RemoveObjectOptions{}); err != nil {
+		return err
+	}
+
+	return s.s3.RemoveObject(ctx, s.cfg.S3.Bucket, checksumPath(cid, filename), minio.RemoveObjectOptions{})
 }
 
 func (s *store) DeleteObjects(ctx context.Context, cid int64, path string) error {
+	if err := s.deletePrefix(fmt.Sprintf("%d/%s", cid, path)); err != nil {
+		return err
+	}
+
+	return s.deletePrefix(checksumPath(cid, path))
+}
+
+// deletePrefix deletes every object whose key starts with prefix
+func (s *store) deletePrefix(prefix string) error {
+	// A delete that's started is finished, even if whoever asked for it goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	objectsCh := make(chan minio.ObjectInfo)
 
+	var listErr, removeErr error
+
 	// Send object names that are needed to be removed to objectsCh
-	// Todo: make sure we aren't leaking go-routines using this terrible pattern
 	go func() {
 		defer close(objectsCh)
 		// List all objects from a bucket-name with a matching prefix.
-		opts := minio.ListObjectsOptions{Prefix: fmt.Sprintf("%d/%s", cid, path), Recursive: true}
+		opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
 
-		for object := range s.s3.ListObjects(context.Background(), s.cfg.S3.Bucket, opts) {
+		for object := range s.s3.ListObjects(ctx, s.cfg.S3.Bucket, opts) {
+			// Stopping at a listing error would otherwise look like everything got deleted
 			if object.Err != nil {
-				log.WithError(object.Err).Error("failed to list object")
+				listErr = object.Err
+				return
+			}
+
+			select {
+			case objectsCh <- object:
+			case <-ctx.Done():
+				return
 			}
-			objectsCh <- object
 		}
 	}()
 
 	// Call RemoveObjects API
-	errorCh := s.s3.RemoveObjects(context.Background(), s.cfg.S3.Bucket, objectsCh, minio.RemoveObjectsOptions{})
+	errorCh := s.s3.RemoveObjects(ctx, s.cfg.S3.Bucket, objectsCh, minio.RemoveObjectsOptions{})
 
-	// Print errors received from RemoveObjects API
+	// Keep draining after the first error, so neither side of the channels is left blocked
 	for e := range errorCh {
-		return errors.Wrap(e.Err, "failed to delete object")
+		if removeErr == nil {
+			removeErr = e.Err
+			cancel()
+		}
+	}
+
+	if removeErr != nil {
+		return errors.Wrap(removeErr, "failed to delete object")
+	}
+
+	// errorCh is only closed once the listing is done, so listErr is settled by now
+	return errors.Wrap(listErr, "failed to list objects")
+}
+
+func (s *store) ListPrefixes(ctx context.Context) ([]int64, error) {
+	var cids []int64
+	seen := make(map[int64]bool)
+
+	// Clips whose objects are gone but not their checksums are listed too, so they can be cleaned up
+	for _, prefix := range []string{"", checksumPrefix} {
+		// Without recursion S3 only returns one level, every clip's objects are grouped under {cid}/
+		for object := range s.s3.ListObjects(ctx, s.cfg.S3.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+			if object.Err != nil {
+				return nil, errors.Wrap(object.Err, "failed to list prefixes")
+			}
+
+			if !strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+
+			cid, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(object.Key, prefix), "/"), 10, 64)
+
+			if err != nil || seen[cid] {
+				continue
+			}
+
+			seen[cid] = true
+			cids = append(cids, cid)
+		}
+	}
+
+	return cids, nil
+}
+
+func (s *store) ListObjects(ctx context.Context, cid int64) ([]services.ObjectInfo, error) {
+	var objects []services.ObjectInfo
+
+	prefix := fmt.Sprintf("%d/", cid)
+
+	for object := range s.s3.ListObjects(ctx, s.cfg.S3.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, errors.Wrap(object.Err, "failed to list objects")
+		}
+
+		objects = append(objects, services.ObjectInfo{
+			Name:         strings.TrimPrefix(object.Key, prefix),
+			Size:         object.Size,
+			ETag:         object.ETag,
+			LastModified: object.LastModified,
+		})
 	}
 
-	return nil
+	return objects, nil
+}
+
+func (s *store) Usage(ctx context.Context, cid int64) (int64, error) {
+	var size int64
+
+	for object := range s.s3.ListObjects(ctx, s.cfg.S3.Bucket, minio.ListObjectsOptions{Prefix: fmt.Sprintf("%d/", cid), Recursive: true}) {
+		if object.Err != nil {
+			return 0, errors.Wrap(object.Err, "failed to list objects")
+		}
+
+		size += object.Size
+	}
+
+	return size, nil
 }
 
 func (s *store) HasObject(ctx context.Context, cid int64, filename string) bool {
-	_, err := s.s3.StatObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), minio.GetObjectOptions{})
+	sse, err := s.sse(cid)
+
+	if err != nil {
+		return false
+	}
+
+	_, err = s.s3.StatObject(ctx, s.cfg.S3.Bucket, fmt.Sprintf("%d/%s", cid, filename), minio.GetObjectOptions{ServerSideEncryption: sse})
 	return err == nil
 }
+
+// sse returns how the objects of a clip are encrypted by S3, or nil if they aren't
+// minio only sends the headers of SSE-S3 when writing, while SSE-C keys go with every request
+func (s *store) sse(cid int64) (encrypt.ServerSide, error) {
+	switch s.cfg.Encryption.Mode {
+	case EncryptionSSES3:
+		return encrypt.NewSSE(), nil
+	case EncryptionSSEC:
+		key, err := deriveKey(s.cfg, cid)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return encrypt.NewSSEC(key)
+	}
+
+	return nil, nil
+}
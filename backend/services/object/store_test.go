@@ -0,0 +1,326 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"webserver/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 implements just enough of the S3 API to follow what PutObject does and read back single objects
+type fakeS3 struct {
+	lock      sync.Mutex
+	objects   map[string][]byte
+	parts     map[int][]byte
+	completed []int
+	aborted   bool
+	failPart  int
+	sse       []string // How every request asked for its object to be encrypted
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	query := req.URL.Query()
+	body, _ := io.ReadAll(req.Body)
+
+	switch {
+	// Deletes don't take any encryption headers, there's nothing to check about them
+	case req.Method == http.MethodDelete:
+	case req.Header.Get("X-Amz-Server-Side-Encryption") != "":
+		f.sse = append(f.sse, EncryptionSSES3)
+	case req.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5") != "":
+		f.sse = append(f.sse, EncryptionSSEC+":"+req.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"))
+	default:
+		f.sse = append(f.sse, EncryptionNone)
+	}
+
+	if req.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		body = decodeChunked(body)
+	}
+
+	switch {
+	case req.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>clips</Bucket><Key>1/raw</Key><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+	case req.Method == http.MethodPut && query.Has("partNumber"):
+		partID, _ := strconv.Atoi(query.Get("partNumber"))
+
+		// Server errors are retried by minio, a denied part fails straight away
+		if partID == f.failPart {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		f.parts[partID] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, partID))
+	case req.Method == http.MethodPost && query.Has("uploadId"):
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+			} `xml:"Part"`
+		}
+
+		xml.Unmarshal(body, &complete)
+
+		var object []byte
+
+		for _, part := range complete.Parts {
+			f.completed = append(f.completed, part.PartNumber)
+			object = append(object, f.parts[part.PartNumber]...)
+		}
+
+		f.objects[req.URL.Path] = object
+
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>clips</Bucket><Key>1/raw</Key><ETag>"object"</ETag></CompleteMultipartUploadResult>`)
+	case req.Method == http.MethodDelete && query.Has("uploadId"):
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodPut:
+		f.objects[req.URL.Path] = body
+		w.Header().Set("ETag", `"object"`)
+	case req.Method == http.MethodGet:
+		object, ok := f.objects[req.URL.Path]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+
+		w.Header().Set("ETag", `"object"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Write(object)
+	case req.Method == http.MethodDelete:
+		delete(f.objects, req.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// decodeChunked strips the chunk signatures minio adds when streaming a body over plain http
+func decodeChunked(body []byte) []byte {
+	var data []byte
+
+	for len(body) > 0 {
+		header, rest, _ := bytes.Cut(body, []byte("\r\n"))
+		size, _ := strconv.ParseInt(string(bytes.SplitN(header, []byte(";"), 2)[0]), 16, 64)
+
+		if size == 0 {
+			break
+		}
+
+		data = append(data, rest[:size]...)
+		body = rest[size+2:]
+	}
+
+	return data
+}
+
+func newTestStore(t *testing.T, fake *fakeS3) *store {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{}
+	cfg.S3.Address = strings.TrimPrefix(srv.URL, "http://")
+	cfg.S3.Bucket = "clips"
+	cfg.S3.Region = "us-east-1"
+	cfg.S3.PartSizeBytes = 1024
+	cfg.S3.UploadConcurrency = 2
+
+	client, err := minio.New(cfg.S3.Address, &minio.Options{
+		Creds:        credentials.NewStaticV4("access", "secret", ""),
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	assert.NoError(t, err)
+
+	s, err := NewStore(client, cfg)
+	assert.NoError(t, err)
+
+	return s.(*store)
+}
+
+func TestStore_PutObject(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		failPart  int
+		hasError  bool
+		completed []int
+		aborted   bool
+	}{
+		{
+			name: "Success - single part",
+			size: 1000,
+		},
+		{
+			name:      "Success - multipart",
+			size:      3*1024 + 512,
+			completed: []int{1, 2, 3, 4},
+		},
+		{
+			name:      "Success - exact multiple of the part size",
+			size:      2 * 1024,
+			completed: []int{1, 2},
+		},
+		{
+			name:     "Abort when a part fails",
+			size:     5 * 1024,
+			failPart: 3,
+			hasError: true,
+			aborted:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeS3{
+				objects:  make(map[string][]byte),
+				parts:    make(map[int][]byte),
+				failPart: tt.failPart,
+			}
+
+			s := newTestStore(t, fake)
+
+			data := bytes.Repeat([]byte("0123456789abcdef"), tt.size/16+1)[:tt.size]
+
+			n, err := s.PutObject(context.Background(), 1, "raw", bytes.NewReader(data))
+
+			assert.Equal(t, tt.hasError, err != nil, err)
+			assert.Equal(t, tt.completed, fake.completed)
+			assert.Equal(t, tt.aborted, fake.aborted)
+			assert.False(t, s.HasActiveUploads(context.Background(), 1))
+
+			if !tt.hasError {
+				assert.Equal(t, int64(tt.size), n)
+				assert.Equal(t, data, fake.objects["/clips/1/raw"])
+			} else {
+				assert.NotContains(t, fake.objects, "/clips/1/raw")
+			}
+		})
+	}
+}
+
+func TestStore_Encryption(t *testing.T) {
+	ctx := context.Background()
+
+	fake := &fakeS3{objects: make(map[string][]byte), parts: make(map[int][]byte)}
+	s := newTestStore(t, fake)
+	s.cfg.Encryption.Mode = EncryptionSSES3
+
+	// SSE-S3 is requested once for every object written, S3 takes care of the rest. The checksum is encrypted like its object
+	_, err := s.PutObject(ctx, 1, "raw", bytes.NewReader(make([]byte, 3*1024)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{EncryptionSSES3, EncryptionNone, EncryptionNone, EncryptionNone, EncryptionSSES3, EncryptionSSES3}, fake.sse)
+
+	// SSE-C keys can't be sent in the clear
+	cfg := *s.cfg
+	cfg.Encryption.Mode = EncryptionSSEC
+	cfg.Encryption.KeyBytes = bytes.Repeat([]byte{1}, 32)
+	cfg.Encryption.PerClip = true
+
+	_, err = NewStore(s.s3, &cfg)
+	assert.Error(t, err)
+
+	fake = &fakeS3{objects: make(map[string][]byte), parts: make(map[int][]byte)}
+	srv := httptest.NewTLSServer(fake)
+	t.Cleanup(srv.Close)
+
+	cfg.S3.Address = strings.TrimPrefix(srv.URL, "https://")
+	cfg.S3.Secure = true
+
+	client, err := minio.New(cfg.S3.Address, &minio.Options{
+		Creds:        credentials.NewStaticV4("access", "secret", ""),
+		Secure:       true,
+		Transport:    srv.Client().Transport,
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	assert.NoError(t, err)
+
+	encrypted, err := NewStore(client, &cfg)
+	assert.NoError(t, err)
+
+	// The key goes with every request of an object, and every clip has its own
+	_, err = encrypted.PutObject(ctx, 1, "raw", bytes.NewReader(make([]byte, 2*1024)))
+	assert.NoError(t, err)
+	_, err = encrypted.PutObject(ctx, 2, "raw", bytes.NewReader(make([]byte, 100)))
+	assert.NoError(t, err)
+
+	assert.Len(t, fake.sse, 7)
+
+	for _, sse := range fake.sse[:5] {
+		assert.True(t, strings.HasPrefix(sse, EncryptionSSEC+":"))
+		assert.Equal(t, fake.sse[0], sse)
+	}
+
+	for _, sse := range fake.sse[5:] {
+		assert.True(t, strings.HasPrefix(sse, EncryptionSSEC+":"))
+		assert.NotEqual(t, fake.sse[0], sse)
+	}
+
+	_, err = encrypted.PresignObject(ctx, 1, "raw", time.Minute)
+	assert.ErrorIs(t, err, ErrPresignUnsupported)
+}
+
+func TestStore_Checksum(t *testing.T) {
+	ctx := context.Background()
+
+	fake := &fakeS3{objects: make(map[string][]byte), parts: make(map[int][]byte)}
+	s := newTestStore(t, fake)
+
+	_, err := s.Checksum(ctx, 1, "raw")
+	assert.ErrorIs(t, err, ErrNoChecksum)
+
+	// Multipart uploads are hashed across all of their parts
+	for _, size := range []int{100, 3*1024 + 512} {
+		data := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+
+		_, err := s.PutObject(ctx, 1, "raw", bytes.NewReader(data))
+		assert.NoError(t, err)
+
+		hash := sha256.Sum256(data)
+
+		checksum, err := s.Checksum(ctx, 1, "raw")
+		assert.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(hash[:]), checksum)
+		assert.Equal(t, checksum, string(fake.objects["/clips/.meta/1/raw"]))
+	}
+
+	// An overwrite that fails takes the old checksum with it, rather than leaving it next to whatever was written
+	fake.failPart = 2
+
+	_, err = s.PutObject(ctx, 1, "raw", bytes.NewReader(make([]byte, 3*1024)))
+	assert.Error(t, err)
+
+	_, err = s.Checksum(ctx, 1, "raw")
+	assert.ErrorIs(t, err, ErrNoChecksum)
+
+	fake.failPart = 0
+
+	_, err = s.PutObject(ctx, 1, "raw", bytes.NewReader([]byte("raw")))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.DeleteObject(ctx, 1, "raw"))
+	assert.NotContains(t, fake.objects, "/clips/.meta/1/raw")
+
+	_, err = s.Checksum(ctx, 1, "raw")
+	assert.ErrorIs(t, err, ErrNoChecksum)
+}
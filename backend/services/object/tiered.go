@@ -0,0 +1,242 @@
+package object
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+	"webserver/services"
+
+	"github.com/friendsofgo/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// tiered writes every object to the hot store, objects are only ever in the cold one because they were frozen
+// While an object is being moved it's in both, reads prefer the hot copy
+type tiered struct {
+	hot  services.ObjectStore
+	cold services.ObjectStore
+}
+
+// NewTieredStore combines hot and cold into a single ObjectStore, see services.TieredObjectStore
+func NewTieredStore(hot, cold services.ObjectStore) services.TieredObjectStore {
+	return &tiered{hot, cold}
+}
+
+// tier returns the store an object is read from
+func (t *tiered) tier(ctx context.Context, cid int64, filename string) services.ObjectStore {
+	if !t.hot.HasObject(ctx, cid, filename) && t.cold.HasObject(ctx, cid, filename) {
+		return t.cold
+	}
+
+	return t.hot
+}
+
+func (t *tiered) PutObject(ctx context.Context, cid int64, filename string, r io.Reader) (int64, error) {
+	n, err := t.hot.PutObject(ctx, cid, filename, r)
+
+	if err != nil {
+		return n, err
+	}
+
+	// A frozen copy would only be shadowed by the new one, so it's just taking up space
+	if t.cold.HasObject(ctx, cid, filename) {
+		if err := t.cold.DeleteObject(ctx, cid, filename); err != nil {
+			log.WithError(err).
+				WithField("clip", cid).
+				WithField("object", filename).
+				Warn("Failed to delete replaced cold object")
+		}
+	}
+
+	return n, nil
+}
+
+func (t *tiered) GetObject(ctx context.Context, cid int64, filename string) (io.ReadSeekCloser, *services.ObjectInfo, error) {
+	return t.tier(ctx, cid, filename).GetObject(ctx, cid, filename)
+}
+
+func (t *tiered) Checksum(ctx context.Context, cid int64, filename string) (string, error) {
+	return t.tier(ctx, cid, filename).Checksum(ctx, cid, filename)
+}
+
+func (t *tiered) PresignObject(ctx context.Context, cid int64, filename string, expiry time.Duration) (*url.URL, error) {
+	return t.tier(ctx, cid, filename).PresignObject(ctx, cid, filename, expiry)
+}
+
+func (t *tiered) DeleteObject(ctx context.Context, cid int64, filename string) error {
+	if err := t.hot.DeleteObject(ctx, cid, filename); err != nil {
+		return err
+	}
+
+	return t.cold.DeleteObject(ctx, cid, filename)
+}
+
+func (t *tiered) DeleteObjects(ctx context.Context, cid int64, path string) error {
+	if err := t.hot.DeleteObjects(ctx, cid, path); err != nil {
+		return err
+	}
+
+	return t.cold.DeleteObjects(ctx, cid, path)
+}
+
+func (t *tiered) HasObject(ctx context.Context, cid int64, filename string) bool {
+	return t.hot.HasObject(ctx, cid, filename) || t.cold.HasObject(ctx, cid, filename)
+}
+
+// HasActiveUploads includes objects being frozen, so the GC leaves clips alone while they're moved
+func (t *tiered) HasActiveUploads(ctx context.Context, cid int64) bool {
+	return t.hot.HasActiveUploads(ctx, cid) || t.cold.HasActiveUploads(ctx, cid)
+}
+
+func (t *tiered) Usage(ctx context.Context, cid int64) (int64, error) {
+	hot, err := t.hot.Usage(ctx, cid)
+
+	if err != nil {
+		return 0, err
+	}
+
+	cold, err := t.cold.Usage(ctx, cid)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return hot + cold, nil
+}
+
+func (t *tiered) ListPrefixes(ctx context.Context) ([]int64, error) {
+	hot, err := t.hot.ListPrefixes(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cold, err := t.cold.ListPrefixes(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(hot))
+
+	for _, cid := range hot {
+		seen[cid] = true
+	}
+
+	for _, cid := range cold {
+		if !seen[cid] {
+			hot = append(hot, cid)
+		}
+	}
+
+	return hot, nil
+}
+
+// ListObjects lists every object once, wherever it's read from
+func (t *tiered) ListObjects(ctx context.Context, cid int64) ([]services.ObjectInfo, error) {
+	hot, err := t.hot.ListObjects(ctx, cid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cold, err := t.cold.ListObjects(ctx, cid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(hot))
+
+	for _, object := range hot {
+		seen[object.Name] = true
+	}
+
+	for _, object := range cold {
+		if !seen[object.Name] {
+			hot = append(hot, object)
+		}
+	}
+
+	return hot, nil
+}
+
+func (t *tiered) Freeze(ctx context.Context, cid int64, filename string) error {
+	return t.move(ctx, cid, filename, t.hot, t.cold)
+}
+
+func (t *tiered) Thaw(ctx context.Context, cid int64, filename string) error {
+	return t.move(ctx, cid, filename, t.cold, t.hot)
+}
+
+func (t *tiered) IsCold(ctx context.Context, cid int64, filename string) bool {
+	return t.tier(ctx, cid, filename) == t.cold
+}
+
+func (t *tiered) ListColdPrefixes(ctx context.Context) ([]int64, error) {
+	return t.cold.ListPrefixes(ctx)
+}
+
+func (t *tiered) ListColdObjects(ctx context.Context, cid int64) ([]services.ObjectInfo, error) {
+	return t.cold.ListObjects(ctx, cid)
+}
+
+// move copies an object from one tier to the other before deleting it, so it can always be read from one of them
+// Objects are copied through the backend, as the tiers don't have to be on the same server or even the same kind of storage
+func (t *tiered) move(ctx context.Context, cid int64, filename string, from, to services.ObjectStore) error {
+	if !from.HasObject(ctx, cid, filename) {
+		return nil
+	}
+
+	object, _, err := from.GetObject(ctx, cid, filename)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to get object")
+	}
+
+	_, err = to.PutObject(ctx, cid, filename, object)
+	object.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+
+	// The copy is hashed as it's written, so a source that went bad is caught before it's the only copy left
+	if err := sameChecksum(ctx, cid, filename, from, to); err != nil {
+		if err := to.DeleteObject(ctx, cid, filename); err != nil {
+			log.WithError(err).
+				WithField("clip", cid).
+				WithField("object", filename).
+				Warn("Failed to delete corrupted copy")
+		}
+
+		return err
+	}
+
+	return errors.Wrap(from.DeleteObject(ctx, cid, filename), "failed to delete moved object")
+}
+
+// sameChecksum fails with ErrChecksumMismatch if the copy of an object doesn't match the checksum of the original
+// Originals without a checksum can't be checked, so their copies are trusted
+func sameChecksum(ctx context.Context, cid int64, filename string, from, to services.ObjectStore) error {
+	expected, err := from.Checksum(ctx, cid, filename)
+
+	if errors.Is(err, ErrNoChecksum) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to get checksum")
+	}
+
+	actual, err := to.Checksum(ctx, cid, filename)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to get checksum of copy")
+	}
+
+	if actual != expected {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
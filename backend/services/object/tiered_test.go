@@ -0,0 +1,120 @@
+package object
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiered_FreezeThaw(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newTestFilesystem(t), newTestFilesystem(t)
+	store := NewTieredStore(hot, cold)
+
+	for _, name := range []string{"dash.mpd", "dash-stream0.m4s", "dash-stream1.m4s"} {
+		_, err := store.PutObject(ctx, 1, name, strings.NewReader(name))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, store.Freeze(ctx, 1, "dash-stream0.m4s"))
+	// Freezing twice does nothing
+	assert.NoError(t, store.Freeze(ctx, 1, "dash-stream0.m4s"))
+
+	assert.False(t, hot.HasObject(ctx, 1, "dash-stream0.m4s"))
+	assert.True(t, cold.HasObject(ctx, 1, "dash-stream0.m4s"))
+	assert.True(t, store.IsCold(ctx, 1, "dash-stream0.m4s"))
+	assert.False(t, store.IsCold(ctx, 1, "dash-stream1.m4s"))
+
+	// Reads don't care which tier an object is in
+	assert.True(t, store.HasObject(ctx, 1, "dash-stream0.m4s"))
+
+	r, info, err := store.GetObject(ctx, 1, "dash-stream0.m4s")
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "dash-stream0.m4s", string(data))
+	assert.Equal(t, int64(len(data)), info.Size)
+
+	objects, err := store.ListObjects(ctx, 1)
+	assert.NoError(t, err)
+
+	var names []string
+
+	for _, object := range objects {
+		names = append(names, object.Name)
+	}
+
+	sort.Strings(names)
+	assert.Equal(t, []string{"dash-stream0.m4s", "dash-stream1.m4s", "dash.mpd"}, names)
+
+	usage, err := store.Usage(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("dash.mpd")+2*len("dash-stream0.m4s")), usage)
+
+	cids, err := store.ListColdPrefixes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, cids)
+
+	assert.NoError(t, store.Thaw(ctx, 1, "dash-stream0.m4s"))
+	assert.True(t, hot.HasObject(ctx, 1, "dash-stream0.m4s"))
+	assert.False(t, cold.HasObject(ctx, 1, "dash-stream0.m4s"))
+}
+
+func TestTiered_PutReplacesColdObject(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newTestFilesystem(t), newTestFilesystem(t)
+	store := NewTieredStore(hot, cold)
+
+	_, err := store.PutObject(ctx, 1, "dash-stream0.m4s", strings.NewReader("first"))
+	assert.NoError(t, err)
+	assert.NoError(t, store.Freeze(ctx, 1, "dash-stream0.m4s"))
+
+	// A clip that's transcoded again writes its renditions to the hot tier
+	_, err = store.PutObject(ctx, 1, "dash-stream0.m4s", strings.NewReader("second"))
+	assert.NoError(t, err)
+
+	assert.False(t, cold.HasObject(ctx, 1, "dash-stream0.m4s"))
+	assert.False(t, store.IsCold(ctx, 1, "dash-stream0.m4s"))
+}
+
+func TestTiered_DeleteObjects(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newTestFilesystem(t), newTestFilesystem(t)
+	store := NewTieredStore(hot, cold)
+
+	for _, name := range []string{"dash.mpd", "dash-stream0.m4s"} {
+		_, err := store.PutObject(ctx, 1, name, strings.NewReader(name))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, store.Freeze(ctx, 1, "dash-stream0.m4s"))
+	assert.NoError(t, store.DeleteObjects(ctx, 1, ""))
+
+	for _, tier := range []*filesystem{hot, cold} {
+		cids, err := tier.ListPrefixes(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, cids)
+	}
+}
+
+func TestTiered_FreezeCorrupted(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := newTestFilesystem(t), newTestFilesystem(t)
+	store := NewTieredStore(hot, cold)
+
+	_, err := store.PutObject(ctx, 1, "dash-stream0.m4s", strings.NewReader("stream"))
+	assert.NoError(t, err)
+
+	corrupt(t, hot, 1, "dash-stream0.m4s", "streaM")
+
+	// The corrupted copy isn't kept, and neither is the hot object dropped
+	assert.ErrorIs(t, store.Freeze(ctx, 1, "dash-stream0.m4s"), ErrChecksumMismatch)
+	assert.True(t, hot.HasObject(ctx, 1, "dash-stream0.m4s"))
+	assert.False(t, cold.HasObject(ctx, 1, "dash-stream0.m4s"))
+}
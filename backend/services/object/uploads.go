@@ -0,0 +1,53 @@
+package object
+
+import (
+	"context"
+	"sync/atomic"
+
+	cmap "github.com/orcaman/concurrent-map/v2"
+)
+
+// uploadTracker counts the uploads in flight per clip, shared by every ObjectStore backend
+type uploadTracker struct {
+	activeUploads cmap.ConcurrentMap[int64, *int64]
+}
+
+func newUploadTracker() *uploadTracker {
+	return &uploadTracker{cmap.NewWithCustomShardingFunction[int64, *int64](func(key int64) uint32 {
+		// Copilot recommended this i have no idea if its correct
+		return uint32(key % 10)
+	})}
+}
+
+func (u *uploadTracker) HasActiveUploads(ctx context.Context, cid int64) bool {
+	return u.activeUploads.Has(cid)
+}
+
+func (u *uploadTracker) AddActiveUpload(ctx context.Context, cid int64) {
+	if u.activeUploads.Has(cid) {
+		count, _ := u.activeUploads.Get(cid)
+
+		// Atomically increment and set the value
+		atomic.AddInt64(count, 1)
+		return
+	}
+
+	count := int64(1)
+
+	u.activeUploads.Set(cid, &count)
+}
+
+func (u *uploadTracker) RemoveActiveUpload(ctx context.Context, cid int64) {
+	if !u.activeUploads.Has(cid) {
+		return
+	}
+
+	count, _ := u.activeUploads.Get(cid)
+
+	// Atomically decrement and set the value
+	atomic.AddInt64(count, -1)
+
+	if atomic.LoadInt64(count) == 0 {
+		u.activeUploads.Remove(cid)
+	}
+}
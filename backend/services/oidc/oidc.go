@@ -0,0 +1,184 @@
+// Package oidc logs users in with OpenID Connect providers, using the authorization code flow with PKCE
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"webserver/config"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUnknownProvider is returned for provider names that aren't configured
+	ErrUnknownProvider = errors.New("unknown oidc provider")
+	// ErrUnverified is returned when a provider's response can't be trusted, like an ID token with a bad signature or nonce
+	ErrUnverified = errors.New("oidc response couldn't be verified")
+)
+
+// Identity is who a provider says logged in
+type Identity struct {
+	Provider string
+	Subject  string
+	// Username is the value of the provider's username claim, empty if the ID token didn't have it
+	Username string
+}
+
+// Providers logs users in with the providers in the config
+// Providers are discovered the first time they're used, so the server still starts while one is unreachable
+type Providers struct {
+	cfg *config.Config
+
+	lock       sync.Mutex
+	discovered map[string]*provider
+}
+
+type provider struct {
+	*config.OIDCProvider
+	oauth2   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// New creates Providers for the providers in the config
+func New(cfg *config.Config) *Providers {
+	return &Providers{cfg: cfg, discovered: make(map[string]*provider)}
+}
+
+// Has reports whether a provider is configured
+func (p *Providers) Has(name string) bool {
+	_, ok := p.cfg.OIDC.Provider[name]
+	return ok
+}
+
+// AutoProvision reports whether accounts are created for identities of a provider that aren't linked to one
+func (p *Providers) AutoProvision(name string) bool {
+	cfg, ok := p.cfg.OIDC.Provider[name]
+	return ok && cfg.AutoProvision
+}
+
+func (p *Providers) get(name string) (*provider, error) {
+	cfg, ok := p.cfg.OIDC.Provider[name]
+
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if discovered, ok := p.discovered[name]; ok {
+		return discovered, nil
+	}
+
+	// The keys of the provider are fetched with the context discovery gets, which has to outlive the request that triggers it
+	discovery, err := gooidc.NewProvider(context.Background(), cfg.Issuer)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover oidc provider %s", name)
+	}
+
+	discovered := &provider{
+		OIDCProvider: cfg,
+		oauth2: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     discovery.Endpoint(),
+			RedirectURL:  fmt.Sprintf("%s/auth/oidc/%s/callback", strings.TrimSuffix(p.cfg.OIDC.RedirectURL, "/"), name),
+			Scopes:       cfg.Scopes,
+		},
+		verifier: discovery.Verifier(&gooidc.Config{ClientID: cfg.ClientID}),
+	}
+
+	p.discovered[name] = discovered
+
+	return discovered, nil
+}
+
+// AuthCodeURL is where users are sent to log in with a provider
+// state and nonce are checked when they come back, the challenge of verifier is what proves the code is exchanged by whoever asked for it
+func (p *Providers) AuthCodeURL(ctx context.Context, name, state, nonce, verifier string) (string, error) {
+	provider, err := p.get(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	return provider.oauth2.AuthCodeURL(state,
+		gooidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", challenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange trades the code a provider sent users back with for their identity, verifying the ID token it comes with
+func (p *Providers) Exchange(ctx context.Context, name, code, nonce, verifier string) (*Identity, error) {
+	provider, err := p.get(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := provider.oauth2.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+
+		// The provider turning the code down is the user's problem, not being able to ask it is ours
+		if errors.As(err, &retrieveErr) {
+			return nil, errors.Wrap(ErrUnverified, retrieveErr.Error())
+		}
+
+		return nil, errors.Wrap(err, "failed to exchange code")
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+
+	if !ok {
+		return nil, errors.Wrap(ErrUnverified, "token response has no id token")
+	}
+
+	idToken, err := provider.verifier.Verify(ctx, raw)
+
+	if err != nil {
+		return nil, errors.Wrap(ErrUnverified, err.Error())
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, errors.Wrap(ErrUnverified, "id token nonce doesn't match")
+	}
+
+	claims := map[string]interface{}{}
+
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errors.Wrap(err, "failed to parse id token claims")
+	}
+
+	identity := &Identity{Provider: name, Subject: idToken.Subject}
+	identity.Username, _ = claims[provider.UsernameClaim].(string)
+
+	return identity, nil
+}
+
+// NewSecret generates a random value for states, nonces and PKCE verifiers
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate secret")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// challenge is the S256 PKCE challenge of a verifier
+func challenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
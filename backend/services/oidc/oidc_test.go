@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"webserver/config"
+	"webserver/services/oidc/oidctest"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProviders(t *testing.T) (*Providers, *oidctest.Server) {
+	idp := oidctest.NewServer("clipable", "secret")
+	t.Cleanup(idp.Close)
+
+	cfg := &config.Config{}
+	cfg.OIDC.RedirectURL = "http://clips.test/api"
+	cfg.OIDC.Provider = map[string]*config.OIDCProvider{
+		"test": {
+			Issuer:        idp.URL,
+			ClientID:      "clipable",
+			ClientSecret:  "secret",
+			Scopes:        []string{"openid", "profile"},
+			UsernameClaim: "preferred_username",
+		},
+	}
+
+	return New(cfg), idp
+}
+
+// authorize follows the auth code URL to the provider and returns the code it sends the user back with
+func authorize(t *testing.T, p *Providers, state, nonce, verifier string) string {
+	authURL, err := p.AuthCodeURL(context.Background(), "test", state, nonce, verifier)
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/auth/oidc/test/callback", callback.Path)
+	assert.Equal(t, state, callback.Query().Get("state"))
+
+	return callback.Query().Get("code")
+}
+
+func TestProviders_Exchange(t *testing.T) {
+	p, idp := newTestProviders(t)
+	idp.Login("subject-1", map[string]interface{}{"preferred_username": "alice"})
+
+	verifier, err := NewSecret()
+	require.NoError(t, err)
+
+	code := authorize(t, p, "state", "nonce", verifier)
+
+	identity, err := p.Exchange(context.Background(), "test", code, "nonce", verifier)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Identity{Provider: "test", Subject: "subject-1", Username: "alice"}, identity)
+
+	// Codes can only be used once
+	_, err = p.Exchange(context.Background(), "test", code, "nonce", verifier)
+	assert.True(t, errors.Is(err, ErrUnverified), err)
+}
+
+func TestProviders_ExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		nonce    string
+		verifier string
+	}{
+		{name: "Wrong verifier", nonce: "nonce", verifier: "someone else's verifier"},
+		{name: "Wrong nonce", nonce: "another nonce", verifier: "verifier"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, idp := newTestProviders(t)
+			idp.Login("subject-1", nil)
+
+			code := authorize(t, p, "state", "nonce", "verifier")
+
+			_, err := p.Exchange(context.Background(), "test", code, tt.nonce, tt.verifier)
+			assert.True(t, errors.Is(err, ErrUnverified), err)
+		})
+	}
+}
+
+func TestProviders_UnknownProvider(t *testing.T) {
+	p, _ := newTestProviders(t)
+
+	assert.True(t, p.Has("test"))
+	assert.False(t, p.Has("other"))
+
+	_, err := p.AuthCodeURL(context.Background(), "other", "state", "nonce", "verifier")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
@@ -0,0 +1,265 @@
+// Package oidctest runs an OpenID Connect provider for tests
+// It logs in whoever Login was last called with as soon as they're sent to it, without a login page
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+// keyID identifies the one key tokens are signed with
+const keyID = "oidctest"
+
+// Server is an OpenID Connect provider with a single client
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	key    *rsa.PrivateKey
+	signer jose.Signer
+
+	lock     sync.Mutex
+	subject  string
+	claims   map[string]interface{}
+	requests map[string]*request
+}
+
+// request is what a code was issued for, the code is only exchanged for a token if the exchange matches it
+type request struct {
+	subject     string
+	claims      map[string]interface{}
+	nonce       string
+	redirectURI string
+	challenge   string
+}
+
+// NewServer starts a provider for the client, close it once the test is done
+func NewServer(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		panic(err)
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyID),
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		signer:       signer,
+		requests:     make(map[string]*request),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/keys", s.keys)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Login sets who users sent to the provider are logged in as, claims are added to their ID tokens
+func (s *Server) Login(subject string, claims map[string]interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.subject = subject
+	s.claims = claims
+}
+
+func (s *Server) discovery(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) keys(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &s.key.PublicKey, KeyID: keyID, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
+
+// authorize logs the user in right away and sends them back to the client with a code
+func (s *Server) authorize(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	if query.Get("client_id") != s.ClientID || query.Get("response_type") != "code" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// Every client has to use PKCE
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "code challenge required", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid redirect uri", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+
+	if s.subject == "" {
+		s.lock.Unlock()
+
+		values := redirect.Query()
+		values.Set("error", "access_denied")
+		values.Set("state", query.Get("state"))
+		redirect.RawQuery = values.Encode()
+
+		http.Redirect(w, req, redirect.String(), http.StatusFound)
+		return
+	}
+
+	code := newCode()
+
+	s.requests[code] = &request{
+		subject:     s.subject,
+		claims:      s.claims,
+		nonce:       query.Get("nonce"),
+		redirectURI: redirect.String(),
+		challenge:   query.Get("code_challenge"),
+	}
+
+	s.lock.Unlock()
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, req, redirect.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token, codes can only be used once
+func (s *Server) token(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := req.BasicAuth()
+
+	if !ok {
+		clientID, clientSecret = req.PostForm.Get("client_id"), req.PostForm.Get("client_secret")
+	}
+
+	if clientID != s.ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.ClientSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.lock.Lock()
+	r, ok := s.requests[req.PostForm.Get("code")]
+	delete(s.requests, req.PostForm.Get("code"))
+	s.lock.Unlock()
+
+	if !ok || req.PostForm.Get("grant_type") != "authorization_code" || req.PostForm.Get("redirect_uri") != r.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	verifier := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+
+	if base64.RawURLEncoding.EncodeToString(verifier[:]) != r.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "code verifier doesn't match"})
+		return
+	}
+
+	claims := map[string]interface{}{}
+
+	for k, v := range r.claims {
+		claims[k] = v
+	}
+
+	now := time.Now()
+
+	claims["iss"] = s.URL
+	claims["sub"] = r.subject
+	claims["aud"] = s.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+
+	if r.nonce != "" {
+		claims["nonce"] = r.nonce
+	}
+
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	signed, err := s.signer.Sign(payload)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	idToken, err := signed.CompactSerialize()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": newCode(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func newCode() string {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+// Package policy decides what users are allowed to do, so the rules live in one place instead of every handler
+package policy
+
+import (
+	"webserver/models"
+)
+
+// The roles users can have, each one can do everything the ones before it can
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists every role in ascending order of what they're allowed to do
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// SystemUserID is the ID of the user background jobs act as, it doesn't exist in the database
+const SystemUserID = -1
+
+// System returns the user background jobs act as, it's allowed to do anything
+func System() *models.User {
+	return &models.User{ID: SystemUserID, Username: "system", Role: RoleAdmin}
+}
+
+// IsSystem checks if user is the one background jobs act as
+func IsSystem(user *models.User) bool {
+	return user != nil && user.ID == SystemUserID
+}
+
+func rank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// HasRole checks if user has role or one above it, disabled users don't have any
+func HasRole(user *models.User, role string) bool {
+	if user == nil || user.Disabled {
+		return false
+	}
+
+	return rank(user.Role) >= rank(role) && rank(role) >= 0
+}
+
+// IsAdmin checks if user is allowed to manage other users
+func IsAdmin(user *models.User) bool {
+	return HasRole(user, RoleAdmin)
+}
+
+// CanViewAll checks if user sees every clip, including the unlisted clips of others
+func CanViewAll(user *models.User) bool {
+	return HasRole(user, RoleModerator)
+}
+
+// CanManageClip checks if user is allowed to edit or delete clip, its creator and moderators are
+func CanManageClip(user *models.User, clip *models.Clip) bool {
+	if user == nil || user.Disabled {
+		return false
+	}
+
+	return clip.CreatorID == user.ID || HasRole(user, RoleModerator)
+}
+
+// CanEditUser checks if user is allowed to edit the account with the ID uid, only its owner and admins are
+func CanEditUser(user *models.User, uid int64) bool {
+	if user == nil || user.Disabled {
+		return false
+	}
+
+	return user.ID == uid || IsAdmin(user)
+}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"testing"
+	"webserver/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *models.User
+		role     string
+		expected bool
+	}{
+		{"Same role", &models.User{Role: RoleModerator}, RoleModerator, true},
+		{"Higher role", &models.User{Role: RoleAdmin}, RoleModerator, true},
+		{"Lower role", &models.User{Role: RoleUser}, RoleModerator, false},
+		{"Disabled", &models.User{Role: RoleAdmin, Disabled: true}, RoleUser, false},
+		{"Unknown role", &models.User{Role: "superuser"}, RoleUser, false},
+		{"Unknown required role", &models.User{Role: RoleAdmin}, "superuser", false},
+		{"No user", nil, RoleUser, false},
+		{"System", System(), RoleAdmin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HasRole(tt.user, tt.role))
+		})
+	}
+}
+
+func TestCanManageClip(t *testing.T) {
+	clip := &models.Clip{ID: 1, CreatorID: 1}
+
+	assert.True(t, CanManageClip(&models.User{ID: 1, Role: RoleUser}, clip))
+	assert.False(t, CanManageClip(&models.User{ID: 2, Role: RoleUser}, clip))
+	assert.True(t, CanManageClip(&models.User{ID: 2, Role: RoleModerator}, clip))
+	assert.True(t, CanManageClip(&models.User{ID: 2, Role: RoleAdmin}, clip))
+	assert.False(t, CanManageClip(&models.User{ID: 1, Role: RoleUser, Disabled: true}, clip))
+	assert.False(t, CanManageClip(nil, clip))
+}
+
+func TestCanEditUser(t *testing.T) {
+	assert.True(t, CanEditUser(&models.User{ID: 1, Role: RoleUser}, 1))
+	assert.False(t, CanEditUser(&models.User{ID: 1, Role: RoleUser}, 2))
+	// Moderators manage clips, not accounts
+	assert.False(t, CanEditUser(&models.User{ID: 1, Role: RoleModerator}, 2))
+	assert.True(t, CanEditUser(&models.User{ID: 1, Role: RoleAdmin}, 2))
+	assert.False(t, CanEditUser(nil, 1))
+}
+
+func TestSystem(t *testing.T) {
+	assert.True(t, IsSystem(System()))
+	assert.True(t, CanViewAll(System()))
+	assert.False(t, IsSystem(&models.User{ID: 1, Role: RoleAdmin}))
+	assert.False(t, IsSystem(nil))
+}
@@ -0,0 +1,102 @@
+package tiering
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+// ffmpeg writes every rendition as a Representation with the single file it's stored in as its BaseURL
+// The manifest is edited as text, so everything the regexps don't touch is served exactly as ffmpeg wrote it
+var (
+	adaptationSetPattern  = regexp.MustCompile(`(?s)<AdaptationSet\b.*?</AdaptationSet>`)
+	representationPattern = regexp.MustCompile(`(?s)[ \t]*<Representation\b([^>]*)>.*?</Representation>[ \t]*\n?`)
+	heightPattern         = regexp.MustCompile(`\sheight="(\d+)"`)
+	baseURLPattern        = regexp.MustCompile(`<BaseURL>([^<]+)</BaseURL>`)
+)
+
+// Rendition is a single quality of a clip in its manifest, Height is 0 for audio
+type Rendition struct {
+	Filename string
+	Height   int
+}
+
+// Renditions lists the renditions of a manifest that are stored in a file of their own
+func Renditions(manifest []byte) []Rendition {
+	var renditions []Rendition
+
+	for _, match := range representationPattern.FindAllSubmatch(manifest, -1) {
+		if rendition, ok := parseRendition(match); ok {
+			renditions = append(renditions, rendition)
+		}
+	}
+
+	return renditions
+}
+
+// DropRenditions removes the renditions drop picks from a manifest
+// An adaptation set always keeps at least one rendition, so the clip stays playable even when all of them are picked
+func DropRenditions(manifest []byte, drop func(Rendition) bool) []byte {
+	return adaptationSetPattern.ReplaceAllFunc(manifest, func(set []byte) []byte {
+		matches := representationPattern.FindAllSubmatchIndex(set, -1)
+		dropped := make([]bool, len(matches))
+		kept := len(matches)
+		lowest := -1
+		lowestHeight := 0
+
+		for i, match := range matches {
+			submatches := make([][]byte, 0, len(match)/2)
+
+			for j := 0; j < len(match); j += 2 {
+				submatches = append(submatches, set[match[j]:match[j+1]])
+			}
+
+			if rendition, ok := parseRendition(submatches); ok && drop(rendition) {
+				dropped[i] = true
+				kept--
+
+				if lowest < 0 || rendition.Height < lowestHeight {
+					lowest, lowestHeight = i, rendition.Height
+				}
+			}
+		}
+
+		// The lowest rendition is the cheapest to keep serving from the cold tier
+		if kept == 0 && lowest >= 0 {
+			dropped[lowest] = false
+		}
+
+		var edited bytes.Buffer
+		last := 0
+
+		for i, match := range matches {
+			if !dropped[i] {
+				continue
+			}
+
+			edited.Write(set[last:match[0]])
+			last = match[1]
+		}
+
+		edited.Write(set[last:])
+
+		return edited.Bytes()
+	})
+}
+
+// parseRendition reads a rendition out of a match of representationPattern
+func parseRendition(match [][]byte) (Rendition, bool) {
+	baseURL := baseURLPattern.FindSubmatch(match[0])
+
+	if baseURL == nil {
+		return Rendition{}, false
+	}
+
+	rendition := Rendition{Filename: string(baseURL[1])}
+
+	if height := heightPattern.FindSubmatch(match[1]); height != nil {
+		rendition.Height, _ = strconv.Atoi(string(height[1]))
+	}
+
+	return rendition, true
+}
@@ -0,0 +1,107 @@
+package tiering
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testManifest is shaped like the manifests ffmpeg writes with -single_file
+const testManifest = `<?xml version="1.0" encoding="utf-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static">
+	<Period id="0" start="PT0.0S">
+		<AdaptationSet id="0" contentType="video" startWithSAP="1" segmentAlignment="true" bitstreamSwitching="true" maxWidth="3840" maxHeight="2160">
+			<Representation id="0" mimeType="video/mp4" codecs="avc1.640033" bandwidth="45000000" width="3840" height="2160" sar="1:1">
+				<BaseURL>dash-stream0.m4s</BaseURL>
+				<SegmentBase indexRange="880-1031" timescale="15360">
+					<Initialization range="0-879" />
+				</SegmentBase>
+			</Representation>
+			<Representation id="1" mimeType="video/mp4" codecs="avc1.640028" bandwidth="8000000" width="1920" height="1080" sar="1:1">
+				<BaseURL>dash-stream1.m4s</BaseURL>
+				<SegmentBase indexRange="880-1031" timescale="15360">
+					<Initialization range="0-879" />
+				</SegmentBase>
+			</Representation>
+			<Representation id="2" mimeType="video/mp4" codecs="avc1.64001f" bandwidth="5000000" width="1280" height="720" sar="1:1">
+				<BaseURL>dash-stream2.m4s</BaseURL>
+				<SegmentBase indexRange="880-1031" timescale="15360">
+					<Initialization range="0-879" />
+				</SegmentBase>
+			</Representation>
+		</AdaptationSet>
+		<AdaptationSet id="1" contentType="audio" startWithSAP="1" segmentAlignment="true" bitstreamSwitching="true">
+			<Representation id="3" mimeType="audio/mp4" codecs="mp4a.40.2" bandwidth="128000" audioSamplingRate="48000">
+				<BaseURL>dash-stream3.m4s</BaseURL>
+				<SegmentBase indexRange="824-975" timescale="48000">
+					<Initialization range="0-823" />
+				</SegmentBase>
+			</Representation>
+		</AdaptationSet>
+	</Period>
+</MPD>
+`
+
+func TestRenditions(t *testing.T) {
+	assert.Equal(t, []Rendition{
+		{Filename: "dash-stream0.m4s", Height: 2160},
+		{Filename: "dash-stream1.m4s", Height: 1080},
+		{Filename: "dash-stream2.m4s", Height: 720},
+		{Filename: "dash-stream3.m4s"},
+	}, Renditions([]byte(testManifest)))
+}
+
+func TestDropRenditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		drop     []string
+		expected []Rendition
+	}{
+		{
+			name: "Drop nothing",
+			expected: []Rendition{
+				{Filename: "dash-stream0.m4s", Height: 2160},
+				{Filename: "dash-stream1.m4s", Height: 1080},
+				{Filename: "dash-stream2.m4s", Height: 720},
+				{Filename: "dash-stream3.m4s"},
+			},
+		},
+		{
+			name: "Drop the top rungs",
+			drop: []string{"dash-stream0.m4s", "dash-stream1.m4s"},
+			expected: []Rendition{
+				{Filename: "dash-stream2.m4s", Height: 720},
+				{Filename: "dash-stream3.m4s"},
+			},
+		},
+		{
+			name: "Keep the lowest rendition of a set",
+			drop: []string{"dash-stream0.m4s", "dash-stream1.m4s", "dash-stream2.m4s", "dash-stream3.m4s"},
+			expected: []Rendition{
+				{Filename: "dash-stream2.m4s", Height: 720},
+				{Filename: "dash-stream3.m4s"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited := DropRenditions([]byte(testManifest), func(rendition Rendition) bool {
+				for _, filename := range tt.drop {
+					if rendition.Filename == filename {
+						return true
+					}
+				}
+
+				return false
+			})
+
+			assert.Equal(t, tt.expected, Renditions(edited))
+			assert.Contains(t, string(edited), "</AdaptationSet>\n\t\t<AdaptationSet id=\"1\"")
+
+			if len(tt.drop) == 0 {
+				assert.Equal(t, testManifest, string(edited))
+			}
+		})
+	}
+}
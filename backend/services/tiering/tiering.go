@@ -0,0 +1,219 @@
+// Package tiering moves the high renditions of clips nobody watches anymore to the cold tier of storage, and back once they're watched again
+package tiering
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/object"
+	"webserver/services/policy"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	manifestFilename = "dash.mpd"
+	downloadFilename = "download-%dp.mp4"
+)
+
+// ErrNotTiered is returned when the ObjectStore only has a single tier
+var ErrNotTiered = errors.New("object store isn't tiered")
+
+// Mover relocates objects between the tiers of a services.TieredObjectStore
+// Clips are cold once they've gone Tiering.After without a view, until then they're hot
+type Mover struct {
+	cfg *config.Config
+	*services.Group
+}
+
+// New creates a Mover for the storage of g
+func New(cfg *config.Config, g *services.Group) *Mover {
+	return &Mover{cfg, g}
+}
+
+// Run moves objects every Tiering.Interval until ctx is done
+func (m *Mover) Run(ctx context.Context) {
+	if !m.cfg.Tiering.Enabled || m.cfg.Tiering.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.cfg.Tiering.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		frozen, thawed, err := m.Move(ctx)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to move objects between storage tiers")
+		}
+
+		if frozen > 0 || thawed > 0 {
+			log.WithField("frozen", frozen).
+				WithField("thawed", thawed).
+				Info("Moved objects between storage tiers")
+		}
+	}
+}
+
+// Move freezes the high renditions of cold clips and thaws everything of clips that were watched again
+// It returns how many objects were moved each way, even when it stops at an error
+func (m *Mover) Move(ctx context.Context) (int, int, error) {
+	store, ok := m.ObjectStore.(services.TieredObjectStore)
+
+	if !ok {
+		return 0, 0, ErrNotTiered
+	}
+
+	cutoff := time.Now().Add(-m.cfg.Tiering.After)
+
+	thawed, err := m.thaw(ctx, store, cutoff)
+
+	if err != nil {
+		return 0, thawed, err
+	}
+
+	frozen, err := m.freeze(ctx, store, cutoff)
+
+	return frozen, thawed, err
+}
+
+func (m *Mover) freeze(ctx context.Context, store services.TieredObjectStore, cutoff time.Time) (int, error) {
+	// The system user finds the clips of every user, unlisted ones included
+	clips, err := m.Clips.FindMany(ctx, policy.System(),
+		models.ClipWhere.Processing.EQ(false),
+		qm.Where(fmt.Sprintf("COALESCE(%s, %s) < ?", models.ClipColumns.LastViewedAt, models.ClipColumns.CreatedAt), cutoff),
+	)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find cold clips")
+	}
+
+	frozen := 0
+
+	for _, clip := range clips {
+		// Objects that are being written are about to change anyway
+		if store.HasActiveUploads(ctx, clip.ID) {
+			continue
+		}
+
+		filenames, err := m.highObjects(ctx, store, clip.ID)
+
+		if err != nil {
+			return frozen, errors.Wrapf(err, "failed to find renditions of clip %d", clip.ID)
+		}
+
+		for _, filename := range filenames {
+			if store.IsCold(ctx, clip.ID, filename) || !store.HasObject(ctx, clip.ID, filename) {
+				continue
+			}
+
+			if err := store.Freeze(ctx, clip.ID, filename); err != nil {
+				return frozen, errors.Wrapf(err, "failed to freeze object %s of clip %d", filename, clip.ID)
+			}
+
+			frozen++
+		}
+	}
+
+	return frozen, nil
+}
+
+func (m *Mover) thaw(ctx context.Context, store services.TieredObjectStore, cutoff time.Time) (int, error) {
+	cids, err := store.ListColdPrefixes(ctx)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list cold clips")
+	}
+
+	thawed := 0
+
+	for _, cid := range cids {
+		clip, err := m.Clips.Find(ctx, cid)
+
+		// The objects of deleted clips are left to the garbage collector
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return thawed, errors.Wrapf(err, "failed to find clip %d", cid)
+		}
+
+		if lastViewed(clip).Before(cutoff) {
+			continue
+		}
+
+		objects, err := store.ListColdObjects(ctx, cid)
+
+		if err != nil {
+			return thawed, errors.Wrapf(err, "failed to list cold objects of clip %d", cid)
+		}
+
+		for _, object := range objects {
+			if err := store.Thaw(ctx, cid, object.Name); err != nil {
+				return thawed, errors.Wrapf(err, "failed to thaw object %s of clip %d", object.Name, cid)
+			}
+
+			thawed++
+		}
+	}
+
+	return thawed, nil
+}
+
+// highObjects are the objects of a clip's renditions and downloads that are at least Tiering.MinHeight tall
+func (m *Mover) highObjects(ctx context.Context, store services.ObjectStore, cid int64) ([]string, error) {
+	if !store.HasObject(ctx, cid, manifestFilename) {
+		return nil, nil
+	}
+
+	reader, _, err := object.GetVerified(ctx, store, cid, manifestFilename)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get manifest")
+	}
+
+	defer reader.Close()
+
+	manifest, err := io.ReadAll(reader)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+
+	var filenames []string
+
+	for _, rendition := range Renditions(manifest) {
+		if rendition.Height > 0 && rendition.Height >= m.cfg.Tiering.MinHeight {
+			filenames = append(filenames, rendition.Filename)
+		}
+	}
+
+	for _, height := range m.cfg.Downloads.Qualities {
+		if height >= m.cfg.Tiering.MinHeight {
+			filenames = append(filenames, fmt.Sprintf(downloadFilename, height))
+		}
+	}
+
+	return filenames, nil
+}
+
+// lastViewed is when a clip was last watched, clips that never were count from when they were uploaded
+func lastViewed(clip *models.Clip) time.Time {
+	if clip.LastViewedAt.Valid {
+		return clip.LastViewedAt.Time
+	}
+
+	return clip.CreatedAt
+}
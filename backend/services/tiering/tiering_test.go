@@ -0,0 +1,120 @@
+package tiering
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/object"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func newTestStore(t *testing.T) (services.TieredObjectStore, services.ObjectStore) {
+	cfg := &config.Config{}
+	cfg.Storage.Path = t.TempDir()
+
+	hot, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	cfg = &config.Config{}
+	cfg.Storage.Path = t.TempDir()
+
+	cold, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	return object.NewTieredStore(hot, cold), cold
+}
+
+func TestMover_Move(t *testing.T) {
+	ctx := context.Background()
+	store, cold := newTestStore(t)
+
+	cfg := &config.Config{}
+	cfg.Tiering.After = 24 * time.Hour
+	cfg.Tiering.MinHeight = 1080
+	cfg.Downloads.Qualities = []int{720, 2160}
+
+	// 1 hasn't been watched in a while, 2 was watched again after it was frozen, 3 was deleted
+	clips := map[int64]*models.Clip{
+		1: {ID: 1, CreatedAt: time.Now().Add(-72 * time.Hour), LastViewedAt: null.TimeFrom(time.Now().Add(-48 * time.Hour))},
+		2: {ID: 2, CreatedAt: time.Now().Add(-72 * time.Hour), LastViewedAt: null.TimeFrom(time.Now())},
+	}
+
+	for _, cid := range []int64{1, 2} {
+		for _, name := range []string{"dash.mpd", "dash-stream0.m4s", "dash-stream1.m4s", "dash-stream2.m4s", "dash-stream3.m4s", "download-720p.mp4", "download-2160p.mp4", "thumbnail.jpg"} {
+			content := name
+
+			if name == "dash.mpd" {
+				content = testManifest
+			}
+
+			_, err := store.PutObject(ctx, cid, name, strings.NewReader(content))
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.NoError(t, store.Freeze(ctx, 2, "dash-stream0.m4s"))
+	_, err := cold.PutObject(ctx, 3, "dash-stream0.m4s", strings.NewReader("deleted"))
+	assert.NoError(t, err)
+
+	m := New(cfg, &services.Group{
+		ObjectStore: store,
+		Clips: &mock.ClipsProvider{
+			FindHook: func(ctx context.Context, cid int64) (*models.Clip, error) {
+				if clip, ok := clips[cid]; ok {
+					return clip, nil
+				}
+
+				return nil, sql.ErrNoRows
+			},
+			// The query itself is left to the database, only the clip it would find is returned
+			FindManyHook: func(ctx context.Context, user *models.User, mods ...qm.QueryMod) (models.ClipSlice, error) {
+				assert.Equal(t, int64(-1), user.ID)
+				return models.ClipSlice{clips[1]}, nil
+			},
+		},
+	})
+
+	frozen, thawed, err := m.Move(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, frozen)
+	assert.Equal(t, 1, thawed)
+
+	for name, isCold := range map[string]bool{
+		"dash.mpd":           false,
+		"dash-stream0.m4s":   true,
+		"dash-stream1.m4s":   true,
+		"dash-stream2.m4s":   false,
+		"dash-stream3.m4s":   false,
+		"download-720p.mp4":  false,
+		"download-2160p.mp4": true,
+		"thumbnail.jpg":      false,
+	} {
+		assert.Equal(t, isCold, store.IsCold(ctx, 1, name), name)
+		assert.False(t, store.IsCold(ctx, 2, name), name)
+	}
+
+	// Deleted clips are left to the garbage collector
+	assert.True(t, cold.HasObject(ctx, 3, "dash-stream0.m4s"))
+
+	// Nothing's left to move the second time around
+	frozen, thawed, err = m.Move(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, frozen)
+	assert.Equal(t, 0, thawed)
+}
+
+func TestMover_MoveNotTiered(t *testing.T) {
+	m := New(&config.Config{}, &services.Group{ObjectStore: &mock.ObjectStoreProvider{}})
+
+	_, _, err := m.Move(context.Background())
+	assert.ErrorIs(t, err, ErrNotTiered)
+}
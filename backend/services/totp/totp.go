@@ -0,0 +1,125 @@
+// Package totp generates and checks the time-based one-time passwords and recovery codes of two-factor authentication
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Period is how long a code is valid for, in seconds
+const Period = 30
+
+// Skew is how many periods before or after the current one are accepted, clocks of phones are rarely exact
+const Skew = 1
+
+// RecoveryCodes is how many recovery codes a user gets when enabling two-factor authentication
+const RecoveryCodes = 10
+
+// Key is a newly generated secret and the URI authenticator apps are set up with, usually by scanning it as a QR code
+type Key struct {
+	Secret string
+	URI    string
+}
+
+// Generate creates a new secret for account, issuer is the name authenticator apps show next to it
+func Generate(issuer, account string) (*Key, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: account,
+		Period:      Period,
+		Algorithm:   otp.AlgorithmSHA1,
+		Digits:      otp.DigitsSix,
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate secret")
+	}
+
+	return &Key{key.Secret(), key.URL()}, nil
+}
+
+// Validate checks code against secret at time t and returns the step it belongs to
+// Codes of lastStep or earlier are rejected, so every code only works once
+func Validate(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+
+	if len(code) != otp.DigitsSix.Length() {
+		return 0, false
+	}
+
+	current := t.Unix() / Period
+
+	for step := current - Skew; step <= current+Skew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*Period, 0), totp.ValidateOpts{
+			Period:    Period,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// NewRecoveryCodes generates n recovery codes, they're returned along with the space separated hashes to store
+func NewRecoveryCodes(n int) ([]string, string, error) {
+	codes := make([]string, n)
+	hashes := make([]string, n)
+
+	for i := range codes {
+		b := make([]byte, 10)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", errors.Wrap(err, "failed to generate recovery code")
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:8] + "-" + code[8:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, strings.Join(hashes, " "), nil
+}
+
+// UseRecoveryCode checks code against the stored hashes and returns the hashes that are left once it's used up
+func UseRecoveryCode(hashes, code string) (string, bool) {
+	hash := hashRecoveryCode(code)
+	remaining := strings.Fields(hashes)
+
+	for i, stored := range remaining {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			return strings.Join(remaining, " "), true
+		}
+	}
+
+	return hashes, false
+}
+
+// hashRecoveryCode hashes a code the way it's stored, users may type it in any case and without the dash
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
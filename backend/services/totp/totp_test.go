@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func code(t *testing.T, secret string, at time.Time) string {
+	c, err := totp.GenerateCodeCustom(secret, at, totp.ValidateOpts{Period: Period, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestGenerate(t *testing.T) {
+	key, err := Generate("Clipable", "alice")
+	require.NoError(t, err)
+
+	uri, err := url.Parse(key.URI)
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Clipable:alice", uri.Path)
+	assert.Equal(t, key.Secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Clipable", uri.Query().Get("issuer"))
+}
+
+func TestValidate(t *testing.T) {
+	key, err := Generate("Clipable", "alice")
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	current := now.Unix() / Period
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		step     int64
+		expected bool
+	}{
+		{"Current code", code(t, key.Secret, now), 0, current, true},
+		{"Previous code", code(t, key.Secret, now.Add(-Period*time.Second)), 0, current - 1, true},
+		{"Next code", code(t, key.Secret, now.Add(Period*time.Second)), 0, current + 1, true},
+		{"Too old", code(t, key.Secret, now.Add(-2*Period*time.Second)), 0, 0, false},
+		{"Already used", code(t, key.Secret, now), current, 0, false},
+		{"Surrounding spaces", " " + code(t, key.Secret, now) + " ", 0, current, true},
+		{"Wrong length", "12345", 0, 0, false},
+		{"Wrong code", "abcdef", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(key.Secret, tt.code, now, tt.lastStep)
+			assert.Equal(t, tt.expected, ok)
+			assert.Equal(t, tt.step, step)
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(RecoveryCodes)
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodes)
+	assert.Len(t, strings.Fields(hashes), RecoveryCodes)
+
+	for _, c := range codes {
+		assert.NotContains(t, hashes, c)
+	}
+
+	// Codes are accepted in any case and without the dash, but only once
+	remaining, ok := UseRecoveryCode(hashes, strings.ToUpper(strings.ReplaceAll(codes[3], "-", "")))
+	require.True(t, ok)
+	assert.Len(t, strings.Fields(remaining), RecoveryCodes-1)
+
+	_, ok = UseRecoveryCode(remaining, codes[3])
+	assert.False(t, ok)
+
+	_, ok = UseRecoveryCode(remaining, "not-a-code")
+	assert.False(t, ok)
+
+	_, ok = UseRecoveryCode("", codes[0])
+	assert.False(t, ok)
+}
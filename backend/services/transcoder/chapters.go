@@ -0,0 +1,49 @@
+package transcoder
+
+import (
+	"fmt"
+	"strings"
+
+	"webserver/models"
+
+	"github.com/pkg/errors"
+)
+
+// GetChapters reads the chapter markers of a probed file, such as the ones OBS or most editors write, into clip chapters
+// Chapters without a title are numbered in the order they appear
+func GetChapters(info *VideoInfo) (models.ClipChapterSlice, error) {
+	chapters := make(models.ClipChapterSlice, 0, len(info.Chapters))
+
+	for i, c := range info.Chapters {
+		start, err := ParseSexagesimal(c.StartTime)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse chapter start time")
+		}
+
+		end, err := ParseSexagesimal(c.EndTime)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse chapter end time")
+		}
+
+		// Zero length chapters can't be seeked to, so there's no point in keeping them
+		if end <= start {
+			continue
+		}
+
+		title := strings.TrimSpace(c.Tags.Title)
+
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+
+		chapters = append(chapters, &models.ClipChapter{
+			Title:   title,
+			StartMS: start.Milliseconds(),
+			EndMS:   end.Milliseconds(),
+		})
+	}
+
+	return chapters, nil
+}
@@ -0,0 +1,234 @@
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"webserver/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// segmentDuration has to match the -seg_duration of the dash muxer, chunks are a multiple of it so every chunk starts on a keyframe
+const segmentDuration = 2 * time.Second
+
+// planChunks slices a clip of the given duration into chunks of roughly chunkDuration
+func planChunks(clipID int64, duration, chunkDuration time.Duration) models.TranscodeChunkSlice {
+	chunkDuration = chunkDuration.Round(segmentDuration)
+
+	if chunkDuration < segmentDuration {
+		chunkDuration = segmentDuration
+	}
+
+	var chunks models.TranscodeChunkSlice
+
+	for start := time.Duration(0); start < duration; start += chunkDuration {
+		length := chunkDuration
+
+		if start+length > duration {
+			length = duration - start
+		}
+
+		chunks = append(chunks, &models.TranscodeChunk{
+			ClipID:     clipID,
+			ChunkIndex: len(chunks),
+			StartMS:    start.Milliseconds(),
+			DurationMS: length.Milliseconds(),
+		})
+	}
+
+	return chunks
+}
+
+func chunkFilename(chunk *models.TranscodeChunk) string {
+	return fmt.Sprintf("chunk-%d.ts", chunk.ChunkIndex)
+}
+
+func msToSeconds(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 3, 64)
+}
+
+// transcodeChunks encodes the video of a clip in independent time slices, skipping slices a previous run already finished,
+// then packages them together with the audio into the final dash.mpd
+func (t *transcoder) transcodeChunks(ctx context.Context, clip *models.Clip, rawURL string, width, height, fps int, duration time.Duration, audioStreams int) error {
+	chunks := planChunks(clip.ID, duration, t.cfg.FFmpeg.ChunkDuration)
+
+	finished, err := t.Chunks.FindMany(ctx, clip.ID)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to find finished chunks")
+	}
+
+	presets := t.GetVideoPresets(width, height, fps)
+
+	chunkCtx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var chunkErr error
+
+	for _, chunk := range chunks {
+		// A finished chunk only counts if it was cut the same way, the chunk duration could have changed since
+		if t.isChunkFinished(ctx, chunk, finished) {
+			log.WithField("clip", clip.ID).
+				WithField("chunk", chunk.ChunkIndex).
+				Debug("Skipping already encoded chunk")
+			t.ReportChunkProgress(clip.ID, chunk.ChunkIndex, int(math.Round(float64(chunk.DurationMS)/1000*30)))
+			continue
+		}
+
+		chunk := chunk
+
+		wg.Add(1)
+
+		t.chunkPool.Submit(func() {
+			defer wg.Done()
+
+			if chunkCtx.Err() != nil {
+				return
+			}
+
+			if err := t.encodeChunk(chunkCtx, clip, rawURL, chunk, presets); err != nil {
+				once.Do(func() {
+					chunkErr = err
+					cancel()
+				})
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if chunkErr != nil {
+		return chunkErr
+	}
+
+	// Shutting down skips the chunks that weren't started yet
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+
+	// Wait until all chunks are flushed and available in S3
+	for t.ObjectStore.HasActiveUploads(ctx, clip.ID) {
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	if err := t.packageChunks(clip, rawURL, chunks, audioStreams); err != nil {
+		return err
+	}
+
+	// The chunks are only needed until the clip is packaged, failing to clean them up only wastes some space
+	if err := t.ObjectStore.DeleteObjects(ctx, clip.ID, "chunk-"); err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Warn("Failed to delete chunks")
+	}
+
+	if err := t.Chunks.DeleteAll(ctx, clip.ID); err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Warn("Failed to delete chunk records")
+	}
+
+	return nil
+}
+
+func (t *transcoder) isChunkFinished(ctx context.Context, chunk *models.TranscodeChunk, finished models.TranscodeChunkSlice) bool {
+	for _, f := range finished {
+		if f.ChunkIndex == chunk.ChunkIndex && f.StartMS == chunk.StartMS && f.DurationMS == chunk.DurationMS {
+			return t.ObjectStore.HasObject(ctx, chunk.ClipID, chunkFilename(chunk))
+		}
+	}
+
+	return false
+}
+
+// encodeChunk encodes every video rendition of a single chunk into one mpegts file, and records it as finished
+func (t *transcoder) encodeChunk(ctx context.Context, clip *models.Clip, rawURL string, chunk *models.TranscodeChunk, presets []string) error {
+	ffmpegArgs := []string{
+		"-ss", msToSeconds(chunk.StartMS),
+		"-t", msToSeconds(chunk.DurationMS),
+		"-i", rawURL,
+		"-threads", strconv.Itoa(t.cfg.FFmpeg.Threads),
+		"-progress", fmt.Sprintf("http://127.0.0.1:12786/progress/%d/%d", clip.ID, chunk.ChunkIndex),
+	}
+
+	ffmpegArgs = append(ffmpegArgs, presets...)
+	ffmpegArgs = append(ffmpegArgs,
+		"-an",
+		"-f", "mpegts",
+		fmt.Sprintf("http://127.0.0.1:12786/s3/%d/%s", clip.ID, chunkFilename(chunk)),
+	)
+
+	output, err := exec.CommandContext(ctx, "ffmpeg", ffmpegArgs...).CombinedOutput()
+
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("ffmpeg failed on chunk %d with args %v: %s", chunk.ChunkIndex, ffmpegArgs, output))
+	}
+
+	// Without the record the chunk is just encoded again after a restart, so this isn't worth failing the clip over
+	if err := t.Chunks.Create(context.Background(), chunk); err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			WithField("chunk", chunk.ChunkIndex).
+			Warn("Failed to record finished chunk")
+	}
+
+	return nil
+}
+
+// packageChunks joins the encoded chunks back together without re-encoding them, adds the audio and writes the dash.mpd
+func (t *transcoder) packageChunks(clip *models.Clip, rawURL string, chunks models.TranscodeChunkSlice, audioStreams int) error {
+	list, err := os.CreateTemp("", fmt.Sprintf("clipable-%d-*.txt", clip.ID))
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create concat list")
+	}
+
+	defer os.Remove(list.Name())
+
+	for _, chunk := range chunks {
+		if _, err := fmt.Fprintf(list, "file 'http://127.0.0.1:12786/s3/%d/%s'\n", clip.ID, chunkFilename(chunk)); err != nil {
+			list.Close()
+			return errors.Wrap(err, "failed to write concat list")
+		}
+	}
+
+	if err := list.Close(); err != nil {
+		return errors.Wrap(err, "failed to write concat list")
+	}
+
+	ffmpegArgs := []string{
+		"-f", "concat",
+		"-safe", "0",
+		"-protocol_whitelist", "file,http,tcp",
+		"-i", list.Name(),
+		"-i", rawURL,
+	}
+
+	ffmpegArgs = append(ffmpegArgs, t.dashArgs()...)
+	ffmpegArgs = append(ffmpegArgs, "-map", "0:v", "-c:v", "copy")
+	ffmpegArgs = append(ffmpegArgs, videoAudioArgs...)
+	ffmpegArgs = append(ffmpegArgs, audioMapping(1, audioStreams)...)
+	ffmpegArgs = append(ffmpegArgs, videoAdaptationSets(audioStreams)...)
+	ffmpegArgs = append(ffmpegArgs,
+		"-f", "dash",
+		fmt.Sprintf("http://127.0.0.1:12786/s3/%d/dash.mpd", clip.ID),
+	)
+
+	output, err := exec.CommandContext(t.ctx, "ffmpeg", ffmpegArgs...).CombinedOutput()
+
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("ffmpeg failed packaging chunks with args %v: %s", ffmpegArgs, output))
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"webserver/models"
+	"webserver/modelsx"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// audioDownloadQuality is the one download audio clips get, as there's nothing to scale
+const audioDownloadQuality = "audio"
+
+// downloadFilename is the object a progressive download of the given quality is stored as, routes.downloadObject has to match it
+func downloadFilename(quality string) string {
+	if quality == audioDownloadQuality {
+		return "download-audio.m4a"
+	}
+
+	return fmt.Sprintf("download-%s.mp4", quality)
+}
+
+// downloadPreset picks the preset a download of the given height is encoded with,
+// the one with the highest bitrate that doesn't exceed fps or the lowest one of that height if none fits
+func (t *transcoder) downloadPreset(height, fps int) (Quality, bool) {
+	var best Quality
+	found := false
+
+	// Presets are sorted by bitrate
+	for _, preset := range t.qualityPresets {
+		if preset.Height != height {
+			continue
+		}
+
+		if !found || preset.Framerate <= fps {
+			best, found = preset, true
+		}
+	}
+
+	return best, found
+}
+
+// encodeDownloads encodes the progressive downloads of a clip, every configured quality that fits the source or the smallest one if none does
+// Downloads are a nice to have, so failing one only skips it
+func (t *transcoder) encodeDownloads(ctx context.Context, clip *models.Clip, rawURL string, width, height, fps, audioStreams int) {
+	if clip.MediaType == modelsx.MediaTypeAudio {
+		t.encodeDownload(ctx, clip, audioDownloadQuality, t.audioDownloadArgs(rawURL, audioStreams))
+		return
+	}
+
+	if fps < 30 {
+		fps = 30
+	}
+
+	vertical := height > width
+	short := height
+
+	if vertical {
+		short = width
+	}
+
+	var qualities []int
+	smallest := t.cfg.Downloads.Qualities[0]
+
+	for _, quality := range t.cfg.Downloads.Qualities {
+		if quality <= short {
+			qualities = append(qualities, quality)
+		}
+
+		if quality < smallest {
+			smallest = quality
+		}
+	}
+
+	if len(qualities) == 0 {
+		qualities = []int{smallest}
+	}
+
+	for _, quality := range qualities {
+		preset, _ := t.downloadPreset(quality, fps)
+
+		if vertical {
+			preset.Width, preset.Height = preset.Height, preset.Width
+		}
+
+		t.encodeDownload(ctx, clip, fmt.Sprintf("%dp", quality), t.videoDownloadArgs(rawURL, preset, audioStreams))
+	}
+}
+
+// encodeDownload runs ffmpeg into a temporary file and stores the result, faststart rewrites the file once it's done so it can't be streamed
+func (t *transcoder) encodeDownload(ctx context.Context, clip *models.Clip, quality string, ffmpegArgs []string) {
+	logger := log.WithField("clip", clip.ID).WithField("quality", quality)
+
+	file, err := os.CreateTemp("", fmt.Sprintf("clipable-%d-*", clip.ID))
+
+	if err != nil {
+		logger.WithError(err).Warn("Failed to create temporary file for download")
+		return
+	}
+
+	file.Close()
+	defer os.Remove(file.Name())
+
+	ffmpegArgs = append(ffmpegArgs, "-movflags", "+faststart", "-f", "mp4", "-y", file.Name())
+
+	output, err := exec.CommandContext(t.ctx, "ffmpeg", ffmpegArgs...).CombinedOutput()
+
+	if err != nil {
+		logger.WithError(err).
+			WithField("output", string(output)).
+			Warn("Failed to encode download")
+		return
+	}
+
+	file, err = os.Open(file.Name())
+
+	if err != nil {
+		logger.WithError(err).Warn("Failed to open encoded download")
+		return
+	}
+
+	defer file.Close()
+
+	if _, err := t.ObjectStore.PutObject(ctx, clip.ID, downloadFilename(quality), file); err != nil {
+		logger.WithError(err).Warn("Failed to store download")
+	}
+}
+
+func (t *transcoder) videoDownloadArgs(rawURL string, preset Quality, audioStreams int) []string {
+	ffmpegArgs := []string{
+		"-i", rawURL,
+		"-threads", strconv.Itoa(t.cfg.FFmpeg.Threads),
+		"-map", "v:0",
+		"-c:v", t.cfg.FFmpeg.Codec,
+		"-preset", t.cfg.FFmpeg.Preset,
+		"-tune", t.cfg.FFmpeg.Tune,
+		"-pix_fmt", "yuv420p",
+		"-vf", fmt.Sprintf("scale=w=%d:h=%d:force_original_aspect_ratio=decrease:force_divisible_by=2", preset.Width, preset.Height),
+		"-b:v", bitString(preset.Bitrate),
+		"-maxrate", bitString(preset.Bitrate * 1.2),
+		"-bufsize", bitString(preset.Bitrate * 2),
+		"-r", strconv.Itoa(preset.Framerate),
+	}
+
+	if audioStreams > 0 {
+		ffmpegArgs = append(ffmpegArgs, "-c:a", "aac", "-b:a", "128k", "-ac", "2")
+		ffmpegArgs = append(ffmpegArgs, audioMapping(0, audioStreams)...)
+	}
+
+	return ffmpegArgs
+}
+
+func (t *transcoder) audioDownloadArgs(rawURL string, audioStreams int) []string {
+	ffmpegArgs := []string{
+		"-i", rawURL,
+		"-threads", strconv.Itoa(t.cfg.FFmpeg.Threads),
+		"-vn",
+		"-c:a", "aac",
+		"-b:a", "192k",
+		"-ac", "2",
+	}
+
+	return append(ffmpegArgs, audioMapping(0, audioStreams)...)
+}
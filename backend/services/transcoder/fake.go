@@ -0,0 +1,208 @@
+package transcoder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/policy"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// fakeHeights are the renditions the Fake writes for videos, from the top rung down
+var fakeHeights = []int{1080, 720, 360}
+
+// Fake stands in for ffmpeg, so flows from upload to playback can be tested without it
+// Queue turns the raw video of a clip into a stub manifest, renditions and thumbnail before it returns,
+// their contents only depend on the raw video so the same upload always gives the same objects
+type Fake struct {
+	*services.Group
+	cfg *config.Config
+
+	// Media is what Probe reports for every clip, a Width of 0 makes clips audio
+	Media services.MediaInfo
+
+	lock   sync.Mutex
+	failed map[int64]bool
+}
+
+// NewFake creates a Fake that probes every clip as a 10 second 1080p video
+func NewFake(cfg *config.Config, grp *services.Group) *Fake {
+	return &Fake{
+		Group: grp,
+		cfg:   cfg,
+		Media: services.MediaInfo{
+			Duration:     10 * time.Second,
+			Width:        1920,
+			Height:       1080,
+			VideoStreams: 1,
+			AudioStreams: 1,
+			Streams:      2,
+		},
+		failed: make(map[int64]bool),
+	}
+}
+
+// Start processes the clips left marked as processing, like the real transcoder does after a restart
+func (f *Fake) Start() error {
+	clips, err := f.Clips.FindMany(context.Background(), policy.System(), models.ClipWhere.Processing.EQ(true))
+
+	if err != nil {
+		return err
+	}
+
+	for _, clip := range clips {
+		if err := f.Queue(context.Background(), clip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Fake) Stop(ctx context.Context) error {
+	return nil
+}
+
+// Queue processes the clip right away, clips that fail report a progress of -2 like they do with ffmpeg
+func (f *Fake) Queue(ctx context.Context, clip *models.Clip) error {
+	if err := f.process(ctx, clip); err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Error("Failed to fake transcode")
+
+		f.lock.Lock()
+		f.failed[clip.ID] = true
+		f.lock.Unlock()
+	}
+
+	return nil
+}
+
+func (f *Fake) process(ctx context.Context, clip *models.Clip) error {
+	raw, _, err := f.ObjectStore.GetObject(ctx, clip.ID, "raw")
+
+	if err != nil {
+		return errors.Wrap(err, "failed to get raw video")
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, raw)
+	raw.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "failed to read raw video")
+	}
+
+	sum := hash.Sum(nil)
+
+	clip.MediaType = modelsx.MediaTypeVideo
+	heights := fakeHeights
+
+	if f.Media.Width == 0 {
+		clip.MediaType = modelsx.MediaTypeAudio
+		heights = nil
+	}
+
+	objects := map[string][]byte{
+		"dash.mpd": fakeManifest(heights),
+		// Every rendition is a single stream after the video ones, the audio stream is last
+		fmt.Sprintf("dash-stream%d.m4s", len(heights)): []byte(fmt.Sprintf("audio %x", sum)),
+	}
+
+	for i, height := range heights {
+		objects[fmt.Sprintf("dash-stream%d.m4s", i)] = []byte(fmt.Sprintf("video %dp %x", height, sum))
+	}
+
+	if clip.MediaType == modelsx.MediaTypeVideo {
+		// A JPEG's start of image marker, so it sniffs as one
+		objects["thumbnail.jpg"] = append([]byte{0xFF, 0xD8, 0xFF}, sum...)
+	}
+
+	if f.cfg.Downloads.Enabled {
+		for _, quality := range f.cfg.Downloads.Qualities {
+			objects[fmt.Sprintf("download-%dp.mp4", quality)] = []byte(fmt.Sprintf("download %dp %x", quality, sum))
+		}
+	}
+
+	for name, data := range objects {
+		if _, err := f.ObjectStore.PutObject(ctx, clip.ID, name, bytes.NewReader(data)); err != nil {
+			return errors.Wrapf(err, "failed to store %s", name)
+		}
+	}
+
+	if !f.cfg.Downloads.KeepOriginal {
+		if err := f.ObjectStore.DeleteObject(ctx, clip.ID, "raw"); err != nil {
+			return errors.Wrap(err, "failed to delete raw video")
+		}
+	}
+
+	if clip.SizeBytes, err = f.ObjectStore.Usage(ctx, clip.ID); err != nil {
+		return errors.Wrap(err, "failed to measure clip size")
+	}
+
+	clip.Processing = false
+
+	return errors.Wrap(
+		f.Clips.Update(ctx, clip, boil.Whitelist(models.ClipColumns.Processing, models.ClipColumns.MediaType, models.ClipColumns.SizeBytes)),
+		"failed to update clip",
+	)
+}
+
+// fakeManifest is shaped like the manifests ffmpeg writes with -single_file, with a rendition for every height and one audio stream
+func fakeManifest(heights []int) []byte {
+	var manifest bytes.Buffer
+
+	manifest.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<MPD xmlns=\"urn:mpeg:dash:schema:mpd:2011\" type=\"static\" mediaPresentationDuration=\"PT10.0S\">\n\t<Period id=\"0\" start=\"PT0.0S\">\n")
+
+	if len(heights) > 0 {
+		manifest.WriteString("\t\t<AdaptationSet id=\"0\" contentType=\"video\">\n")
+
+		for i, height := range heights {
+			fmt.Fprintf(&manifest, "\t\t\t<Representation id=\"%d\" mimeType=\"video/mp4\" width=\"%d\" height=\"%d\">\n\t\t\t\t<BaseURL>dash-stream%d.m4s</BaseURL>\n\t\t\t</Representation>\n", i, height*16/9, height, i)
+		}
+
+		manifest.WriteString("\t\t</AdaptationSet>\n")
+	}
+
+	fmt.Fprintf(&manifest, "\t\t<AdaptationSet id=\"1\" contentType=\"audio\">\n\t\t\t<Representation id=\"%d\" mimeType=\"audio/mp4\">\n\t\t\t\t<BaseURL>dash-stream%d.m4s</BaseURL>\n\t\t\t</Representation>\n\t\t</AdaptationSet>\n", len(heights), len(heights))
+	manifest.WriteString("\t</Period>\n</MPD>\n")
+
+	return manifest.Bytes()
+}
+
+func (f *Fake) Probe(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+	if !f.ObjectStore.HasObject(ctx, cid, "raw") {
+		return nil, ErrUnreadableMedia
+	}
+
+	media := f.Media
+
+	return &media, nil
+}
+
+// GetProgress only knows about failed clips, every other clip is done by the time Queue returns
+func (f *Fake) GetProgress(cid int64) (int, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.failed[cid] {
+		return -2, true
+	}
+
+	return 0, false
+}
+
+func (f *Fake) ReportProgress(cid int64, frame int) {}
+
+func (f *Fake) ReportChunkProgress(cid int64, chunk int, frame int) {}
@@ -0,0 +1,86 @@
+package transcoder
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"webserver/config"
+	"webserver/models"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/mock"
+	"webserver/services/object"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestFake_Queue(t *testing.T) {
+	ctx := context.Background()
+	store := object.NewMemoryStore()
+
+	cfg := &config.Config{}
+	cfg.Downloads.Enabled = true
+	cfg.Downloads.Qualities = []int{720}
+
+	var updated *models.Clip
+
+	f := NewFake(cfg, &services.Group{
+		ObjectStore: store,
+		Clips: &mock.ClipsProvider{
+			UpdateHook: func(ctx context.Context, clip *models.Clip, columns boil.Columns) error {
+				updated = clip
+				return nil
+			},
+		},
+	})
+
+	_, err := store.PutObject(ctx, 1, "raw", strings.NewReader("video"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, f.Queue(ctx, &models.Clip{ID: 1, Processing: true}))
+
+	if assert.NotNil(t, updated) {
+		assert.False(t, updated.Processing)
+		assert.Equal(t, modelsx.MediaTypeVideo, updated.MediaType)
+
+		usage, err := store.Usage(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, usage, updated.SizeBytes)
+	}
+
+	objects, err := store.ListObjects(ctx, 1)
+	assert.NoError(t, err)
+
+	var names []string
+
+	for _, object := range objects {
+		names = append(names, object.Name)
+	}
+
+	assert.Equal(t, []string{"dash-stream0.m4s", "dash-stream1.m4s", "dash-stream2.m4s", "dash-stream3.m4s", "dash.mpd", "download-720p.mp4", "thumbnail.jpg"}, names)
+
+	manifest, _, err := store.GetObject(ctx, 1, "dash.mpd")
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(manifest)
+	assert.NoError(t, err)
+
+	for _, name := range names[:4] {
+		assert.Contains(t, string(data), "<BaseURL>"+name+"</BaseURL>")
+	}
+
+	_, ok := f.GetProgress(1)
+	assert.False(t, ok)
+
+	// Without a raw video there's nothing to transcode
+	assert.NoError(t, f.Queue(ctx, &models.Clip{ID: 2, Processing: true}))
+
+	progress, ok := f.GetProgress(2)
+	assert.True(t, ok)
+	assert.Equal(t, -2, progress)
+
+	_, err = f.Probe(ctx, 2)
+	assert.ErrorIs(t, err, ErrUnreadableMedia)
+}
@@ -0,0 +1,52 @@
+package transcoder
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PerceptualHash computes a 64 bit difference hash (dHash) of the frame halfway through the video
+// Re-encodes, resizes and small edits of the same video end up only a few bits apart
+func PerceptualHash(file string, duration time.Duration) (int64, error) {
+	cmd := exec.Command("ffmpeg",
+		"-v", "error",
+		"-ss", strconv.FormatFloat((duration/2).Seconds(), 'f', 3, 64),
+		"-i", file,
+		"-vf", "scale=9:8,format=gray", // One extra column so every pixel has a right hand neighbour to compare against
+		"-frames:v", "1",
+		"-f", "rawvideo",
+		"-",
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("ffmpeg failed: %s", stderr.String()))
+	}
+
+	if len(out) != 9*8 {
+		return 0, fmt.Errorf("unexpected frame size %d", len(out))
+	}
+
+	var hash uint64
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+
+			if out[y*9+x] < out[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return int64(hash), nil
+}
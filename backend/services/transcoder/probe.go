@@ -0,0 +1,53 @@
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"webserver/services"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrUnreadableMedia is returned by Probe for files ffprobe can't make sense of
+var ErrUnreadableMedia = errors.New("file is not readable media")
+
+func (t *transcoder) Probe(ctx context.Context, cid int64) (*services.MediaInfo, error) {
+	info, err := probeContext(ctx, fmt.Sprintf("http://127.0.0.1:12786/s3/%d/raw", cid))
+
+	// ffprobe getting killed says nothing about the file
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "context error")
+	}
+
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) {
+		log.WithError(err).WithField("clip", cid).Debug("Failed to probe raw video")
+		return nil, ErrUnreadableMedia
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to probe raw video")
+	}
+
+	media := &services.MediaInfo{
+		Streams:      len(info.Streams),
+		AudioStreams: lo.CountBy(info.Streams, func(s StreamInfo) bool { return s.CodecType == "audio" }),
+	}
+
+	// Cover art isn't video, see GetVideoStats
+	videoStreams := lo.Filter(info.Streams, func(s StreamInfo, _ int) bool { return s.CodecType == "video" && s.Disposition.AttachedPic == 0 })
+	media.VideoStreams = len(videoStreams)
+
+	if len(videoStreams) > 0 {
+		media.Width, media.Height = displaySize(videoStreams[0])
+	}
+
+	// Some containers don't know how long they are without decoding all of them, that's left to the transcoder
+	if duration, err := ParseSexagesimal(info.Format.Duration); err == nil {
+		media.Duration = duration
+	}
+
+	return media, nil
+}
@@ -1,6 +1,7 @@
 package transcoder
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -22,8 +23,9 @@ type Quality struct {
 }
 
 type VideoInfo struct {
-	Streams []StreamInfo `json:"streams"`
-	Format  FormatInfo   `json:"format"`
+	Streams  []StreamInfo  `json:"streams"`
+	Format   FormatInfo    `json:"format"`
+	Chapters []ChapterInfo `json:"chapters"`
 }
 
 type FormatInfo struct {
@@ -34,38 +36,59 @@ type SideData struct {
 	Rotation int `json:"rotation"`
 }
 
+type Disposition struct {
+	AttachedPic int `json:"attached_pic"`
+}
+
+type ChapterTags struct {
+	Title string `json:"title"`
+}
+
+type ChapterInfo struct {
+	StartTime string      `json:"start_time"`
+	EndTime   string      `json:"end_time"`
+	Tags      ChapterTags `json:"tags"`
+}
+
 type StreamInfo struct {
-	Width        int        `json:"width"`
-	Height       int        `json:"height"`
-	Index        int        `json:"index"`
-	CodecType    string     `json:"codec_type"`
-	RFrameRate   string     `json:"r_frame_rate"`
-	SideDataList []SideData `json:"side_data_list"`
+	Width        int         `json:"width"`
+	Height       int         `json:"height"`
+	Index        int         `json:"index"`
+	CodecType    string      `json:"codec_type"`
+	RFrameRate   string      `json:"r_frame_rate"`
+	SideDataList []SideData  `json:"side_data_list"`
+	Disposition  Disposition `json:"disposition"`
 }
 
+// ErrNoVideoStream is returned by GetVideoStats when the file only contains audio
+var ErrNoVideoStream = errors.New("no video stream found")
+
 func bitString(bitrate float32) string {
 	return strconv.FormatFloat(float64(bitrate), 'f', 1, 64) + "M"
 }
 
-func GetVideoStats(file string) (width int, height int, fps int, duration time.Duration, audioStreams int, err error) {
-	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration:stream=width,height,r_frame_rate,index,codec_type:stream_side_data=rotation", "-sexagesimal", "-of", "json", file)
+func probeContext(ctx context.Context, file string) (*VideoInfo, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-show_entries", "format=duration:stream=width,height,r_frame_rate,index,codec_type:stream_side_data=rotation:stream_disposition=attached_pic:chapter=start_time,end_time:chapter_tags=title", "-sexagesimal", "-of", "json", file)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return 0, 0, 0, 0, 0, errors.Wrap(err, fmt.Sprintf("ffprobe failed: %s", out))
+		return nil, errors.Wrap(err, fmt.Sprintf("ffprobe failed: %s", out))
 	}
 
 	var info VideoInfo
 
-	err = json.Unmarshal(out, &info)
-
-	if err != nil {
-		return 0, 0, 0, 0, 0, err
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, err
 	}
 
-	videoStream, ok := lo.Find(info.Streams, func(s StreamInfo) bool { return s.CodecType == "video" })
+	return &info, nil
+}
+
+func GetVideoStats(info *VideoInfo) (width int, height int, fps int, duration time.Duration, audioStreams int, err error) {
+	// Cover art embedded in audio files shows up as a single frame video stream, so it doesn't count as video
+	videoStream, ok := lo.Find(info.Streams, func(s StreamInfo) bool { return s.CodecType == "video" && s.Disposition.AttachedPic == 0 })
 
 	if !ok {
-		return 0, 0, 0, 0, 0, errors.New("no video stream found")
+		return 0, 0, 0, 0, 0, ErrNoVideoStream
 	}
 
 	fps, err = strconv.Atoi(strings.Split(videoStream.RFrameRate, "/")[0])
@@ -82,16 +105,39 @@ func GetVideoStats(file string) (width int, height int, fps int, duration time.D
 		return 0, 0, 0, 0, 0, err
 	}
 
-	// If the video is rotated 90 or 270 degrees swap the width and height
-	if len(videoStream.SideDataList) > 0 {
-		rotation := math.Abs(float64(videoStream.SideDataList[0].Rotation))
+	width, height = displaySize(videoStream)
+
+	return width, height, fps, dur, audioStreams, nil
+}
+
+// displaySize is the size a video stream is shown at, which is sideways for videos rotated 90 or 270 degrees
+func displaySize(stream StreamInfo) (int, int) {
+	if len(stream.SideDataList) > 0 {
+		rotation := math.Abs(float64(stream.SideDataList[0].Rotation))
 
 		if rotation == 90 || rotation == 270 {
-			videoStream.Width, videoStream.Height = videoStream.Height, videoStream.Width
+			return stream.Height, stream.Width
 		}
 	}
 
-	return videoStream.Width, videoStream.Height, fps, dur, audioStreams, nil
+	return stream.Width, stream.Height
+}
+
+// GetAudioStats is the audio only counterpart of GetVideoStats, used for files without a video stream
+func GetAudioStats(info *VideoInfo) (duration time.Duration, audioStreams int, err error) {
+	audioStreams = lo.CountBy(info.Streams, func(s StreamInfo) bool { return s.CodecType == "audio" })
+
+	if audioStreams == 0 {
+		return 0, 0, errors.New("no audio stream found")
+	}
+
+	dur, err := ParseSexagesimal(info.Format.Duration)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return dur, audioStreams, nil
 }
 
 func ParseSexagesimal(duration string) (time.Duration, error) {
@@ -123,6 +169,32 @@ func ParseSexagesimal(duration string) (time.Duration, error) {
 }
 
 func (t *transcoder) GetPresets(width int, height int, fps int, audioStreams int) []string {
+	ffmpegArgs := t.GetVideoPresets(width, height, fps)
+
+	ffmpegArgs = append(ffmpegArgs, videoAudioArgs...)
+	ffmpegArgs = append(ffmpegArgs, audioMapping(0, audioStreams)...)
+
+	return append(ffmpegArgs, videoAdaptationSets(audioStreams)...)
+}
+
+// videoAudioArgs encode the audio track that goes alongside the video renditions
+var videoAudioArgs = []string{
+	"-c:a", "aac",
+	"-b:a", "128k",
+	"-ac", "1",
+	"-ar", "96000",
+}
+
+func videoAdaptationSets(audioStreams int) []string {
+	if audioStreams > 0 {
+		return []string{"-adaptation_sets", "id=0,streams=v id=1,streams=a"}
+	}
+
+	return []string{"-adaptation_sets", "id=0,streams=v"}
+}
+
+// GetVideoPresets returns the ffmpeg arguments for encoding every quality rendition that fits the source, without any audio
+func (t *transcoder) GetVideoPresets(width int, height int, fps int) []string {
 	if fps < 30 {
 		fps = 30
 	}
@@ -150,7 +222,17 @@ func (t *transcoder) GetPresets(width int, height int, fps int, audioStreams int
 		presets = []Quality{t.qualityPresets[0]} // If no quality was select use the lowest one
 	}
 
-	ffmpegArgs := []string{"-aspect", aspectRatio}
+	ffmpegArgs := []string{
+		"-preset", t.cfg.FFmpeg.Preset,
+		"-tune", t.cfg.FFmpeg.Tune,
+		"-keyint_min", strconv.Itoa(fps),
+		"-g", strconv.Itoa(fps),
+		"-sc_threshold", "0",
+		"-c:v", t.cfg.FFmpeg.Codec,
+		"-pix_fmt", "yuv420p",
+		"-x264opts", "no-scenecut",
+		"-aspect", aspectRatio,
+	}
 
 	for i, preset := range presets {
 		ffmpegArgs = append(ffmpegArgs,
@@ -173,3 +255,37 @@ func (t *transcoder) GetPresets(width int, height int, fps int, audioStreams int
 
 	return ffmpegArgs
 }
+
+// GetAudioPresets returns the ffmpeg arguments for packaging an audio only clip as both DASH and HLS
+func (t *transcoder) GetAudioPresets(audioStreams int) []string {
+	ffmpegArgs := []string{
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-ac", "2",
+		"-ar", "48000",
+		"-hls_playlist", "1",
+	}
+
+	ffmpegArgs = append(ffmpegArgs, audioMapping(0, audioStreams)...)
+
+	return append(ffmpegArgs, "-adaptation_sets", "id=0,streams=a")
+}
+
+// audioMapping maps every audio stream of the given input, merging them into one if there are multiple
+func audioMapping(input int, audioStreams int) []string {
+	var ffmpegArgs []string
+
+	if audioStreams > 0 {
+		ffmpegArgs = append(ffmpegArgs, "-map", strconv.Itoa(input)+":a")
+	}
+
+	if audioStreams > 1 {
+		ffmpegArgs = append(
+			ffmpegArgs,
+			"-filter_complex",
+			"amerge=inputs="+strconv.Itoa(audioStreams),
+		)
+	}
+
+	return ffmpegArgs
+}
@@ -7,16 +7,21 @@ import (
 	"os/exec"
 	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"webserver/config"
 	"webserver/models"
+	"webserver/modelsx"
 	"webserver/services"
+	"webserver/services/policy"
 
 	"github.com/alitto/pond"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
@@ -26,28 +31,44 @@ type transcoder struct {
 	*services.Group
 	cfg  *config.Config
 	pool *pond.WorkerPool
+	// chunkPool encodes the chunks of every clip, so a single long clip can use all of the concurrency slots
+	chunkPool *pond.WorkerPool
 
 	qualityPresets []Quality
 
 	progress cmap.ConcurrentMap[int64, *clipProgress]
 	started  bool
+
+	// ctx is cancelled once the shutdown grace period runs out, killing any ffmpeg process still running
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping atomic.Bool
 }
 
 type clipProgress struct {
 	maxFrames    int
 	currentFrame int
+
+	// chunkFrames holds the frame each chunk is at, currentFrame is their sum while encoding in chunks
+	chunkLock   sync.Mutex
+	chunkFrames map[int]int
 }
 
 func New(cfg *config.Config, grp *services.Group) (services.Transcoder, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	t := &transcoder{
-		pool:  pond.New(cfg.FFmpeg.Concurrency, 1000),
-		cfg:   cfg,
-		Group: grp,
+		pool:      pond.New(cfg.FFmpeg.Concurrency, 1000),
+		chunkPool: pond.New(cfg.FFmpeg.Concurrency, 0),
+		cfg:       cfg,
+		Group:     grp,
 		progress: cmap.NewWithCustomShardingFunction[int64, *clipProgress](func(key int64) uint32 {
 			// Copilot recommended this i have no idea if its correct
 			return uint32(key % 10)
 		}),
 		started: false,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 
 	// Parse the quality presets
@@ -75,33 +96,102 @@ func New(cfg *config.Config, grp *services.Group) (services.Transcoder, error) {
 		return t.qualityPresets[i].Bitrate < t.qualityPresets[j].Bitrate
 	})
 
+	if cfg.Downloads.Enabled {
+		if len(cfg.Downloads.Qualities) == 0 {
+			return nil, fmt.Errorf("no download qualities defined")
+		}
+
+		// Downloads borrow the bitrate of the streaming rendition of the same height
+		for _, quality := range cfg.Downloads.Qualities {
+			if _, ok := t.downloadPreset(quality, 0); !ok {
+				return nil, fmt.Errorf("no quality preset with a height of %d for downloads", quality)
+			}
+		}
+	}
+
 	return t, nil
 }
 
 func (t *transcoder) Start() error {
 	// Find all clips that are marked as processing while starting to resume their processing
-	orphanedClips, err := t.Clips.FindMany(context.Background(), &models.User{ID: -1}, models.ClipWhere.Processing.EQ(true))
+	orphanedClips, err := t.Clips.FindMany(context.Background(), policy.System(), models.ClipWhere.Processing.EQ(true))
 
 	if err != nil {
 		return err
 	}
 
 	for _, clip := range orphanedClips {
-		if err := t.Queue(context.Background(), clip); err != nil {
+		// The rest are still marked as processing, they're resumed on the next start
+		if err := t.Queue(context.Background(), clip); err == ErrStopping {
+			return nil
+		} else if err != nil {
 			return err
 		}
 	}
 
+	// Measuring takes a listing of storage per clip, the queue shouldn't wait on it
+	go t.measureClips(t.ctx)
+
 	return nil
 }
 
+// measureClips records the sizes of clips transcoded before sizes were recorded, they don't count towards quotas until then
+// Clips that are measured once aren't found again, so this only does any work on the first start after upgrading
+func (t *transcoder) measureClips(ctx context.Context) {
+	unmeasuredClips, err := t.Clips.FindMany(ctx, policy.System(),
+		models.ClipWhere.Processing.EQ(false),
+		models.ClipWhere.SizeBytes.EQ(0),
+	)
+
+	if err != nil {
+		log.WithError(err).Warn("Failed to find clips to measure")
+		return
+	}
+
+	for _, clip := range unmeasuredClips {
+		if t.stopping.Load() {
+			return
+		}
+
+		t.measureClip(ctx, clip)
+	}
+}
+
+// measureClip records how much storage a clip's objects take up
+func (t *transcoder) measureClip(ctx context.Context, clip *models.Clip) {
+	size, err := t.ObjectStore.Usage(ctx, clip.ID)
+
+	if err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Warn("Failed to measure clip size")
+		return
+	}
+
+	clip.SizeBytes = size
+
+	if err := t.Clips.Update(ctx, clip, boil.Whitelist(models.ClipColumns.SizeBytes)); err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Warn("Failed to store clip size")
+	}
+}
+
+// ErrStopping is returned by Queue once a shutdown began, the clip stays marked as processing and is queued again on the next start
+var ErrStopping = errors.New("transcoder is stopping")
+
+// Queue adds a clip to the queue, waiting for room in it if it's full
 func (t *transcoder) Queue(ctx context.Context, clip *models.Clip) error {
+	if t.stopping.Load() {
+		return ErrStopping
+	}
+
 	t.progress.Set(clip.ID, &clipProgress{
 		maxFrames:    0,
 		currentFrame: -1,
 	})
 
-	t.pool.Submit(func() {
+	err := t.submit(func() {
 		defer func() {
 			if v := recover(); v != nil {
 				log.WithField("clip", clip.ID).
@@ -109,12 +199,61 @@ func (t *transcoder) Queue(ctx context.Context, clip *models.Clip) error {
 					Error("Panic in transcoder")
 			}
 		}()
+
+		// Clips still waiting in the queue when a shutdown begins are left for the next start
+		if t.stopping.Load() {
+			t.progress.Remove(clip.ID)
+			return
+		}
+
 		t.process(ctx, clip)
 	})
 
+	if err != nil {
+		t.progress.Remove(clip.ID)
+	}
+
+	return err
+}
+
+// submit hands task to the pool, a shutdown that stopped the pool since Queue checked is reported as ErrStopping
+func (t *transcoder) submit(task func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = ErrStopping
+		}
+	}()
+
+	t.pool.Submit(task)
+
 	return nil
 }
 
+// Stop waits for the running transcodes to finish, without starting any queued ones
+// If ctx is done first the running transcodes are killed and their clips are left marked as processing,
+// so they are resumed on the next start instead of being deleted
+func (t *transcoder) Stop(ctx context.Context) error {
+	t.stopping.Store(true)
+
+	done := make(chan struct{})
+
+	go func() {
+		// Running clips still need the chunk pool to finish, so it can only be stopped after them
+		t.pool.StopAndWait()
+		t.chunkPool.StopAndWait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
 func (t *transcoder) GetProgress(clipID int64) (int, bool) {
 	prog, ok := t.progress.Get(clipID)
 
@@ -145,6 +284,36 @@ func (t *transcoder) ReportProgress(clipID int64, currentFrame int) {
 	prog.currentFrame = currentFrame
 }
 
+func (t *transcoder) ReportChunkProgress(clipID int64, chunk int, currentFrame int) {
+	prog, ok := t.progress.Get(clipID)
+
+	if !ok {
+		return
+	}
+
+	prog.chunkLock.Lock()
+	defer prog.chunkLock.Unlock()
+
+	if prog.currentFrame == -2 {
+		// If the clip is marked as failed, don't update the progress
+		return
+	}
+
+	if prog.chunkFrames == nil {
+		prog.chunkFrames = make(map[int]int)
+	}
+
+	prog.chunkFrames[chunk] = currentFrame
+
+	total := 0
+
+	for _, frames := range prog.chunkFrames {
+		total += frames
+	}
+
+	prog.currentFrame = total
+}
+
 func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 	// Maybe just use https://stackoverflow.com/questions/53352348/mpeg-dash-output-generated-by-ffmpeg-not-working ?
 	// Example of variables in ffmpeg https://ottverse.com/hls-packaging-using-ffmpeg-live-vod/
@@ -158,7 +327,12 @@ func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 	defer func() {
 		// If the clip is not marked as successful when we return, mark it as failed (-2) and then remove it from the progress map after 1 minute
 		// This should give ample time for the client to call progress and notice the failure
-		if !success {
+		if !success && t.ctx.Err() != nil {
+			// Killed by a shutdown, the raw video is still around so the clip can be transcoded again on the next start
+			log.WithField("clip", clip.ID).
+				Warn("Transcode interrupted by shutdown, it will be resumed on the next start")
+			t.progress.Remove(clip.ID)
+		} else if !success {
 			t.ReportProgress(clip.ID, -2)
 
 			if err := t.Clips.Delete(ctx, clip); err != nil {
@@ -175,24 +349,21 @@ func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 
 	rawURL := fmt.Sprintf("http://127.0.0.1:12786/s3/%d/raw", clip.ID)
 
-	cmd := exec.Command("ffmpeg",
-		"-i", rawURL,
-		"-vf", `scale='if(gt(dar,1280/720),720*dar,1280)':'if(gt(dar,1280/720),720,1280/dar)',setsar=1,crop=1280:720`, // Scale to 1280 width, then crop image height to 720
-		"-frames:v", "1",
-		fmt.Sprintf("http://127.0.0.1:12786/s3/%d/thumbnail.jpg", clip.ID),
-	)
-
-	output, err := cmd.CombinedOutput()
+	info, err := probeContext(t.ctx, rawURL)
 
 	if err != nil {
 		log.WithError(err).
-			WithField("output", string(output)).
-			WithField("command", cmd.String()).
-			Error("Failed to create thumbnail for video, we'd appreciate it if you'd report this issue to us on GitHub with a sample clip that causes the issue: https://github.com/clipable/clipable/issues/new")
+			Error("Error probing video")
 		return
 	}
 
-	width, height, fps, duration, audioStreams, err := GetVideoStats(rawURL)
+	width, height, fps, duration, audioStreams, err := GetVideoStats(info)
+
+	// Files without a video stream are packaged as audio only clips
+	if errors.Is(err, ErrNoVideoStream) {
+		clip.MediaType = modelsx.MediaTypeAudio
+		duration, audioStreams, err = GetAudioStats(info)
+	}
 
 	if err != nil {
 		log.WithError(err).
@@ -200,62 +371,60 @@ func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 		return
 	}
 
-	log.Infoln("Width", width, "Height", height, "FPS", fps, "Duration", duration, "AudioStreams", audioStreams)
-	start := time.Now()
+	if t.cfg.Dedupe.Perceptual && clip.MediaType != modelsx.MediaTypeAudio {
+		hash, err := PerceptualHash(rawURL, duration)
 
-	ffmpegArgs := []string{
-		"-i", rawURL,
-		"-preset", t.cfg.FFmpeg.Preset,
-		"-tune", t.cfg.FFmpeg.Tune,
-		"-keyint_min", strconv.Itoa(fps),
-		"-threads", strconv.Itoa(t.cfg.FFmpeg.Threads),
-		"-hls_playlist_type", "vod",
-		"-g", strconv.Itoa(fps),
-		"-seg_duration", "2",
-		"-sc_threshold", "0",
-		"-c:v", t.cfg.FFmpeg.Codec,
-		"-pix_fmt", "yuv420p",
-		"-c:a", "aac",
-		"-b:a", "128k",
-		"-ac", "1",
-		"-ar", "96000",
-		"-use_template", "1",
-		"-use_timeline", "1",
-		"-single_file", "1",
-		"-x264opts", "no-scenecut",
-		"-streaming", "0",
-		"-movflags", "+faststart+dash+global_sidx",
-		"-global_sidx", "1",
-		"-utc_timing_url", "https://time.akamai.com/?iso",
-		"-progress", fmt.Sprintf("http://127.0.0.1:12786/progress/%d", clip.ID),
+		// Near-duplicate detection is best effort, it shouldn't stop the clip from being transcoded
+		if err != nil {
+			log.WithError(err).
+				WithField("clip", clip.ID).
+				Warn("Failed to create perceptual hash")
+		} else {
+			clip.PerceptualHash = null.Int64From(hash)
+		}
 	}
 
-	ffmpegArgs = append(ffmpegArgs, t.GetPresets(width, height, fps, audioStreams)...)
+	// Chapters are a nice to have, a file with broken chapter markers should still be transcoded
+	chapters, err := GetChapters(info)
 
-	if audioStreams > 0 {
-		ffmpegArgs = append(ffmpegArgs, "-map", "0:a")
+	if err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Warn("Failed to read chapters")
 	}
 
-	if audioStreams > 1 {
-		ffmpegArgs = append(
-			ffmpegArgs,
-			"-filter_complex",
-			"amerge=inputs="+strconv.Itoa(audioStreams),
-		)
+	thumbnailArgs := []string{
+		"-i", rawURL,
+		"-vf", `scale='if(gt(dar,1280/720),720*dar,1280)':'if(gt(dar,1280/720),720,1280/dar)',setsar=1,crop=1280:720`, // Scale to 1280 width, then crop image height to 720
 	}
 
-	if audioStreams > 0 {
-		ffmpegArgs = append(ffmpegArgs, "-adaptation_sets", "id=0,streams=v id=1,streams=a")
-	} else {
-		ffmpegArgs = append(ffmpegArgs, "-adaptation_sets", "id=0,streams=v")
+	if clip.MediaType == modelsx.MediaTypeAudio {
+		// There's no frame to grab, so render the waveform of the whole file instead
+		thumbnailArgs = []string{
+			"-i", rawURL,
+			"-filter_complex", "aformat=channel_layouts=mono,showwavespic=s=1280x720:colors=#ff00a0",
+		}
 	}
 
-	ffmpegArgs = append(ffmpegArgs,
-		"-f", "dash",
-		fmt.Sprintf("http://127.0.0.1:12786/s3/%d/dash.mpd", clip.ID),
+	thumbnailArgs = append(thumbnailArgs,
+		"-frames:v", "1",
+		fmt.Sprintf("http://127.0.0.1:12786/s3/%d/thumbnail.jpg", clip.ID),
 	)
 
-	cmd = exec.Command("ffmpeg", ffmpegArgs...)
+	cmd := exec.CommandContext(t.ctx, "ffmpeg", thumbnailArgs...)
+
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		log.WithError(err).
+			WithField("output", string(output)).
+			WithField("command", cmd.String()).
+			Error("Failed to create thumbnail for video, we'd appreciate it if you'd report this issue to us on GitHub with a sample clip that causes the issue: https://github.com/clipable/clipable/issues/new")
+		return
+	}
+
+	log.Infoln("Width", width, "Height", height, "FPS", fps, "Duration", duration, "AudioStreams", audioStreams, "MediaType", clip.MediaType)
+	start := time.Now()
 
 	prog, ok := t.progress.Get(clip.ID)
 
@@ -267,22 +436,37 @@ func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 
 	prog.maxFrames = int(math.Round(duration.Seconds() * 30))
 
-	output, err = cmd.CombinedOutput()
+	if clip.MediaType != modelsx.MediaTypeAudio && t.cfg.FFmpeg.ChunkDuration > 0 {
+		err = t.transcodeChunks(ctx, clip, rawURL, width, height, fps, duration, audioStreams)
+	} else {
+		err = t.transcodeSinglePass(clip, rawURL, width, height, fps, audioStreams)
+	}
 
 	if err != nil {
 		log.WithError(err).
-			WithField("output", string(output)).
-			WithField("args", ffmpegArgs).
+			WithField("clip", clip.ID).
 			Error("Failed to transcode video, we'd appreciate it if you'd report this issue to us on GitHub with a sample clip that causes the issue: https://github.com/clipable/clipable/issues/new")
 		return
 	}
 
 	log.Infoln("Finished transcoding video", clip.ID, "in", time.Since(start))
 
-	if err := t.ObjectStore.DeleteObject(ctx, clip.ID, "raw"); err != nil {
-		log.WithError(err).
-			Error("Error deleting raw video")
-		return
+	if t.cfg.Downloads.Enabled {
+		t.encodeDownloads(ctx, clip, rawURL, width, height, fps, audioStreams)
+
+		// Shutting down kills the encode, the clip is transcoded again on the next start
+		if t.ctx.Err() != nil {
+			return
+		}
+	}
+
+	// The raw video is kept as the original download
+	if !t.cfg.Downloads.KeepOriginal {
+		if err := t.ObjectStore.DeleteObject(ctx, clip.ID, "raw"); err != nil {
+			log.WithError(err).
+				Error("Error deleting raw video")
+			return
+		}
 	}
 
 	// Wait until all uploads are flushed and available in S3
@@ -290,9 +474,28 @@ func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 		time.Sleep(500 * time.Millisecond)
 	}
 
+	if len(chapters) > 0 {
+		if err := t.Chapters.Replace(ctx, clip.ID, chapters); err != nil {
+			log.WithError(err).
+				WithField("clip", clip.ID).
+				Warn("Failed to store chapters")
+		}
+	}
+
+	// The renditions replace the raw video as what the clip takes up
+	size, err := t.ObjectStore.Usage(ctx, clip.ID)
+
+	if err != nil {
+		log.WithError(err).
+			WithField("clip", clip.ID).
+			Warn("Failed to measure clip size")
+	} else {
+		clip.SizeBytes = size
+	}
+
 	clip.Processing = false
 
-	if err := t.Clips.Update(ctx, clip, boil.Whitelist(models.ClipColumns.Processing)); err != nil {
+	if err := t.Clips.Update(ctx, clip, boil.Whitelist(models.ClipColumns.Processing, models.ClipColumns.MediaType, models.ClipColumns.PerceptualHash, models.ClipColumns.SizeBytes)); err != nil {
 		log.WithError(err).
 			Error("Error updating clip")
 		return
@@ -302,3 +505,45 @@ func (t *transcoder) process(ctx context.Context, clip *models.Clip) {
 
 	success = true
 }
+
+// dashArgs are the muxer arguments shared by every pass that writes the final dash.mpd
+func (t *transcoder) dashArgs() []string {
+	return []string{
+		"-threads", strconv.Itoa(t.cfg.FFmpeg.Threads),
+		"-hls_playlist_type", "vod",
+		"-seg_duration", "2",
+		"-use_template", "1",
+		"-use_timeline", "1",
+		"-single_file", "1",
+		"-streaming", "0",
+		"-movflags", "+faststart+dash+global_sidx",
+		"-global_sidx", "1",
+		"-utc_timing_url", "https://time.akamai.com/?iso",
+	}
+}
+
+// transcodeSinglePass encodes and packages the whole clip with a single ffmpeg process
+func (t *transcoder) transcodeSinglePass(clip *models.Clip, rawURL string, width, height, fps, audioStreams int) error {
+	ffmpegArgs := append([]string{"-i", rawURL}, t.dashArgs()...)
+
+	ffmpegArgs = append(ffmpegArgs, "-progress", fmt.Sprintf("http://127.0.0.1:12786/progress/%d", clip.ID))
+
+	if clip.MediaType == modelsx.MediaTypeAudio {
+		ffmpegArgs = append(ffmpegArgs, t.GetAudioPresets(audioStreams)...)
+	} else {
+		ffmpegArgs = append(ffmpegArgs, t.GetPresets(width, height, fps, audioStreams)...)
+	}
+
+	ffmpegArgs = append(ffmpegArgs,
+		"-f", "dash",
+		fmt.Sprintf("http://127.0.0.1:12786/s3/%d/dash.mpd", clip.ID),
+	)
+
+	output, err := exec.CommandContext(t.ctx, "ffmpeg", ffmpegArgs...).CombinedOutput()
+
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("ffmpeg failed with args %v: %s", ffmpegArgs, output))
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package verify reads stored objects back to find the ones that no longer match their checksums
+package verify
+
+import (
+	"context"
+	"time"
+	"webserver/config"
+	"webserver/modelsx"
+	"webserver/services"
+	"webserver/services/object"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Verifier checks every object in storage against the checksum PutObject stored with it
+// Corrupted objects are only reported, whether they can be encoded or uploaded again is up to whoever runs the server
+type Verifier struct {
+	cfg *config.Config
+	*services.Group
+}
+
+// New creates a Verifier for the storage of g
+func New(cfg *config.Config, g *services.Group) *Verifier {
+	return &Verifier{cfg, g}
+}
+
+// Run verifies storage every Verify.Interval until ctx is done
+func (v *Verifier) Run(ctx context.Context) {
+	if v.cfg.Verify.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(v.cfg.Verify.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		verification, err := v.Verify(ctx)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to verify objects")
+			continue
+		}
+
+		for _, corrupt := range verification.Corrupt {
+			log.WithField("clip", corrupt.ClipID).
+				WithField("object", corrupt.Name).
+				WithField("expected", corrupt.Expected).
+				WithField("actual", corrupt.Actual).
+				WithField("error", corrupt.Error).
+				Error("Found corrupted object")
+		}
+
+		log.WithField("objects", verification.Objects).
+			WithField("unverified", verification.Unverified).
+			WithField("corrupt", len(verification.Corrupt)).
+			Info("Verified objects")
+	}
+}
+
+// Verify reads every object back and reports the ones that don't match their checksums or can't be read
+func (v *Verifier) Verify(ctx context.Context) (*modelsx.Verification, error) {
+	cids, err := v.ObjectStore.ListPrefixes(ctx)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list clip prefixes")
+	}
+
+	verification := &modelsx.Verification{Corrupt: []*modelsx.CorruptObject{}}
+
+	for _, cid := range cids {
+		// An object being written may not match the checksum it's about to get
+		if v.ObjectStore.HasActiveUploads(ctx, cid) {
+			continue
+		}
+
+		objects, err := v.ObjectStore.ListObjects(ctx, cid)
+
+		if err != nil {
+			return verification, errors.Wrapf(err, "failed to list objects of clip %d", cid)
+		}
+
+		for _, info := range objects {
+			corrupt, err := v.verify(ctx, cid, info)
+
+			if errors.Is(err, object.ErrNoChecksum) {
+				verification.Unverified++
+				continue
+			} else if err != nil {
+				return verification, err
+			}
+
+			verification.Objects++
+			verification.Bytes += info.Size
+
+			if corrupt != nil {
+				verification.Corrupt = append(verification.Corrupt, corrupt)
+			}
+		}
+	}
+
+	return verification, nil
+}
+
+// verify checks a single object, it returns nil for objects that are intact or were replaced or deleted while they were read
+func (v *Verifier) verify(ctx context.Context, cid int64, info services.ObjectInfo) (*modelsx.CorruptObject, error) {
+	expected, actual, err := object.Verify(ctx, v.ObjectStore, cid, info.Name)
+
+	if err == nil || errors.Is(err, object.ErrNoChecksum) {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "context error")
+	}
+
+	// Objects replaced since they were listed have a new checksum, deleted ones have nothing left to check
+	if checksum, checksumErr := v.ObjectStore.Checksum(ctx, cid, info.Name); checksumErr != nil || checksum != expected {
+		return nil, nil
+	}
+
+	corrupt := &modelsx.CorruptObject{ClipID: cid, Name: info.Name, Size: info.Size, Expected: expected, Actual: actual}
+
+	if !errors.Is(err, object.ErrChecksumMismatch) {
+		corrupt.Error = err.Error()
+	}
+
+	return corrupt, nil
+}
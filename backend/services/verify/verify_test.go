@@ -0,0 +1,60 @@
+package verify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"webserver/config"
+	"webserver/services"
+	"webserver/services/object"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := &config.Config{}
+	cfg.Storage.Path = t.TempDir()
+
+	store, err := object.NewFilesystemStore(cfg)
+	assert.NoError(t, err)
+
+	for _, name := range []string{"dash.mpd", "dash-stream0.m4s", "thumbnail.jpg"} {
+		_, err := store.PutObject(ctx, 1, name, strings.NewReader(name))
+		assert.NoError(t, err)
+	}
+
+	// One object went bad on disk, another was copied in by hand and has no checksum yet
+	assert.NoError(t, os.WriteFile(filepath.Join(cfg.Storage.Path, "1", "dash-stream0.m4s"), []byte("dash-streamX.m4s"), 0o644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(cfg.Storage.Path, "2"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(cfg.Storage.Path, "2", "raw"), []byte("raw"), 0o644))
+
+	v := New(cfg, &services.Group{ObjectStore: store})
+
+	verification, err := v.Verify(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, verification.Objects)
+	assert.Equal(t, int64(len("dash.mpd")+len("dash-stream0.m4s")+len("thumbnail.jpg")), verification.Bytes)
+	assert.Equal(t, 1, verification.Unverified)
+
+	if assert.Len(t, verification.Corrupt, 1) {
+		corrupt := verification.Corrupt[0]
+
+		assert.Equal(t, int64(1), corrupt.ClipID)
+		assert.Equal(t, "dash-stream0.m4s", corrupt.Name)
+		assert.NotEqual(t, corrupt.Expected, corrupt.Actual)
+		assert.Empty(t, corrupt.Error)
+	}
+
+	// Uploading it again replaces the checksum along with the object
+	_, err = store.PutObject(ctx, 1, "dash-stream0.m4s", strings.NewReader("dash-stream0.m4s"))
+	assert.NoError(t, err)
+
+	verification, err = v.Verify(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, verification.Corrupt)
+}
@@ -37,6 +37,8 @@ export default function Page({ params }: { params: { id: string } }) {
         localStorage.setItem("volume", videoElement.volume.toString());
       };
       await player.load(`/api/clips/${params.id}/dash.mpd`);
+      // Chapters are optional, clips without any respond with an empty track
+      player.addChaptersTrack(`/api/clips/${params.id}/chapters.vtt`, "en").catch(() => {});
       videoElement.volume = parseFloat(localStorage.getItem("volume") || "1");
       videoElement.play();
     };
@@ -47,6 +49,14 @@ export default function Page({ params }: { params: { id: string } }) {
     };
   }, [mainPlayer]);
 
+  useEffect(() => {
+    const { videoElement } = mainPlayer as { player: any; videoElement: HTMLVideoElement };
+    if (!videoElement || videoDetails?.media_type !== "audio") return;
+
+    // Audio clips have no picture of their own, so keep the waveform on screen while playing
+    videoElement.poster = `/api/clips/${params.id}/thumbnail.jpg`;
+  }, [mainPlayer, videoDetails]);
+
   const fetchVideo = async () => {
     const vid = await getClip(params.id);
     if (!vid) return;
@@ -1,16 +1,20 @@
 "use client";
 
 import { FormEvent, useContext, useEffect, useState } from "react";
-import { useRouter } from "next/navigation";
-import { login, registrationAllowed } from "@/shared/api";
+import { useRouter, useSearchParams } from "next/navigation";
+import { login, loginTOTP, registrationAllowed } from "@/shared/api";
 import { UserContext } from "@/context/user-context";
 
 export default function Home() {
   const router = useRouter();
+  const params = useSearchParams();
   const userContext = useContext(UserContext);
 
   const [username, setUsername] = useState<string>("");
   const [password, setPassword] = useState<string>("");
+  const [code, setCode] = useState<string>("");
+  // Logins with a provider are sent back here when the account has two-factor authentication
+  const [needsCode, setNeedsCode] = useState<boolean>(params.get("totp") === "required");
   const [isRegistrationAllowed, setRegistrationAllowed] = useState<boolean>(false);
 
   useEffect(() => {
@@ -21,12 +25,26 @@ export default function Home() {
   }, []);
 
 
+  const loggedIn = () => {
+    userContext.reload();
+    // Only paths on this site, so the link can't send users elsewhere
+    const redirect = params.get("redirect");
+    router.push(redirect?.startsWith("/") && !redirect.startsWith("//") && !redirect.startsWith("/\\") ? redirect : "/");
+  };
+
   const loginUser = async (e: FormEvent<HTMLFormElement>) => {
     e.preventDefault();
-    const ok = await login(username, password);
-    if (ok) {
-      userContext.reload();
-      router.push("/");
+    if (needsCode) {
+      if (await loginTOTP(code)) {
+        loggedIn();
+      }
+      return;
+    }
+    const result = await login(username, password);
+    if (result === "totp") {
+      setNeedsCode(true);
+    } else if (result === "ok") {
+      loggedIn();
     }
   };
 
@@ -34,30 +52,50 @@ export default function Home() {
     <main className="h-screen">
       <div className="container mx-auto flex flex-col space-y-6 justify-center items-center py-3">
         <form className="form-control w-full max-w-xs" onSubmit={loginUser} id="loginForm">
-          <label className="label" htmlFor="username">
-            <span className="label-text">Username</span>
-          </label>
-          <input
-            type="text"
-            placeholder="Username"
-            id="username"
-            className="input input-bordered w-full max-w-xs"
-            onChange={(e) => {
-              setUsername(e.target.value);
-            }}
-          />
-          <label className="label" htmlFor="password">
-            <span className="label-text">Password</span>
-          </label>
-          <input
-            type="password"
-            placeholder="Password"
-            id="password"
-            className="input input-bordered w-full max-w-xs"
-            onChange={(e) => {
-              setPassword(e.target.value);
-            }}
-          />
+          {needsCode ? (
+            <>
+              <label className="label" htmlFor="code">
+                <span className="label-text">Authenticator or recovery code</span>
+              </label>
+              <input
+                type="text"
+                autoComplete="one-time-code"
+                placeholder="123456"
+                id="code"
+                className="input input-bordered w-full max-w-xs"
+                onChange={(e) => {
+                  setCode(e.target.value);
+                }}
+              />
+            </>
+          ) : (
+            <>
+              <label className="label" htmlFor="username">
+                <span className="label-text">Username</span>
+              </label>
+              <input
+                type="text"
+                placeholder="Username"
+                id="username"
+                className="input input-bordered w-full max-w-xs"
+                onChange={(e) => {
+                  setUsername(e.target.value);
+                }}
+              />
+              <label className="label" htmlFor="password">
+                <span className="label-text">Password</span>
+              </label>
+              <input
+                type="password"
+                placeholder="Password"
+                id="password"
+                className="input input-bordered w-full max-w-xs"
+                onChange={(e) => {
+                  setPassword(e.target.value);
+                }}
+              />
+            </>
+          )}
         </form>
 
         <button className="btn btn-primary w-full max-w-xs" form="loginForm">
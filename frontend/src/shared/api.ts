@@ -4,6 +4,11 @@ export interface User {
   id: string;
   username: string;
   joined_at: string;
+  // Only present for the current user, quota is missing when storage is unlimited
+  usage?: number;
+  quota?: number;
+  // Only present for the current user, whether they log in with a code of an authenticator app
+  two_factor?: boolean;
 }
 
 export interface Clip {
@@ -11,6 +16,7 @@ export interface Clip {
   creator: User;
   description?: string;
   id: string;
+  media_type: "video" | "audio";
   processing: boolean;
   title: string;
   unlisted: boolean;
@@ -106,7 +112,10 @@ export const registrationAllowed = async (): Promise<boolean> => {
   return response.ok;
 };
 
-export const login = async (username: string, password: string): Promise<boolean> => {
+// "totp" means the password was right, but a code has to be sent with loginTOTP to finish logging in
+export type LoginResult = "ok" | "totp" | "failed";
+
+export const login = async (username: string, password: string): Promise<LoginResult> => {
   const response = await fetch(`${API_URL}/auth/login`, {
     method: "POST",
     credentials: "include",
@@ -115,6 +124,23 @@ export const login = async (username: string, password: string): Promise<boolean
     },
     body: JSON.stringify({ username, password }),
   });
+  if (response.status === 202) {
+    return "totp";
+  }
+  return response.ok ? "ok" : "failed";
+};
+
+// Codes of authenticator apps are 6 digits, anything else is taken as a recovery code
+export const loginTOTP = async (code: string): Promise<boolean> => {
+  const trimmed = code.trim();
+  const response = await fetch(`${API_URL}/auth/login/totp`, {
+    method: "POST",
+    credentials: "include",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify(/^\d{6}$/.test(trimmed) ? { code: trimmed } : { recovery_code: trimmed }),
+  });
   return response.ok;
 };
 